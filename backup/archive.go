@@ -0,0 +1,179 @@
+package backup
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/poundbot/poundbot/types"
+)
+
+// Version is the archive format written by Write. Read accepts this
+// version and older.
+const Version = 1
+
+// Archive records are one JSON object per line. The first line is always
+// the header.
+const (
+	kindHeader      = "header"
+	kindAccount     = "account"
+	kindUser        = "user"
+	kindDiscordAuth = "discord_auth"
+)
+
+type record struct {
+	Kind string          `json:"kind"`
+	Data json.RawMessage `json:"data"`
+}
+
+type header struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// An Archive is everything needed to restore an installation. Accounts
+// include their servers, channel tags and clans.
+type Archive struct {
+	Version      int
+	CreatedAt    time.Time
+	Accounts     []types.Account
+	Users        []types.User
+	DiscordAuths []types.DiscordAuth
+}
+
+// Write writes the archive as JSON lines
+func (a Archive) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+
+	write := func(kind string, v interface{}) error {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("could not encode %s: %w", kind, err)
+		}
+		return enc.Encode(record{Kind: kind, Data: data})
+	}
+
+	if err := write(kindHeader, header{Version: Version, CreatedAt: a.CreatedAt}); err != nil {
+		return err
+	}
+	for _, account := range a.Accounts {
+		if err := write(kindAccount, account); err != nil {
+			return err
+		}
+	}
+	for _, user := range a.Users {
+		if err := write(kindUser, user); err != nil {
+			return err
+		}
+	}
+	for _, da := range a.DiscordAuths {
+		if err := write(kindDiscordAuth, da); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// Read reads an archive written by Write
+func Read(r io.Reader) (Archive, error) {
+	var a Archive
+	dec := json.NewDecoder(r)
+
+	line := 0
+	for {
+		var rec record
+		err := dec.Decode(&rec)
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return a, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		if line == 1 {
+			if rec.Kind != kindHeader {
+				return a, errors.New("not a poundbot backup: missing header")
+			}
+			var h header
+			if err := json.Unmarshal(rec.Data, &h); err != nil {
+				return a, fmt.Errorf("line %d: %w", line, err)
+			}
+			if h.Version < 1 || h.Version > Version {
+				return a, fmt.Errorf("unsupported backup version %d", h.Version)
+			}
+			a.Version = h.Version
+			a.CreatedAt = h.CreatedAt
+			continue
+		}
+
+		switch rec.Kind {
+		case kindAccount:
+			var account types.Account
+			err = json.Unmarshal(rec.Data, &account)
+			a.Accounts = append(a.Accounts, account)
+		case kindUser:
+			var user types.User
+			err = json.Unmarshal(rec.Data, &user)
+			a.Users = append(a.Users, user)
+		case kindDiscordAuth:
+			var da types.DiscordAuth
+			err = json.Unmarshal(rec.Data, &da)
+			a.DiscordAuths = append(a.DiscordAuths, da)
+		default:
+			err = fmt.Errorf("unknown record kind %q", rec.Kind)
+		}
+		if err != nil {
+			return a, fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+
+	if line == 0 {
+		return a, errors.New("not a poundbot backup: empty")
+	}
+	return a, nil
+}
+
+// Filter returns the parts of the archive belonging to the guilds. Users are
+// kept if any of their player IDs are registered in one of the guilds.
+func (a Archive) Filter(guilds []string) Archive {
+	out := Archive{Version: a.Version, CreatedAt: a.CreatedAt}
+
+	var playerIDs []string
+	for _, account := range a.Accounts {
+		if containsString(guilds, account.GuildSnowflake) {
+			out.Accounts = append(out.Accounts, account)
+			playerIDs = append(playerIDs, account.RegisteredPlayerIDs...)
+		}
+	}
+
+	for _, user := range a.Users {
+		for _, pID := range user.PlayerIDs {
+			if containsString(playerIDs, pID) {
+				out.Users = append(out.Users, user)
+				break
+			}
+		}
+	}
+
+	for _, da := range a.DiscordAuths {
+		if containsString(guilds, da.GuildSnowflake) {
+			out.DiscordAuths = append(out.DiscordAuths, da)
+		}
+	}
+
+	return out
+}
+
+func containsString(list []string, s string) bool {
+	for i := range list {
+		if list[i] == s {
+			return true
+		}
+	}
+	return false
+}
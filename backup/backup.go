@@ -0,0 +1,30 @@
+// Package backup exports and restores PoundBot data through the
+// storage.Storage interfaces, so it works with every storage driver and can
+// move an installation from one driver to another.
+package backup
+
+import (
+	"fmt"
+
+	"github.com/poundbot/poundbot/pbclock"
+	"github.com/poundbot/poundbot/storage"
+)
+
+var iclock = pbclock.Clock
+
+// Backup reads everything in the store into an Archive
+func Backup(s storage.Storage) (Archive, error) {
+	a := Archive{Version: Version, CreatedAt: iclock().Now().UTC()}
+
+	if err := s.Accounts().All(&a.Accounts); err != nil {
+		return a, fmt.Errorf("could not read accounts: %w", err)
+	}
+	if err := s.Users().All(&a.Users); err != nil {
+		return a, fmt.Errorf("could not read users: %w", err)
+	}
+	if err := s.DiscordAuths().All(&a.DiscordAuths); err != nil {
+		return a, fmt.Errorf("could not read discord auths: %w", err)
+	}
+
+	return a, nil
+}
@@ -0,0 +1,196 @@
+package backup
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/storage/memory"
+	"github.com/poundbot/poundbot/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func userInfoFor(playerID, snowflake string) storage.UserInfoGetter {
+	return userInfo{playerID: playerID, discordID: snowflake}
+}
+
+// newStore creates a store with two guilds
+func newStore(t *testing.T) storage.Storage {
+	s := memory.NewMemory()
+	as := s.Accounts()
+
+	for _, guild := range []string{"one", "two"} {
+		assert.Nil(t, as.UpsertBase(types.BaseAccount{GuildSnowflake: guild, OwnerSnowflake: "owner-" + guild}))
+		server := types.AccountServer{Key: "key-" + guild, Name: "server " + guild}
+		server.SetChannelIDForTag("1234", "chat")
+		assert.Nil(t, as.AddServer(guild, server))
+		assert.Nil(t, as.AddClan("key-"+guild, types.Clan{Tag: "FoF", Members: []string{"game:" + guild}}))
+		assert.Nil(t, as.SetRegisteredPlayerIDs(guild, []string{"game:" + guild}))
+		assert.Nil(t, s.Users().UpsertPlayer(userInfoFor("game:"+guild, "user-"+guild)))
+		assert.Nil(t, s.DiscordAuths().Upsert(types.DiscordAuth{
+			GuildSnowflake: guild,
+			PlayerID:       "game:pending-" + guild,
+			DiscordInfo:    types.DiscordInfo{DiscordName: "pending#" + guild},
+			Pin:            1234,
+			ExpiresAt:      time.Now().Add(time.Hour),
+		}))
+	}
+
+	return s
+}
+
+func TestArchive_WriteRead(t *testing.T) {
+	t.Parallel()
+
+	a, err := Backup(newStore(t))
+	assert.Nil(t, err)
+
+	var buf bytes.Buffer
+	assert.Nil(t, a.Write(&buf))
+	assert.Equal(t, 1+2+2+2, strings.Count(buf.String(), "\n"), "one line per record plus the header")
+
+	got, err := Read(&buf)
+	assert.Nil(t, err)
+	assert.Equal(t, Version, got.Version)
+	assert.True(t, a.CreatedAt.Equal(got.CreatedAt))
+	if assert.Len(t, got.Accounts, 2) {
+		server := got.Accounts[0].Servers[0]
+		channelID, _ := server.ChannelIDForTag("chat")
+		assert.Equal(t, "1234", channelID)
+		assert.Equal(t, []types.Clan{{Tag: "FoF", Members: []string{"game:one"}}}, server.Clans)
+	}
+	assert.Len(t, got.Users, 2)
+	assert.Len(t, got.DiscordAuths, 2)
+}
+
+func TestRead(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		in      string
+		wantErr string
+	}{
+		{name: "empty", in: "", wantErr: "empty"},
+		{name: "no header", in: `{"kind":"user","data":{}}`, wantErr: "missing header"},
+		{name: "newer version", in: `{"kind":"header","data":{"version":99}}`, wantErr: "unsupported backup version 99"},
+		{
+			name:    "unknown kind",
+			in:      `{"kind":"header","data":{"version":1}}` + "\n" + `{"kind":"thing","data":{}}`,
+			wantErr: `line 2: unknown record kind "thing"`,
+		},
+		{name: "header only", in: `{"kind":"header","data":{"version":1}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Read(strings.NewReader(tt.in))
+			if tt.wantErr == "" {
+				assert.Nil(t, err)
+				return
+			}
+			if assert.NotNil(t, err) {
+				assert.Contains(t, err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestArchive_Filter(t *testing.T) {
+	t.Parallel()
+
+	a, _ := Backup(newStore(t))
+	got := a.Filter([]string{"two"})
+
+	if assert.Len(t, got.Accounts, 1) {
+		assert.Equal(t, "two", got.Accounts[0].GuildSnowflake)
+	}
+	if assert.Len(t, got.Users, 1) {
+		assert.Equal(t, "user-two", got.Users[0].Snowflake)
+	}
+	if assert.Len(t, got.DiscordAuths, 1) {
+		assert.Equal(t, "game:pending-two", got.DiscordAuths[0].PlayerID)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	t.Parallel()
+
+	a, _ := Backup(newStore(t))
+
+	// The restore target has guild one with a changed server and an extra
+	// server, and is missing guild two.
+	s := memory.NewMemory()
+	s.Accounts().UpsertBase(types.BaseAccount{GuildSnowflake: "one", OwnerSnowflake: "owner-one"})
+	s.Accounts().SetRegisteredPlayerIDs("one", []string{"game:one"})
+	s.Accounts().AddServer("one", types.AccountServer{Key: "key-one", Name: "renamed"})
+	s.Accounts().AddServer("one", types.AccountServer{Key: "extra"})
+	s.Users().UpsertPlayer(userInfoFor("game:one", "user-one"))
+
+	changes, err := Restore(s, a, true)
+	assert.Nil(t, err)
+	assert.Equal(t, []Change{
+		{Action: ActionUnchanged, Kind: "account", ID: "one"},
+		{Action: ActionUpdate, Kind: "server", ID: "key-one", Fields: []string{"Name", "Clans", "Channels"}},
+		{Action: ActionRemove, Kind: "server", ID: "extra"},
+		{Action: ActionAdd, Kind: "account", ID: "two"},
+		{Action: ActionAdd, Kind: "server", ID: "key-two"},
+		{Action: ActionUnchanged, Kind: "user", ID: "user-one"},
+		{Action: ActionAdd, Kind: "user", ID: "user-two"},
+		{Action: ActionAdd, Kind: "discord_auth", ID: "game:pending-one"},
+		{Action: ActionAdd, Kind: "discord_auth", ID: "game:pending-two"},
+	}, changes)
+
+	_, err = s.Accounts().GetByDiscordGuild("two")
+	assert.NotNil(t, err, "dry run should not write")
+
+	_, err = Restore(s, a, false)
+	assert.Nil(t, err)
+
+	changes, err = Restore(s, a, true)
+	assert.Nil(t, err)
+	for _, c := range changes {
+		assert.Equal(t, ActionUnchanged, c.Action, c.String())
+	}
+
+	account, err := s.Accounts().GetByServerKey("key-one")
+	assert.Nil(t, err)
+	assert.Len(t, account.Servers, 1, "servers missing from the archive should be removed")
+	assert.Equal(t, "server one", account.Servers[0].Name)
+}
+
+func TestRestore_conflicts(t *testing.T) {
+	t.Parallel()
+
+	a, _ := Backup(newStore(t))
+
+	s := memory.NewMemory()
+	s.Accounts().UpsertBase(types.BaseAccount{GuildSnowflake: "other"})
+	s.Accounts().AddServer("other", types.AccountServer{Key: "key-one"})
+	s.Users().UpsertPlayer(userInfoFor("game:two", "someone-else"))
+
+	changes, err := Restore(s, a.Filter([]string{"one", "two"}), false)
+	assert.Nil(t, err)
+	assert.Contains(t, changes, Change{
+		Action: ActionConflict, Kind: "server", ID: "key-one", Fields: []string{"key belongs to guild other"},
+	})
+	assert.Contains(t, changes, Change{
+		Action: ActionConflict, Kind: "user", ID: "user-two", Fields: []string{"game:two belongs to someone-else"},
+	})
+
+	account, _ := s.Accounts().GetByDiscordGuild("one")
+	assert.Empty(t, account.Servers, "conflicting servers should not be added")
+}
+
+func TestDiffFields_times(t *testing.T) {
+	t.Parallel()
+
+	wipe := time.Date(2020, 6, 4, 18, 0, 0, 0, time.UTC)
+	a := types.AccountServer{LastWipe: wipe}
+	b := types.AccountServer{LastWipe: wipe.In(time.FixedZone("EDT", -4*60*60))}
+	assert.Empty(t, diffFields(a, b, "LastWipe"), "times for the same instant should be equal")
+
+	b.LastWipe = wipe.Add(time.Minute)
+	assert.Equal(t, []string{"LastWipe"}, diffFields(a, b, "LastWipe"))
+}
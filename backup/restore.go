@@ -0,0 +1,294 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/types"
+)
+
+// Change actions reported by Restore
+const (
+	ActionAdd       = "add"
+	ActionUpdate    = "update"
+	ActionRemove    = "remove"
+	ActionUnchanged = "unchanged"
+	ActionConflict  = "conflict" // skipped because it belongs to someone else
+)
+
+// A Change is one difference between an archive and the store
+type Change struct {
+	Action string
+	Kind   string   // account, server, user or discord_auth
+	ID     string   // guild, server key, discord ID or player ID
+	Fields []string // the fields that differ for updates, or why it conflicts
+}
+
+func (c Change) String() string {
+	s := fmt.Sprintf("%-9s %-12s %s", c.Action, c.Kind, c.ID)
+	if len(c.Fields) != 0 {
+		s += " (" + strings.Join(c.Fields, ", ") + ")"
+	}
+	return s
+}
+
+// Restore writes the archive to the store and returns what changed. With
+// dryRun nothing is written, so the changes are what would happen.
+//
+// Servers that are in the store but not in the archive are removed from
+// their account. Accounts, users and discord auths missing from the
+// archive are left alone. Account Disabled flags are not restored; PoundBot
+// updates them from Discord when it connects.
+func Restore(s storage.Storage, a Archive, dryRun bool) ([]Change, error) {
+	r := restorer{store: s, dryRun: dryRun}
+
+	for _, account := range a.Accounts {
+		if err := r.account(account); err != nil {
+			return r.changes, fmt.Errorf("account %s: %w", account.GuildSnowflake, err)
+		}
+	}
+	for _, user := range a.Users {
+		if err := r.user(user); err != nil {
+			return r.changes, fmt.Errorf("user %s: %w", user.Snowflake, err)
+		}
+	}
+	if err := r.discordAuths(a.DiscordAuths); err != nil {
+		return r.changes, fmt.Errorf("discord auths: %w", err)
+	}
+
+	return r.changes, nil
+}
+
+type restorer struct {
+	store   storage.Storage
+	dryRun  bool
+	changes []Change
+}
+
+func (r *restorer) add(c Change) {
+	r.changes = append(r.changes, c)
+}
+
+// write runs f unless this is a dry run
+func (r *restorer) write(f func() error) error {
+	if r.dryRun {
+		return nil
+	}
+	return f()
+}
+
+func (r *restorer) account(account types.Account) error {
+	as := r.store.Accounts()
+	guild := account.GuildSnowflake
+
+	existing, err := as.GetByDiscordGuild(guild)
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		r.add(Change{Action: ActionAdd, Kind: kindAccount, ID: guild})
+	case err != nil:
+		return err
+	default:
+		fields := diffFields(
+			existing.BaseAccount, account.BaseAccount,
+			"OwnerSnowflake", "CommandPrefix", "AdminSnowflakes", "RegisteredPlayerIDs",
+		)
+		r.add(changeFor(kindAccount, guild, fields))
+	}
+
+	err = r.write(func() error {
+		if err := as.UpsertBase(account.BaseAccount); err != nil {
+			return err
+		}
+		return as.SetRegisteredPlayerIDs(guild, account.RegisteredPlayerIDs)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, server := range account.Servers {
+		if err := r.server(guild, existing, server); err != nil {
+			return fmt.Errorf("server %s: %w", server.Key, err)
+		}
+	}
+
+	for _, server := range existing.Servers {
+		if _, err := account.ServerFromKey(server.Key); err == nil {
+			continue
+		}
+		r.add(Change{Action: ActionRemove, Kind: "server", ID: server.Key})
+		if err := r.write(func() error { return as.RemoveServer(guild, server.Key) }); err != nil {
+			return fmt.Errorf("server %s: %w", server.Key, err)
+		}
+	}
+
+	return nil
+}
+
+func (r *restorer) server(guild string, existing types.Account, server types.AccountServer) error {
+	as := r.store.Accounts()
+
+	current, err := existing.ServerFromKey(server.Key)
+	if err == nil {
+		fields := diffFields(current, server, "Name", "Address", "RaidDelay", "RaidCooldown", "OfflineAlert", "KillFeed", "LastWipe", "NextWipe", "WipeMessage", "Clans", "Channels", "RoleMaps")
+		r.add(changeFor("server", server.Key, fields))
+		if len(fields) == 0 {
+			return nil
+		}
+		return r.write(func() error { return as.UpdateServer(guild, server.Key, server) })
+	}
+
+	// Server keys are unique across guilds
+	owner, err := as.GetByServerKey(server.Key)
+	if err == nil {
+		r.add(Change{
+			Action: ActionConflict,
+			Kind:   "server",
+			ID:     server.Key,
+			Fields: []string{"key belongs to guild " + owner.GuildSnowflake},
+		})
+		return nil
+	}
+	if !errors.Is(err, storage.ErrNotFound) {
+		return err
+	}
+
+	r.add(Change{Action: ActionAdd, Kind: "server", ID: server.Key})
+	return r.write(func() error { return as.AddServer(guild, server) })
+}
+
+// userInfo implements storage.UserInfoGetter for restoring player IDs
+type userInfo struct {
+	playerID, discordID string
+}
+
+func (u userInfo) GetPlayerID() string  { return u.playerID }
+func (u userInfo) GetDiscordID() string { return u.discordID }
+
+func (r *restorer) user(user types.User) error {
+	us := r.store.Users()
+
+	existing, err := us.GetByDiscordID(user.Snowflake)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return err
+	}
+	isNew := err != nil
+
+	var missing, conflicts []string
+	for _, pID := range user.PlayerIDs {
+		if containsString(existing.PlayerIDs, pID) {
+			continue
+		}
+		owner, err := us.GetByPlayerID(pID)
+		switch {
+		case err == nil:
+			conflicts = append(conflicts, fmt.Sprintf("%s belongs to %s", pID, owner.Snowflake))
+		case errors.Is(err, storage.ErrNotFound):
+			missing = append(missing, pID)
+		default:
+			return err
+		}
+	}
+
+	if len(conflicts) != 0 {
+		r.add(Change{Action: ActionConflict, Kind: kindUser, ID: user.Snowflake, Fields: conflicts})
+	}
+
+	switch {
+	case isNew && len(missing) == 0:
+		// Nothing can be restored for this user
+		return nil
+	case isNew:
+		r.add(Change{Action: ActionAdd, Kind: kindUser, ID: user.Snowflake})
+	case len(missing) != 0:
+		r.add(Change{Action: ActionUpdate, Kind: kindUser, ID: user.Snowflake, Fields: []string{"PlayerIDs"}})
+	case len(conflicts) == 0:
+		r.add(Change{Action: ActionUnchanged, Kind: kindUser, ID: user.Snowflake})
+	}
+
+	return r.write(func() error {
+		for _, pID := range missing {
+			if err := us.UpsertPlayer(userInfo{playerID: pID, discordID: user.Snowflake}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *restorer) discordAuths(auths []types.DiscordAuth) error {
+	ds := r.store.DiscordAuths()
+
+	var existing []types.DiscordAuth
+	if err := ds.All(&existing); err != nil {
+		return err
+	}
+	byPlayerID := map[string]types.DiscordAuth{}
+	for _, da := range existing {
+		byPlayerID[da.PlayerID] = da
+	}
+
+	for _, da := range auths {
+		current, ok := byPlayerID[da.PlayerID]
+		if ok {
+			fields := diffFields(current, da, "GuildSnowflake", "DiscordName", "Snowflake", "Pin")
+			r.add(changeFor(kindDiscordAuth, da.PlayerID, fields))
+			if len(fields) == 0 {
+				continue
+			}
+		} else {
+			r.add(Change{Action: ActionAdd, Kind: kindDiscordAuth, ID: da.PlayerID})
+		}
+
+		da := da
+		if err := r.write(func() error { return ds.Upsert(da) }); err != nil {
+			return fmt.Errorf("%s: %w", da.PlayerID, err)
+		}
+	}
+	return nil
+}
+
+func changeFor(kind, id string, fields []string) Change {
+	if len(fields) == 0 {
+		return Change{Action: ActionUnchanged, Kind: kind, ID: id}
+	}
+	return Change{Action: ActionUpdate, Kind: kind, ID: id, Fields: fields}
+}
+
+// diffFields returns the named fields that differ between two structs of
+// the same type. Empty and nil lists are considered equal, as are times
+// for the same instant.
+func diffFields(a, b interface{}, names ...string) []string {
+	av := reflect.ValueOf(a)
+	bv := reflect.ValueOf(b)
+
+	var fields []string
+	for _, name := range names {
+		af := av.FieldByName(name)
+		bf := bv.FieldByName(name)
+		if isEmpty(af) && isEmpty(bf) {
+			continue
+		}
+		if at, ok := af.Interface().(time.Time); ok {
+			if !at.Equal(bf.Interface().(time.Time)) {
+				fields = append(fields, name)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(af.Interface(), bf.Interface()) {
+			fields = append(fields, name)
+		}
+	}
+	return fields
+}
+
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	}
+	return false
+}
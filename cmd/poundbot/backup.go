@@ -0,0 +1,131 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/poundbot/poundbot/backup"
+	"github.com/poundbot/poundbot/storage"
+	"github.com/spf13/viper"
+)
+
+// runCommand runs a poundbot subcommand such as backup or restore
+func runCommand(name string, args []string) error {
+	store, err := newStorage(viper.GetViper())
+	if err != nil {
+		return fmt.Errorf("could not connect to DB: %w", err)
+	}
+	defer store.Close()
+
+	// Init sets up the schema, which on MongoDB recreates the chat queue and
+	// builds indexes, so backups leave the database as it is. SQLite still
+	// needs its migrations to read older databases.
+	if name == "restore" || viper.GetString("storage.driver") == "sqlite" {
+		store.Init()
+	}
+
+	switch name {
+	case "backup":
+		return runBackup(store, args)
+	case "restore":
+		return runRestore(store, args)
+	}
+	return fmt.Errorf("unknown command %q", name)
+}
+
+// guildList splits a comma separated list of guild IDs
+func guildList(guilds string) []string {
+	if len(guilds) == 0 {
+		return nil
+	}
+	return strings.Split(guilds, ",")
+}
+
+func runBackup(store storage.Storage, args []string) error {
+	fs := flag.NewFlagSet("backup", flag.ExitOnError)
+	out := fs.String("o", "-", "The file to write the backup to, or - for stdout")
+	guilds := fs.String("guilds", "", "Comma separated guild IDs to back up. Defaults to all guilds")
+	fs.Parse(args)
+
+	a, err := backup.Backup(store)
+	if err != nil {
+		return err
+	}
+	if g := guildList(*guilds); g != nil {
+		a = a.Filter(g)
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "-" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if err := a.Write(w); err != nil {
+		return fmt.Errorf("could not write backup: %w", err)
+	}
+	log.Printf("Backed up %d accounts, %d users and %d discord auths",
+		len(a.Accounts), len(a.Users), len(a.DiscordAuths))
+	return nil
+}
+
+func runRestore(store storage.Storage, args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+	in := fs.String("i", "-", "The backup file to restore, or - for stdin")
+	guilds := fs.String("guilds", "", "Comma separated guild IDs to restore. Defaults to all guilds")
+	dryRun := fs.Bool("dry-run", false, "Show what would change without writing anything")
+	fs.Parse(args)
+
+	var r io.Reader = os.Stdin
+	if *in != "-" {
+		f, err := os.Open(*in)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	a, err := backup.Read(r)
+	if err != nil {
+		return fmt.Errorf("could not read backup: %w", err)
+	}
+	if g := guildList(*guilds); g != nil {
+		a = a.Filter(g)
+	}
+
+	log.Printf("Restoring backup from %s", a.CreatedAt.Format("2006-01-02 15:04:05 MST"))
+	changes, err := backup.Restore(store, a, *dryRun)
+	for _, c := range changes {
+		if c.Action != backup.ActionUnchanged {
+			fmt.Println(c)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if *dryRun {
+		log.Printf("Dry run: %d changes not written", countChanged(changes))
+		return nil
+	}
+	log.Printf("Restored %d changes", countChanged(changes))
+	return nil
+}
+
+func countChanged(changes []backup.Change) int {
+	count := 0
+	for _, c := range changes {
+		if c.Action != backup.ActionUnchanged && c.Action != backup.ActionConflict {
+			count++
+		}
+	}
+	return count
+}
@@ -17,7 +17,10 @@ import (
 	"github.com/poundbot/poundbot/gameapi"
 	pblog "github.com/poundbot/poundbot/log"
 	"github.com/poundbot/poundbot/messages"
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/storage/memory"
 	"github.com/poundbot/poundbot/storage/mongodb"
+	"github.com/poundbot/poundbot/storage/sqlite"
 	"github.com/spf13/viper"
 )
 
@@ -39,11 +42,38 @@ type service interface {
 	Stop()
 }
 
-func newServerConfig(cfg *viper.Viper, storage *mongodb.MongoDB) *gameapi.ServerConfig {
+func newServerConfig(cfg *viper.Viper, store storage.Storage) *gameapi.ServerConfig {
 	return &gameapi.ServerConfig{
 		BindAddr: cfg.GetString("http.bind_address"),
 		Port:     cfg.GetInt("http.port"),
-		Storage:  storage,
+		Storage:  store,
+
+		ChatLogRetention: cfg.GetDuration("chatlog.retention"),
+		OfflineAfter:     cfg.GetDuration("status.offline_after"),
+	}
+}
+
+// newStorage creates the storage backend set by storage.driver
+func newStorage(cfg *viper.Viper) (storage.Storage, error) {
+	switch driver := cfg.GetString("storage.driver"); driver {
+	case "mongodb":
+		dialAddr := cfg.GetString("mongo.dial-addr")
+		if len(dialAddr) != 0 {
+			log.Warn("DEPRECIATION WARNING: mongo.dial-addr has been renamed to mongo.dial.")
+		} else {
+			dialAddr = cfg.GetString("mongo.dial")
+		}
+
+		return mongodb.NewMongoDB(
+			dialAddr,
+			cfg.GetString("mongo.database"),
+		)
+	case "sqlite":
+		return sqlite.NewSQLite(cfg.GetString("sqlite.path"))
+	case "memory":
+		return memory.NewMemory(), nil
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", driver)
 	}
 }
 
@@ -80,8 +110,12 @@ func main() {
 
 	servicesCount := 2 // Always at least 1 for discord, but should always be >1
 
+	viper.SetDefault("storage.driver", "mongodb")
 	viper.SetDefault("mongo.dial", "mongodb://localhost:27017")
 	viper.SetDefault("mongo.database", "poundbot")
+	viper.SetDefault("sqlite.path", "poundbot.db")
+	viper.SetDefault("chatlog.retention", "720h")
+	viper.SetDefault("status.offline_after", "5m")
 	viper.SetDefault("http.bind_addr", "")
 	viper.SetDefault("http.port", 9090)
 	viper.SetDefault("discord.token", "YOUR DISCORD BOT AUTH TOKEN")
@@ -122,6 +156,17 @@ func main() {
 		os.Exit(0)
 	}
 
+	switch cmd := flag.Arg(0); cmd {
+	case "":
+	case "backup", "restore":
+		if err := runCommand(cmd, flag.Args()[1:]); err != nil {
+			log.Fatalf("%s failed: %s", cmd, err)
+		}
+		return
+	default:
+		log.Fatalf("Unknown command %q", cmd)
+	}
+
 	discordToken := viper.GetString("discord.token")
 
 	if len(discordToken) == 0 || discordToken == "YOUR DISCORD BOT AUTH TOKEN" {
@@ -135,18 +180,7 @@ func main() {
 		}()
 	}
 
-	dialAddr := viper.GetString("mongo.dial-addr")
-	if len(dialAddr) != 0 {
-		log.Warn("DEPRECIATION WARNING: mongo.dial-addr has been renamed to mongo.dial.")
-	} else {
-		dialAddr = viper.GetString("mongo.dial")
-	}
-
-	store, err := mongodb.NewMongoDB(
-		dialAddr,
-		viper.GetString("mongo.database"),
-	)
-
+	store, err := newStorage(viper.GetViper())
 	if err != nil {
 		log.Panicf("Could not connect to DB: %v", err)
 	}
@@ -155,9 +189,14 @@ func main() {
 
 	webConfig := newServerConfig(viper.GetViper(), store)
 
+	// Chat from Discord is queued through the dispatcher, so it can wake the
+	// game servers waiting for it
+	chatDispatcher := gameapi.NewChatDispatcher(store.ChatQueue())
+
 	// Discord server
 	dr := discord.NewRunner(discordToken, store.Accounts(), store.DiscordAuths(),
-		store.Users(), store.MessageLocks(), store.ChatQueue())
+		store.Users(), store.RaidHistory(), store.ChatLog(), store.MessageLocks(), chatDispatcher,
+		store.OnlinePlayers())
 	if err := start(dr, "Discord"); err != nil {
 		log.Fatalf("Could not start Discord, %v", err)
 		os.Exit(1)
@@ -169,7 +208,7 @@ func main() {
 		dr,
 		gameapi.ServerChannels{
 			AuthSuccess: dr.AuthSuccess,
-			ChatQueue:   store.ChatQueue(),
+			ChatQueue:   chatDispatcher,
 		},
 	)
 
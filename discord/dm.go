@@ -3,11 +3,13 @@ package discord
 import (
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/nicksnyder/go-i18n/v2/i18n"
 	"github.com/poundbot/poundbot/messages"
+	"github.com/poundbot/poundbot/storage"
 	"github.com/poundbot/poundbot/types"
 
 	"github.com/sirupsen/logrus"
@@ -24,12 +26,21 @@ type dmAuthStorage interface {
 
 type dmDiscordAccountStorage interface {
 	GetByDiscordID(snowflake string) (types.DiscordAuth, error)
+	IncrementAttempts(playerID string) (int, error)
 }
 
+type dmRaidHistoryStorage interface {
+	Find(storage.RaidHistoryQuery) ([]types.RaidHistory, error)
+}
+
+// dmRaidsLimit is how many raids the raids command lists
+const dmRaidsLimit = 10
+
 type dm struct {
 	us       dmUserStorage
 	as       dmAuthStorage
 	das      dmDiscordAccountStorage
+	rhs      dmRaidHistoryStorage
 	authChan chan<- types.DiscordAuth
 }
 
@@ -83,6 +94,13 @@ func (i dm) process(m discordgo.MessageCreate) string {
 		},
 	}):
 		return i.unregister(m.Author.ID, parts[1:])
+	case localizer.MustLocalize(&i18n.LocalizeConfig{
+		DefaultMessage: &i18n.Message{
+			ID:    "InstructCommandRaids",
+			Other: "raids",
+		},
+	}):
+		return i.raids(m.Author.ID, parts[1:])
 	}
 
 	return localizer.MustLocalize(&i18n.LocalizeConfig{
@@ -131,6 +149,66 @@ func (i dm) unregister(authorID string, parts []string) string {
 	return fmt.Sprintf("Could not find an ID for game %s.\n%s", game, i.status(authorID))
 }
 
+// raids lists the most recent raids on the user's bases, optionally only
+// for the named server
+func (i dm) raids(authorID string, parts []string) string {
+	u, err := i.us.GetByDiscordID(authorID)
+	// Users who unregistered from every game have no player IDs, which
+	// would match everyone's raids
+	if err != nil || len(u.PlayerIDs) == 0 {
+		return "You are not registered anywhere."
+	}
+
+	raids, err := i.rhs.Find(storage.RaidHistoryQuery{
+		PlayerIDs:  u.PlayerIDs,
+		ServerName: strings.Join(parts, " "),
+		Limit:      dmRaidsLimit,
+	})
+	if err != nil {
+		log.WithFields(logrus.Fields{"sys": "dm.raids()"}).WithError(err).Error("storage: Could not find raids")
+		return localizer.MustLocalize(&i18n.LocalizeConfig{
+			DefaultMessage: &i18n.Message{
+				ID:    "InternalError",
+				Other: "Internal error. Please try again.",
+			}})
+	}
+
+	if len(raids) == 0 {
+		return localizer.MustLocalize(&i18n.LocalizeConfig{
+			DefaultMessage: &i18n.Message{
+				ID:    "InstructCommandRaidsNone",
+				Other: "No raids found.",
+			}})
+	}
+
+	lines := make([]string, len(raids)+1)
+	lines[0] = localizer.MustLocalize(&i18n.LocalizeConfig{
+		DefaultMessage: &i18n.Message{
+			ID:    "InstructCommandRaidsHeader",
+			Other: "Your most recent raids:",
+		}})
+	for j, rh := range raids {
+		lines[j+1] = raidHistoryLine(rh)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// raidHistoryLine formats a raid for the raids command
+func raidHistoryLine(rh types.RaidHistory) string {
+	items := make([]string, 0, len(rh.Items))
+	for k, v := range rh.Items {
+		items = append(items, fmt.Sprintf("%s(%d)", k, v))
+	}
+	sort.Strings(items)
+
+	return fmt.Sprintf("`%s` %s: %s - %s",
+		rh.EndedAt.UTC().Format("2006-01-02 15:04 MST"),
+		escapeDiscordString(rh.ServerName),
+		strings.Join(rh.GridPositions, ", "),
+		strings.Join(items, ", "),
+	)
+}
+
 func (i dm) help(authorID string) string {
 	return messages.DMHelpText()
 }
@@ -148,12 +226,41 @@ func (i dm) validatePIN(pin, authorID string) string {
 
 	vpLog = vpLog.WithFields(logrus.Fields{"playerid": da.PlayerID, "guildid": da.GuildSnowflake})
 
-	if !(pinString(da.Pin) == pin) {
+	switch da.Status(iclock().Now()) {
+	case types.DiscordAuthExpired:
 		return localizer.MustLocalize(&i18n.LocalizeConfig{
 			DefaultMessage: &i18n.Message{
-				ID:    "PINInvalid",
-				Other: "Invalid PIN. Please try again.",
+				ID:    "PINExpired",
+				Other: "Your PIN has expired. Request a new one in game.",
 			}})
+	case types.DiscordAuthLocked:
+		return pinLockedMessage(da)
+	}
+
+	if !(pinString(da.Pin) == pin) {
+		// Guesses can arrive at the same time, so the store counts them
+		da.Attempts, err = i.das.IncrementAttempts(da.PlayerID)
+		if err != nil {
+			vpLog.WithError(err).Error("storage: Could not update PIN attempts")
+			return localizer.MustLocalize(&i18n.LocalizeConfig{
+				DefaultMessage: &i18n.Message{
+					ID:    "PINInternalError",
+					Other: "Internal error. Please try again.",
+				}})
+		}
+
+		if da.AttemptsLeft() == 0 {
+			vpLog.Info("Too many invalid PINs")
+			return pinLockedMessage(da)
+		}
+
+		return localizer.MustLocalize(&i18n.LocalizeConfig{
+			DefaultMessage: &i18n.Message{
+				ID:    "PINInvalidAttemptsLeft",
+				Other: "Invalid PIN. Attempts left: {{.Attempts}}",
+			},
+			TemplateData: map[string]int{"Attempts": da.AttemptsLeft()},
+		})
 	}
 
 	authResult := make(chan string)
@@ -181,3 +288,14 @@ func (i dm) validatePIN(pin, authorID string) string {
 	i.authChan <- da
 	return <-authResult
 }
+
+// pinLockedMessage tells the user they have used all their PIN attempts
+func pinLockedMessage(da types.DiscordAuth) string {
+	return localizer.MustLocalize(&i18n.LocalizeConfig{
+		DefaultMessage: &i18n.Message{
+			ID:    "PINLocked",
+			Other: "Too many invalid PINs. You can request a new PIN in game after {{.Time}}.",
+		},
+		TemplateData: map[string]string{"Time": da.ExpiresAt.UTC().Format("15:04 MST")},
+	})
+}
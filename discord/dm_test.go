@@ -0,0 +1,153 @@
+package discord
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/storage/mocks"
+	"github.com/poundbot/poundbot/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDM_raids(t *testing.T) {
+	t.Parallel()
+
+	var user types.User
+	user.Snowflake = "user"
+	user.PlayerIDs = []string{"game:1"}
+	raid := types.RaidHistory{
+		PlayerID:      "game:1",
+		ServerName:    "My_Server",
+		GridPositions: []string{"A1", "B2"},
+		Items:         map[string]int{"wall": 2, "door": 1},
+		EndedAt:       time.Date(2020, 6, 1, 12, 30, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		name     string
+		parts    []string
+		query    *storage.RaidHistoryQuery
+		raids    []types.RaidHistory
+		err      error
+		want     string
+		noUser   bool
+		unlinked bool
+	}{
+		{
+			name:   "not registered",
+			noUser: true,
+			want:   "You are not registered anywhere.",
+		},
+		{
+			name:     "unregistered from all games",
+			unlinked: true,
+			want:     "You are not registered anywhere.",
+		},
+		{
+			name:  "no raids",
+			query: &storage.RaidHistoryQuery{PlayerIDs: []string{"game:1"}, Limit: 10},
+			want:  "No raids found.",
+		},
+		{
+			name:  "raids on a server",
+			parts: []string{"My", "Server"},
+			query: &storage.RaidHistoryQuery{PlayerIDs: []string{"game:1"}, ServerName: "My Server", Limit: 10},
+			raids: []types.RaidHistory{raid},
+			want:  "Your most recent raids:\n`2020-06-01 12:30 UTC` My\\_Server: A1, B2 - door(1), wall(2)",
+		},
+		{
+			name:  "storage error",
+			query: &storage.RaidHistoryQuery{PlayerIDs: []string{"game:1"}, Limit: 10},
+			err:   errors.New("broken"),
+			want:  "Internal error. Please try again.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			us := mocks.UsersStore{}
+			rhs := mocks.RaidHistoryStore{}
+			switch {
+			case tt.noUser:
+				us.On("GetByDiscordID", "user").Return(types.User{}, storage.ErrNotFound)
+			case tt.unlinked:
+				unlinked := user
+				unlinked.PlayerIDs = []string{}
+				us.On("GetByDiscordID", "user").Return(unlinked, nil)
+			default:
+				us.On("GetByDiscordID", "user").Return(user, nil)
+			}
+			if tt.query != nil {
+				rhs.On("Find", *tt.query).Return(tt.raids, tt.err).Once()
+			}
+
+			d := dm{us: &us, rhs: &rhs}
+			assert.Equal(t, tt.want, d.raids("user", tt.parts))
+			rhs.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDM_validatePIN(t *testing.T) {
+	t.Parallel()
+
+	// Fixed dates keep the tests independent of the mocked clock
+	future := time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC)
+	past := time.Date(1960, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		auth     types.DiscordAuth
+		pin      string
+		attempts int
+		want     string
+	}{
+		{
+			name: "expired",
+			auth: types.DiscordAuth{Pin: 1234, ExpiresAt: past},
+			pin:  "0001",
+			want: "Your PIN has expired. Request a new one in game.",
+		},
+		{
+			name: "locked",
+			auth: types.DiscordAuth{Pin: 1234, ExpiresAt: future, Attempts: types.DiscordAuthMaxAttempts},
+			pin:  "1234",
+			want: "Too many invalid PINs. You can request a new PIN in game after 00:00 UTC.",
+		},
+		{
+			name:     "wrong pin",
+			auth:     types.DiscordAuth{Pin: 1234, ExpiresAt: future, Attempts: 1},
+			pin:      "0001",
+			attempts: 2,
+			want:     "Invalid PIN. Attempts left: 3",
+		},
+		{
+			name:     "last wrong pin",
+			auth:     types.DiscordAuth{Pin: 1234, ExpiresAt: future, Attempts: types.DiscordAuthMaxAttempts - 1},
+			pin:      "0001",
+			attempts: types.DiscordAuthMaxAttempts,
+			want:     "Too many invalid PINs. You can request a new PIN in game after 00:00 UTC.",
+		},
+		{
+			name:     "concurrent wrong pins",
+			auth:     types.DiscordAuth{Pin: 1234, ExpiresAt: future, Attempts: 1},
+			pin:      "0001",
+			attempts: types.DiscordAuthMaxAttempts,
+			want:     "Too many invalid PINs. You can request a new PIN in game after 00:00 UTC.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			das := mocks.DiscordAuthsStore{}
+			das.On("GetByDiscordID", "user").Return(tt.auth, nil)
+			if tt.attempts != 0 {
+				das.On("IncrementAttempts", tt.auth.PlayerID).Return(tt.attempts, nil).Once()
+			}
+
+			d := dm{das: &das}
+			assert.Equal(t, tt.want, d.validatePIN(tt.pin, "user"))
+			das.AssertExpectations(t)
+		})
+	}
+}
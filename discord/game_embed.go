@@ -0,0 +1,142 @@
+package discord
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/poundbot/poundbot/types"
+)
+
+// Discord's embed limits, in characters
+const (
+	embedTitleLimit       = 256
+	embedDescriptionLimit = 4096
+	embedFieldsLimit      = 25
+	embedFieldNameLimit   = 256
+	embedFieldValueLimit  = 1024
+	embedFooterLimit      = 2048
+	embedAuthorNameLimit  = 256
+	embedTotalLimit       = 6000
+)
+
+// gameMessageText joins message parts, escaping the parts that ask for it
+func gameMessageText(parts []types.GameMessagePart) string {
+	var text string
+	for i := range parts {
+		switch parts[i].Escape {
+		case true:
+			text = text + escapeDiscordString(parts[i].Content)
+		case false:
+			text = text + parts[i].Content
+		}
+	}
+	return text
+}
+
+// gameMessageEmbed renders a game message as a Discord embed. Errors wrap
+// types.ErrInvalidEmbed if Discord would reject the embed.
+func gameMessageEmbed(m types.GameMessage) (*discordgo.MessageEmbed, error) {
+	e := embedBuilder{embed: &discordgo.MessageEmbed{Color: m.EmbedStyle.ColorInt()}}
+	e.embed.Description = e.text("description", m.MessageParts, embedDescriptionLimit)
+
+	if m.Embed == nil {
+		return e.build()
+	}
+	me := m.Embed
+
+	e.embed.Title = e.text("title", me.Title, embedTitleLimit)
+	e.embed.URL = e.url("url", me.URL)
+
+	if name := e.text("author name", me.Author.Name, embedAuthorNameLimit); len(name) != 0 {
+		e.embed.Author = &discordgo.MessageEmbedAuthor{
+			Name:    name,
+			URL:     e.url("author url", me.Author.URL),
+			IconURL: e.url("author icon url", me.Author.IconURL),
+		}
+	}
+
+	if len(me.Fields) > embedFieldsLimit {
+		e.fail("has %d fields, the limit is %d", len(me.Fields), embedFieldsLimit)
+	}
+	for i, field := range me.Fields {
+		name := e.text(fmt.Sprintf("field %d name", i+1), field.Name, embedFieldNameLimit)
+		value := e.text(fmt.Sprintf("field %d value", i+1), field.Value, embedFieldValueLimit)
+		if len(name) == 0 || len(value) == 0 {
+			e.fail("field %d needs a name and a value", i+1)
+		}
+		e.embed.Fields = append(e.embed.Fields, &discordgo.MessageEmbedField{
+			Name:   name,
+			Value:  value,
+			Inline: field.Inline,
+		})
+	}
+
+	if text := e.text("footer", me.Footer.Text, embedFooterLimit); len(text) != 0 {
+		e.embed.Footer = &discordgo.MessageEmbedFooter{
+			Text:    text,
+			IconURL: e.url("footer icon url", me.Footer.IconURL),
+		}
+	}
+
+	if !me.Timestamp.IsZero() {
+		e.embed.Timestamp = me.Timestamp.UTC().Format(time.RFC3339)
+	}
+	if imageURL := e.url("image url", me.ImageURL); len(imageURL) != 0 {
+		e.embed.Image = &discordgo.MessageEmbedImage{URL: imageURL}
+	}
+	if thumbnailURL := e.url("thumbnail url", me.ThumbnailURL); len(thumbnailURL) != 0 {
+		e.embed.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: thumbnailURL}
+	}
+
+	return e.build()
+}
+
+// embedBuilder keeps the first problem found while rendering an embed, and
+// the total length of its text
+type embedBuilder struct {
+	embed *discordgo.MessageEmbed
+	total int
+	err   error
+}
+
+func (e *embedBuilder) fail(format string, args ...interface{}) {
+	if e.err == nil {
+		e.err = fmt.Errorf("%w: "+format, append([]interface{}{types.ErrInvalidEmbed}, args...)...)
+	}
+}
+
+// text renders message parts, checking them against the limit
+func (e *embedBuilder) text(name string, parts []types.GameMessagePart, limit int) string {
+	text := gameMessageText(parts)
+	length := utf8.RuneCountInString(text)
+	if length > limit {
+		e.fail("%s is %d characters, the limit is %d", name, length, limit)
+	}
+	e.total += length
+	return text
+}
+
+// url checks that a URL is empty or an absolute http(s) URL
+func (e *embedBuilder) url(name, rawURL string) string {
+	if len(rawURL) == 0 {
+		return ""
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+		e.fail("%s must be an http or https URL", name)
+	}
+	return rawURL
+}
+
+func (e *embedBuilder) build() (*discordgo.MessageEmbed, error) {
+	if e.total > embedTotalLimit {
+		e.fail("text is %d characters, the limit is %d", e.total, embedTotalLimit)
+	}
+	if e.err != nil {
+		return nil, e.err
+	}
+	return e.embed, nil
+}
@@ -0,0 +1,143 @@
+package discord
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/poundbot/poundbot/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func parts(s string) []types.GameMessagePart {
+	return []types.GameMessagePart{{Content: s, Escape: true}}
+}
+
+func TestGameMessageEmbed(t *testing.T) {
+	t.Parallel()
+
+	fields := func(n int) []types.GameMessageEmbedField {
+		var f []types.GameMessageEmbedField
+		for i := 0; i < n; i++ {
+			f = append(f, types.GameMessageEmbedField{Name: parts("name"), Value: parts("value")})
+		}
+		return f
+	}
+
+	tests := []struct {
+		name    string
+		m       types.GameMessage
+		want    *discordgo.MessageEmbed
+		wantErr string
+	}{
+		{
+			name: "description only",
+			m: types.GameMessage{
+				EmbedStyle: types.GameMessageEmbedStyle{Color: "#ff0000"},
+				MessageParts: []types.GameMessagePart{
+					{Content: "**bold** "},
+					{Content: "@everyone", Escape: true},
+				},
+			},
+			want: &discordgo.MessageEmbed{Description: "**bold** @​everyone", Color: 0xff0000},
+		},
+		{
+			name: "full embed",
+			m: types.GameMessage{
+				EmbedStyle:   types.GameMessageEmbedStyle{Color: "#000000"},
+				MessageParts: parts("description"),
+				Embed: &types.GameMessageEmbed{
+					Title: parts("`title`"),
+					URL:   "https://example.com",
+					Author: types.GameMessageEmbedAuthor{
+						Name:    parts("author"),
+						IconURL: "https://example.com/author.png",
+					},
+					Fields: []types.GameMessageEmbedField{
+						{Name: parts("Players"), Value: parts("10"), Inline: true},
+					},
+					Footer:       types.GameMessageEmbedFooter{Text: parts("footer")},
+					Timestamp:    time.Date(2020, 6, 1, 12, 0, 0, 0, time.FixedZone("X", 3600)),
+					ImageURL:     "http://example.com/image.png",
+					ThumbnailURL: "https://example.com/thumb.png",
+				},
+			},
+			want: &discordgo.MessageEmbed{
+				Description: "description",
+				Title:       "\\`title\\`",
+				URL:         "https://example.com",
+				Author:      &discordgo.MessageEmbedAuthor{Name: "author", IconURL: "https://example.com/author.png"},
+				Fields:      []*discordgo.MessageEmbedField{{Name: "Players", Value: "10", Inline: true}},
+				Footer:      &discordgo.MessageEmbedFooter{Text: "footer"},
+				Timestamp:   "2020-06-01T11:00:00Z",
+				Image:       &discordgo.MessageEmbedImage{URL: "http://example.com/image.png"},
+				Thumbnail:   &discordgo.MessageEmbedThumbnail{URL: "https://example.com/thumb.png"},
+			},
+		},
+		{
+			name:    "long description",
+			m:       types.GameMessage{MessageParts: parts(strings.Repeat("a", embedDescriptionLimit+1))},
+			wantErr: "description is 4097 characters, the limit is 4096",
+		},
+		{
+			name: "long title",
+			m: types.GameMessage{Embed: &types.GameMessageEmbed{
+				Title: parts(strings.Repeat("é", embedTitleLimit+1)),
+			}},
+			wantErr: "title is 257 characters",
+		},
+		{
+			name: "escaping counts",
+			m: types.GameMessage{Embed: &types.GameMessageEmbed{
+				Title: parts(strings.Repeat("`", embedTitleLimit/2+1)),
+			}},
+			wantErr: "title is 258 characters",
+		},
+		{
+			name:    "too many fields",
+			m:       types.GameMessage{Embed: &types.GameMessageEmbed{Fields: fields(embedFieldsLimit + 1)}},
+			wantErr: "has 26 fields, the limit is 25",
+		},
+		{
+			name: "empty field value",
+			m: types.GameMessage{Embed: &types.GameMessageEmbed{Fields: []types.GameMessageEmbedField{
+				{Name: parts("name")},
+			}}},
+			wantErr: "field 1 needs a name and a value",
+		},
+		{
+			name:    "bad url",
+			m:       types.GameMessage{Embed: &types.GameMessageEmbed{ImageURL: "javascript:alert(1)"}},
+			wantErr: "image url must be an http or https URL",
+		},
+		{
+			name: "total too long",
+			m: types.GameMessage{
+				MessageParts: parts(strings.Repeat("a", embedDescriptionLimit)),
+				Embed: &types.GameMessageEmbed{Fields: []types.GameMessageEmbedField{
+					{Name: parts("name"), Value: parts(strings.Repeat("b", embedFieldValueLimit))},
+					{Name: parts("name"), Value: parts(strings.Repeat("b", embedFieldValueLimit))},
+				}},
+			},
+			wantErr: "text is 6152 characters, the limit is 6000",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := gameMessageEmbed(tt.m)
+			if tt.wantErr != "" {
+				if assert.NotNil(t, err) {
+					assert.True(t, errors.Is(err, types.ErrInvalidEmbed))
+					assert.Contains(t, err.Error(), tt.wantErr)
+				}
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -3,6 +3,7 @@ package discord
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
@@ -11,40 +12,48 @@ import (
 )
 
 type gameDiscordMessageSender interface {
-	sendChannelMessage(userID, channelID, message string) error
-	sendChannelEmbed(userID, channelID, message string, color int) error
+	sendChannelMessage(userID, channelID, message string) (string, error)
+	sendChannelEmbed(userID, channelID string, embed *discordgo.MessageEmbed) (string, error)
+	editChannelMessage(userID, channelID, messageID, message string, embed *discordgo.MessageEmbed) error
+	deleteChannelMessage(channelID, messageID string) error
+	pinChannelMessage(channelID, messageID string) error
+}
+
+type chatLogger interface {
+	Add(types.ChatLogEntry) error
 }
 
 type guildFinder func(string) (*discordgo.Guild, error)
 
-// gameMessageHandler handles the messages interface from games
+// gameMessageHandler handles the messages interface from games, sending,
+// editing or deleting a message in a channel
 func gameMessageHandler(userID string, m types.GameMessage, gf guildFinder, ms gameDiscordMessageSender) {
-	defer close(m.ErrorResponse)
+	defer close(m.Response)
 
 	mhLog := log.WithFields(logrus.Fields{
 		"cmd":   "gameMessageHandler",
 		"gID":   m.Snowflake,
 		"cName": m.ChannelName,
+		"mID":   m.MessageID,
 	})
 
-	sendErrorResponse := func(errorCh chan<- error, err error) {
-		select {
-		case errorCh <- err:
-		case <-time.After(time.Second / 2):
-			mhLog.WithError(err).Error("no response sending message error to channel")
-		}
+	sendResponse := func(response types.GameMessageResponse) {
+		sendGameMessageResponse(m.Response, response, mhLog)
+	}
+	sendErrorResponse := func(err error) {
+		sendResponse(types.GameMessageResponse{Error: err})
 	}
 
 	channelID := ""
 
 	if len(m.Snowflake) == 0 {
-		sendErrorResponse(m.ErrorResponse, fmt.Errorf("no server defined"))
+		sendErrorResponse(fmt.Errorf("no server defined"))
 		mhLog.Error("no guild id provided with channel name")
 		return
 	}
 	guild, err := gf(m.Snowflake)
 	if err != nil {
-		sendErrorResponse(m.ErrorResponse, fmt.Errorf("server not found"))
+		sendErrorResponse(fmt.Errorf("server not found"))
 		mhLog.WithError(err).Error("Could not get guild from session")
 		return
 	}
@@ -58,37 +67,138 @@ func gameMessageHandler(userID string, m types.GameMessage, gf guildFinder, ms g
 	}
 
 	if len(channelID) == 0 {
-		sendErrorResponse(m.ErrorResponse, errors.New("channel not found"))
+		sendErrorResponse(errors.New("channel not found"))
 		mhLog.Info("could not find channel")
 		return
 	}
 
-	var message string
-	for i := range m.MessageParts {
-		switch m.MessageParts[i].Escape {
-		case true:
-			message = message + escapeDiscordString(m.MessageParts[i].Content)
-		case false:
-			message = message + m.MessageParts[i].Content
+	if m.Action == types.GameMessageActionDelete {
+		if err := ms.deleteChannelMessage(channelID, m.MessageID); err != nil {
+			sendErrorResponse(gameMessageError(err))
+			mhLog.WithError(err).Error("Error deleting message")
+			return
 		}
+		sendResponse(types.GameMessageResponse{ChannelID: channelID, MessageID: m.MessageID})
+		return
 	}
 
+	var message string
+	var embed *discordgo.MessageEmbed
 	switch m.Type {
 	case types.GameMessageTypePlain:
-		err = ms.sendChannelMessage(userID, channelID, message)
+		message = gameMessageText(m.MessageParts)
 	case types.GameMessageTypeEmbed:
-		err = ms.sendChannelEmbed(userID, channelID, message, m.EmbedStyle.ColorInt())
+		embed, err = gameMessageEmbed(m)
+		if err != nil {
+			sendErrorResponse(err)
+			mhLog.WithError(err).Info("invalid embed")
+			return
+		}
+	}
+
+	messageID := m.MessageID
+	switch {
+	case m.Action == types.GameMessageActionEdit:
+		err = ms.editChannelMessage(userID, channelID, messageID, message, embed)
+	case embed != nil:
+		messageID, err = ms.sendChannelEmbed(userID, channelID, embed)
+	default:
+		messageID, err = ms.sendChannelMessage(userID, channelID, message)
+	}
+	if err != nil {
+		sendErrorResponse(gameMessageError(err))
+		mhLog.WithError(err).Error("Error sending message to channel")
+		return
+	}
+
+	// The message was sent even if it can't be pinned
+	if m.Pin && m.Action == types.GameMessageActionSend {
+		if err := ms.pinChannelMessage(channelID, messageID); err != nil {
+			mhLog.WithError(err).Warn("Could not pin message")
+		}
+	}
+
+	sendResponse(types.GameMessageResponse{ChannelID: channelID, MessageID: messageID})
+}
+
+// sendGameMessageResponse sends the result of a game message, giving up if
+// nothing is waiting for it
+func sendGameMessageResponse(rChan chan<- types.GameMessageResponse, response types.GameMessageResponse, l *logrus.Entry) {
+	select {
+	case rChan <- response:
+	case <-time.After(time.Second / 2):
+		l.WithError(response.Error).Error("no response sending message result to channel")
+	}
+}
+
+type playerDMSender interface {
+	isGuildMember(guildID, userID string) (bool, error)
+	sendPrivateMessage(snowflake, messageID, message string) (string, error)
+	sendPrivateEmbed(snowflake string, embed *discordgo.MessageEmbed) (string, error)
+}
+
+// playerDMHandler sends a game message to a player's discord user, if they
+// are still a member of the server's guild
+func playerDMHandler(dm types.PlayerDM, ds playerDMSender) {
+	m := dm.Message
+	defer close(m.Response)
+
+	dmLog := log.WithFields(logrus.Fields{
+		"cmd": "playerDMHandler",
+		"gID": m.Snowflake,
+		"uID": dm.Snowflake,
+	})
+
+	sendErrorResponse := func(err error) {
+		sendGameMessageResponse(m.Response, types.GameMessageResponse{Error: err}, dmLog)
+	}
+
+	isMember, err := ds.isGuildMember(m.Snowflake, dm.Snowflake)
+	if err != nil {
+		sendErrorResponse(errors.New("could not send to user"))
+		dmLog.WithError(err).Error("Could not get guild member")
+		return
+	}
+	if !isMember {
+		sendErrorResponse(types.ErrNotGuildMember)
+		dmLog.Info("user is not a guild member")
+		return
+	}
+
+	var messageID string
+	switch m.Type {
+	case types.GameMessageTypeEmbed:
+		var embed *discordgo.MessageEmbed
+		embed, err = gameMessageEmbed(m)
+		if err != nil {
+			sendErrorResponse(err)
+			dmLog.WithError(err).Info("invalid embed")
+			return
+		}
+		messageID, err = ds.sendPrivateEmbed(dm.Snowflake, embed)
+	default:
+		messageID, err = ds.sendPrivateMessage(dm.Snowflake, "", gameMessageText(m.MessageParts))
 	}
 	if err != nil {
-		m.ErrorResponse <- errors.New("could not send to channel")
-		mhLog.WithError(err).Error("Error sending chat to channel")
+		sendErrorResponse(privateMessageError(err))
+		dmLog.WithError(err).Error("Error sending DM")
 		return
 	}
 
+	sendGameMessageResponse(m.Response, types.GameMessageResponse{MessageID: messageID}, dmLog)
+}
+
+// gameMessageError is the error returned to the game for a failed send,
+// edit or delete
+func gameMessageError(err error) error {
+	if errors.Is(err, types.ErrMessageNotFound) {
+		return types.ErrMessageNotFound
+	}
+	return errors.New("could not send to channel")
 }
 
 // gameChatHandler handles game chat messages
-func gameChatHandler(userID string, cm types.ChatMessage, gf guildFinder, ms gameDiscordMessageSender) {
+func gameChatHandler(userID string, cm types.ChatMessage, gf guildFinder, ms gameDiscordMessageSender, cl chatLogger) {
 	ccLog := log.WithFields(logrus.Fields{
 		"cmd":   "gameChatHandler",
 		"pID":   cm.PlayerID,
@@ -96,12 +206,16 @@ func gameChatHandler(userID string, cm types.ChatMessage, gf guildFinder, ms gam
 		"name":  cm.DisplayName,
 		"dName": cm.DiscordName,
 	})
+	if cm.ErrorResponse != nil {
+		defer close(cm.ErrorResponse)
+	}
+
 	var clan = ""
 	if cm.ClanTag != "" {
 		clan = fmt.Sprintf("[%s] ", cm.ClanTag)
 	}
 
-	err := ms.sendChannelMessage(
+	_, err := ms.sendChannelMessage(
 		userID,
 		cm.ChannelID,
 		fmt.Sprintf("☢️ @%s **%s%s**: %s",
@@ -111,5 +225,88 @@ func gameChatHandler(userID string, cm types.ChatMessage, gf guildFinder, ms gam
 
 	if err != nil {
 		ccLog.WithError(err).Error("Error sending chat to channel.")
+		if cm.ErrorResponse != nil {
+			select {
+			case cm.ErrorResponse <- errors.New("could not send to channel"):
+			case <-time.After(time.Second / 2):
+				ccLog.Error("no response sending chat error to channel")
+			}
+		}
+		return
+	}
+
+	if err := cl.Add(types.NewChatLogEntry(types.ChatSourceGame, cm)); err != nil {
+		ccLog.WithError(err).Error("storage: Could not log chat")
+	}
+}
+
+type guildMemberGetter interface {
+	guildMember(guildID, userID string) (*discordgo.Member, error)
+}
+
+// guildMemberHandler looks up a user's membership of a guild for the game
+func guildMemberHandler(gmr types.GuildMemberRequest, mg guildMemberGetter, state roleGuildGetter) {
+	defer close(gmr.ResponseChan)
+
+	gmLog := log.WithFields(logrus.Fields{"cmd": "guildMemberHandler", "gID": gmr.GuildID, "uID": gmr.Snowflake})
+
+	respond := func(response types.GuildMemberResponse) {
+		select {
+		case gmr.ResponseChan <- response:
+		case <-time.After(time.Second / 2):
+			gmLog.WithError(response.Error).Error("no response sending guild member")
+		}
+	}
+
+	member, err := mg.guildMember(gmr.GuildID, gmr.Snowflake)
+	if err != nil {
+		gmLog.WithError(err).Error("Could not get guild member")
+		respond(types.GuildMemberResponse{Error: errors.New("could not get guild member")})
+		return
+	}
+	if member == nil {
+		respond(types.GuildMemberResponse{})
+		return
+	}
+
+	guild, err := state.Guild(gmr.GuildID)
+	if err != nil {
+		gmLog.WithError(err).Error("Could not find guild")
+		respond(types.GuildMemberResponse{Error: errors.New("server not found")})
+		return
+	}
+
+	respond(types.GuildMemberResponse{Member: newGuildMember(member, guild.Roles)})
+}
+
+// newGuildMember converts a discord member, naming its roles from the guild's
+func newGuildMember(member *discordgo.Member, guildRoles []*discordgo.Role) *types.GuildMember {
+	gm := types.GuildMember{
+		Snowflake:   member.User.ID,
+		DiscordName: member.User.String(),
+		Nick:        member.Nick,
+	}
+	// Timestamps are empty when not set
+	if joinedAt, err := member.JoinedAt.Parse(); err == nil {
+		gm.JoinedAt = joinedAt.UTC()
+	}
+	if boostingSince, err := member.PremiumSince.Parse(); err == nil {
+		gm.BoostingSince = boostingSince.UTC()
 	}
+
+	var roles []*discordgo.Role
+	for _, role := range guildRoles {
+		for _, roleID := range member.Roles {
+			if role.ID == roleID {
+				roles = append(roles, role)
+				break
+			}
+		}
+	}
+	sort.Slice(roles, func(i, j int) bool { return roles[i].Position > roles[j].Position })
+	for _, role := range roles {
+		gm.Roles = append(gm.Roles, types.GuildMemberRole{ID: role.ID, Name: role.Name, Color: role.Color})
+	}
+
+	return &gm
 }
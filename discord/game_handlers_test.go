@@ -0,0 +1,288 @@
+package discord
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/poundbot/poundbot/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type gameMessageSenderMock struct {
+	calls []string
+	err   error
+}
+
+func (m *gameMessageSenderMock) sendChannelMessage(userID, channelID, message string) (string, error) {
+	m.calls = append(m.calls, "send "+channelID+" "+message)
+	return "new", m.err
+}
+
+func (m *gameMessageSenderMock) sendChannelEmbed(userID, channelID string, embed *discordgo.MessageEmbed) (string, error) {
+	m.calls = append(m.calls, "embed "+channelID+" "+embed.Description)
+	return "new", m.err
+}
+
+func (m *gameMessageSenderMock) editChannelMessage(userID, channelID, messageID, message string, embed *discordgo.MessageEmbed) error {
+	if embed != nil {
+		message = embed.Description
+	}
+	m.calls = append(m.calls, "edit "+channelID+" "+messageID+" "+message)
+	return m.err
+}
+
+func (m *gameMessageSenderMock) deleteChannelMessage(channelID, messageID string) error {
+	m.calls = append(m.calls, "delete "+channelID+" "+messageID)
+	return m.err
+}
+
+func (m *gameMessageSenderMock) pinChannelMessage(channelID, messageID string) error {
+	m.calls = append(m.calls, "pin "+channelID+" "+messageID)
+	return m.err
+}
+
+func TestGameMessageHandler(t *testing.T) {
+	t.Parallel()
+
+	guild := &discordgo.Guild{Channels: []*discordgo.Channel{
+		{ID: "1234", Name: "general", Type: discordgo.ChannelTypeGuildText},
+	}}
+	gf := func(string) (*discordgo.Guild, error) { return guild, nil }
+	parts := []types.GameMessagePart{{Content: "hi"}}
+
+	tests := []struct {
+		name    string
+		m       types.GameMessage
+		err     error
+		want    types.GameMessageResponse
+		wantErr error
+		calls   []string
+	}{
+		{
+			name:  "send",
+			m:     types.GameMessage{ChannelName: "general", MessageParts: parts},
+			want:  types.GameMessageResponse{ChannelID: "1234", MessageID: "new"},
+			calls: []string{"send 1234 hi"},
+		},
+		{
+			name:  "send embed",
+			m:     types.GameMessage{Type: types.GameMessageTypeEmbed, ChannelName: "general", MessageParts: parts},
+			want:  types.GameMessageResponse{ChannelID: "1234", MessageID: "new"},
+			calls: []string{"embed 1234 hi"},
+		},
+		{
+			name:  "send pinned",
+			m:     types.GameMessage{Type: types.GameMessageTypeEmbed, ChannelName: "1234", MessageParts: parts, Pin: true},
+			want:  types.GameMessageResponse{ChannelID: "1234", MessageID: "new"},
+			calls: []string{"embed 1234 hi", "pin 1234 new"},
+		},
+		{
+			name: "edit pinned",
+			m: types.GameMessage{
+				Action: types.GameMessageActionEdit, ChannelName: "1234", MessageID: "1", MessageParts: parts, Pin: true,
+			},
+			want:  types.GameMessageResponse{ChannelID: "1234", MessageID: "1"},
+			calls: []string{"edit 1234 1 hi"},
+		},
+		{
+			name: "edit",
+			m: types.GameMessage{
+				Action: types.GameMessageActionEdit, ChannelName: "1234", MessageID: "1", MessageParts: parts,
+			},
+			want:  types.GameMessageResponse{ChannelID: "1234", MessageID: "1"},
+			calls: []string{"edit 1234 1 hi"},
+		},
+		{
+			name:  "delete",
+			m:     types.GameMessage{Action: types.GameMessageActionDelete, ChannelName: "1234", MessageID: "1"},
+			want:  types.GameMessageResponse{ChannelID: "1234", MessageID: "1"},
+			calls: []string{"delete 1234 1"},
+		},
+		{
+			name:    "deleted in discord",
+			m:       types.GameMessage{Action: types.GameMessageActionDelete, ChannelName: "1234", MessageID: "1"},
+			err:     types.ErrMessageNotFound,
+			wantErr: types.ErrMessageNotFound,
+			calls:   []string{"delete 1234 1"},
+		},
+		{
+			name:    "send failed",
+			m:       types.GameMessage{ChannelName: "general", MessageParts: parts},
+			err:     errors.New("not permitted to send to channel"),
+			wantErr: errors.New("could not send to channel"),
+			calls:   []string{"send 1234 hi"},
+		},
+		{
+			name:    "unknown channel",
+			m:       types.GameMessage{ChannelName: "other", MessageParts: parts},
+			wantErr: errors.New("channel not found"),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ms := &gameMessageSenderMock{err: tt.err}
+			rChan := make(chan types.GameMessageResponse)
+			tt.m.Snowflake = "guild"
+			tt.m.Response = rChan
+			go gameMessageHandler("bot", tt.m, gf, ms)
+
+			got := <-rChan
+			_, open := <-rChan
+			assert.False(t, open, "response channel should be closed")
+
+			assert.Equal(t, tt.calls, ms.calls)
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, got.Error)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+type playerDMSenderMock struct {
+	member  bool
+	sendErr error
+	sent    []string
+}
+
+func (m *playerDMSenderMock) isGuildMember(guildID, userID string) (bool, error) {
+	return m.member, nil
+}
+
+func (m *playerDMSenderMock) sendPrivateMessage(snowflake, messageID, message string) (string, error) {
+	m.sent = append(m.sent, snowflake+" "+message)
+	return "dm", m.sendErr
+}
+
+func (m *playerDMSenderMock) sendPrivateEmbed(snowflake string, embed *discordgo.MessageEmbed) (string, error) {
+	m.sent = append(m.sent, snowflake+" embed "+embed.Description)
+	return "dm", m.sendErr
+}
+
+func TestPlayerDMHandler(t *testing.T) {
+	t.Parallel()
+
+	parts := []types.GameMessagePart{{Content: "hi"}}
+	dmClosed := &discordgo.RESTError{Message: &discordgo.APIErrorMessage{
+		Code: discordgo.ErrCodeCannotSendMessagesToThisUser,
+	}}
+
+	tests := []struct {
+		name    string
+		m       types.GameMessage
+		ds      *playerDMSenderMock
+		want    types.GameMessageResponse
+		wantErr error
+		sent    []string
+	}{
+		{
+			name: "plain",
+			m:    types.GameMessage{MessageParts: parts},
+			ds:   &playerDMSenderMock{member: true},
+			want: types.GameMessageResponse{MessageID: "dm"},
+			sent: []string{"user hi"},
+		},
+		{
+			name: "embed",
+			m:    types.GameMessage{Type: types.GameMessageTypeEmbed, MessageParts: parts},
+			ds:   &playerDMSenderMock{member: true},
+			want: types.GameMessageResponse{MessageID: "dm"},
+			sent: []string{"user embed hi"},
+		},
+		{
+			name:    "not a member",
+			m:       types.GameMessage{MessageParts: parts},
+			ds:      &playerDMSenderMock{},
+			wantErr: types.ErrNotGuildMember,
+		},
+		{
+			name:    "DMs closed",
+			m:       types.GameMessage{MessageParts: parts},
+			ds:      &playerDMSenderMock{member: true, sendErr: fmt.Errorf("error sending private message, %w", dmClosed)},
+			wantErr: types.ErrDMsClosed,
+			sent:    []string{"user hi"},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rChan := make(chan types.GameMessageResponse)
+			tt.m.Snowflake = "guild"
+			tt.m.Response = rChan
+			go playerDMHandler(types.PlayerDM{Snowflake: "user", Message: tt.m}, tt.ds)
+
+			got := <-rChan
+			assert.Equal(t, tt.sent, tt.ds.sent)
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, got.Error)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNewGuildMember(t *testing.T) {
+	t.Parallel()
+
+	guildRoles := []*discordgo.Role{
+		{ID: "1", Name: "@everyone", Position: 0},
+		{ID: "2", Name: "Member", Position: 1, Color: 0x00ff00},
+		{ID: "3", Name: "VIP", Position: 2, Color: 0xff0000},
+		{ID: "4", Name: "Admin", Position: 3},
+	}
+
+	tests := []struct {
+		name   string
+		member *discordgo.Member
+		want   *types.GuildMember
+	}{
+		{
+			name: "roles highest first",
+			member: &discordgo.Member{
+				User:         &discordgo.User{ID: "user", Username: "player", Discriminator: "0001"},
+				Nick:         "nick",
+				Roles:        []string{"2", "3"},
+				JoinedAt:     "2020-06-01T12:00:00.000000+00:00",
+				PremiumSince: "2020-06-02T12:00:00.000000+02:00",
+			},
+			want: &types.GuildMember{
+				Snowflake:   "user",
+				DiscordName: "player#0001",
+				Nick:        "nick",
+				Roles: []types.GuildMemberRole{
+					{ID: "3", Name: "VIP", Color: 0xff0000},
+					{ID: "2", Name: "Member", Color: 0x00ff00},
+				},
+				JoinedAt:      time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC),
+				BoostingSince: time.Date(2020, 6, 2, 10, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "not boosting",
+			member: &discordgo.Member{
+				User:     &discordgo.User{ID: "user", Username: "player", Discriminator: "0001"},
+				JoinedAt: "2020-06-01T12:00:00.000000+00:00",
+			},
+			want: &types.GuildMember{
+				Snowflake:   "user",
+				DiscordName: "player#0001",
+				JoinedAt:    time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, newGuildMember(tt.member, guildRoles))
+		})
+	}
+}
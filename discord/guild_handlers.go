@@ -1,8 +1,10 @@
 package discord
 
 import (
+	"errors"
+
 	"github.com/bwmarrin/discordgo"
-	"github.com/globalsign/mgo"
+	"github.com/poundbot/poundbot/storage"
 	"github.com/poundbot/poundbot/types"
 	"github.com/sirupsen/logrus"
 )
@@ -37,7 +39,7 @@ func (g guildCreate) guildCreate(s *discordgo.Session, gc *discordgo.GuildCreate
 
 	account, err := g.as.GetByDiscordGuild(gc.ID)
 	if err != nil {
-		if err != mgo.ErrNotFound {
+		if !errors.Is(err, storage.ErrNotFound) {
 			// Some other storage error
 			log.WithError(err).Error("Error loading account")
 			return
@@ -107,7 +109,7 @@ func guildMemberAdd(uf userFinder, gma guildMemberAdder, gID, uID string) {
 	}
 	_, err = gma.GetByDiscordGuild(gID)
 	if err != nil {
-		if err != mgo.ErrNotFound {
+		if !errors.Is(err, storage.ErrNotFound) {
 			gmaLog.WithError(err).Trace("Could not get account for guild")
 		}
 		return
@@ -138,7 +140,7 @@ func guildMemberRemove(uf userFinder, gmr guildMemberRemover, gID, uID string) {
 	}
 	account, err := gmr.GetByDiscordGuild(gID)
 	if err != nil {
-		if err != mgo.ErrNotFound {
+		if !errors.Is(err, storage.ErrNotFound) {
 			gmrLog.WithError(err).Trace("Could not get account for guild ID")
 		}
 		return
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 	"text/tabwriter"
@@ -12,10 +13,14 @@ import (
 	"github.com/gofrs/uuid"
 	"github.com/nicksnyder/go-i18n/v2/i18n"
 	"github.com/poundbot/poundbot/messages"
+	"github.com/poundbot/poundbot/storage"
 	"github.com/poundbot/poundbot/types"
 	"github.com/sirupsen/logrus"
 )
 
+// channelTagRegexp matches the message tags that can be bound to channels
+var channelTagRegexp = regexp.MustCompile(`\A[a-z0-9_-]+\z`)
+
 type instructResponseType int
 
 const (
@@ -35,7 +40,17 @@ type instructAccountUpdater interface {
 	RemoveServer(snowflake, serverKey string) error
 }
 
-func instruct(botID, channelID, authorID, message string, account types.Account, au instructAccountUpdater) instructResponse {
+type instructChatLogFinder interface {
+	Find(storage.ChatLogQuery) ([]types.ChatLogEntry, error)
+}
+
+type instructUserFinder interface {
+	GetByDiscordID(snowflake string) (types.User, error)
+	GetByPlayerID(playerID string) (types.User, error)
+}
+
+func instruct(botID, channelID, authorID, message string, account types.Account, au instructAccountUpdater,
+	cls instructChatLogFinder, uf instructUserFinder, rg roleGuildGetter, ops instructOnlinePlayersStore) instructResponse {
 	guildID := account.GuildSnowflake
 	adminIDs := account.GetAdminIDs()
 	iLog := log.WithFields(logrus.Fields{
@@ -107,7 +122,21 @@ func instruct(botID, channelID, authorID, message string, account types.Account,
 			Other: "server",
 		},
 	}):
-		return instructServer(parts, channelID, guildID, account, au)
+		return instructServer(parts, channelID, guildID, account, au, rg, ops)
+	case localizer.MustLocalize(&i18n.LocalizeConfig{
+		DefaultMessage: &i18n.Message{
+			ID:    "InstructCommandChatLog",
+			Other: "chatlog",
+		},
+	}):
+		return instructChatLog(parts, account, cls, uf)
+	case localizer.MustLocalize(&i18n.LocalizeConfig{
+		DefaultMessage: &i18n.Message{
+			ID:    "InstructCommandPlayers",
+			Other: "players",
+		},
+	}):
+		return instructPlayers(parts, account, ops, uf, rg)
 	}
 
 	msg := localizer.MustLocalize(&i18n.LocalizeConfig{
@@ -125,7 +154,8 @@ func instruct(botID, channelID, authorID, message string, account types.Account,
 	}
 }
 
-func instructServer(parts []string, channelID, guildID string, account types.Account, au instructAccountUpdater) instructResponse {
+func instructServer(parts []string, channelID, guildID string, account types.Account, au instructAccountUpdater,
+	rg roleGuildGetter, opc instructOnlinePlayersClearer) instructResponse {
 	isLog := log.WithFields(logrus.Fields{"sys": "instructServer",
 		"gID":       guildID,
 		"cID":       channelID,
@@ -250,10 +280,10 @@ func instructServer(parts []string, channelID, guildID string, account types.Acc
 		},
 	})
 
-	chathereCmd := localizer.MustLocalize(&i18n.LocalizeConfig{
+	channelCmd := localizer.MustLocalize(&i18n.LocalizeConfig{
 		DefaultMessage: &i18n.Message{
-			ID:    "InstructCommandServerChatHere",
-			Other: "chathere",
+			ID:    "InstructCommandServerChannel",
+			Other: "channel",
 		},
 	})
 
@@ -271,6 +301,34 @@ func instructServer(parts []string, channelID, guildID string, account types.Acc
 		},
 	})
 
+	roleMapCmd := localizer.MustLocalize(&i18n.LocalizeConfig{
+		DefaultMessage: &i18n.Message{
+			ID:    "InstructCommandServerRoleMap",
+			Other: "rolemap",
+		},
+	})
+
+	offlineAlertCmd := localizer.MustLocalize(&i18n.LocalizeConfig{
+		DefaultMessage: &i18n.Message{
+			ID:    "InstructCommandServerOfflineAlert",
+			Other: "offlinealert",
+		},
+	})
+
+	killFeedCmd := localizer.MustLocalize(&i18n.LocalizeConfig{
+		DefaultMessage: &i18n.Message{
+			ID:    "InstructCommandServerKillFeed",
+			Other: "killfeed",
+		},
+	})
+
+	wipeCmd := localizer.MustLocalize(&i18n.LocalizeConfig{
+		DefaultMessage: &i18n.Message{
+			ID:    "InstructCommandServerWipe",
+			Other: "wipe",
+		},
+	})
+
 	if len(account.Servers)-1 < serverID {
 		return instructResponse{
 			responseType: instructResponseChannel,
@@ -298,6 +356,8 @@ func instructServer(parts []string, channelID, guildID string, account types.Acc
 
 		if err = au.UpdateServer(guildID, oldKey, server); err != nil {
 			isLog.WithError(err).Error("storage error updating server")
+		} else {
+			clearOnlinePlayers(opc, oldKey, isLog)
 		}
 
 		return instructResponse{message: messages.ServerKeyMessage(server.Name, server.Key)}
@@ -332,6 +392,7 @@ func instructServer(parts []string, channelID, guildID string, account types.Acc
 				message:      "Error removing server. Please try again.",
 			}
 		}
+		clearOnlinePlayers(opc, server.Key, isLog)
 		return instructResponse{
 			responseType: instructResponseChannel,
 			message: localizer.MustLocalize(&i18n.LocalizeConfig{
@@ -345,11 +406,25 @@ func instructServer(parts []string, channelID, guildID string, account types.Acc
 				},
 			}),
 		}
-	case chathereCmd:
-		isLog = isLog.WithField("cmd", "server chathere")
-		isLog.Trace("server chathere")
-		server.SetChannelIDForTag(channelID, "chat")
-		server.SetChannelIDForTag(channelID, "serverchat")
+	case channelCmd:
+		isLog = isLog.WithField("cmd", "server channel")
+		isLog.Trace("server channel")
+		tags, err := parseChannelTags(instructions[1:])
+		if err != nil {
+			return instructResponse{
+				responseType: instructResponseChannel,
+				message: localizer.MustLocalize(&i18n.LocalizeConfig{
+					DefaultMessage: &i18n.Message{
+						ID:    "InstructCommandServerChannelUsage",
+						Other: "Usage: `server [id] channel <tag> [tag...]`. Tags are letters, numbers, `-` and `_`, like `chat`, `raids` or `admin`.",
+					},
+				}),
+			}
+		}
+
+		for _, tag := range tags {
+			server.SetChannelIDForTag(channelID, tag)
+		}
 
 		if err = au.UpdateServer(guildID, server.Key, server); err != nil {
 			isLog.WithError(err).Error("storage error updating server")
@@ -360,12 +435,13 @@ func instructServer(parts []string, channelID, guildID string, account types.Acc
 			responseType: instructResponseChannel,
 			message: localizer.MustLocalize(&i18n.LocalizeConfig{
 				DefaultMessage: &i18n.Message{
-					ID:    "InstructCommandServerChatHereResponse",
-					Other: "Server {{.Name}} ({{.ID}}) will chat here",
+					ID:    "InstructCommandServerChannelResponse",
+					Other: "Server {{.Name}} ({{.ID}}) will send {{.Tags}} here",
 				},
 				TemplateData: map[string]string{
 					"Name": server.Name,
 					"ID":   fmt.Sprint(serverID + 1),
+					"Tags": "`" + strings.Join(tags, "`, `") + "`",
 				},
 			}),
 		}
@@ -466,10 +542,104 @@ func instructServer(parts []string, channelID, guildID string, account types.Acc
 				},
 			}),
 		}
+	case roleMapCmd:
+		isLog = isLog.WithField("cmd", "server rolemap")
+		isLog.Trace("server rolemap")
+		return instructServerRoleMap(instructions[1:], serverID, guildID, server, au, rg)
+	case offlineAlertCmd:
+		isLog = isLog.WithField("cmd", "server offlineAlert")
+		isLog.Trace("server offlineAlert")
+		if len(instructions) != 2 {
+			return instructResponse{
+				responseType: instructResponseChannel,
+				message: localizer.MustLocalize(&i18n.LocalizeConfig{
+					DefaultMessage: &i18n.Message{
+						ID:    "InstructCommandServerOfflineAlertUsage",
+						Other: "Usage: `server [id] offlinealert <duration|off>`",
+					},
+				}),
+			}
+		}
+
+		offCmd := localizer.MustLocalize(&i18n.LocalizeConfig{
+			DefaultMessage: &i18n.Message{
+				ID:    "InstructCommandServerOfflineAlertOff",
+				Other: "off",
+			},
+		})
+		templateData := map[string]string{"Name": server.Name, "ID": fmt.Sprint(serverID + 1)}
+
+		var message string
+		if strings.EqualFold(instructions[1], offCmd) {
+			server.OfflineAlert = ""
+			message = localizer.MustLocalize(&i18n.LocalizeConfig{
+				DefaultMessage: &i18n.Message{
+					ID:    "InstructCommandServerOfflineAlertOffResponse",
+					Other: "Offline alerts for {{.ID}}:{{.Name}} are off",
+				},
+				TemplateData: templateData,
+			})
+		} else {
+			// The watchdog checks servers every minute
+			after, err := time.ParseDuration(instructions[1])
+			if err != nil || after < time.Minute {
+				return instructResponse{
+					responseType: instructResponseChannel,
+					message: localizer.MustLocalize(&i18n.LocalizeConfig{
+						DefaultMessage: &i18n.Message{
+							ID:    "InstructCommandServerOfflineAlertInvalidFormat",
+							Other: "Invalid duration format. Use at least a minute. Examples: `5m` = 5 minutes, `1h` = 1 hour",
+						},
+					}),
+				}
+			}
+			server.OfflineAlert = instructions[1]
+			templateData["OfflineAlert"] = server.OfflineAlert
+			message = localizer.MustLocalize(&i18n.LocalizeConfig{
+				DefaultMessage: &i18n.Message{
+					ID:    "InstructCommandServerOfflineAlertResponse",
+					Other: "Admins will be alerted when {{.ID}}:{{.Name}} hasn't checked in for {{.OfflineAlert}}",
+				},
+				TemplateData: templateData,
+			})
+		}
+
+		if err = au.UpdateServer(guildID, server.Key, server); err != nil {
+			isLog.WithError(err).Error("storage error updating server")
+			return instructResponse{message: "Internal error. Please try again."}
+		}
+
+		return instructResponse{responseType: instructResponseChannel, message: message}
+	case killFeedCmd:
+		isLog = isLog.WithField("cmd", "server killfeed")
+		isLog.Trace("server killfeed")
+		return instructServerKillFeed(instructions[1:], serverID, guildID, server, au)
+	case wipeCmd:
+		isLog = isLog.WithField("cmd", "server wipe")
+		isLog.Trace("server wipe")
+		return instructServerWipe(instructions[1:], serverID, guildID, server, au, iclock().Now().UTC())
 	}
 	return instructResponse{responseType: instructResponseNone}
 }
 
+// parseChannelTags lowercases and validates the message tags for the
+// channel command
+func parseChannelTags(args []string) ([]string, error) {
+	if len(args) == 0 {
+		return nil, errors.New("no tags")
+	}
+
+	tags := make([]string, len(args))
+	for i, arg := range args {
+		tag := strings.ToLower(arg)
+		if !channelTagRegexp.MatchString(tag) {
+			return nil, fmt.Errorf("invalid tag %s", arg)
+		}
+		tags[i] = tag
+	}
+	return tags, nil
+}
+
 func instructServerArgs(parts []string, servers []types.AccountServer) (int, []string, error) {
 	resetCmd := localizer.MustLocalize(&i18n.LocalizeConfig{
 		DefaultMessage: &i18n.Message{
@@ -492,10 +662,10 @@ func instructServerArgs(parts []string, servers []types.AccountServer) (int, []s
 		},
 	})
 
-	chathereCmd := localizer.MustLocalize(&i18n.LocalizeConfig{
+	channelCmd := localizer.MustLocalize(&i18n.LocalizeConfig{
 		DefaultMessage: &i18n.Message{
-			ID:    "InstructCommandServerChatHere",
-			Other: "chathere",
+			ID:    "InstructCommandServerChannel",
+			Other: "channel",
 		},
 	})
 
@@ -513,8 +683,36 @@ func instructServerArgs(parts []string, servers []types.AccountServer) (int, []s
 		},
 	})
 
+	roleMapCmd := localizer.MustLocalize(&i18n.LocalizeConfig{
+		DefaultMessage: &i18n.Message{
+			ID:    "InstructCommandServerRoleMap",
+			Other: "rolemap",
+		},
+	})
+
+	offlineAlertCmd := localizer.MustLocalize(&i18n.LocalizeConfig{
+		DefaultMessage: &i18n.Message{
+			ID:    "InstructCommandServerOfflineAlert",
+			Other: "offlinealert",
+		},
+	})
+
+	killFeedCmd := localizer.MustLocalize(&i18n.LocalizeConfig{
+		DefaultMessage: &i18n.Message{
+			ID:    "InstructCommandServerKillFeed",
+			Other: "killfeed",
+		},
+	})
+
+	wipeCmd := localizer.MustLocalize(&i18n.LocalizeConfig{
+		DefaultMessage: &i18n.Message{
+			ID:    "InstructCommandServerWipe",
+			Other: "wipe",
+		},
+	})
+
 	var serverID int
-	var commands = []string{resetCmd, renameCmd, deleteCmd, chathereCmd, raidDelayCmd, raidCooldownCmd}
+	var commands = []string{resetCmd, renameCmd, deleteCmd, channelCmd, raidDelayCmd, raidCooldownCmd, roleMapCmd, offlineAlertCmd, killFeedCmd, wipeCmd}
 	isCommand := func(s string) bool {
 		for i := range commands {
 			if s == commands[i] {
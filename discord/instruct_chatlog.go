@@ -0,0 +1,226 @@
+package discord
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/types"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	chatLogLimit     = 25
+	chatLogMaxLength = 1900 // Leaves room under Discord's 2000 character limit
+)
+
+var userMentionRegexp = regexp.MustCompile(`\A<@!?([0-9]+)>\z`)
+
+// chatLogArgs are the parsed arguments of the chatlog command
+type chatLogArgs struct {
+	serverID  int // -1 for all servers
+	snowflake string
+	playerID  string
+	since     time.Time
+}
+
+// parseChatLogArgs parses `[server] [@user|playerID] [since]`. The
+// arguments are told apart by their format, so they can be in any order.
+func parseChatLogArgs(parts []string, now time.Time) (chatLogArgs, error) {
+	args := chatLogArgs{serverID: -1}
+	for _, part := range parts {
+		if m := userMentionRegexp.FindStringSubmatch(part); m != nil && len(args.snowflake) == 0 {
+			args.snowflake = m[1]
+			continue
+		}
+
+		if since, ok := parseSince(part, now); ok && args.since.IsZero() {
+			args.since = since
+			continue
+		}
+
+		if strings.Contains(part, ":") && len(args.playerID) == 0 {
+			args.playerID = part
+			continue
+		}
+
+		if id, err := strconv.Atoi(part); err == nil && args.serverID == -1 {
+			if id < 1 {
+				return args, errors.New("invalid server id")
+			}
+			args.serverID = id - 1
+			continue
+		}
+
+		return args, fmt.Errorf("invalid argument %s", part)
+	}
+
+	if len(args.snowflake) != 0 && len(args.playerID) != 0 {
+		return args, errors.New("only one of user or player ID allowed")
+	}
+
+	return args, nil
+}
+
+// parseSince parses a duration ago (`90m`, `12h`, `7d`) or a date
+// (`2006-01-02`)
+func parseSince(s string, now time.Time) (time.Time, bool) {
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, true
+	}
+
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
+		if err != nil || days < 1 {
+			return time.Time{}, false
+		}
+		return now.AddDate(0, 0, -days), true
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return time.Time{}, false
+	}
+	return now.Add(-d), true
+}
+
+// instructChatLog searches the chat log of the account's servers
+func instructChatLog(parts []string, account types.Account, cls instructChatLogFinder, uf instructUserFinder) instructResponse {
+	clLog := log.WithFields(logrus.Fields{"sys": "instructChatLog", "gID": account.GuildSnowflake})
+
+	usage := instructResponse{
+		responseType: instructResponseChannel,
+		message: localizer.MustLocalize(&i18n.LocalizeConfig{
+			DefaultMessage: &i18n.Message{
+				ID:    "InstructCommandChatLogUsage",
+				Other: "Usage: `chatlog [server id] [@user|game:playerid] [since]`. Since is a duration like `12h` or `7d`, or a date like `2006-01-02`.",
+			},
+		}),
+	}
+
+	args, err := parseChatLogArgs(parts, iclock().Now().UTC())
+	if err != nil {
+		clLog.WithError(err).Trace("invalid chatlog args")
+		return usage
+	}
+
+	if len(account.Servers) == 0 {
+		return instructResponse{
+			responseType: instructResponseChannel,
+			message: localizer.MustLocalize(&i18n.LocalizeConfig{
+				DefaultMessage: &i18n.Message{
+					ID:    "InstructCommandChatLogNoServers",
+					Other: "You have no servers defined. See `help`.",
+				},
+			}),
+		}
+	}
+
+	query := storage.ChatLogQuery{Since: args.since, Limit: chatLogLimit}
+	serverNames := map[string]string{}
+	for i, server := range account.Servers {
+		if args.serverID != -1 && args.serverID != i {
+			continue
+		}
+		query.ServerKeys = append(query.ServerKeys, server.Key)
+		serverNames[server.Key] = server.Name
+	}
+	if len(query.ServerKeys) == 0 {
+		return instructResponse{
+			responseType: instructResponseChannel,
+			message: localizer.MustLocalize(&i18n.LocalizeConfig{
+				DefaultMessage: &i18n.Message{
+					ID:    "InstructCommandServerDoesNotExist",
+					Other: "Invalid server ID. Check server list.",
+				},
+			}),
+		}
+	}
+
+	if len(args.snowflake) != 0 {
+		query.Snowflake = args.snowflake
+		user, err := uf.GetByDiscordID(args.snowflake)
+		switch {
+		case err == nil:
+			query.PlayerIDs = user.PlayerIDs
+		case !errors.Is(err, storage.ErrNotFound):
+			clLog.WithError(err).Error("storage: Could not get user")
+			return instructResponse{message: "Internal error. Please try again."}
+		}
+	}
+	if len(args.playerID) != 0 {
+		query.PlayerIDs = []string{args.playerID}
+	}
+
+	entries, err := cls.Find(query)
+	if err != nil {
+		clLog.WithError(err).Error("storage: Could not find chat log")
+		return instructResponse{message: "Internal error. Please try again."}
+	}
+
+	if len(entries) == 0 {
+		return instructResponse{
+			message: localizer.MustLocalize(&i18n.LocalizeConfig{
+				DefaultMessage: &i18n.Message{
+					ID:    "InstructCommandChatLogNone",
+					Other: "No chat messages found.",
+				},
+			}),
+		}
+	}
+
+	message := localizer.MustLocalize(&i18n.LocalizeConfig{
+		DefaultMessage: &i18n.Message{
+			ID:    "InstructCommandChatLogHeader",
+			Other: "Most recent chat messages, oldest first:",
+		},
+	})
+
+	// Entries are newest first. Keep as many of the newest as will fit, then
+	// list them in the order they were sent.
+	var lines []string
+	length := len(message)
+	for _, entry := range entries {
+		line := chatLogLine(entry, serverNames[entry.ServerKey])
+		if length+len(line)+1 > chatLogMaxLength {
+			break
+		}
+		length += len(line) + 1
+		lines = append(lines, line)
+	}
+	for i := len(lines) - 1; i >= 0; i-- {
+		message += "\n" + lines[i]
+	}
+
+	return instructResponse{message: message}
+}
+
+// chatLogLine formats a chat log entry for the chatlog command
+func chatLogLine(entry types.ChatLogEntry, serverName string) string {
+	var from string
+	switch entry.Source {
+	case types.ChatSourceDiscord:
+		from = fmt.Sprintf("%s (%s)", entry.DiscordName, entry.Snowflake)
+	default:
+		from = entry.DisplayName
+		if len(entry.ClanTag) != 0 {
+			from = fmt.Sprintf("[%s] %s", entry.ClanTag, from)
+		}
+		if len(entry.PlayerID) != 0 {
+			from = fmt.Sprintf("%s (%s)", from, entry.PlayerID)
+		}
+	}
+
+	return fmt.Sprintf("`%s` %s %s **%s**: %s",
+		entry.SentAt.UTC().Format("01-02 15:04 MST"),
+		escapeDiscordString(serverName),
+		entry.Source,
+		escapeDiscordString(from),
+		escapeDiscordString(entry.Message),
+	)
+}
@@ -0,0 +1,113 @@
+package discord
+
+import (
+	"testing"
+	"time"
+
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/storage/mocks"
+	"github.com/poundbot/poundbot/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseChatLogArgs(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2020, 6, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		parts   []string
+		want    chatLogArgs
+		wantErr bool
+	}{
+		{name: "none", want: chatLogArgs{serverID: -1}},
+		{name: "server", parts: []string{"2"}, want: chatLogArgs{serverID: 1}},
+		{name: "mention", parts: []string{"<@!1234>"}, want: chatLogArgs{serverID: -1, snowflake: "1234"}},
+		{name: "player ID", parts: []string{"game:1"}, want: chatLogArgs{serverID: -1, playerID: "game:1"}},
+		{name: "hours", parts: []string{"12h"}, want: chatLogArgs{serverID: -1, since: now.Add(-12 * time.Hour)}},
+		{name: "days", parts: []string{"7d"}, want: chatLogArgs{serverID: -1, since: now.AddDate(0, 0, -7)}},
+		{
+			name:  "everything in any order",
+			parts: []string{"2020-06-01", "<@1234>", "1"},
+			want:  chatLogArgs{serverID: 0, snowflake: "1234", since: time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		{name: "server zero", parts: []string{"0"}, wantErr: true},
+		{name: "unknown", parts: []string{"bogus"}, wantErr: true},
+		{name: "user and player", parts: []string{"<@1234>", "game:1"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseChatLogArgs(tt.parts, now)
+			if tt.wantErr {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_instructChatLog(t *testing.T) {
+	t.Parallel()
+
+	account := types.Account{Servers: []types.AccountServer{{Key: "key1", Name: "one"}, {Key: "key2", Name: "two"}}}
+	sent := time.Date(2020, 6, 1, 12, 30, 0, 0, time.UTC)
+	entries := []types.ChatLogEntry{
+		{
+			Source: types.ChatSourceGame, ServerKey: "key2", PlayerID: "game:1",
+			DisplayName: "player", ClanTag: "FoF", Message: "second", SentAt: sent.Add(time.Minute),
+		},
+		{
+			Source: types.ChatSourceDiscord, ServerKey: "key2", Snowflake: "1234",
+			DiscordName: "user#0001", Message: "first", SentAt: sent,
+		},
+	}
+
+	var user types.User
+	user.PlayerIDs = []string{"game:1"}
+
+	tests := []struct {
+		name  string
+		parts []string
+		query *storage.ChatLogQuery
+		want  instructResponse
+	}{
+		{
+			name:  "user on a server",
+			parts: []string{"2", "<@1234>"},
+			query: &storage.ChatLogQuery{
+				ServerKeys: []string{"key2"}, Snowflake: "1234", PlayerIDs: []string{"game:1"}, Limit: chatLogLimit,
+			},
+			want: instructResponse{message: "Most recent chat messages, oldest first:\n" +
+				"`06-01 12:30 UTC` two discord **user#0001 (1234)**: first\n" +
+				"`06-01 12:31 UTC` two game **[FoF] player (game:1)**: second"},
+		},
+		{
+			name:  "invalid server",
+			parts: []string{"3"},
+			want:  instructResponse{responseType: instructResponseChannel, message: "Invalid server ID. Check server list."},
+		},
+		{
+			name:  "bad argument",
+			parts: []string{"bogus"},
+			want: instructResponse{
+				responseType: instructResponseChannel,
+				message:      "Usage: `chatlog [server id] [@user|game:playerid] [since]`. Since is a duration like `12h` or `7d`, or a date like `2006-01-02`.",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cls := mocks.ChatLogStore{}
+			us := mocks.UsersStore{}
+			us.On("GetByDiscordID", "1234").Return(user, nil)
+			if tt.query != nil {
+				cls.On("Find", *tt.query).Return(entries, nil).Once()
+			}
+
+			assert.Equal(t, tt.want, instructChatLog(tt.parts, account, &cls, &us))
+			cls.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package discord
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"github.com/poundbot/poundbot/types"
+	"github.com/sirupsen/logrus"
+)
+
+// instructServerKillFeed shows or sets the server's kill feed filters. Args
+// are `[pvp <on|off>|linked <on|off>|distance <meters>]`.
+func instructServerKillFeed(args []string, serverID int, guildID string, server types.AccountServer,
+	au instructAccountUpdater) instructResponse {
+	kfLog := log.WithFields(logrus.Fields{"sys": "instructServerKillFeed", "gID": guildID, "sKey": server.Key})
+
+	usage := instructResponse{
+		responseType: instructResponseChannel,
+		message: localizer.MustLocalize(&i18n.LocalizeConfig{
+			DefaultMessage: &i18n.Message{
+				ID:    "InstructCommandServerKillFeedUsage",
+				Other: "Usage: `server [id] killfeed [pvp <on|off>|linked <on|off>|distance <meters>]`. Without arguments, shows the kill feed filters.",
+			},
+		}),
+	}
+
+	if len(args) == 0 {
+		return instructResponse{
+			responseType: instructResponseChannel,
+			message:      killFeedFilters(serverID, server),
+		}
+	}
+	if len(args) != 2 {
+		return usage
+	}
+
+	pvpCmd := localizer.MustLocalize(&i18n.LocalizeConfig{
+		DefaultMessage: &i18n.Message{
+			ID:    "InstructCommandServerKillFeedPvP",
+			Other: "pvp",
+		},
+	})
+	linkedCmd := localizer.MustLocalize(&i18n.LocalizeConfig{
+		DefaultMessage: &i18n.Message{
+			ID:    "InstructCommandServerKillFeedLinked",
+			Other: "linked",
+		},
+	})
+	distanceCmd := localizer.MustLocalize(&i18n.LocalizeConfig{
+		DefaultMessage: &i18n.Message{
+			ID:    "InstructCommandServerKillFeedDistance",
+			Other: "distance",
+		},
+	})
+
+	switch strings.ToLower(args[0]) {
+	case pvpCmd:
+		on, ok := parseOnOff(args[1])
+		if !ok {
+			return usage
+		}
+		server.KillFeed.PvPOnly = on
+	case linkedCmd:
+		on, ok := parseOnOff(args[1])
+		if !ok {
+			return usage
+		}
+		server.KillFeed.LinkedOnly = on
+	case distanceCmd:
+		meters, err := strconv.ParseFloat(args[1], 64)
+		if err != nil || meters < 0 {
+			return usage
+		}
+		server.KillFeed.MinDistance = meters
+	default:
+		return usage
+	}
+
+	if err := au.UpdateServer(guildID, server.Key, server); err != nil {
+		kfLog.WithError(err).Error("storage error updating server")
+		return instructResponse{message: "Internal error. Please try again."}
+	}
+
+	return instructResponse{
+		responseType: instructResponseChannel,
+		message:      killFeedFilters(serverID, server),
+	}
+}
+
+// parseOnOff parses a localized on or off
+func parseOnOff(s string) (on bool, ok bool) {
+	switch strings.ToLower(s) {
+	case onOffString(true):
+		return true, true
+	case onOffString(false):
+		return false, true
+	}
+	return false, false
+}
+
+func onOffString(on bool) string {
+	if on {
+		return localizer.MustLocalize(&i18n.LocalizeConfig{
+			DefaultMessage: &i18n.Message{
+				ID:    "InstructCommandServerKillFeedOn",
+				Other: "on",
+			},
+		})
+	}
+	return localizer.MustLocalize(&i18n.LocalizeConfig{
+		DefaultMessage: &i18n.Message{
+			ID:    "InstructCommandServerKillFeedOff",
+			Other: "off",
+		},
+	})
+}
+
+// killFeedFilters describes a server's kill feed filters
+func killFeedFilters(serverID int, server types.AccountServer) string {
+	return localizer.MustLocalize(&i18n.LocalizeConfig{
+		DefaultMessage: &i18n.Message{
+			ID:    "InstructCommandServerKillFeedFilters",
+			Other: "Kill feed for server {{.Name}} ({{.ID}}): PvP only `{{.PvP}}`, linked players only `{{.Linked}}`, minimum distance `{{.Distance}}m`",
+		},
+		TemplateData: map[string]string{
+			"Name":     server.Name,
+			"ID":       fmt.Sprint(serverID + 1),
+			"PvP":      onOffString(server.KillFeed.PvPOnly),
+			"Linked":   onOffString(server.KillFeed.LinkedOnly),
+			"Distance": strconv.FormatFloat(server.KillFeed.MinDistance, 'f', -1, 64),
+		},
+	})
+}
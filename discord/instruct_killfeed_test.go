@@ -0,0 +1,69 @@
+package discord
+
+import (
+	"testing"
+
+	"github.com/poundbot/poundbot/storage/mocks"
+	"github.com/poundbot/poundbot/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstructServer_killFeed(t *testing.T) {
+	t.Parallel()
+
+	usage := "Usage: `server [id] killfeed [pvp <on|off>|linked <on|off>|distance <meters>]`. Without arguments, shows the kill feed filters."
+
+	tests := []struct {
+		name    string
+		args    []string
+		current types.KillFeedFilter
+		want    *types.KillFeedFilter
+		message string
+	}{
+		{
+			name:    "show",
+			args:    []string{"killfeed"},
+			current: types.KillFeedFilter{PvPOnly: true, MinDistance: 50},
+			message: "Kill feed for server server (1): PvP only `on`, linked players only `off`, minimum distance `50m`",
+		},
+		{
+			name:    "pvp",
+			args:    []string{"killfeed", "PvP", "on"},
+			want:    &types.KillFeedFilter{PvPOnly: true},
+			message: "Kill feed for server server (1): PvP only `on`, linked players only `off`, minimum distance `0m`",
+		},
+		{
+			name:    "linked off",
+			args:    []string{"killfeed", "linked", "off"},
+			current: types.KillFeedFilter{LinkedOnly: true},
+			want:    &types.KillFeedFilter{},
+			message: "Kill feed for server server (1): PvP only `off`, linked players only `off`, minimum distance `0m`",
+		},
+		{
+			name:    "distance",
+			args:    []string{"killfeed", "distance", "100.5"},
+			want:    &types.KillFeedFilter{MinDistance: 100.5},
+			message: "Kill feed for server server (1): PvP only `off`, linked players only `off`, minimum distance `100.5m`",
+		},
+		{name: "negative distance", args: []string{"killfeed", "distance", "-1"}, message: usage},
+		{name: "not on or off", args: []string{"killfeed", "pvp", "maybe"}, message: usage},
+		{name: "unknown filter", args: []string{"killfeed", "npc", "on"}, message: usage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := types.AccountServer{Key: "key", Name: "server", KillFeed: tt.current}
+			account := types.Account{Servers: []types.AccountServer{server}}
+
+			as := mocks.AccountsStore{}
+			if tt.want != nil {
+				want := server
+				want.KillFeed = *tt.want
+				as.On("UpdateServer", "guild", "key", want).Return(nil).Once()
+			}
+
+			got := instructServer(tt.args, "here", "guild", account, &as, nil, nil)
+			assert.Equal(t, instructResponse{responseType: instructResponseChannel, message: tt.message}, got)
+			as.AssertExpectations(t)
+		})
+	}
+}
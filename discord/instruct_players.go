@@ -0,0 +1,196 @@
+package discord
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/types"
+	"github.com/sirupsen/logrus"
+)
+
+// playersMaxLength leaves room under Discord's 2000 character limit
+const playersMaxLength = 1900
+
+type instructOnlinePlayersGetter interface {
+	Online(serverKey string) ([]types.OnlinePlayer, error)
+}
+
+type instructOnlinePlayersClearer interface {
+	Clear(serverKey string, before time.Time) error
+}
+
+type instructOnlinePlayersStore interface {
+	instructOnlinePlayersGetter
+	instructOnlinePlayersClearer
+}
+
+// instructPlayers lists the players online on the account's servers, and
+// which of them are linked to members of the guild
+func instructPlayers(parts []string, account types.Account, ops instructOnlinePlayersGetter, uf instructUserFinder,
+	rg roleGuildGetter) instructResponse {
+	ipLog := log.WithFields(logrus.Fields{"sys": "instructPlayers", "gID": account.GuildSnowflake})
+
+	if len(parts) > 1 {
+		return instructResponse{
+			responseType: instructResponseChannel,
+			message: localizer.MustLocalize(&i18n.LocalizeConfig{
+				DefaultMessage: &i18n.Message{
+					ID:    "InstructCommandPlayersUsage",
+					Other: "Usage: `players [server id]`",
+				},
+			}),
+		}
+	}
+
+	serverID := -1
+	if len(parts) == 1 {
+		id, err := strconv.Atoi(parts[0])
+		if err != nil || id < 1 || id > len(account.Servers) {
+			return instructResponse{
+				responseType: instructResponseChannel,
+				message: localizer.MustLocalize(&i18n.LocalizeConfig{
+					DefaultMessage: &i18n.Message{
+						ID:    "InstructCommandServerDoesNotExist",
+						Other: "Invalid server ID. Check server list.",
+					},
+				}),
+			}
+		}
+		serverID = id - 1
+	}
+
+	if len(account.Servers) == 0 {
+		return instructResponse{
+			responseType: instructResponseChannel,
+			message: localizer.MustLocalize(&i18n.LocalizeConfig{
+				DefaultMessage: &i18n.Message{
+					ID:    "InstructCommandPlayersNoServers",
+					Other: "You have no servers defined. See `help`.",
+				},
+			}),
+		}
+	}
+
+	// Linked players are only shown as members while they are in the guild
+	members := map[string]string{}
+	if guild, err := rg.Guild(account.GuildSnowflake); err == nil {
+		for _, member := range guild.Members {
+			members[member.User.ID] = member.User.String()
+		}
+	} else {
+		ipLog.WithError(err).Error("Could not find guild")
+	}
+
+	internalError := instructResponse{
+		responseType: instructResponseChannel,
+		message: localizer.MustLocalize(&i18n.LocalizeConfig{
+			DefaultMessage: &i18n.Message{
+				ID:    "InstructCommandPlayersInternalError",
+				Other: "Internal error. Please try again.",
+			},
+		}),
+	}
+
+	var lines []string
+	for i, server := range account.Servers {
+		if serverID != -1 && serverID != i {
+			continue
+		}
+
+		players, err := ops.Online(server.Key)
+		if err != nil {
+			ipLog.WithError(err).Error("storage: Could not get online players")
+			return internalError
+		}
+
+		lines = append(lines, localizer.MustLocalize(&i18n.LocalizeConfig{
+			DefaultMessage: &i18n.Message{
+				ID:    "InstructCommandPlayersHeader",
+				Other: "**{{.Name}}** ({{.ID}}): {{.Count}} online",
+			},
+			TemplateData: map[string]string{
+				"Name":  escapeDiscordString(server.Name),
+				"ID":    fmt.Sprint(i + 1),
+				"Count": fmt.Sprint(len(players)),
+			},
+		}))
+
+		for _, player := range players {
+			line, err := onlinePlayerLine(player, uf, members)
+			if err != nil {
+				ipLog.WithError(err).Error("storage: Could not get user")
+				return internalError
+			}
+			lines = append(lines, line)
+		}
+	}
+
+	return instructResponse{message: joinLines(lines, playersMaxLength)}
+}
+
+// onlinePlayerLine formats an online player for the players command
+func onlinePlayerLine(player types.OnlinePlayer, uf instructUserFinder, members map[string]string) (string, error) {
+	name := player.DisplayName
+	if len(name) == 0 {
+		name = player.PlayerID
+	}
+	line := fmt.Sprintf("- %s `%s`", escapeDiscordString(name), player.PlayerID)
+
+	user, err := uf.GetByPlayerID(player.PlayerID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return line, nil
+		}
+		return "", err
+	}
+
+	discordName, ok := members[user.Snowflake]
+	if !ok {
+		return line, nil
+	}
+	return line + " " + localizer.MustLocalize(&i18n.LocalizeConfig{
+		DefaultMessage: &i18n.Message{
+			ID:    "InstructCommandPlayersLinked",
+			Other: "linked to {{.DiscordName}}",
+		},
+		TemplateData: map[string]string{"DiscordName": escapeDiscordString(discordName)},
+	}), nil
+}
+
+// joinLines joins as many lines as fit in max characters, noting how many
+// were left out
+func joinLines(lines []string, max int) string {
+	var b strings.Builder
+	for i, line := range lines {
+		more := localizer.MustLocalize(&i18n.LocalizeConfig{
+			DefaultMessage: &i18n.Message{
+				ID:    "InstructCommandPlayersMore",
+				Other: "…and {{.Count}} more",
+			},
+			TemplateData: map[string]string{"Count": fmt.Sprint(len(lines) - i)},
+		})
+		if b.Len()+len(line)+len(more)+2 > max {
+			b.WriteString(more)
+			break
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// clearOnlinePlayers forgets the players online on a server that was removed
+// or had its key reset, since its disconnect events won't be accepted
+func clearOnlinePlayers(opc instructOnlinePlayersClearer, serverKey string, l *logrus.Entry) {
+	if opc == nil {
+		return
+	}
+	if err := opc.Clear(serverKey, iclock().Now().UTC()); err != nil {
+		l.WithError(err).Error("storage error clearing online players")
+	}
+}
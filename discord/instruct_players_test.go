@@ -0,0 +1,104 @@
+package discord
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/storage/memory"
+	"github.com/poundbot/poundbot/storage/mocks"
+	"github.com/poundbot/poundbot/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstructPlayers(t *testing.T) {
+	t.Parallel()
+
+	account := types.Account{Servers: []types.AccountServer{{Key: "one", Name: "One"}, {Key: "two", Name: "Two"}}}
+	account.GuildSnowflake = "guild"
+
+	at := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	ops := memory.NewMemory().OnlinePlayers()
+	ops.Connect(types.OnlinePlayer{ServerKey: "one", PlayerID: "game:1", DisplayName: "member", ConnectedAt: at})
+	ops.Connect(types.OnlinePlayer{ServerKey: "one", PlayerID: "game:2", DisplayName: "left_guild", ConnectedAt: at.Add(time.Minute)})
+	ops.Connect(types.OnlinePlayer{ServerKey: "one", PlayerID: "game:3", ConnectedAt: at.Add(2 * time.Minute)})
+
+	linked := func(snowflake string) types.User {
+		var u types.User
+		u.Snowflake = snowflake
+		return u
+	}
+	us := &mocks.UsersStore{}
+	us.On("GetByPlayerID", "game:1").Return(linked("user-1"), nil)
+	us.On("GetByPlayerID", "game:2").Return(linked("user-2"), nil)
+	us.On("GetByPlayerID", "game:3").Return(types.User{}, storage.ErrNotFound)
+
+	rg := guildGetterMock{guild: &discordgo.Guild{Members: []*discordgo.Member{
+		{User: &discordgo.User{ID: "user-1", Username: "someone", Discriminator: "0001"}},
+	}}}
+
+	tests := []struct {
+		name  string
+		parts []string
+		want  string
+	}{
+		{
+			name: "all servers",
+			want: "**One** (1): 3 online\n" +
+				"- member `game:1` linked to someone#0001\n" +
+				"- left\\_guild `game:2`\n" +
+				"- game:3 `game:3`\n" +
+				"**Two** (2): 0 online",
+		},
+		{name: "one server", parts: []string{"2"}, want: "**Two** (2): 0 online"},
+		{name: "invalid server", parts: []string{"3"}, want: "Invalid server ID. Check server list."},
+		{name: "too many args", parts: []string{"1", "2"}, want: "Usage: `players [server id]`"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := instructPlayers(tt.parts, account, ops, us, rg)
+			assert.Equal(t, tt.want, got.message)
+		})
+	}
+}
+
+func TestInstructPlayers_storageError(t *testing.T) {
+	t.Parallel()
+
+	account := types.Account{Servers: []types.AccountServer{{Key: "one", Name: "One"}}}
+	ops := &mocks.OnlinePlayersStore{}
+	ops.On("Online", "one").Return(nil, errors.New("broken"))
+
+	got := instructPlayers(nil, account, ops, &mocks.UsersStore{}, guildGetterMock{guild: &discordgo.Guild{}})
+	assert.Equal(t, instructResponse{responseType: instructResponseChannel, message: "Internal error. Please try again."}, got)
+}
+
+func TestJoinLines(t *testing.T) {
+	t.Parallel()
+
+	lines := []string{strings.Repeat("a", 10), strings.Repeat("b", 10), strings.Repeat("c", 10)}
+	assert.Equal(t, strings.Join(lines, "\n"), joinLines(lines, 100))
+	assert.Equal(t, strings.Repeat("a", 10)+"\n…and 2 more", joinLines(lines, 30))
+}
+
+func TestInstructServer_deleteClearsOnlinePlayers(t *testing.T) {
+	t.Parallel()
+
+	account := types.Account{Servers: []types.AccountServer{{Key: "key", Name: "server"}}}
+
+	ops := memory.NewMemory().OnlinePlayers()
+	ops.Connect(types.OnlinePlayer{ServerKey: "key", PlayerID: "game:1",
+		ConnectedAt: time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)})
+
+	as := &mocks.AccountsStore{}
+	as.On("RemoveServer", "guild", "key").Return(nil)
+
+	instructServer([]string{"delete"}, "here", "guild", account, as, nil, ops)
+
+	players, err := ops.Online("key")
+	assert.Nil(t, err)
+	assert.Empty(t, players)
+}
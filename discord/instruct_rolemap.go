@@ -0,0 +1,182 @@
+package discord
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"github.com/poundbot/poundbot/types"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	roleMentionRegexp = regexp.MustCompile(`\A<@&([0-9]+)>\z`)
+	gameGroupRegexp   = regexp.MustCompile(`\A[A-Za-z0-9_.-]+\z`)
+)
+
+// findGuildRole finds a role by mention, ID or name. Names are matched
+// without case.
+func findGuildRole(roles []*discordgo.Role, s string) (*discordgo.Role, error) {
+	if m := roleMentionRegexp.FindStringSubmatch(s); m != nil {
+		s = m[1]
+	}
+
+	var found *discordgo.Role
+	for _, role := range roles {
+		if role.ID == s {
+			return role, nil
+		}
+		if strings.EqualFold(role.Name, s) {
+			if found != nil {
+				return nil, fmt.Errorf("more than one role is named %s", s)
+			}
+			found = role
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("role %s not found", s)
+	}
+	return found, nil
+}
+
+// instructServerRoleMap lists, sets or removes the game groups for the
+// server's discord roles. Args are `[<role> <group|off>]`.
+func instructServerRoleMap(args []string, serverID int, guildID string, server types.AccountServer,
+	au instructAccountUpdater, rg roleGuildGetter) instructResponse {
+	rmLog := log.WithFields(logrus.Fields{"sys": "instructServerRoleMap", "gID": guildID, "sKey": server.Key})
+
+	usage := instructResponse{
+		responseType: instructResponseChannel,
+		message: localizer.MustLocalize(&i18n.LocalizeConfig{
+			DefaultMessage: &i18n.Message{
+				ID:    "InstructCommandServerRoleMapUsage",
+				Other: "Usage: `server [id] rolemap [<discord role> <game group|off>]`. Quote role names with spaces. Without arguments, lists the role maps.",
+			},
+		}),
+	}
+
+	if len(args) != 0 && len(args) != 2 {
+		return usage
+	}
+
+	guild, err := rg.Guild(guildID)
+	if err != nil {
+		rmLog.WithError(err).Error("Could not find guild")
+		return instructResponse{message: "Internal error. Please try again."}
+	}
+
+	if len(args) == 0 {
+		return instructResponse{
+			responseType: instructResponseChannel,
+			message:      roleMapList(serverID, server, guild.Roles),
+		}
+	}
+
+	role, err := findGuildRole(guild.Roles, args[0])
+	if err != nil {
+		return instructResponse{
+			responseType: instructResponseChannel,
+			message: localizer.MustLocalize(&i18n.LocalizeConfig{
+				DefaultMessage: &i18n.Message{
+					ID:    "InstructCommandServerRoleMapInvalidRole",
+					Other: "Could not find one role named {{.Role}}. Use the role's name, ID or mention.",
+				},
+				TemplateData: map[string]string{"Role": escapeDiscordString(args[0])},
+			}),
+		}
+	}
+
+	templateData := map[string]string{
+		"Name":  server.Name,
+		"ID":    fmt.Sprint(serverID + 1),
+		"Role":  escapeDiscordString(role.Name),
+		"Group": args[1],
+	}
+
+	var message string
+	offCmd := localizer.MustLocalize(&i18n.LocalizeConfig{
+		DefaultMessage: &i18n.Message{
+			ID:    "InstructCommandServerRoleMapOff",
+			Other: "off",
+		},
+	})
+	if strings.EqualFold(args[1], offCmd) {
+		if !server.RemoveRoleMap(role.ID) {
+			return instructResponse{
+				responseType: instructResponseChannel,
+				message: localizer.MustLocalize(&i18n.LocalizeConfig{
+					DefaultMessage: &i18n.Message{
+						ID:    "InstructCommandServerRoleMapNotMapped",
+						Other: "Role {{.Role}} is not mapped on server {{.Name}} ({{.ID}})",
+					},
+					TemplateData: templateData,
+				}),
+			}
+		}
+		message = localizer.MustLocalize(&i18n.LocalizeConfig{
+			DefaultMessage: &i18n.Message{
+				ID:    "InstructCommandServerRoleMapRemoved",
+				Other: "Role {{.Role}} is no longer mapped on server {{.Name}} ({{.ID}})",
+			},
+			TemplateData: templateData,
+		})
+	} else {
+		if !gameGroupRegexp.MatchString(args[1]) {
+			return usage
+		}
+		server.SetRoleMap(role.ID, args[1])
+		message = localizer.MustLocalize(&i18n.LocalizeConfig{
+			DefaultMessage: &i18n.Message{
+				ID:    "InstructCommandServerRoleMapResponse",
+				Other: "Members of {{.Role}} will be in group `{{.Group}}` on server {{.Name}} ({{.ID}})",
+			},
+			TemplateData: templateData,
+		})
+	}
+
+	if err := au.UpdateServer(guildID, server.Key, server); err != nil {
+		rmLog.WithError(err).Error("storage error updating server")
+		return instructResponse{message: "Internal error. Please try again."}
+	}
+
+	return instructResponse{responseType: instructResponseChannel, message: message}
+}
+
+// roleMapList lists a server's role maps. Roles that have been deleted from
+// the guild are listed by ID.
+func roleMapList(serverID int, server types.AccountServer, roles []*discordgo.Role) string {
+	templateData := map[string]string{"Name": server.Name, "ID": fmt.Sprint(serverID + 1)}
+	if len(server.RoleMaps) == 0 {
+		return localizer.MustLocalize(&i18n.LocalizeConfig{
+			DefaultMessage: &i18n.Message{
+				ID:    "InstructCommandServerRoleMapNone",
+				Other: "Server {{.Name}} ({{.ID}}) has no role maps",
+			},
+			TemplateData: templateData,
+		})
+	}
+
+	lines := []string{localizer.MustLocalize(&i18n.LocalizeConfig{
+		DefaultMessage: &i18n.Message{
+			ID:    "InstructCommandServerRoleMapHeader",
+			Other: "Role maps for server {{.Name}} ({{.ID}}):",
+		},
+		TemplateData: templateData,
+	})}
+	for _, rm := range server.RoleMaps {
+		name := localizer.MustLocalize(&i18n.LocalizeConfig{
+			DefaultMessage: &i18n.Message{
+				ID:    "InstructCommandServerRoleMapDeletedRole",
+				Other: "deleted role {{.RoleID}}",
+			},
+			TemplateData: map[string]string{"RoleID": rm.RoleID},
+		})
+		if role, err := findGuildRole(roles, rm.RoleID); err == nil {
+			name = escapeDiscordString(role.Name)
+		}
+		lines = append(lines, fmt.Sprintf("%s → `%s`", name, rm.Group))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,123 @@
+package discord
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/poundbot/poundbot/storage/mocks"
+	"github.com/poundbot/poundbot/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type guildGetterMock struct {
+	guild *discordgo.Guild
+}
+
+func (m guildGetterMock) Guild(guildID string) (*discordgo.Guild, error) {
+	return m.guild, nil
+}
+
+func TestFindGuildRole(t *testing.T) {
+	t.Parallel()
+
+	roles := []*discordgo.Role{
+		{ID: "10", Name: "VIP"},
+		{ID: "11", Name: "Server Booster"},
+		{ID: "12", Name: "twin"},
+		{ID: "13", Name: "Twin"},
+	}
+
+	tests := []struct {
+		name    string
+		s       string
+		want    string
+		wantErr bool
+	}{
+		{name: "mention", s: "<@&11>", want: "11"},
+		{name: "id", s: "10", want: "10"},
+		{name: "name", s: "server booster", want: "11"},
+		{name: "ambiguous name", s: "twin", wantErr: true},
+		{name: "missing", s: "admin", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := findGuildRole(roles, tt.s)
+			if tt.wantErr {
+				assert.NotNil(t, err)
+				return
+			}
+			if assert.Nil(t, err) {
+				assert.Equal(t, tt.want, got.ID)
+			}
+		})
+	}
+}
+
+func TestInstructServer_roleMap(t *testing.T) {
+	t.Parallel()
+
+	rg := guildGetterMock{guild: &discordgo.Guild{
+		Roles: []*discordgo.Role{{ID: "10", Name: "VIP"}, {ID: "11", Name: "Server Booster"}},
+	}}
+
+	tests := []struct {
+		name     string
+		parts    []string
+		roleMaps []types.RoleMap
+		want     []types.RoleMap // nil if the server shouldn't be updated
+		message  string
+	}{
+		{
+			name:     "list",
+			parts:    []string{"rolemap"},
+			roleMaps: []types.RoleMap{{RoleID: "11", Group: "vip"}, {RoleID: "12", Group: "mods"}},
+			message:  "Role maps for server server (1):\nServer Booster → `vip`\ndeleted role 12 → `mods`",
+		},
+		{
+			name:    "list none",
+			parts:   []string{"rolemap"},
+			message: "Server server (1) has no role maps",
+		},
+		{
+			name:     "set",
+			parts:    []string{"rolemap", "Server Booster", "vip"},
+			roleMaps: []types.RoleMap{{RoleID: "10", Group: "donor"}},
+			want:     []types.RoleMap{{RoleID: "10", Group: "donor"}, {RoleID: "11", Group: "vip"}},
+			message:  "Members of Server Booster will be in group `vip` on server server (1)",
+		},
+		{
+			name:     "remove",
+			parts:    []string{"rolemap", "<@&10>", "off"},
+			roleMaps: []types.RoleMap{{RoleID: "10", Group: "donor"}},
+			want:     []types.RoleMap{},
+			message:  "Role VIP is no longer mapped on server server (1)",
+		},
+		{
+			name:    "remove unmapped",
+			parts:   []string{"rolemap", "VIP", "off"},
+			message: "Role VIP is not mapped on server server (1)",
+		},
+		{
+			name:    "missing role",
+			parts:   []string{"rolemap", "admin", "admins"},
+			message: "Could not find one role named admin. Use the role's name, ID or mention.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			account := types.Account{Servers: []types.AccountServer{{Key: "key", Name: "server", RoleMaps: tt.roleMaps}}}
+
+			as := mocks.AccountsStore{}
+			if tt.want != nil {
+				as.On("UpdateServer", "guild", "key", mock.MatchedBy(func(s types.AccountServer) bool {
+					return assert.ObjectsAreEqual(tt.want, s.RoleMaps)
+				})).Return(nil).Once()
+			}
+
+			got := instructServer(tt.parts, "here", "guild", account, &as, rg, nil)
+			assert.Equal(t, instructResponse{responseType: instructResponseChannel, message: tt.message}, got)
+			as.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package discord
+
+import (
+	"testing"
+
+	"github.com/poundbot/poundbot/storage/mocks"
+	"github.com/poundbot/poundbot/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseChannelTags(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    []string
+		wantErr bool
+	}{
+		{name: "none", wantErr: true},
+		{name: "tags", args: []string{"Chat", "server_chat", "raid-alerts"}, want: []string{"chat", "server_chat", "raid-alerts"}},
+		{name: "invalid", args: []string{"chat", "tag:chat"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseChannelTags(tt.args)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestInstructServer_channel(t *testing.T) {
+	t.Parallel()
+
+	server := types.AccountServer{Key: "key", Name: "server"}
+	server.SetChannelIDForTag("old", "chat")
+	account := types.Account{Servers: []types.AccountServer{server}}
+
+	want := types.AccountServer{
+		Key:      "key",
+		Name:     "server",
+		Channels: []types.AccountServerChannel{{ChannelID: "here", Tags: []string{"chat", "admin"}}},
+	}
+
+	as := mocks.AccountsStore{}
+	as.On("UpdateServer", "guild", "key", want).Return(nil).Once()
+
+	got := instructServer([]string{"channel", "chat", "admin"}, "here", "guild", account, &as, nil, nil)
+	assert.Equal(t, instructResponse{
+		responseType: instructResponseChannel,
+		message:      "Server server (1) will send `chat`, `admin` here",
+	}, got)
+	as.AssertExpectations(t)
+}
+
+func TestInstructServer_offlineAlert(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		args    []string
+		current string
+		saved   bool
+		want    string
+		message string
+	}{
+		{
+			name:    "on",
+			args:    []string{"offlinealert", "10m"},
+			saved:   true,
+			want:    "10m",
+			message: "Admins will be alerted when 1:server hasn't checked in for 10m",
+		},
+		{
+			name:    "off",
+			args:    []string{"offlinealert", "OFF"},
+			current: "10m",
+			saved:   true,
+			message: "Offline alerts for 1:server are off",
+		},
+		{
+			name:    "too short",
+			args:    []string{"offlinealert", "30s"},
+			message: "Invalid duration format. Use at least a minute. Examples: `5m` = 5 minutes, `1h` = 1 hour",
+		},
+		{
+			name:    "usage",
+			args:    []string{"offlinealert"},
+			message: "Usage: `server [id] offlinealert <duration|off>`",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := types.AccountServer{Key: "key", Name: "server", OfflineAlert: tt.current}
+			account := types.Account{Servers: []types.AccountServer{server}}
+
+			as := mocks.AccountsStore{}
+			want := server
+			want.OfflineAlert = tt.want
+			as.On("UpdateServer", "guild", "key", want).Return(nil)
+
+			got := instructServer(tt.args, "here", "guild", account, &as, nil, nil)
+			assert.Equal(t, instructResponse{responseType: instructResponseChannel, message: tt.message}, got)
+			if tt.saved {
+				as.AssertExpectations(t)
+			} else {
+				as.AssertNotCalled(t, "UpdateServer", "guild", "key", want)
+			}
+		})
+	}
+}
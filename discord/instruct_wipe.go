@@ -0,0 +1,157 @@
+package discord
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"github.com/poundbot/poundbot/types"
+	"github.com/sirupsen/logrus"
+)
+
+// wipeTimeFormat is how wipe times are shown. They are always UTC.
+const wipeTimeFormat = "2006-01-02 15:04 MST"
+
+// instructServerWipe shows or schedules the server's wipes. Args are
+// `[<YYYY-MM-DD> [HH:MM]|off|message <text|off>]`. Wipe times are UTC.
+func instructServerWipe(args []string, serverID int, guildID string, server types.AccountServer,
+	au instructAccountUpdater, now time.Time) instructResponse {
+	wLog := log.WithFields(logrus.Fields{"sys": "instructServerWipe", "gID": guildID, "sKey": server.Key})
+
+	usage := instructResponse{
+		responseType: instructResponseChannel,
+		message: localizer.MustLocalize(&i18n.LocalizeConfig{
+			DefaultMessage: &i18n.Message{
+				ID:    "InstructCommandServerWipeUsage",
+				Other: "Usage: `server [id] wipe [<YYYY-MM-DD> [HH:MM]|off|message <text|off>]`. Times are UTC. Without arguments, shows the server's wipes.",
+			},
+		}),
+	}
+
+	if len(args) == 0 {
+		return instructResponse{
+			responseType: instructResponseChannel,
+			message:      wipeSchedule(serverID, server),
+		}
+	}
+
+	offCmd := localizer.MustLocalize(&i18n.LocalizeConfig{
+		DefaultMessage: &i18n.Message{
+			ID:    "InstructCommandServerWipeOff",
+			Other: "off",
+		},
+	})
+	messageCmd := localizer.MustLocalize(&i18n.LocalizeConfig{
+		DefaultMessage: &i18n.Message{
+			ID:    "InstructCommandServerWipeMessage",
+			Other: "message",
+		},
+	})
+	templateData := map[string]string{"Name": server.Name, "ID": fmt.Sprint(serverID + 1)}
+
+	var message string
+	switch {
+	case strings.EqualFold(args[0], messageCmd):
+		if len(args) < 2 {
+			return usage
+		}
+		if len(args) == 2 && strings.EqualFold(args[1], offCmd) {
+			server.WipeMessage = ""
+		} else {
+			server.WipeMessage = strings.Join(args[1:], " ")
+		}
+		message = localizer.MustLocalize(&i18n.LocalizeConfig{
+			DefaultMessage: &i18n.Message{
+				ID:    "InstructCommandServerWipeMessageResponse",
+				Other: "Wipe announcement for {{.ID}}:{{.Name}} updated",
+			},
+			TemplateData: templateData,
+		})
+	case len(args) == 1 && strings.EqualFold(args[0], offCmd):
+		server.NextWipe = time.Time{}
+		message = localizer.MustLocalize(&i18n.LocalizeConfig{
+			DefaultMessage: &i18n.Message{
+				ID:    "InstructCommandServerWipeOffResponse",
+				Other: "Scheduled wipe for {{.ID}}:{{.Name}} cancelled",
+			},
+			TemplateData: templateData,
+		})
+	default:
+		next, ok := parseWipeTime(args)
+		if !ok {
+			return usage
+		}
+		if !next.After(now) {
+			return instructResponse{
+				responseType: instructResponseChannel,
+				message: localizer.MustLocalize(&i18n.LocalizeConfig{
+					DefaultMessage: &i18n.Message{
+						ID:    "InstructCommandServerWipeInPast",
+						Other: "The wipe must be in the future. Times are UTC.",
+					},
+				}),
+			}
+		}
+		server.NextWipe = next
+		templateData["NextWipe"] = next.Format(wipeTimeFormat)
+		message = localizer.MustLocalize(&i18n.LocalizeConfig{
+			DefaultMessage: &i18n.Message{
+				ID:    "InstructCommandServerWipeResponse",
+				Other: "{{.ID}}:{{.Name}} will wipe at {{.NextWipe}}. Reminders are sent to the `wipe` channel.",
+			},
+			TemplateData: templateData,
+		})
+	}
+
+	if err := au.UpdateServer(guildID, server.Key, server); err != nil {
+		wLog.WithError(err).Error("storage error updating server")
+		return instructResponse{message: "Internal error. Please try again."}
+	}
+
+	return instructResponse{responseType: instructResponseChannel, message: message}
+}
+
+// parseWipeTime parses a UTC date (`2006-01-02`) and optional time
+// (`15:04`)
+func parseWipeTime(args []string) (time.Time, bool) {
+	var t time.Time
+	var err error
+	switch len(args) {
+	case 1:
+		t, err = time.Parse("2006-01-02", args[0])
+	case 2:
+		t, err = time.Parse("2006-01-02 15:04", args[0]+" "+args[1])
+	default:
+		return time.Time{}, false
+	}
+	return t, err == nil
+}
+
+// wipeSchedule describes when a server last wiped and will wipe next
+func wipeSchedule(serverID int, server types.AccountServer) string {
+	wipeTime := func(t time.Time) string {
+		if t.IsZero() {
+			return localizer.MustLocalize(&i18n.LocalizeConfig{
+				DefaultMessage: &i18n.Message{
+					ID:    "InstructCommandServerWipeNone",
+					Other: "none",
+				},
+			})
+		}
+		return t.UTC().Format(wipeTimeFormat)
+	}
+
+	return localizer.MustLocalize(&i18n.LocalizeConfig{
+		DefaultMessage: &i18n.Message{
+			ID:    "InstructCommandServerWipeSchedule",
+			Other: "Wipes for server {{.Name}} ({{.ID}}): last `{{.LastWipe}}`, next `{{.NextWipe}}`",
+		},
+		TemplateData: map[string]string{
+			"Name":     server.Name,
+			"ID":       fmt.Sprint(serverID + 1),
+			"LastWipe": wipeTime(server.LastWipe),
+			"NextWipe": wipeTime(server.NextWipe),
+		},
+	})
+}
@@ -0,0 +1,92 @@
+package discord
+
+import (
+	"testing"
+	"time"
+
+	"github.com/poundbot/poundbot/storage/mocks"
+	"github.com/poundbot/poundbot/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstructServer_wipe(t *testing.T) {
+	t.Parallel()
+
+	usage := "Usage: `server [id] wipe [<YYYY-MM-DD> [HH:MM]|off|message <text|off>]`. Times are UTC. Without arguments, shows the server's wipes."
+	lastWipe := time.Date(2020, 6, 4, 18, 0, 0, 0, time.UTC)
+	nextWipe := time.Date(2100, 7, 2, 19, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		args        []string
+		nextWipe    time.Time
+		wipeMessage string
+		update      func(*types.AccountServer)
+		message     string
+	}{
+		{
+			name:     "show",
+			args:     []string{"wipe"},
+			nextWipe: nextWipe,
+			message:  "Wipes for server server (1): last `2020-06-04 18:00 UTC`, next `2100-07-02 19:00 UTC`",
+		},
+		{
+			name:    "show unscheduled",
+			args:    []string{"wipe"},
+			message: "Wipes for server server (1): last `2020-06-04 18:00 UTC`, next `none`",
+		},
+		{
+			name:    "schedule",
+			args:    []string{"wipe", "2100-07-02", "19:00"},
+			update:  func(s *types.AccountServer) { s.NextWipe = nextWipe },
+			message: "1:server will wipe at 2100-07-02 19:00 UTC. Reminders are sent to the `wipe` channel.",
+		},
+		{
+			name:    "schedule date",
+			args:    []string{"wipe", "2100-07-02"},
+			update:  func(s *types.AccountServer) { s.NextWipe = time.Date(2100, 7, 2, 0, 0, 0, 0, time.UTC) },
+			message: "1:server will wipe at 2100-07-02 00:00 UTC. Reminders are sent to the `wipe` channel.",
+		},
+		{
+			name:     "cancel",
+			args:     []string{"wipe", "OFF"},
+			nextWipe: nextWipe,
+			update:   func(s *types.AccountServer) { s.NextWipe = time.Time{} },
+			message:  "Scheduled wipe for 1:server cancelled",
+		},
+		{
+			name:    "message",
+			args:    []string{"wipe", "message", "New", "map", "**now**"},
+			update:  func(s *types.AccountServer) { s.WipeMessage = "New map **now**" },
+			message: "Wipe announcement for 1:server updated",
+		},
+		{
+			name:        "message off",
+			args:        []string{"wipe", "message", "off"},
+			wipeMessage: "New map",
+			update:      func(s *types.AccountServer) { s.WipeMessage = "" },
+			message:     "Wipe announcement for 1:server updated",
+		},
+		{name: "past", args: []string{"wipe", "2000-01-01"}, message: "The wipe must be in the future. Times are UTC."},
+		{name: "invalid date", args: []string{"wipe", "next", "thursday"}, message: usage},
+		{name: "no message", args: []string{"wipe", "message"}, message: usage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := types.AccountServer{Key: "key", Name: "server", LastWipe: lastWipe, NextWipe: tt.nextWipe,
+				WipeMessage: tt.wipeMessage}
+			account := types.Account{Servers: []types.AccountServer{server}}
+
+			as := mocks.AccountsStore{}
+			if tt.update != nil {
+				want := server
+				tt.update(&want)
+				as.On("UpdateServer", "guild", "key", want).Return(nil).Once()
+			}
+
+			got := instructServer(tt.args, "here", "guild", account, &as, nil, nil)
+			assert.Equal(t, instructResponse{responseType: instructResponseChannel, message: tt.message}, got)
+			as.AssertExpectations(t)
+		})
+	}
+}
@@ -2,6 +2,7 @@ package discord
 
 import (
 	"fmt"
+	"net/http"
 	"strings"
 
 	"errors"
@@ -33,6 +34,7 @@ func (r *Runner) messageCreate(s *discordgo.Session, m *discordgo.MessageCreate)
 				us:       r.us,
 				as:       r.as,
 				das:      r.das,
+				rhs:      r.rhs,
 				authChan: r.AuthSuccess,
 			}
 			s.ChannelMessageSend(m.ChannelID, d.process(*m))
@@ -69,14 +71,14 @@ func (r *Runner) messageCreate(s *discordgo.Session, m *discordgo.MessageCreate)
 	// Detect prefix
 	if strings.HasPrefix(m.Message.Content, account.GetCommandPrefix()) {
 		m.Message.Content = strings.TrimPrefix(m.Message.Content, account.GetCommandPrefix())
-		response = instruct(s.State.User.ID, m.ChannelID, m.Author.ID, m.Content, account, r.as)
+		response = instruct(s.State.User.ID, m.ChannelID, m.Author.ID, m.Content, account, r.as, r.cls, r.us, s.State, r.ops)
 		respond = true
 	}
 
 	// Detect mention
 	for _, mention := range m.Mentions {
 		if mention.ID == s.State.User.ID {
-			response = instruct(s.State.User.ID, m.ChannelID, m.Author.ID, m.Content, account, r.as)
+			response = instruct(s.State.User.ID, m.ChannelID, m.Author.ID, m.Content, account, r.as, r.cls, r.us, s.State, r.ops)
 			respond = true
 		}
 	}
@@ -86,7 +88,7 @@ func (r *Runner) messageCreate(s *discordgo.Session, m *discordgo.MessageCreate)
 		case instructResponsePrivate:
 			_, err = r.sendPrivateMessage(m.Author.ID, "", response.message)
 		case instructResponseChannel:
-			err = r.sendChannelMessage(r.session.State.User.ID, m.ChannelID, response.message)
+			_, err = r.sendChannelMessage(r.session.State.User.ID, m.ChannelID, response.message)
 		}
 		if err != nil {
 			mcLog.WithError(err).Error("error sending response to command")
@@ -126,7 +128,7 @@ func (r *Runner) messageCreate(s *discordgo.Session, m *discordgo.MessageCreate)
 				}
 				if len(cm.Message) > 128 {
 					cm.Message = truncateString(cm.Message, 128)
-					err = r.sendChannelMessage(r.session.State.User.ID, cm.ChannelID, localizer.MustLocalize(&i18n.LocalizeConfig{
+					_, err = r.sendChannelMessage(r.session.State.User.ID, cm.ChannelID, localizer.MustLocalize(&i18n.LocalizeConfig{
 						DefaultMessage: &i18n.Message{
 							ID:    "TruncatedMessage",
 							Other: "*Truncated message to {{.Message}}",
@@ -140,6 +142,10 @@ func (r *Runner) messageCreate(s *discordgo.Session, m *discordgo.MessageCreate)
 				err = r.cqs.InsertMessage(cm)
 				if err != nil {
 					mcLog.WithError(err).Error("Storage error saving message")
+					return
+				}
+				if err := r.cls.Add(types.NewChatLogEntry(types.ChatSourceDiscord, cm)); err != nil {
+					mcLog.WithError(err).Error("Storage error logging message")
 				}
 			}()
 		}
@@ -191,52 +197,107 @@ func sendChannelList(userID, guildID string, ch chan<- types.ServerChannelsRespo
 	return nil
 }
 
-func (r *Runner) sendChannelMessage(userID, channelID, message string) error {
+// sendChannelMessage sends a message to a channel and returns its ID
+func (r *Runner) sendChannelMessage(userID, channelID, message string) (string, error) {
 	scmLog := log.WithFields(logrus.Fields{"sys": "RUN", "ssys": "sendChannelMessage", "cID": channelID, "uID": userID})
 	canSend, err := canSendToChannel(r.session.State, userID, channelID)
 	if err != nil {
 		scmLog.WithError(err).Warn("Cannot send to channel")
-		return fmt.Errorf("cannot send to channel, %w", err)
+		return "", fmt.Errorf("cannot send to channel, %w", err)
 	}
 
 	if !canSend {
-		return errors.New("not permitted to send to channel")
+		return "", errors.New("not permitted to send to channel")
 	}
 
-	_, err = r.session.ChannelMessageSend(channelID, message)
+	m, err := r.session.ChannelMessageSend(channelID, message)
 	if err != nil {
 		scmLog.WithError(err).Warn("error sending message to channel")
-		return fmt.Errorf("error sending message to channel, %w", err)
+		return "", fmt.Errorf("error sending message to channel, %w", err)
 	}
-	return nil
+	return m.ID, nil
 }
 
-func (r *Runner) sendChannelEmbed(userID, channelID, message string, color int) error {
-	sceLog := log.WithFields(logrus.Fields{"sys": "RUN", "ssys": "sendChannelMessage", "cID": channelID, "uID": userID})
+// sendChannelEmbed sends an embed to a channel and returns its message ID
+func (r *Runner) sendChannelEmbed(userID, channelID string, embed *discordgo.MessageEmbed) (string, error) {
+	sceLog := log.WithFields(logrus.Fields{"sys": "RUN", "ssys": "sendChannelEmbed", "cID": channelID, "uID": userID})
 	canEmbed, err := canEmbedToChannel(r.session.State, userID, channelID)
 	if err != nil {
 		sceLog.WithError(err).Warn("Cannot embed to channel")
-		return fmt.Errorf("cannot embed to channel, %w", err)
+		return "", fmt.Errorf("cannot embed to channel, %w", err)
 	}
 
 	if !canEmbed {
-		return errors.New("not permitted to embed to channel")
+		return "", errors.New("not permitted to embed to channel")
 	}
 
-	_, err = r.session.ChannelMessageSendEmbed(channelID, &discordgo.MessageEmbed{
-		Description: message,
-		Color:       color,
-	})
+	m, err := r.session.ChannelMessageSendEmbed(channelID, embed)
 	if err != nil {
 		sceLog.WithError(err).Warn("error embedding message to channel")
+		return "", fmt.Errorf("error embedding message to channel, %w", err)
 	}
+	return m.ID, nil
+}
 
+// editChannelMessage replaces the content of a message. With an embed, the
+// content is cleared and the embed replaced.
+func (r *Runner) editChannelMessage(userID, channelID, messageID, message string, embed *discordgo.MessageEmbed) error {
+	ecmLog := log.WithFields(logrus.Fields{"sys": "RUN", "ssys": "editChannelMessage", "cID": channelID, "mID": messageID})
+
+	check := canSendToChannel
+	if embed != nil {
+		check = canEmbedToChannel
+	}
+	permitted, err := check(r.session.State, userID, channelID)
 	if err != nil {
-		return fmt.Errorf("error embedding message to channel, %w", err)
+		ecmLog.WithError(err).Warn("Cannot edit in channel")
+		return fmt.Errorf("cannot edit in channel, %w", err)
+	}
+	if !permitted {
+		return errors.New("not permitted to edit in channel")
+	}
+
+	edit := discordgo.NewMessageEdit(channelID, messageID).SetContent(message)
+	if embed != nil {
+		edit.SetEmbed(embed)
+	}
+	if _, err := r.session.ChannelMessageEditComplex(edit); err != nil {
+		ecmLog.WithError(err).Warn("error editing message")
+		return discordMessageError("error editing message", err)
+	}
+	return nil
+}
+
+// deleteChannelMessage deletes a message
+func (r *Runner) deleteChannelMessage(channelID, messageID string) error {
+	if err := r.session.ChannelMessageDelete(channelID, messageID); err != nil {
+		log.WithFields(logrus.Fields{"sys": "RUN", "ssys": "deleteChannelMessage", "cID": channelID, "mID": messageID}).
+			WithError(err).Warn("error deleting message")
+		return discordMessageError("error deleting message", err)
+	}
+	return nil
+}
+
+// pinChannelMessage pins a message to its channel
+func (r *Runner) pinChannelMessage(channelID, messageID string) error {
+	if err := r.session.ChannelMessagePin(channelID, messageID); err != nil {
+		log.WithFields(logrus.Fields{"sys": "RUN", "ssys": "pinChannelMessage", "cID": channelID, "mID": messageID}).
+			WithError(err).Warn("error pinning message")
+		return fmt.Errorf("error pinning message, %w", err)
 	}
 	return nil
 }
 
+// discordMessageError wraps an error from Discord, returning
+// types.ErrMessageNotFound if the message doesn't exist any more
+func discordMessageError(message string, err error) error {
+	var restErr *discordgo.RESTError
+	if errors.As(err, &restErr) && restErr.Response != nil && restErr.Response.StatusCode == http.StatusNotFound {
+		return types.ErrMessageNotFound
+	}
+	return fmt.Errorf("%s, %w", message, err)
+}
+
 // sendPrivateMessage sends or updates a private message to a user
 func (r *Runner) sendPrivateMessage(snowflake, messageID, message string) (string, error) {
 	spmLog := log.WithFields(logrus.Fields{"sys": "RUN", "ssys": "sendPrivateMessage", "cID": snowflake, "mID": messageID})
@@ -273,6 +334,39 @@ func (r *Runner) sendPrivateMessage(snowflake, messageID, message string) (strin
 	return m.ID, nil
 }
 
+// sendPrivateEmbed sends an embed to a user
+func (r *Runner) sendPrivateEmbed(snowflake string, embed *discordgo.MessageEmbed) (string, error) {
+	speLog := log.WithFields(logrus.Fields{"sys": "RUN", "ssys": "sendPrivateEmbed", "cID": snowflake})
+
+	channel, err := r.session.UserChannelCreate(snowflake)
+	if err != nil {
+		speLog.WithError(err).Error("Error creating user channel")
+		return "", fmt.Errorf("could not create user channel, %w", err)
+	}
+
+	m, err := r.session.ChannelMessageSendEmbed(channel.ID, embed)
+	if err != nil {
+		speLog.WithError(err).Error("error sending private embed")
+		return "", fmt.Errorf("error sending private embed, %w", err)
+	}
+
+	return m.ID, nil
+}
+
+// privateMessageError is the error returned to the game for a failed DM
+func privateMessageError(err error) error {
+	var restErr *discordgo.RESTError
+	if errors.As(err, &restErr) {
+		switch {
+		case restErr.Message != nil && restErr.Message.Code == discordgo.ErrCodeCannotSendMessagesToThisUser:
+			return types.ErrDMsClosed
+		case restErr.Response != nil && restErr.Response.StatusCode == http.StatusTooManyRequests:
+			return types.ErrRateLimited
+		}
+	}
+	return errors.New("could not send to user")
+}
+
 func canSendToChannel(pg channelPermissionsGetter, userID, channelID string) (bool, error) {
 	cstcLog := log.WithFields(logrus.Fields{"sys": "RUN", "ssys": "canSendToChannel", "uID": userID, "cID": channelID})
 	perms, err := pg.UserChannelPermissions(userID, channelID)
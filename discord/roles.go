@@ -2,8 +2,10 @@ package discord
 
 import (
 	"errors"
+	"time"
+
 	"github.com/bwmarrin/discordgo"
-	"github.com/globalsign/mgo"
+	"github.com/poundbot/poundbot/storage"
 	"github.com/poundbot/poundbot/types"
 	"github.com/sirupsen/logrus"
 )
@@ -22,60 +24,149 @@ type roleMemberAdder interface {
 	GuildMemberRoleRemove(guildID, userID, roleID string) (err error)
 }
 
+// rolesSetHandler sets the role's members to the users linked to the role
+// set's players, and reports the result if asked
 func rolesSetHandler(userID string, rs types.RoleSet, state roleGuildGetter, rpg rolePlayerGetter, rma roleMemberAdder) {
 	rsLog := log.WithFields(logrus.Fields{"cmd": "rolesSetHandler", "rsRole": rs.Role, "gID": rs.GuildID})
-	rsLog.Tracef("roles set: %s", rs)
-	guild, err := state.Guild(rs.GuildID)
+	rsLog.Tracef("roles set: %v", rs)
+
+	result := setRole(userID, rs, state, rpg, rma, rsLog)
+	sendRoleSetResult(rs.ResultChan, result, rsLog)
+}
+
+// roleMemberChangeHandler adds or removes the users linked to players from
+// a role. Only the players' users are changed, so the rest of the guild
+// isn't looked up. Members are looked up with mg, as the state may not have
+// every member of large guilds.
+func roleMemberChangeHandler(userID string, rmc types.RoleMemberChange, state roleGuildGetter, mg guildMemberGetter,
+	rpg rolePlayerGetter, rma roleMemberAdder) {
+	rcLog := log.WithFields(logrus.Fields{"cmd": "roleMemberChangeHandler", "rsRole": rmc.Role, "gID": rmc.GuildID})
+	rcLog.Tracef("role member change: %v", rmc)
+
+	result := changeRoleMembers(userID, rmc, state, mg, rpg, rma, rcLog)
+	sendRoleSetResult(rmc.ResultChan, result, rcLog)
+}
+
+func changeRoleMembers(userID string, rmc types.RoleMemberChange, state roleGuildGetter, mg guildMemberGetter,
+	rpg rolePlayerGetter, rma roleMemberAdder, rcLog *logrus.Entry) types.RoleSetResult {
+	result := types.NewRoleSetResult(rmc.Role)
+
+	guild, err := state.Guild(rmc.GuildID)
 	if err != nil {
-		rsLog.WithError(err).Error("Could not find guild")
-		return
+		rcLog.WithError(err).Error("Could not find guild")
+		result.Error = "server not found"
+		return result
 	}
 
-	var me *discordgo.Member
-	maxRolePermit := -1
-	for _, member := range guild.Members {
-		if member.User.ID == userID {
-			me = member
-		}
+	gRole := manageableRole(userID, guild, rmc.Role, &result, rcLog)
+	if gRole == nil {
+		return result
 	}
+	rcLog = rcLog.WithFields(logrus.Fields{"rName": gRole.Name, "rID": gRole.ID})
 
-	if me == nil {
-		rsLog.WithError(errors.New("could not find myself in guild")).Error("can't find me")
-		return
-	}
+	for _, playerID := range rmc.PlayerIDs {
+		pLog := rcLog.WithField("pID", playerID)
+		u, err := rpg.GetByPlayerID(playerID)
+		if err != nil {
+			failure := types.RoleSetFailure{
+				RoleSetMember: types.RoleSetMember{PlayerID: playerID},
+				Error:         "player is not linked",
+			}
+			if !errors.Is(err, storage.ErrNotFound) {
+				pLog.WithError(err).Error("storage error finding user")
+				failure.Error = "could not find linked user"
+			}
+			result.Failures = append(result.Failures, failure)
+			continue
+		}
 
-	var gRole *discordgo.Role
-	for _, role := range guild.Roles {
-		rsLog.Tracef("%s is %d", role.Name, role.Position)
-		if role.ID == rs.Role || role.Name == rs.Role {
-			gRole = role
-			// break
+		rsMember := types.RoleSetMember{PlayerID: playerID, Snowflake: u.Snowflake, DiscordName: u.DiscordName}
+		member, err := mg.guildMember(guild.ID, u.Snowflake)
+		if err != nil {
+			pLog.WithError(err).Error("Could not get guild member")
+			result.Failures = append(result.Failures, types.RoleSetFailure{
+				RoleSetMember: rsMember,
+				Error:         "could not find member",
+			})
+			continue
 		}
-		if role.Position > maxRolePermit && role.Permissions&discordgo.PermissionManageRoles != 0 {
-			for _, roleID := range me.Roles {
-				rsLog.Tracef("Checking permissions on %s for %s", roleID, role.Name)
-				if role.ID == roleID {
-					maxRolePermit = role.Position
-					break
-				}
+		if member == nil {
+			result.Failures = append(result.Failures, types.RoleSetFailure{
+				RoleSetMember: rsMember,
+				Error:         "not a member of the server",
+			})
+			continue
+		}
+		rsMember.DiscordName = member.User.String()
+
+		hasRole := false
+		for _, role := range member.Roles {
+			if role == gRole.ID {
+				hasRole = true
+				break
+			}
+		}
+
+		switch {
+		case !rmc.Remove && !hasRole:
+			if err := rma.GuildMemberRoleAdd(guild.ID, member.User.ID, gRole.ID); err != nil {
+				pLog.WithError(err).Error("Could not set role")
+				result.Failures = append(result.Failures, types.RoleSetFailure{
+					RoleSetMember: rsMember,
+					Error:         "could not add role",
+				})
+				continue
+			}
+			result.Added = append(result.Added, rsMember)
+		case rmc.Remove && hasRole:
+			if err := rma.GuildMemberRoleRemove(guild.ID, member.User.ID, gRole.ID); err != nil {
+				pLog.WithError(err).Error("Could not remove role")
+				result.Failures = append(result.Failures, types.RoleSetFailure{
+					RoleSetMember: rsMember,
+					Error:         "could not remove role",
+				})
+				continue
 			}
+			result.Removed = append(result.Removed, rsMember)
 		}
 	}
 
-	if gRole == nil {
-		rsLog.Tracef("could not find role %s", rs.Role)
+	return result
+}
+
+// sendRoleSetResult sends the result if it was asked for
+func sendRoleSetResult(rChan chan<- types.RoleSetResult, result types.RoleSetResult, l *logrus.Entry) {
+	if rChan == nil {
 		return
 	}
+	defer close(rChan)
+	select {
+	case rChan <- result:
+	case <-time.After(time.Second / 2):
+		l.Error("no response sending role set result")
+	}
+}
 
-	rsLog = rsLog.WithFields(logrus.Fields{"rName": gRole.Name, "rID": gRole.ID})
+func setRole(userID string, rs types.RoleSet, state roleGuildGetter, rpg rolePlayerGetter, rma roleMemberAdder,
+	rsLog *logrus.Entry) types.RoleSetResult {
+	result := types.NewRoleSetResult(rs.Role)
 
-	if maxRolePermit < gRole.Position {
-		rsLog.WithField("rPerms", gRole.Permissions).Trace("I can't do that, dave.")
-		return
+	guild, err := state.Guild(rs.GuildID)
+	if err != nil {
+		rsLog.WithError(err).Error("Could not find guild")
+		result.Error = "server not found"
+		return result
 	}
 
+	gRole := manageableRole(userID, guild, rs.Role, &result, rsLog)
+	if gRole == nil {
+		return result
+	}
+	rsLog = rsLog.WithFields(logrus.Fields{"rName": gRole.Name, "rID": gRole.ID})
+
 	for _, member := range guild.Members {
 		rsLog = rsLog.WithFields(logrus.Fields{"uID": member.User.ID, "uName": member.User.Username})
+		rsMember := types.RoleSetMember{Snowflake: member.User.ID, DiscordName: member.User.String()}
 		hasRole := false
 		for _, role := range member.Roles {
 			if role == gRole.ID {
@@ -86,8 +177,12 @@ func rolesSetHandler(userID string, rs types.RoleSet, state roleGuildGetter, rpg
 
 		u, err := rpg.GetByDiscordID(member.User.ID)
 		if err != nil {
-			if err != mgo.ErrNotFound {
+			if !errors.Is(err, storage.ErrNotFound) {
 				rsLog.WithError(err).Error("storage error finding user")
+				result.Failures = append(result.Failures, types.RoleSetFailure{
+					RoleSetMember: rsMember,
+					Error:         "could not find linked user",
+				})
 				continue
 			}
 		}
@@ -110,7 +205,13 @@ func rolesSetHandler(userID string, rs types.RoleSet, state roleGuildGetter, rpg
 			rsLog.Tracef("adding role")
 			if err := rma.GuildMemberRoleAdd(guild.ID, member.User.ID, gRole.ID); err != nil {
 				rsLog.WithField("uID", u.Snowflake).WithError(err).Error("Could not set role")
+				result.Failures = append(result.Failures, types.RoleSetFailure{
+					RoleSetMember: rsMember,
+					Error:         "could not add role",
+				})
+				continue
 			}
+			result.Added = append(result.Added, rsMember)
 			continue
 		}
 
@@ -118,8 +219,120 @@ func rolesSetHandler(userID string, rs types.RoleSet, state roleGuildGetter, rpg
 			rsLog.Tracef("removing role")
 			if err := rma.GuildMemberRoleRemove(guild.ID, member.User.ID, gRole.ID); err != nil {
 				rsLog.WithField("uID", u.Snowflake).WithError(err).Error("Could not remove role")
+				result.Failures = append(result.Failures, types.RoleSetFailure{
+					RoleSetMember: rsMember,
+					Error:         "could not remove role",
+				})
+				continue
+			}
+			result.Removed = append(result.Removed, rsMember)
+			continue
+		}
+	}
+
+	return result
+}
+
+// manageableRole finds a guild's role by ID or name, setting the result's
+// RoleFound and PermissionOK. It returns nil unless the bot can manage the
+// role.
+func manageableRole(userID string, guild *discordgo.Guild, name string, result *types.RoleSetResult,
+	rsLog *logrus.Entry) *discordgo.Role {
+	var me *discordgo.Member
+	maxRolePermit := -1
+	for _, member := range guild.Members {
+		if member.User.ID == userID {
+			me = member
+		}
+	}
+
+	if me == nil {
+		rsLog.WithError(errors.New("could not find myself in guild")).Error("can't find me")
+		result.Error = "PoundBot is not a member of the server"
+		return nil
+	}
+
+	var gRole *discordgo.Role
+	for _, role := range guild.Roles {
+		rsLog.Tracef("%s is %d", role.Name, role.Position)
+		if role.ID == name || role.Name == name {
+			gRole = role
+			// break
+		}
+		if role.Position > maxRolePermit && role.Permissions&discordgo.PermissionManageRoles != 0 {
+			for _, roleID := range me.Roles {
+				rsLog.Tracef("Checking permissions on %s for %s", roleID, role.Name)
+				if role.ID == roleID {
+					maxRolePermit = role.Position
+					break
+				}
+			}
+		}
+	}
+
+	if gRole == nil {
+		rsLog.Tracef("could not find role %s", name)
+		return nil
+	}
+	result.RoleFound = true
+	result.RoleID = gRole.ID
+
+	if maxRolePermit < gRole.Position {
+		rsLog.WithField("rPerms", gRole.Permissions).Trace("I can't do that, dave.")
+		return nil
+	}
+	result.PermissionOK = true
+	return gRole
+}
+
+// roleMembersHandler finds the members of roles for the game
+func roleMembersHandler(rmr types.RoleMembersRequest, state roleGuildGetter) {
+	defer close(rmr.ResponseChan)
+
+	rmLog := log.WithFields(logrus.Fields{"cmd": "roleMembersHandler", "gID": rmr.GuildID})
+
+	respond := func(response types.RoleMembersResponse) {
+		select {
+		case rmr.ResponseChan <- response:
+		case <-time.After(time.Second / 2):
+			rmLog.WithError(response.Error).Error("no response sending role members")
+		}
+	}
+
+	guild, err := state.Guild(rmr.GuildID)
+	if err != nil {
+		rmLog.WithError(err).Error("Could not find guild")
+		respond(types.RoleMembersResponse{Error: errors.New("server not found")})
+		return
+	}
+
+	respond(types.RoleMembersResponse{Roles: roleMembers(guild, rmr.RoleIDs)})
+}
+
+// roleMembers lists the members of each of the guild's roles
+func roleMembers(guild *discordgo.Guild, roleIDs []string) []types.RoleMembers {
+	roles := make([]types.RoleMembers, len(roleIDs))
+	for i, roleID := range roleIDs {
+		roles[i].RoleID = roleID
+		for _, role := range guild.Roles {
+			if role.ID == roleID {
+				roles[i].Name = role.Name
+				roles[i].Found = true
+				break
 			}
+		}
+		if !roles[i].Found {
 			continue
 		}
+
+		for _, member := range guild.Members {
+			for _, memberRoleID := range member.Roles {
+				if memberRoleID == roleID {
+					roles[i].Snowflakes = append(roles[i].Snowflakes, member.User.ID)
+					break
+				}
+			}
+		}
 	}
+	return roles
 }
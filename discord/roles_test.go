@@ -0,0 +1,270 @@
+package discord
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type roleUsersMock map[string]types.User
+
+func (m roleUsersMock) GetByPlayerID(playerID string) (types.User, error) {
+	for _, user := range m {
+		for _, pID := range user.PlayerIDs {
+			if pID == playerID {
+				return user, nil
+			}
+		}
+	}
+	return types.User{}, storage.ErrNotFound
+}
+
+func (m roleUsersMock) GetByDiscordID(snowflake string) (types.User, error) {
+	user, ok := m[snowflake]
+	if !ok {
+		return types.User{}, storage.ErrNotFound
+	}
+	return user, nil
+}
+
+// roleMemberAdderMock records role changes, failing for users in fail
+type roleMemberAdderMock struct {
+	fail    map[string]bool
+	added   []string
+	removed []string
+}
+
+func (m *roleMemberAdderMock) GuildMemberRoleAdd(guildID, userID, roleID string) error {
+	if m.fail[userID] {
+		return errors.New("forbidden")
+	}
+	m.added = append(m.added, userID)
+	return nil
+}
+
+func (m *roleMemberAdderMock) GuildMemberRoleRemove(guildID, userID, roleID string) error {
+	if m.fail[userID] {
+		return errors.New("forbidden")
+	}
+	m.removed = append(m.removed, userID)
+	return nil
+}
+
+func TestRolesSetHandler(t *testing.T) {
+	t.Parallel()
+
+	member := func(id string, roles ...string) *discordgo.Member {
+		return &discordgo.Member{User: &discordgo.User{ID: id, Username: id, Discriminator: "0001"}, Roles: roles}
+	}
+	linked := func(playerID string) types.User {
+		var u types.User
+		u.PlayerIDs = []string{playerID}
+		return u
+	}
+	us := roleUsersMock{"add": linked("game:1"), "keep": linked("game:2"), "fail": linked("game:3")}
+
+	tests := []struct {
+		name  string
+		role  string
+		roles []*discordgo.Role
+		want  types.RoleSetResult
+		added []string
+	}{
+		{
+			name:  "set",
+			role:  "VIP",
+			roles: []*discordgo.Role{{ID: "bot", Position: 2, Permissions: discordgo.PermissionManageRoles}, {ID: "10", Name: "VIP", Position: 1}},
+			want: types.RoleSetResult{
+				Role:         "VIP",
+				RoleID:       "10",
+				RoleFound:    true,
+				PermissionOK: true,
+				Added:        []types.RoleSetMember{{Snowflake: "add", DiscordName: "add#0001"}},
+				Removed:      []types.RoleSetMember{{Snowflake: "remove", DiscordName: "remove#0001"}},
+				Failures: []types.RoleSetFailure{{
+					RoleSetMember: types.RoleSetMember{Snowflake: "fail", DiscordName: "fail#0001"},
+					Error:         "could not add role",
+				}},
+			},
+			added: []string{"add"},
+		},
+		{
+			name:  "role above bot",
+			role:  "VIP",
+			roles: []*discordgo.Role{{ID: "bot", Position: 1, Permissions: discordgo.PermissionManageRoles}, {ID: "10", Name: "VIP", Position: 2}},
+			want: types.RoleSetResult{
+				Role:      "VIP",
+				RoleID:    "10",
+				RoleFound: true,
+				Added:     []types.RoleSetMember{},
+				Removed:   []types.RoleSetMember{},
+				Failures:  []types.RoleSetFailure{},
+			},
+		},
+		{
+			name:  "missing role",
+			role:  "Admin",
+			roles: []*discordgo.Role{{ID: "bot", Position: 2, Permissions: discordgo.PermissionManageRoles}},
+			want: types.RoleSetResult{
+				Role:     "Admin",
+				Added:    []types.RoleSetMember{},
+				Removed:  []types.RoleSetMember{},
+				Failures: []types.RoleSetFailure{},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rg := guildGetterMock{guild: &discordgo.Guild{
+				ID:    "guild",
+				Roles: tt.roles,
+				Members: []*discordgo.Member{
+					member("me", "bot"),
+					member("add"),
+					member("keep", "10"),
+					member("remove", "10"),
+					member("fail"),
+				},
+			}}
+			rma := &roleMemberAdderMock{fail: map[string]bool{"fail": true}}
+			rChan := make(chan types.RoleSetResult, 1)
+
+			rolesSetHandler("me", types.RoleSet{
+				GuildID:    "guild",
+				Role:       tt.role,
+				PlayerIDs:  []string{"game:1", "game:2", "game:3"},
+				ResultChan: rChan,
+			}, rg, us, rma)
+
+			assert.Equal(t, tt.want, <-rChan)
+			assert.Equal(t, tt.added, rma.added)
+		})
+	}
+}
+
+func TestRoleMembers(t *testing.T) {
+	t.Parallel()
+
+	guild := &discordgo.Guild{
+		Roles: []*discordgo.Role{{ID: "10", Name: "VIP"}, {ID: "11", Name: "Moderator"}},
+		Members: []*discordgo.Member{
+			{User: &discordgo.User{ID: "user-1"}, Roles: []string{"10", "11"}},
+			{User: &discordgo.User{ID: "user-2"}, Roles: []string{"10"}},
+			{User: &discordgo.User{ID: "user-3"}},
+		},
+	}
+
+	assert.Equal(t, []types.RoleMembers{
+		{RoleID: "11", Name: "Moderator", Found: true, Snowflakes: []string{"user-1"}},
+		{RoleID: "12"},
+		{RoleID: "10", Name: "VIP", Found: true, Snowflakes: []string{"user-1", "user-2"}},
+	}, roleMembers(guild, []string{"11", "12", "10"}))
+}
+
+func TestRoleMemberChangeHandler(t *testing.T) {
+	t.Parallel()
+
+	linked := func(snowflake, playerID string) types.User {
+		var u types.User
+		u.Snowflake = snowflake
+		u.DiscordName = snowflake + "#0001"
+		u.PlayerIDs = []string{playerID}
+		return u
+	}
+	us := roleUsersMock{
+		"add":    linked("add", "game:1"),
+		"has":    linked("has", "game:2"),
+		"fail":   linked("fail", "game:3"),
+		"absent": linked("absent", "game:4"),
+		"broken": linked("broken", "game:6"),
+	}
+	member := func(id string, roles ...string) *discordgo.Member {
+		return &discordgo.Member{User: &discordgo.User{ID: id, Username: id, Discriminator: "0001"}, Roles: roles}
+	}
+	// Only the bot is in the state, like a large guild
+	rg := guildGetterMock{guild: &discordgo.Guild{
+		ID:      "guild",
+		Roles:   []*discordgo.Role{{ID: "bot", Position: 2, Permissions: discordgo.PermissionManageRoles}, {ID: "10", Name: "VIP", Position: 1}},
+		Members: []*discordgo.Member{member("me", "bot")},
+	}}
+	mg := guildMemberGetterMock{
+		members: map[string]*discordgo.Member{"add": member("add"), "has": member("has", "10"), "fail": member("fail")},
+		err:     map[string]error{"broken": errors.New("broken")},
+	}
+
+	rsMember := func(playerID, snowflake string) types.RoleSetMember {
+		return types.RoleSetMember{PlayerID: playerID, Snowflake: snowflake, DiscordName: snowflake + "#0001"}
+	}
+
+	tests := []struct {
+		name   string
+		remove bool
+		want   types.RoleSetResult
+	}{
+		{
+			name: "add",
+			want: types.RoleSetResult{
+				Role:         "VIP",
+				RoleID:       "10",
+				RoleFound:    true,
+				PermissionOK: true,
+				Added:        []types.RoleSetMember{rsMember("game:1", "add")},
+				Removed:      []types.RoleSetMember{},
+				Failures: []types.RoleSetFailure{
+					{RoleSetMember: rsMember("game:3", "fail"), Error: "could not add role"},
+					{RoleSetMember: rsMember("game:4", "absent"), Error: "not a member of the server"},
+					{RoleSetMember: types.RoleSetMember{PlayerID: "game:5"}, Error: "player is not linked"},
+					{RoleSetMember: rsMember("game:6", "broken"), Error: "could not find member"},
+				},
+			},
+		},
+		{
+			name:   "remove",
+			remove: true,
+			want: types.RoleSetResult{
+				Role:         "VIP",
+				RoleID:       "10",
+				RoleFound:    true,
+				PermissionOK: true,
+				Added:        []types.RoleSetMember{},
+				Removed:      []types.RoleSetMember{rsMember("game:2", "has")},
+				Failures: []types.RoleSetFailure{
+					{RoleSetMember: rsMember("game:4", "absent"), Error: "not a member of the server"},
+					{RoleSetMember: types.RoleSetMember{PlayerID: "game:5"}, Error: "player is not linked"},
+					{RoleSetMember: rsMember("game:6", "broken"), Error: "could not find member"},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rma := &roleMemberAdderMock{fail: map[string]bool{"fail": true}}
+			rChan := make(chan types.RoleSetResult, 1)
+
+			roleMemberChangeHandler("me", types.RoleMemberChange{
+				GuildID:    "guild",
+				Role:       "VIP",
+				PlayerIDs:  []string{"game:1", "game:2", "game:3", "game:4", "game:5", "game:6"},
+				Remove:     tt.remove,
+				ResultChan: rChan,
+			}, rg, mg, us, rma)
+
+			assert.Equal(t, tt.want, <-rChan)
+		})
+	}
+}
+
+// guildMemberGetterMock finds members by user ID, failing for users with an
+// error
+type guildMemberGetterMock struct {
+	members map[string]*discordgo.Member
+	err     map[string]error
+}
+
+func (m guildMemberGetterMock) guildMember(guildID, userID string) (*discordgo.Member, error) {
+	return m.members[userID], m.err[userID]
+}
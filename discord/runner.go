@@ -3,6 +3,7 @@ package discord
 import (
 	"errors"
 	"fmt"
+	"net/http"
 	"strings"
 	"time"
 
@@ -24,34 +25,49 @@ type Runner struct {
 	mls             storage.MessageLocksStore
 	das             storage.DiscordAuthsStore
 	us              storage.UsersStore
+	rhs             storage.RaidHistoryStore
+	cls             storage.ChatLogStore
+	ops             storage.OnlinePlayersStore
 	token           string
 	status          chan bool
 	chatChan        chan types.ChatMessage
 	raidAlertChan   chan types.RaiAlertWithMessageChannel
 	gameMessageChan chan types.GameMessage
+	playerDMChan    chan types.PlayerDM
+	guildMemberChan chan types.GuildMemberRequest
+	roleMembersChan chan types.RoleMembersRequest
 	authChan        chan types.DiscordAuth
 	AuthSuccess     chan types.DiscordAuth
 	channelsRequest chan types.ServerChannelsRequest
 	roleSetChan     chan types.RoleSet
+	roleChangeChan  chan types.RoleMemberChange
 	shutdown        bool
 }
 
 func NewRunner(token string, as storage.AccountsStore, das storage.DiscordAuthsStore,
-	us storage.UsersStore, mls storage.MessageLocksStore, cqs storage.ChatQueueStore) *Runner {
+	us storage.UsersStore, rhs storage.RaidHistoryStore, cls storage.ChatLogStore,
+	mls storage.MessageLocksStore, cqs storage.ChatQueueStore, ops storage.OnlinePlayersStore) *Runner {
 	return &Runner{
 		cqs:             cqs,
 		mls:             mls,
 		as:              as,
 		das:             das,
 		us:              us,
+		rhs:             rhs,
+		cls:             cls,
+		ops:             ops,
 		token:           token,
 		chatChan:        make(chan types.ChatMessage),
 		authChan:        make(chan types.DiscordAuth),
 		AuthSuccess:     make(chan types.DiscordAuth),
 		raidAlertChan:   make(chan types.RaiAlertWithMessageChannel),
 		gameMessageChan: make(chan types.GameMessage),
+		playerDMChan:    make(chan types.PlayerDM),
+		guildMemberChan: make(chan types.GuildMemberRequest),
+		roleMembersChan: make(chan types.RoleMembersRequest),
 		channelsRequest: make(chan types.ServerChannelsRequest),
 		roleSetChan:     make(chan types.RoleSet),
+		roleChangeChan:  make(chan types.RoleMemberChange),
 	}
 }
 
@@ -86,8 +102,14 @@ func (r Runner) AuthDiscord(da types.DiscordAuth) {
 	r.authChan <- da
 }
 
-func (r Runner) SendChatMessage(cm types.ChatMessage) {
-	r.chatChan <- cm
+// SendChatMessage sends chat from the game to its discord channel
+func (r Runner) SendChatMessage(cm types.ChatMessage, timeout time.Duration) error {
+	select {
+	case r.chatChan <- cm:
+		return nil
+	case <-time.After(timeout):
+		return errors.New("no response from discord handler")
+	}
 }
 
 // SendGameMessage sends a message from the game to a discord channel
@@ -100,6 +122,36 @@ func (r Runner) SendGameMessage(gm types.GameMessage, timeout time.Duration) err
 	}
 }
 
+// SendPlayerDM sends a message from the game to a player's discord user
+func (r Runner) SendPlayerDM(dm types.PlayerDM, timeout time.Duration) error {
+	select {
+	case r.playerDMChan <- dm:
+		return nil
+	case <-time.After(timeout):
+		return errors.New("no response from discord handler")
+	}
+}
+
+// GuildMember sends a request for a user's membership of a discord guild
+func (r Runner) GuildMember(gmr types.GuildMemberRequest, timeout time.Duration) error {
+	select {
+	case r.guildMemberChan <- gmr:
+		return nil
+	case <-time.After(timeout):
+		return errors.New("no response from discord handler")
+	}
+}
+
+// RoleMembers sends a request for the members of discord roles
+func (r Runner) RoleMembers(rmr types.RoleMembersRequest, timeout time.Duration) error {
+	select {
+	case r.roleMembersChan <- rmr:
+		return nil
+	case <-time.After(timeout):
+		return errors.New("no response from discord handler")
+	}
+}
+
 // ServerChannels sends a request to get the visible chnnels for a discord guild
 func (r Runner) ServerChannels(scr types.ServerChannelsRequest) {
 	r.channelsRequest <- scr
@@ -115,6 +167,16 @@ func (r Runner) SetRole(rs types.RoleSet, timeout time.Duration) error {
 	}
 }
 
+// ChangeRoleMembers sends a request to add or remove players from a role
+func (r Runner) ChangeRoleMembers(rmc types.RoleMemberChange, timeout time.Duration) error {
+	select {
+	case r.roleChangeChan <- rmc:
+		return nil
+	case <-time.After(timeout):
+		return errors.New("no response from discord handler")
+	}
+}
+
 // Stop stops the runner
 func (r *Runner) Stop() {
 	defer r.session.Close()
@@ -181,12 +243,20 @@ func (r *Runner) runner() {
 					go r.discordAuthHandler(da)
 				case m := <-r.gameMessageChan:
 					go gameMessageHandler(r.session.State.User.ID, m, r.session.State.Guild, r)
+				case dm := <-r.playerDMChan:
+					go playerDMHandler(dm, r)
+				case gmr := <-r.guildMemberChan:
+					go guildMemberHandler(gmr, r, r.session.State)
+				case rmr := <-r.roleMembersChan:
+					go roleMembersHandler(rmr, r.session.State)
 				case cm := <-r.chatChan:
-					go gameChatHandler(r.session.State.User.ID, cm, r.session.State.Guild, r)
+					go gameChatHandler(r.session.State.User.ID, cm, r.session.State.Guild, r, r.cls)
 				case cr := <-r.channelsRequest:
 					go sendChannelList(r.session.State.User.ID, cr.GuildID, cr.ResponseChan, r.session.State)
 				case rs := <-r.roleSetChan:
 					go rolesSetHandler(r.session.State.User.ID, rs, r.session.State, r.us, r.session)
+				case rmc := <-r.roleChangeChan:
+					go roleMemberChangeHandler(r.session.State.User.ID, rmc, r.session.State, r, r.us, r.session)
 				}
 			}
 		}
@@ -274,6 +344,28 @@ func (r *Runner) ready(s *discordgo.Session, event *discordgo.Ready) {
 	r.status <- true
 }
 
+// guildMember gets a member of a guild, or nil if the user isn't a member.
+// It checks the state first, then asks Discord in case the state doesn't
+// have every member of large guilds.
+func (r *Runner) guildMember(guildID, userID string) (*discordgo.Member, error) {
+	if member, err := r.session.State.Member(guildID, userID); err == nil {
+		return member, nil
+	}
+
+	member, err := r.session.GuildMember(guildID, userID)
+	var restErr *discordgo.RESTError
+	if errors.As(err, &restErr) && restErr.Response != nil && restErr.Response.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	return member, err
+}
+
+// isGuildMember checks if the user is a member of the guild
+func (r *Runner) isGuildMember(guildID, userID string) (bool, error) {
+	member, err := r.guildMember(guildID, userID)
+	return member != nil, err
+}
+
 // Returns nil user if they don't exist; Returns error if there was a communications error
 func (r *Runner) getUserByName(guildID, name string) (discordgo.User, error) {
 	guild, err := r.session.State.Guild(guildID)
@@ -2,20 +2,37 @@ package gameapi
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/blang/semver"
 	"github.com/gorilla/mux"
 	"github.com/poundbot/poundbot/pbclock"
+	"github.com/poundbot/poundbot/storage"
 	"github.com/poundbot/poundbot/types"
 )
 
 var iclock = pbclock.Clock
 
+const (
+	defaultChatBatch = 10
+	maxChatBatch     = 50
+)
+
+// chatLease is how long a game server has to acknowledge a chat message
+// before it is handed out again
+const chatLease = 30 * time.Second
+
 type chatQueue interface {
-	GetGameServerMessage(sk, tag string, to time.Duration) (types.ChatMessage, bool)
+	AckMessage(sk, id string) error
+	GetGameServerMessage(sk, tag string, lease, to time.Duration) (types.ChatMessage, bool)
+}
+
+type chatSender interface {
+	SendChatMessage(types.ChatMessage, time.Duration) error
 }
 
 type discordChat struct {
@@ -24,6 +41,42 @@ type discordChat struct {
 	Message     string
 }
 
+// queuedDiscordChat is a chat message in a batch, for connectors that
+// acknowledge messages
+type queuedDiscordChat struct {
+	ID string
+	discordChat
+	QueuedAt time.Time
+}
+
+func newQueuedDiscordChat(cm types.ChatMessage) queuedDiscordChat {
+	return queuedDiscordChat{
+		ID:          cm.ID.Hex(),
+		discordChat: newDiscordChat(cm),
+		QueuedAt:    cm.QueuedAt,
+	}
+}
+
+// gameChat is chat from the game to be sent to Discord
+type gameChat struct {
+	PlayerID    string
+	DisplayName string
+	ClanTag     string
+	Message     string
+	Tag         string // Defaults to chat
+}
+
+// gameChatResult reports what happened to chat from the game
+type gameChatResult struct {
+	ChannelBound bool // The server has a channel for the chat's tag
+	Sent         bool // The chat was sent to the channel
+}
+
+// chatAck is a game server acknowledging the chat messages it has received
+type chatAck struct {
+	IDs []string
+}
+
 func newDiscordChat(cm types.ChatMessage) discordChat {
 	return discordChat{
 		ClanTag:     cm.ClanTag,
@@ -35,25 +88,42 @@ func newDiscordChat(cm types.ChatMessage) discordChat {
 // A Chat is for handling discord <-> rust chat
 type chat struct {
 	cqs        chatQueue
+	cs         chatSender
 	timeout    time.Duration
 	minVersion semver.Version
+	ackVersion semver.Version // Connectors from this version get batches of messages and acknowledge them
 }
 
 // initChat initializes a chat handler and returns it
-func initChat(api *mux.Router, path string, cq chatQueue) {
+func initChat(api *mux.Router, path string, cq chatQueue, cs chatSender) {
 	c := chat{
 		cqs:        cq,
+		cs:         cs,
 		timeout:    10 * time.Second,
 		minVersion: semver.Version{Major: 1, Patch: 3},
+		ackVersion: semver.Version{Major: 2, Minor: 1},
 	}
 
 	api.HandleFunc(path, c.handle).Methods(http.MethodGet, http.MethodPost)
+	api.HandleFunc(path+"/ack", c.ack).Methods(http.MethodPost)
+}
+
+// acknowledges reports whether the connector making the request
+// acknowledges the chat messages it receives
+func (c *chat) acknowledges(r *http.Request) bool {
+	version, err := semver.Make(r.Header.Get("X-PoundBotConnector-Version"))
+	return err == nil && version.GTE(c.ackVersion)
 }
 
 // handle manages Discord to GameServer chat requests
 //
 // HTTP GET requests wait for messages or disconnect with http.StatusNoContent
-// after timeout seconds.
+// after timeout seconds. Connectors that acknowledge messages get a JSON
+// array of up to max leased messages. Older connectors get one message,
+// which is removed from the queue.
+//
+// HTTP POST requests send chat from the game to the Discord channel for its
+// tag.
 func (c *chat) handle(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
@@ -69,7 +139,12 @@ func (c *chat) handle(w http.ResponseWriter, r *http.Request) {
 
 	switch r.Method {
 	case http.MethodGet:
-		m, found := c.cqs.GetGameServerMessage(sc.serverKey, "chat", c.timeout)
+		if c.acknowledges(r) {
+			c.getBatch(w, r, sc)
+			return
+		}
+
+		m, found := c.cqs.GetGameServerMessage(sc.serverKey, "chat", 0, c.timeout)
 		if !found {
 			w.WriteHeader(http.StatusNoContent)
 			return
@@ -82,5 +157,165 @@ func (c *chat) handle(w http.ResponseWriter, r *http.Request) {
 		}
 
 		w.Write(b)
+	case http.MethodPost:
+		c.post(w, r, sc)
+	}
+}
+
+// post sends chat from the game to the Discord channel for its tag
+func (c *chat) post(w http.ResponseWriter, r *http.Request, sc serverContext) {
+	pLog := logWithRequest(r.RequestURI, sc)
+
+	var gc gameChat
+	if err := json.NewDecoder(r.Body).Decode(&gc); err != nil || len(gc.Message) == 0 {
+		pLog.WithError(err).Info("Invalid chat")
+		handleError(w, types.RESTError{
+			Error:      "Invalid request. Message is required.",
+			StatusCode: http.StatusBadRequest,
+		})
+		return
+	}
+	if len(gc.Tag) == 0 {
+		gc.Tag = "chat"
+	}
+
+	server, err := sc.account.ServerFromKey(sc.serverKey)
+	if err != nil {
+		pLog.WithError(err).Error("Can't find server in account")
+		handleError(w, types.RESTError{
+			Error:      "Error finding server identity",
+			StatusCode: http.StatusInternalServerError,
+		})
+		return
+	}
+
+	var result gameChatResult
+	channelID, found := server.ChannelIDForTag(gc.Tag)
+	if found {
+		result.ChannelBound = true
+		result.Sent, err = c.send(gc, sc, channelID)
+		if err != nil {
+			pLog.WithError(err).Error("Could not send chat to discord handler")
+			handleError(w, types.RESTError{
+				Error:      "internal error sending chat to discord handler",
+				StatusCode: http.StatusInternalServerError,
+			})
+			return
+		}
 	}
+
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		pLog.WithError(err).Error("Could not write chat result")
+	}
+}
+
+// send sends the chat to the discord handler, and reports whether it could
+// be sent to the channel
+func (c *chat) send(gc gameChat, sc serverContext, channelID string) (bool, error) {
+	eChan := make(chan error)
+	cm := types.ChatMessage{
+		ChannelID:     channelID,
+		ClanTag:       gc.ClanTag,
+		DisplayName:   gc.DisplayName,
+		Message:       gc.Message,
+		ServerKey:     sc.serverKey,
+		Tag:           gc.Tag,
+		ErrorResponse: eChan,
+	}
+	if len(gc.PlayerID) != 0 {
+		cm.PlayerID = fmt.Sprintf("%s:%s", sc.game, gc.PlayerID)
+	}
+
+	if err := c.cs.SendChatMessage(cm, c.timeout); err != nil {
+		return false, err
+	}
+
+	select {
+	case err := <-eChan:
+		return err == nil, nil
+	case <-time.After(c.timeout):
+		return false, errors.New("timed out receiving discord response")
+	}
+}
+
+// getBatch waits for a message, then leases up to max pending messages to
+// the game server
+func (c *chat) getBatch(w http.ResponseWriter, r *http.Request, sc serverContext) {
+	bLog := logWithRequest(r.RequestURI, sc)
+
+	max := defaultChatBatch
+	if param := r.URL.Query().Get("max"); len(param) != 0 {
+		var err error
+		max, err = strconv.Atoi(param)
+		if err != nil || max < 1 || max > maxChatBatch {
+			handleError(w, types.RESTError{
+				Error:      fmt.Sprintf("Invalid max, must be 1 to %d", maxChatBatch),
+				StatusCode: http.StatusBadRequest,
+			})
+			return
+		}
+	}
+
+	m, found := c.cqs.GetGameServerMessage(sc.serverKey, "chat", chatLease, c.timeout)
+	if !found {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	batch := []queuedDiscordChat{newQueuedDiscordChat(m)}
+	for len(batch) < max {
+		m, found := c.cqs.GetGameServerMessage(sc.serverKey, "chat", chatLease, 0)
+		if !found {
+			break
+		}
+		batch = append(batch, newQueuedDiscordChat(m))
+	}
+
+	if err := json.NewEncoder(w).Encode(batch); err != nil {
+		bLog.WithError(err).Error("Could not write chat")
+	}
+}
+
+// ack removes the chat messages the game server has received from the
+// queue. Messages that are no longer queued are ignored, so acknowledging
+// twice is not an error.
+func (c *chat) ack(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	sc, err := getServerContext(r.Context())
+	aLog := logWithRequest(r.RequestURI, sc)
+
+	if err != nil {
+		aLog.WithError(err).Info("Can't find server")
+		handleError(w, types.RESTError{
+			Error:      "Error finding server identity",
+			StatusCode: http.StatusForbidden,
+		})
+		return
+	}
+
+	var ack chatAck
+	if err := json.NewDecoder(r.Body).Decode(&ack); err != nil || len(ack.IDs) == 0 {
+		aLog.WithError(err).Info("Invalid chat ack")
+		handleError(w, types.RESTError{
+			Error:      "Invalid request. IDs are required.",
+			StatusCode: http.StatusBadRequest,
+		})
+		return
+	}
+
+	for _, id := range ack.IDs {
+		err := c.cqs.AckMessage(sc.serverKey, id)
+		if err == nil || errors.Is(err, storage.ErrNotFound) {
+			continue
+		}
+		aLog.WithError(err).Error("storage: Could not ack chat message")
+		handleError(w, types.RESTError{
+			Error:      "Could not acknowledge messages",
+			StatusCode: http.StatusInternalServerError,
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
 }
@@ -0,0 +1,206 @@
+package gameapi
+
+import (
+	"sync"
+	"time"
+
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/types"
+)
+
+// A chatWaiter is a game server request waiting for a chat message
+type chatWaiter struct {
+	lease    time.Duration
+	messages chan types.ChatMessage // buffered, and closed if no message is coming
+	timedOut bool                   // set once the request gave up waiting
+}
+
+// A ChatDispatcher hands queued chat messages to the game server requests
+// waiting for them. Requests wait in memory rather than each watching the
+// store. The dispatcher leases messages on behalf of the requests waiting
+// for their server and tag, as soon as a message is inserted.
+//
+// A single watcher asks the store which servers and tags have messages
+// ready each RecheckTime, to pick up messages whose lease has expired or
+// that another PoundBot queued. Only those are delivered, so the store is
+// queried once per check however many requests are waiting.
+//
+// ChatDispatcher implements storage.ChatQueueStore, so it can be shared by
+// everything that inserts and reads chat messages.
+type ChatDispatcher struct {
+	cqs         storage.ChatQueueStore
+	RecheckTime time.Duration
+
+	mu      sync.Mutex
+	waiters map[storage.ChatQueueKey][]*chatWaiter // in the order they started waiting
+}
+
+// NewChatDispatcher creates a ChatDispatcher for the queue
+func NewChatDispatcher(cqs storage.ChatQueueStore) *ChatDispatcher {
+	return &ChatDispatcher{
+		cqs:         cqs,
+		RecheckTime: 5 * time.Second,
+		waiters:     map[storage.ChatQueueKey][]*chatWaiter{},
+	}
+}
+
+// Run delivers the messages ready in the store every RecheckTime until done
+func (d *ChatDispatcher) Run(done <-chan struct{}) {
+	dLog := log.WithField("sys", "CHATDISPATCH")
+	dLog.Info("Starting")
+
+	ticker := time.NewTicker(d.RecheckTime)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			dLog.Warn("Shutting down")
+			return
+		case <-ticker.C:
+			d.deliverReady()
+		}
+	}
+}
+
+// InsertMessage implements storage.ChatQueueStore.InsertMessage, delivering
+// the message to a request waiting for its server and tag
+func (d *ChatDispatcher) InsertMessage(m types.ChatMessage) error {
+	if err := d.cqs.InsertMessage(m); err != nil {
+		return err
+	}
+	go d.deliver(storage.ChatQueueKey{ServerKey: m.ServerKey, Tag: m.Tag})
+	return nil
+}
+
+// AckMessage implements storage.ChatQueueStore.AckMessage
+func (d *ChatDispatcher) AckMessage(sk, id string) error {
+	return d.cqs.AckMessage(sk, id)
+}
+
+// ReadyKeys implements storage.ChatQueueStore.ReadyKeys
+func (d *ChatDispatcher) ReadyKeys() ([]storage.ChatQueueKey, error) {
+	return d.cqs.ReadyKeys()
+}
+
+// GetGameServerMessage implements storage.ChatQueueStore.GetGameServerMessage.
+// The store is checked once when the request starts waiting, and after that
+// only when a message is ready for the server and tag.
+func (d *ChatDispatcher) GetGameServerMessage(sk, tag string, lease, to time.Duration) (types.ChatMessage, bool) {
+	key := storage.ChatQueueKey{ServerKey: sk, Tag: tag}
+	w := &chatWaiter{lease: lease, messages: make(chan types.ChatMessage, 1)}
+
+	// Start waiting before checking, so an insert in between is not missed
+	d.mu.Lock()
+	d.waiters[key] = append(d.waiters[key], w)
+	d.mu.Unlock()
+	d.deliver(key)
+
+	timer := time.NewTimer(to)
+	defer timer.Stop()
+
+	select {
+	case m, ok := <-w.messages:
+		return m, ok
+	case <-timer.C:
+	}
+
+	if d.remove(key, w) {
+		return types.ChatMessage{}, false
+	}
+	// A message is being leased for the request
+	m, ok := <-w.messages
+	return m, ok
+}
+
+// deliverReady delivers messages to the requests waiting for the servers
+// and tags that have messages ready in the store
+func (d *ChatDispatcher) deliverReady() {
+	d.mu.Lock()
+	waiting := len(d.waiters) != 0
+	d.mu.Unlock()
+	if !waiting {
+		return
+	}
+
+	keys, err := d.cqs.ReadyKeys()
+	if err != nil {
+		log.WithField("sys", "CHATDISPATCH").WithError(err).Error("storage: Could not get ready chat")
+		return
+	}
+	for _, key := range keys {
+		d.deliver(key)
+	}
+}
+
+// deliver leases messages for the key to the requests waiting for it, in
+// the order they started waiting, until there are no more of either
+func (d *ChatDispatcher) deliver(key storage.ChatQueueKey) {
+	for {
+		w := d.next(key)
+		if w == nil {
+			return
+		}
+
+		m, found := d.cqs.GetGameServerMessage(key.ServerKey, key.Tag, w.lease, 0)
+		if !found {
+			d.requeue(key, w)
+			return
+		}
+		w.messages <- m
+	}
+}
+
+// next takes the request that has waited longest for the key
+func (d *ChatDispatcher) next(key storage.ChatQueueKey) *chatWaiter {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	waiters := d.waiters[key]
+	if len(waiters) == 0 {
+		return nil
+	}
+	w := waiters[0]
+	if len(waiters) == 1 {
+		delete(d.waiters, key)
+	} else {
+		d.waiters[key] = waiters[1:]
+	}
+	return w
+}
+
+// requeue puts a request that didn't get a message back in front, unless it
+// gave up waiting in the meantime
+func (d *ChatDispatcher) requeue(key storage.ChatQueueKey, w *chatWaiter) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if w.timedOut {
+		close(w.messages)
+		return
+	}
+	d.waiters[key] = append([]*chatWaiter{w}, d.waiters[key]...)
+}
+
+// remove stops a request waiting. It returns false if a message is being
+// leased for the request, which then arrives on its messages channel.
+func (d *ChatDispatcher) remove(key storage.ChatQueueKey, w *chatWaiter) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	waiters := d.waiters[key]
+	for i := range waiters {
+		if waiters[i] != w {
+			continue
+		}
+		waiters = append(waiters[:i:i], waiters[i+1:]...)
+		if len(waiters) == 0 {
+			delete(d.waiters, key)
+		} else {
+			d.waiters[key] = waiters
+		}
+		return true
+	}
+	w.timedOut = true
+	return false
+}
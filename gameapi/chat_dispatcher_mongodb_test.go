@@ -0,0 +1,46 @@
+// +build integration
+
+package gameapi
+
+import (
+	"os"
+	"testing"
+
+	"github.com/globalsign/mgo"
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/storage/mongodb"
+	"github.com/poundbot/poundbot/storage/mongodb/mongotest"
+)
+
+// The MongoDB chat queue copies a session for every waiting request, so
+// the benchmark also reports the sockets open to MongoDB.
+func init() {
+	chatDeliveryStores = append(chatDeliveryStores, chatDeliveryStore{
+		name: "mongodb",
+		new: func(b *testing.B) storage.ChatQueueStore {
+			coll, err := mongotest.NewCollection("chat_queue")
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.Cleanup(coll.Close)
+
+			dial := os.Getenv("MONGODB_DIAL")
+			if dial == "" {
+				dial = "mongodb://localhost"
+			}
+			s, err := mongodb.NewMongoDB(dial, coll.C.Database.Name)
+			if err != nil {
+				b.Fatal(err)
+			}
+			s.Init()
+			b.Cleanup(s.Close)
+
+			mgo.SetStats(true)
+			mgo.ResetStats()
+			return s.ChatQueue()
+		},
+		report: func(b *testing.B) {
+			b.ReportMetric(float64(mgo.GetStats().SocketsAlive), "mongo-sockets")
+		},
+	})
+}
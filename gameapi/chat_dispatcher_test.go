@@ -0,0 +1,236 @@
+package gameapi
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/storage/memory"
+	"github.com/poundbot/poundbot/storage/sqlite"
+	"github.com/poundbot/poundbot/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// countingChatQueue counts the calls to GetGameServerMessage, and the most
+// that were running at once
+type countingChatQueue struct {
+	storage.ChatQueueStore
+	calls, running, maxRunning int64
+}
+
+func (c *countingChatQueue) GetGameServerMessage(sk, tag string, lease, to time.Duration) (types.ChatMessage, bool) {
+	atomic.AddInt64(&c.calls, 1)
+	running := atomic.AddInt64(&c.running, 1)
+	defer atomic.AddInt64(&c.running, -1)
+	for {
+		max := atomic.LoadInt64(&c.maxRunning)
+		if running <= max || atomic.CompareAndSwapInt64(&c.maxRunning, max, running) {
+			break
+		}
+	}
+	return c.ChatQueueStore.GetGameServerMessage(sk, tag, lease, to)
+}
+
+func (c *countingChatQueue) reset() {
+	atomic.StoreInt64(&c.calls, 0)
+	atomic.StoreInt64(&c.maxRunning, atomic.LoadInt64(&c.running))
+}
+
+func newCountingChatQueue() *countingChatQueue {
+	return &countingChatQueue{ChatQueueStore: memory.NewMemory().ChatQueue()}
+}
+
+func TestChatDispatcher_GetGameServerMessage(t *testing.T) {
+	t.Parallel()
+
+	cq := newCountingChatQueue()
+	d := NewChatDispatcher(cq)
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		assert.Nil(t, d.InsertMessage(types.ChatMessage{ServerKey: "other", Tag: "chat", Message: "other"}))
+		assert.Nil(t, d.InsertMessage(types.ChatMessage{ServerKey: "key", Tag: "other", Message: "other tag"}))
+		assert.Nil(t, d.InsertMessage(types.ChatMessage{ServerKey: "key", Tag: "chat", Message: "hello"}))
+	}()
+
+	start := time.Now()
+	got, found := d.GetGameServerMessage("key", "chat", 0, 5*time.Second)
+	assert.True(t, found)
+	assert.Equal(t, "hello", got.Message)
+	assert.True(t, time.Since(start) < time.Second, "request should be woken by the insert")
+	assert.Equal(t, int64(2), atomic.LoadInt64(&cq.calls), "store should only be checked when woken for the server and tag")
+
+	_, found = d.GetGameServerMessage("key", "chat", 0, time.Millisecond)
+	assert.False(t, found, "request should time out")
+}
+
+func TestChatDispatcher_Run(t *testing.T) {
+	t.Parallel()
+
+	cq := newCountingChatQueue()
+	d := NewChatDispatcher(cq)
+	d.RecheckTime = 10 * time.Millisecond
+
+	done := make(chan struct{})
+	defer close(done)
+	go d.Run(done)
+
+	idle := make(chan bool)
+	go func() {
+		_, found := d.GetGameServerMessage("idle", "chat", 0, 200*time.Millisecond)
+		idle <- found
+	}()
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		// Inserted without the dispatcher, like another PoundBot would
+		assert.Nil(t, cq.InsertMessage(types.ChatMessage{ServerKey: "key", Tag: "chat", Message: "hello"}))
+	}()
+
+	got, found := d.GetGameServerMessage("key", "chat", 0, 5*time.Second)
+	assert.True(t, found, "watcher should deliver the message")
+	assert.Equal(t, "hello", got.Message)
+
+	assert.False(t, <-idle, "idle request should time out")
+	assert.Equal(t, int64(3), atomic.LoadInt64(&cq.calls),
+		"store should only be checked when requests start waiting and for keys with messages ready")
+}
+
+func TestChatDispatcher_GetGameServerMessage_waiters(t *testing.T) {
+	t.Parallel()
+
+	cq := newCountingChatQueue()
+	d := NewChatDispatcher(cq)
+
+	const waiters = 5
+	got := make(chan string, waiters)
+	for i := 0; i < waiters; i++ {
+		go func() {
+			m, found := d.GetGameServerMessage("key", "chat", time.Minute, 5*time.Second)
+			assert.True(t, found)
+			got <- m.Message
+		}()
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	var want []string
+	for i := 0; i < waiters; i++ {
+		want = append(want, fmt.Sprint(i))
+		assert.Nil(t, d.InsertMessage(types.ChatMessage{ServerKey: "key", Tag: "chat", Message: fmt.Sprint(i)}))
+	}
+
+	var messages []string
+	for i := 0; i < waiters; i++ {
+		messages = append(messages, <-got)
+	}
+	assert.ElementsMatch(t, want, messages, "each request should get its own message")
+}
+
+// A chatDeliveryStore is a chat queue for BenchmarkChatDelivery. report
+// adds metrics for the store, if it has any.
+type chatDeliveryStore struct {
+	name   string
+	new    func(b *testing.B) storage.ChatQueueStore
+	report func(b *testing.B)
+}
+
+// chatDeliveryStores are the stores BenchmarkChatDelivery runs with. Stores
+// that need a server are added by integration builds.
+var chatDeliveryStores = []chatDeliveryStore{
+	{name: "memory", new: func(b *testing.B) storage.ChatQueueStore { return memory.NewMemory().ChatQueue() }},
+	{name: "sqlite", new: func(b *testing.B) storage.ChatQueueStore {
+		dir, err := ioutil.TempDir("", "poundbot")
+		if err != nil {
+			b.Fatal(err)
+		}
+		s, err := sqlite.NewSQLite(filepath.Join(dir, "poundbot.db"))
+		if err != nil {
+			b.Fatal(err)
+		}
+		s.Init()
+		b.Cleanup(func() {
+			s.Close()
+			os.RemoveAll(dir)
+		})
+		return s.ChatQueue()
+	}},
+}
+
+// benchmarkChatDelivery delivers messages to one of many waiting servers at
+// a time, and reports the most store calls that were open at once
+func benchmarkChatDelivery(b *testing.B, cq storage.ChatQueueStore, counter *countingChatQueue, report func(*testing.B)) {
+	const servers = 200
+
+	received := make(chan struct{})
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < servers; i++ {
+		wg.Add(1)
+		go func(sk string) {
+			defer wg.Done()
+			for {
+				_, found := cq.GetGameServerMessage(sk, "chat", 0, time.Minute)
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if found {
+					received <- struct{}{}
+				}
+			}
+		}(fmt.Sprint(i))
+	}
+
+	// Let the servers start waiting
+	time.Sleep(50 * time.Millisecond)
+	counter.reset()
+	before := cpuTime()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		cq.InsertMessage(types.ChatMessage{ServerKey: fmt.Sprint(i % servers), Tag: "chat"})
+		<-received
+	}
+
+	b.StopTimer()
+	if cpu := cpuTime() - before; cpu > 0 {
+		b.ReportMetric(float64(cpu.Nanoseconds())/float64(b.N), "cpu-ns/op")
+	}
+	b.ReportMetric(float64(atomic.LoadInt64(&counter.maxRunning)), "open-store-calls")
+	if report != nil {
+		report(b)
+	}
+
+	close(done)
+	for i := 0; i < servers; i++ {
+		cq.InsertMessage(types.ChatMessage{ServerKey: fmt.Sprint(i), Tag: "chat"})
+	}
+	wg.Wait()
+}
+
+// BenchmarkChatDelivery compares game servers waiting on the store with
+// waiting on a ChatDispatcher
+func BenchmarkChatDelivery(b *testing.B) {
+	for _, store := range chatDeliveryStores {
+		store := store
+		b.Run(store.name+"/store", func(b *testing.B) {
+			cq := &countingChatQueue{ChatQueueStore: store.new(b)}
+			benchmarkChatDelivery(b, cq, cq, store.report)
+		})
+		b.Run(store.name+"/dispatcher", func(b *testing.B) {
+			cq := &countingChatQueue{ChatQueueStore: store.new(b)}
+			d := NewChatDispatcher(cq)
+			done := make(chan struct{})
+			defer close(done)
+			go d.Run(done)
+			benchmarkChatDelivery(b, d, cq, store.report)
+		})
+	}
+}
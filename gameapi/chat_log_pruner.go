@@ -0,0 +1,55 @@
+package gameapi
+
+import (
+	"time"
+)
+
+type chatLogRemover interface {
+	RemoveBefore(time.Time) (int, error)
+}
+
+// A ChatLogPruner removes chat log entries older than the retention period
+type ChatLogPruner struct {
+	clr       chatLogRemover
+	retention time.Duration
+	SleepTime time.Duration
+	done      <-chan struct{}
+}
+
+func newChatLogPruner(clr chatLogRemover, retention time.Duration, done <-chan struct{}) *ChatLogPruner {
+	return &ChatLogPruner{
+		clr:       clr,
+		retention: retention,
+		SleepTime: time.Hour,
+		done:      done,
+	}
+}
+
+// Run prunes the chat log every SleepTime until done. It prunes once at
+// startup so a long downtime doesn't leave old entries around.
+func (p *ChatLogPruner) Run() {
+	pLog := log.WithField("sys", "CHATLOG")
+	pLog.Info("Starting")
+	for {
+		p.prune()
+
+		select {
+		case <-p.done:
+			pLog.Warn("Shutting down")
+			return
+		case <-time.After(p.SleepTime):
+		}
+	}
+}
+
+func (p *ChatLogPruner) prune() {
+	pLog := log.WithField("sys", "CHATLOG")
+	removed, err := p.clr.RemoveBefore(iclock().Now().UTC().Add(-p.retention))
+	if err != nil {
+		pLog.WithError(err).Error("storage: Could not prune chat log")
+		return
+	}
+	if removed != 0 {
+		pLog.Infof("Removed %d chat log entries", removed)
+	}
+}
@@ -0,0 +1,33 @@
+package gameapi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/poundbot/poundbot/storage/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestChatLogPruner_Run(t *testing.T) {
+	t.Parallel()
+
+	done := make(chan struct{}, 1)
+	clr := mocks.ChatLogStore{}
+
+	var cutoff time.Time
+	clr.On("RemoveBefore", mock.AnythingOfType("time.Time")).
+		Return(func(before time.Time) int {
+			cutoff = before
+			done <- struct{}{}
+			return 2
+		}, nil).Once()
+
+	p := newChatLogPruner(&clr, 24*time.Hour, done)
+	start := iclock().Now()
+	p.Run()
+
+	clr.AssertExpectations(t)
+	if d := start.Sub(cutoff); d < 24*time.Hour || d > 25*time.Hour {
+		t.Errorf("cutoff was %s before start, want 24h", d)
+	}
+}
@@ -2,109 +2,329 @@ package gameapi
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/blang/semver"
 	"github.com/globalsign/mgo/bson"
 	"github.com/poundbot/poundbot/pbclock"
+	"github.com/poundbot/poundbot/storage"
 	"github.com/poundbot/poundbot/types"
 	"github.com/stretchr/testify/assert"
 )
 
 type chatQueueMock struct {
-	message bool
+	messages int // how many messages are queued
+	leases   []time.Duration
+	acked    []string
+	ackErr   error
 }
 
 type discordMessageHandler struct {
 	message *types.ChatMessage
+	sendErr error // sending to the discord handler fails
+	chanErr error // sending to the channel fails
 }
 
-func (dmh *discordMessageHandler) SendChatMessage(cm types.ChatMessage) {
+func (dmh *discordMessageHandler) SendChatMessage(cm types.ChatMessage, timeout time.Duration) error {
+	if dmh.sendErr != nil {
+		return dmh.sendErr
+	}
+	eChan := cm.ErrorResponse
+	go func() {
+		defer close(eChan)
+		if dmh.chanErr != nil {
+			eChan <- dmh.chanErr
+		}
+	}()
+	cm.ErrorResponse = nil
 	dmh.message = &cm
+	return nil
 }
 
-func (cqm chatQueueMock) GetGameServerMessage(sk, tag string, to time.Duration) (types.ChatMessage, bool) {
-	if !cqm.message {
+func (cqm *chatQueueMock) GetGameServerMessage(sk, tag string, lease, to time.Duration) (types.ChatMessage, bool) {
+	cqm.leases = append(cqm.leases, lease)
+	if cqm.messages == 0 {
 		return types.ChatMessage{}, false
 	}
+	cqm.messages--
 	cm := types.ChatMessage{
+		ID:          bson.ObjectIdHex(fmt.Sprintf("5cafadc080e1a9498fea8f0%d", len(cqm.leases))),
 		PlayerID:    "1234",
 		ClanTag:     "FoO",
 		DisplayName: "player",
 		Message:     "hello there!",
+		QueuedAt:    time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC),
 	}
 	return cm, true
 }
 
+func (cqm *chatQueueMock) AckMessage(sk, id string) error {
+	if cqm.ackErr != nil {
+		return cqm.ackErr
+	}
+	if id == "gone" {
+		return storage.ErrNotFound
+	}
+	cqm.acked = append(cqm.acked, id)
+	return nil
+}
+
+// chatContext is the request context for server bloop
+func chatContext() context.Context {
+	ctx := context.WithValue(context.Background(), contextKeyRequestUUID, "request-1")
+	ctx = context.WithValue(ctx, contextKeyServerKey, "bloop")
+	ctx = context.WithValue(ctx, contextKeyGame, "game")
+	return context.WithValue(ctx, contextKeyAccount, types.Account{
+		ID: bson.ObjectIdHex("5cafadc080e1a9498fea8f03"),
+		Servers: []types.AccountServer{
+			{
+				Key:  "bloop",
+				Name: "server-name",
+				Channels: []types.AccountServerChannel{
+					{ChannelID: "1234", Tags: []string{"chat"}},
+				},
+			},
+		},
+	})
+}
+
 func TestChat_Handle(t *testing.T) {
 	pbclock.Mock()
 	t.Parallel()
 
+	chatMessage := `"ClanTag":"FoO","DisplayName":"player","Message":"hello there!"`
+	queuedAt := `"QueuedAt":"2020-06-01T12:00:00Z"`
+
 	tests := []struct {
-		name     string
-		s        *chat
-		method   string             // http method
-		body     string             // response body
-		status   int                // response status
-		dMessage bool               // true if there is a discord message in queue
-		rBody    string             // request body
-		rMessage *types.ChatMessage // message from Rust
-		log      string
+		name      string
+		s         *chat
+		method    string             // http method
+		url       string             // request url, defaults to /chat
+		body      string             // response body
+		status    int                // response status
+		dMessages int                // how many discord messages are in the queue
+		rBody     string             // request body
+		rMessage  *types.ChatMessage // message from Rust
+		version   string             // connector version
+		leases    []time.Duration
+		log       string
 	}{
 		{
-			name:     "chat GET",
-			method:   http.MethodGet,
-			s:        &chat{},
-			status:   http.StatusOK,
-			dMessage: true,
-			body:     "{\"ClanTag\":\"FoO\",\"DisplayName\":\"player\",\"Message\":\"hello there!\"}",
+			name:      "chat GET",
+			method:    http.MethodGet,
+			s:         &chat{ackVersion: semver.Version{Major: 2, Minor: 1}},
+			status:    http.StatusOK,
+			dMessages: 2,
+			version:   "2.0.5",
+			leases:    []time.Duration{0},
+			body:      "{" + chatMessage + "}",
 		},
 		{
 			name:   "chat GET no message",
 			method: http.MethodGet,
 			s:      &chat{},
 			status: http.StatusNoContent,
+			leases: []time.Duration{0},
 			body:   "",
 		},
+		{
+			name:      "chat GET batch",
+			method:    http.MethodGet,
+			s:         &chat{ackVersion: semver.Version{Major: 2, Minor: 1}},
+			status:    http.StatusOK,
+			dMessages: 2,
+			version:   "2.1.0",
+			leases:    []time.Duration{chatLease, chatLease, chatLease},
+			body: `[{"ID":"5cafadc080e1a9498fea8f01",` + chatMessage + "," + queuedAt + `},` +
+				`{"ID":"5cafadc080e1a9498fea8f02",` + chatMessage + "," + queuedAt + "}]\n",
+		},
+		{
+			name:      "chat GET batch max",
+			method:    http.MethodGet,
+			url:       "/chat?max=1",
+			s:         &chat{ackVersion: semver.Version{Major: 2, Minor: 1}},
+			status:    http.StatusOK,
+			dMessages: 2,
+			version:   "2.1.0",
+			leases:    []time.Duration{chatLease},
+			body:      `[{"ID":"5cafadc080e1a9498fea8f01",` + chatMessage + "," + queuedAt + "}]\n",
+		},
+		{
+			name:    "chat GET batch invalid max",
+			method:  http.MethodGet,
+			url:     "/chat?max=500",
+			s:       &chat{ackVersion: semver.Version{Major: 2, Minor: 1}},
+			status:  http.StatusBadRequest,
+			version: "2.1.0",
+			body:    "{\"StatusCode\":400,\"Error\":\"Invalid max, must be 1 to 50\"}\n",
+		},
+		{
+			name:    "chat GET batch no message",
+			method:  http.MethodGet,
+			s:       &chat{ackVersion: semver.Version{Major: 2, Minor: 1}},
+			status:  http.StatusNoContent,
+			version: "2.1.0",
+			leases:  []time.Duration{chatLease},
+			body:    "",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			tt.s.cqs = &chatQueueMock{message: tt.dMessage}
+			cqm := &chatQueueMock{messages: tt.dMessages}
+			tt.s.cqs = cqm
 
-			req, err := http.NewRequest(tt.method, "/chat", strings.NewReader(tt.rBody))
+			url := tt.url
+			if len(url) == 0 {
+				url = "/chat"
+			}
+			req, err := http.NewRequest(tt.method, url, strings.NewReader(tt.rBody))
 			if err != nil {
 				t.Fatal(err)
 			}
+			req.Header.Set("X-PoundBotConnector-Version", tt.version)
 
 			rr := httptest.NewRecorder()
 
-			ctx := context.WithValue(context.Background(), contextKeyRequestUUID, "request-1")
-			ctx = context.WithValue(ctx, contextKeyServerKey, "bloop")
-			ctx = context.WithValue(ctx, contextKeyGame, "game")
-			ctx = context.WithValue(ctx, contextKeyAccount, types.Account{
-				ID: bson.ObjectIdHex("5cafadc080e1a9498fea8f03"),
-				Servers: []types.AccountServer{
-					{
-						Key:  "bloop",
-						Name: "server-name",
-						Channels: []types.AccountServerChannel{
-							{ChannelID: "1234", Tags: []string{"chat"}},
-						},
-					},
-				},
-			})
-
-			req = req.WithContext(ctx)
+			req = req.WithContext(chatContext())
 			handler := http.HandlerFunc(tt.s.handle)
 			handler.ServeHTTP(rr, req)
 
 			assert.Equal(t, tt.body, rr.Body.String(), "handler returned bad body")
 			assert.Equal(t, tt.status, rr.Code, "handler returned wrong status code")
+			assert.Equal(t, tt.leases, cqm.leases, "wrong leases")
 			// assert.Equal(t, tt.log, hook.LastEntry().Message, "log was incorrect")
 		})
 	}
 }
+
+func TestChat_ack(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		rBody  string
+		ackErr error
+		status int
+		acked  []string
+	}{
+		{
+			name:   "acked",
+			rBody:  `{"IDs":["one","gone","two"]}`,
+			status: http.StatusNoContent,
+			acked:  []string{"one", "two"},
+		},
+		{
+			name:   "no IDs",
+			rBody:  `{"IDs":[]}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "invalid body",
+			rBody:  `nope`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "storage error",
+			rBody:  `{"IDs":["one"]}`,
+			ackErr: errors.New("broken"),
+			status: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cqm := &chatQueueMock{ackErr: tt.ackErr}
+			c := chat{cqs: cqm}
+
+			req := httptest.NewRequest(http.MethodPost, "/chat/ack", strings.NewReader(tt.rBody))
+			rr := httptest.NewRecorder()
+			c.ack(rr, req.WithContext(chatContext()))
+
+			assert.Equal(t, tt.status, rr.Code)
+			assert.Equal(t, tt.acked, cqm.acked)
+		})
+	}
+}
+
+func TestChat_post(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		rBody   string
+		sendErr error
+		chanErr error
+		status  int
+		body    string
+		message *types.ChatMessage
+	}{
+		{
+			name:   "sent",
+			rBody:  `{"PlayerID":"1","DisplayName":"player","ClanTag":"FoO","Message":"hello"}`,
+			status: http.StatusOK,
+			body:   `{"ChannelBound":true,"Sent":true}` + "\n",
+			message: &types.ChatMessage{
+				ChannelID:   "1234",
+				ClanTag:     "FoO",
+				DisplayName: "player",
+				Message:     "hello",
+				PlayerID:    "game:1",
+				ServerKey:   "bloop",
+				Tag:         "chat",
+			},
+		},
+		{
+			name:   "no channel for tag",
+			rBody:  `{"DisplayName":"SERVER","Message":"hello","Tag":"serverchat"}`,
+			status: http.StatusOK,
+			body:   `{"ChannelBound":false,"Sent":false}` + "\n",
+		},
+		{
+			name:    "could not send to channel",
+			rBody:   `{"PlayerID":"1","Message":"hello"}`,
+			chanErr: errors.New("could not send to channel"),
+			status:  http.StatusOK,
+			body:    `{"ChannelBound":true,"Sent":false}` + "\n",
+			message: &types.ChatMessage{
+				ChannelID: "1234",
+				Message:   "hello",
+				PlayerID:  "game:1",
+				ServerKey: "bloop",
+				Tag:       "chat",
+			},
+		},
+		{
+			name:    "discord handler error",
+			rBody:   `{"Message":"hello"}`,
+			sendErr: errors.New("no response from discord handler"),
+			status:  http.StatusInternalServerError,
+			body:    `{"StatusCode":500,"Error":"internal error sending chat to discord handler"}` + "\n",
+		},
+		{
+			name:   "no message",
+			rBody:  `{"PlayerID":"1"}`,
+			status: http.StatusBadRequest,
+			body:   `{"StatusCode":400,"Error":"Invalid request. Message is required."}` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dmh := &discordMessageHandler{sendErr: tt.sendErr, chanErr: tt.chanErr}
+			c := chat{cs: dmh, timeout: time.Second}
+
+			req := httptest.NewRequest(http.MethodPost, "/chat", strings.NewReader(tt.rBody))
+			rr := httptest.NewRecorder()
+			c.handle(rr, req.WithContext(chatContext()))
+
+			assert.Equal(t, tt.status, rr.Code)
+			assert.Equal(t, tt.body, rr.Body.String())
+			assert.Equal(t, tt.message, dmh.message)
+		})
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !windows
+// +build !windows
+
+package gameapi
+
+import (
+	"syscall"
+	"time"
+)
+
+// cpuTime is the CPU time used by the process so far
+func cpuTime() time.Duration {
+	var ru syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &ru); err != nil {
+		return 0
+	}
+	return time.Duration(ru.Utime.Nano() + ru.Stime.Nano())
+}
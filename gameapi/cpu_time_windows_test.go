@@ -0,0 +1,8 @@
+package gameapi
+
+import "time"
+
+// cpuTime is not measured on Windows
+func cpuTime() time.Duration {
+	return 0
+}
@@ -2,18 +2,29 @@ package gameapi
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/poundbot/poundbot/storage"
 	"github.com/poundbot/poundbot/types"
 )
 
+// Statuses returned by the check endpoint, along with the
+// types.DiscordAuth statuses
+const (
+	discordAuthRegistered = "registered"
+	discordAuthNone       = "none"
+)
+
 type discordAuthenticator interface {
 	AuthDiscord(types.DiscordAuth)
 }
 
-type daAuthUpserter interface {
+type daAuthStore interface {
+	GetByPlayerID(string) (types.DiscordAuth, error)
 	Upsert(types.DiscordAuth) error
 }
 
@@ -22,7 +33,7 @@ type daUserGetter interface {
 }
 
 type discordAuth struct {
-	dau daAuthUpserter
+	dau daAuthStore
 	us  daUserGetter
 	da  discordAuthenticator
 }
@@ -31,7 +42,13 @@ type discordAuthRequest struct {
 	types.DiscordAuth
 }
 
-func initDiscordAuth(api *mux.Router, path string, dau daAuthUpserter, us daUserGetter, dah discordAuthenticator) {
+// discordAuthStatus is the check endpoint response
+type discordAuthStatus struct {
+	Status    string
+	ExpiresAt *time.Time `json:",omitempty"`
+}
+
+func initDiscordAuth(api *mux.Router, path string, dau daAuthStore, us daUserGetter, dah discordAuthenticator) {
 	da := discordAuth{dau: dau, us: us, da: dah}
 	api.HandleFunc(path, da.createDiscordAuth).Methods("PUT")
 	api.HandleFunc(fmt.Sprintf("%s/check/{player_id}", path), da.checkPlayer).Methods("GET")
@@ -78,7 +95,29 @@ func (da *discordAuth) createDiscordAuth(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	now := iclock().Now().UTC()
+	existing, err := da.dau.GetByPlayerID(dAuth.PlayerID)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		hLog.WithError(err).Error("storage: Could not get discord auth")
+		handleError(w, types.RESTError{
+			StatusCode: http.StatusInternalServerError,
+			Error:      "Could not check for an existing PIN request",
+		})
+		return
+	}
+	if err == nil && existing.Status(now) == types.DiscordAuthLocked {
+		// A new PIN would reset the attempts
+		handleError(w, types.RESTError{
+			StatusCode: http.StatusTooManyRequests,
+			Error: fmt.Sprintf("Too many incorrect PINs. Try again after %s.",
+				existing.ExpiresAt.Format("15:04 MST")),
+		})
+		return
+	}
+
 	dAuth.GuildSnowflake = sc.account.GuildSnowflake
+	dAuth.ExpiresAt = now.Add(types.DiscordAuthTTL)
+	dAuth.Attempts = 0
 
 	err = da.dau.Upsert(dAuth.DiscordAuth)
 	if err != nil {
@@ -106,11 +145,30 @@ func (da *discordAuth) checkPlayer(w http.ResponseWriter, r *http.Request) {
 	}
 
 	cpLog.Trace("Checking player")
-	_, err = da.us.GetByPlayerID(fmt.Sprintf("%s:%s", sc.game, params["player_id"]))
-	if err != nil {
-		cpLog.Trace("Player not found")
-		w.WriteHeader(http.StatusNotFound)
+	playerID := fmt.Sprintf("%s:%s", sc.game, params["player_id"])
+	_, err = da.us.GetByPlayerID(playerID)
+	if err == nil {
+		cpLog.Trace("Player found")
+		writeDiscordAuthStatus(w, http.StatusOK, discordAuthStatus{Status: discordAuthRegistered})
 		return
 	}
-	cpLog.Trace("Player found")
+
+	// Not registered, so report on any PIN request. This is still a 404 for
+	// plugins that only look at the status code.
+	status := discordAuthStatus{Status: discordAuthNone}
+	dAuth, err := da.dau.GetByPlayerID(playerID)
+	switch {
+	case err == nil:
+		status.Status = dAuth.Status(iclock().Now().UTC())
+		status.ExpiresAt = &dAuth.ExpiresAt
+	case !errors.Is(err, storage.ErrNotFound):
+		cpLog.WithError(err).Error("storage: Could not get discord auth")
+	}
+	cpLog.WithField("status", status.Status).Trace("Player not found")
+	writeDiscordAuthStatus(w, http.StatusNotFound, status)
+}
+
+func writeDiscordAuthStatus(w http.ResponseWriter, statusCode int, status discordAuthStatus) {
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(status)
 }
@@ -0,0 +1,158 @@
+package gameapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/gorilla/mux"
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/storage/mocks"
+	"github.com/poundbot/poundbot/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func discordAuthContext() context.Context {
+	ctx := context.WithValue(context.Background(), contextKeyServerKey, "bloop")
+	ctx = context.WithValue(ctx, contextKeyRequestUUID, "request-1")
+	ctx = context.WithValue(ctx, contextKeyGame, "game")
+	return context.WithValue(ctx, contextKeyAccount, types.Account{
+		ID:          bson.ObjectIdHex("5cafadc080e1a9498fea8f03"),
+		BaseAccount: types.BaseAccount{GuildSnowflake: "guild"},
+		Servers:     []types.AccountServer{{Key: "bloop", Name: "server1"}},
+	})
+}
+
+func TestDiscordAuth_checkPlayer(t *testing.T) {
+	t.Parallel()
+
+	expiresAt := time.Date(1960, 2, 3, 4, 5, 6, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		registered bool
+		auth       *types.DiscordAuth
+		status     int
+		rBody      string
+	}{
+		{
+			name:       "registered",
+			registered: true,
+			status:     http.StatusOK,
+			rBody:      `{"Status":"registered"}`,
+		},
+		{
+			name:   "no request",
+			status: http.StatusNotFound,
+			rBody:  `{"Status":"none"}`,
+		},
+		{
+			name:   "expired",
+			auth:   &types.DiscordAuth{PlayerID: "game:1", ExpiresAt: expiresAt},
+			status: http.StatusNotFound,
+			rBody:  `{"Status":"expired","ExpiresAt":"1960-02-03T04:05:06Z"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			us := mocks.UsersStore{}
+			das := mocks.DiscordAuthsStore{}
+			if tt.registered {
+				us.On("GetByPlayerID", "game:1").Return(types.User{}, nil)
+			} else {
+				us.On("GetByPlayerID", "game:1").Return(types.User{}, storage.ErrNotFound)
+			}
+			if tt.auth != nil {
+				das.On("GetByPlayerID", "game:1").Return(*tt.auth, nil)
+			} else {
+				das.On("GetByPlayerID", "game:1").Return(types.DiscordAuth{}, storage.ErrNotFound)
+			}
+
+			req, err := http.NewRequest(http.MethodGet, "/discord_auth/check/1", http.NoBody)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req = mux.SetURLVars(req.WithContext(discordAuthContext()), map[string]string{"player_id": "1"})
+
+			rr := httptest.NewRecorder()
+			da := discordAuth{dau: &das, us: &us}
+			http.HandlerFunc(da.checkPlayer).ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.status, rr.Code)
+			assert.JSONEq(t, tt.rBody, rr.Body.String())
+		})
+	}
+}
+
+type discordAuthRecorder struct {
+	auth *types.DiscordAuth
+}
+
+func (d *discordAuthRecorder) AuthDiscord(da types.DiscordAuth) {
+	d.auth = &da
+}
+
+func TestDiscordAuth_createDiscordAuth(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		existing *types.DiscordAuth
+		status   int
+		created  bool
+	}{
+		{name: "new", status: http.StatusOK, created: true},
+		{
+			name:     "locked",
+			existing: &types.DiscordAuth{ExpiresAt: time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC), Attempts: types.DiscordAuthMaxAttempts},
+			status:   http.StatusTooManyRequests,
+		},
+		{
+			name:     "locked and expired",
+			existing: &types.DiscordAuth{ExpiresAt: time.Date(1960, 1, 1, 0, 0, 0, 0, time.UTC), Attempts: types.DiscordAuthMaxAttempts},
+			status:   http.StatusOK,
+			created:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			us := mocks.UsersStore{}
+			das := mocks.DiscordAuthsStore{}
+			us.On("GetByPlayerID", "game:1").Return(types.User{}, storage.ErrNotFound)
+			if tt.existing != nil {
+				das.On("GetByPlayerID", "game:1").Return(*tt.existing, nil)
+			} else {
+				das.On("GetByPlayerID", "game:1").Return(types.DiscordAuth{}, storage.ErrNotFound)
+			}
+			das.On("Upsert", mock.AnythingOfType("types.DiscordAuth")).Return(nil)
+
+			req, err := http.NewRequest(http.MethodPut, "/discord_auth",
+				strings.NewReader(`{"PlayerID": "1", "DiscordName": "user#0001", "Pin": 1234}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rr := httptest.NewRecorder()
+			dar := discordAuthRecorder{}
+			da := discordAuth{dau: &das, us: &us, da: &dar}
+			http.HandlerFunc(da.createDiscordAuth).ServeHTTP(rr, req.WithContext(discordAuthContext()))
+
+			assert.Equal(t, tt.status, rr.Code)
+			if !tt.created {
+				assert.Nil(t, dar.auth)
+				das.AssertNotCalled(t, "Upsert", mock.Anything)
+				return
+			}
+			if assert.NotNil(t, dar.auth) {
+				assert.Equal(t, "guild", dar.auth.GuildSnowflake)
+				assert.Equal(t, 0, dar.auth.Attempts)
+				assert.False(t, dar.auth.ExpiresAt.IsZero(), "expiry should be set")
+			}
+		})
+	}
+}
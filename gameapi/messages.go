@@ -2,29 +2,51 @@ package gameapi
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/poundbot/poundbot/storage"
 	"github.com/poundbot/poundbot/types"
+	"github.com/sirupsen/logrus"
 )
 
+// channelTagPrefix addresses a channel by message tag instead of by name
+const channelTagPrefix = "tag:"
+
 type discordMessageSender interface {
 	SendGameMessage(types.GameMessage, time.Duration) error
 	ServerChannels(types.ServerChannelsRequest)
 }
 
+type gameMessageStore interface {
+	Add(types.SentGameMessage) error
+	Get(messageID string) (types.SentGameMessage, error)
+	Remove(messageID string) error
+}
+
+// sentMessage is the Discord message a game message was sent as. DMs have
+// no ChannelID.
+type sentMessage struct {
+	MessageID string
+	ChannelID string `json:",omitempty"`
+}
+
 // A Chat is for handling discord <-> rust chat
 type messages struct {
 	dms     discordMessageSender
+	gms     gameMessageStore
 	timeout time.Duration
 }
 
 // initMessages initializes a chat handler and returns it
-func initMessages(api *mux.Router, path string, dms discordMessageSender) {
+func initMessages(api *mux.Router, path string, dms discordMessageSender, gms gameMessageStore) {
 	m := messages{
 		dms:     dms,
+		gms:     gms,
 		timeout: 10 * time.Second,
 	}
 
@@ -33,6 +55,9 @@ func initMessages(api *mux.Router, path string, dms discordMessageSender) {
 
 	api.HandleFunc(fmt.Sprintf("%s/{channel}", path), m.channelHandler).
 		Methods(http.MethodPost)
+
+	api.HandleFunc(fmt.Sprintf("%s/{channel}/{message_id}", path), m.messageHandler).
+		Methods(http.MethodPatch, http.MethodDelete)
 }
 
 func (mh *messages) rootHandler(w http.ResponseWriter, r *http.Request) {
@@ -107,14 +132,146 @@ func (mh *messages) channelHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// tag:<tag> addresses the channel bound to a message tag, so it doesn't
+	// matter what the channel is called
+	if tag, ok := channelTag(channel); ok {
+		channelID, err := channelIDForTag(sc, tag)
+		if err != nil {
+			mhLog.WithError(err).Info("No channel for tag")
+			handleError(w, types.RESTError{
+				Error:      fmt.Sprintf("no channel for tag %s", tag),
+				StatusCode: http.StatusNotFound,
+			})
+			return
+		}
+		channel = channelID
+	}
+
 	message.Snowflake = sc.account.GuildSnowflake
 	message.ChannelName = channel
-	eChan := make(chan error)
-	message.ErrorResponse = eChan
+	message.Action = types.GameMessageActionSend
 
 	mhLog.Tracef("Incoming message %v", message)
 
-	// sending message
+	sent, ok := mh.send(w, message, mhLog)
+	if !ok {
+		return
+	}
+
+	err = mh.gms.Add(types.SentGameMessage{
+		MessageID: sent.MessageID,
+		ChannelID: sent.ChannelID,
+		ServerKey: sc.serverKey,
+		SentAt:    iclock().Now().UTC(),
+	})
+	if err != nil {
+		// The message was sent, so the game still gets its ID
+		mhLog.WithError(err).Error("storage: Could not add sent message")
+	}
+
+	if err := json.NewEncoder(w).Encode(sent); err != nil {
+		mhLog.WithError(err).Error("http response failed to write")
+	}
+}
+
+// messageHandler edits or deletes a message the server sent. The message
+// is found by its ID, and must be addressed by the channel it was sent to.
+func (mh *messages) messageHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	vars := mux.Vars(r)
+	channel := vars["channel"]
+	messageID := vars["message_id"]
+
+	sc, err := getServerContext(r.Context())
+	mhLog := logWithRequest(r.RequestURI, sc).WithField("mID", messageID)
+
+	if err != nil {
+		mhLog.WithError(err).Info("Can't find server")
+		handleError(w, types.RESTError{
+			Error:      "Error finding server identity",
+			StatusCode: http.StatusInternalServerError,
+		})
+		return
+	}
+
+	notFound := types.RESTError{
+		Error:      types.ErrMessageNotFound.Error(),
+		StatusCode: http.StatusNotFound,
+	}
+
+	sent, err := mh.gms.Get(messageID)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		mhLog.WithError(err).Error("storage: Could not get sent message")
+		handleError(w, types.RESTError{
+			Error:      "Error finding message",
+			StatusCode: http.StatusInternalServerError,
+		})
+		return
+	}
+	// Messages sent by other servers are not found, rather than forbidden,
+	// so servers can't find out about each other's messages
+	if err != nil || sent.ServerKey != sc.serverKey {
+		handleError(w, notFound)
+		return
+	}
+
+	inChannel, err := mh.inChannel(sc, channel, sent.ChannelID)
+	if err != nil {
+		mhLog.WithError(err).Error("Could not get channels")
+		handleError(w, types.RESTError{
+			Error:      "Could not get channels",
+			StatusCode: http.StatusInternalServerError,
+		})
+		return
+	}
+	if !inChannel {
+		handleError(w, notFound)
+		return
+	}
+
+	var message types.GameMessage
+	if r.Method == http.MethodDelete {
+		message.Action = types.GameMessageActionDelete
+	} else {
+		if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+			mhLog.WithError(err).Info("Invalid JSON")
+			handleError(w, types.RESTError{
+				Error:      "Invalid request",
+				StatusCode: http.StatusBadRequest,
+			})
+			return
+		}
+		message.Action = types.GameMessageActionEdit
+	}
+	message.Snowflake = sc.account.GuildSnowflake
+	message.ChannelName = sent.ChannelID
+	message.MessageID = sent.MessageID
+
+	_, ok := mh.send(w, message, mhLog)
+	if !ok {
+		return
+	}
+
+	if message.Action == types.GameMessageActionDelete {
+		if err := mh.gms.Remove(sent.MessageID); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			mhLog.WithError(err).Error("storage: Could not remove sent message")
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(sentMessage{MessageID: sent.MessageID, ChannelID: sent.ChannelID}); err != nil {
+		mhLog.WithError(err).Error("http response failed to write")
+	}
+}
+
+// send sends a game message to the discord handler and waits for the
+// result. If it fails, the error is written to w and ok is false.
+func (mh *messages) send(w http.ResponseWriter, message types.GameMessage, mhLog *logrus.Entry) (sentMessage, bool) {
+	rChan := make(chan types.GameMessageResponse)
+	message.Response = rChan
+
 	if err := mh.dms.SendGameMessage(message, mh.timeout); err != nil {
 		mhLog.Error("timed out sending message to channel")
 		if err := handleError(w, types.RESTError{
@@ -123,31 +280,41 @@ func (mh *messages) channelHandler(w http.ResponseWriter, r *http.Request) {
 		}); err != nil {
 			mhLog.WithError(err).Error("http response failed to write")
 		}
-		return
+		return sentMessage{}, false
 	}
 
 	select {
-	case err := <-eChan:
-		if err != nil {
-			var status int
-			switch err.Error() {
-			case "channel not found":
-				status = http.StatusNotFound
-			case "could not send to channel":
-				status = http.StatusForbidden
-			default:
-				status = http.StatusInternalServerError
-			}
-			mhLog.WithError(err).Error("error from discord handler")
-			if err := handleError(w, types.RESTError{
-				Error:      err.Error(),
-				StatusCode: status,
-			}); err != nil {
-				mhLog.WithError(err).Error("http response failed to write")
-			}
+	case response := <-rChan:
+		err := response.Error
+		mhLog.WithError(err).Trace("message chan returned")
+		if err == nil {
+			return sentMessage{MessageID: response.MessageID, ChannelID: response.ChannelID}, true
 		}
 
-		mhLog.WithError(err).Trace("message chan returned")
+		var status int
+		switch {
+		case err.Error() == "channel not found":
+			status = http.StatusNotFound
+		case err.Error() == "could not send to channel":
+			status = http.StatusForbidden
+		case errors.Is(err, types.ErrInvalidEmbed):
+			status = http.StatusBadRequest
+		case errors.Is(err, types.ErrMessageNotFound):
+			// Deleted in Discord, so it can't be edited or deleted again
+			status = http.StatusNotFound
+			if err := mh.gms.Remove(message.MessageID); err != nil && !errors.Is(err, storage.ErrNotFound) {
+				mhLog.WithError(err).Error("storage: Could not remove sent message")
+			}
+		default:
+			status = http.StatusInternalServerError
+		}
+		mhLog.WithError(err).Error("error from discord handler")
+		if err := handleError(w, types.RESTError{
+			Error:      err.Error(),
+			StatusCode: status,
+		}); err != nil {
+			mhLog.WithError(err).Error("http response failed to write")
+		}
 	case <-time.After(mh.timeout):
 		mhLog.Error("timed out receiving discord response")
 		if err := handleError(w, types.RESTError{
@@ -157,4 +324,57 @@ func (mh *messages) channelHandler(w http.ResponseWriter, r *http.Request) {
 			mhLog.WithError(err).Error("http response failed to write")
 		}
 	}
+	return sentMessage{}, false
+}
+
+// inChannel reports whether the channel in a request is the channel with
+// the ID. Like sending, the channel can be a name, an ID or tag:<tag>.
+func (mh *messages) inChannel(sc serverContext, channel, channelID string) (bool, error) {
+	if tag, ok := channelTag(channel); ok {
+		tagChannelID, err := channelIDForTag(sc, tag)
+		return err == nil && tagChannelID == channelID, nil
+	}
+	if channel == channelID {
+		return true, nil
+	}
+
+	rChan := make(chan types.ServerChannelsResponse)
+	mh.dms.ServerChannels(types.ServerChannelsRequest{GuildID: sc.account.GuildSnowflake, ResponseChan: rChan})
+
+	select {
+	case response := <-rChan:
+		if !response.OK {
+			return false, errors.New("could not get channels")
+		}
+		for _, c := range response.Channels {
+			if c.ID == channelID {
+				return c.Name == channel, nil
+			}
+		}
+		return false, nil
+	case <-time.After(mh.timeout):
+		return false, errors.New("timed out getting channels")
+	}
+}
+
+// channelTag returns the tag of a tag:<tag> channel. Tags are lowercase,
+// like when they are bound.
+func channelTag(channel string) (string, bool) {
+	if !strings.HasPrefix(channel, channelTagPrefix) {
+		return "", false
+	}
+	return strings.ToLower(strings.TrimPrefix(channel, channelTagPrefix)), true
+}
+
+// channelIDForTag finds the channel bound to the tag for the request's server
+func channelIDForTag(sc serverContext, tag string) (string, error) {
+	server, err := sc.account.ServerFromKey(sc.serverKey)
+	if err != nil {
+		return "", err
+	}
+	channelID, found := server.ChannelIDForTag(tag)
+	if !found {
+		return "", errors.New("tag not bound")
+	}
+	return channelID, nil
 }
@@ -0,0 +1,255 @@
+package gameapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/storage/memory"
+	"github.com/poundbot/poundbot/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type gameMessageSenderMock struct {
+	message  *types.GameMessage
+	err      error
+	channels []types.ServerChannel
+}
+
+func (m *gameMessageSenderMock) SendGameMessage(gm types.GameMessage, timeout time.Duration) error {
+	go func(rChan chan<- types.GameMessageResponse) {
+		defer close(rChan)
+		if m.err != nil {
+			rChan <- types.GameMessageResponse{Error: m.err}
+			return
+		}
+		messageID := gm.MessageID
+		if len(messageID) == 0 {
+			messageID = "5678"
+		}
+		rChan <- types.GameMessageResponse{ChannelID: gm.ChannelName, MessageID: messageID}
+	}(gm.Response)
+	gm.Response = nil
+	m.message = &gm
+	return nil
+}
+
+func (m *gameMessageSenderMock) ServerChannels(r types.ServerChannelsRequest) {
+	go func() {
+		defer close(r.ResponseChan)
+		r.ResponseChan <- types.ServerChannelsResponse{OK: true, Channels: m.channels}
+	}()
+}
+
+func TestMessages_channelHandler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		channel     string
+		status      int
+		channelName string
+		sendErr     error
+		want        string
+	}{
+		{
+			name:        "channel name",
+			channel:     "general",
+			status:      http.StatusOK,
+			channelName: "general",
+			want:        `{"MessageID":"5678","ChannelID":"general"}`,
+		},
+		{
+			name:        "tag",
+			channel:     "tag:chat",
+			status:      http.StatusOK,
+			channelName: "1234",
+			want:        `{"MessageID":"5678","ChannelID":"1234"}`,
+		},
+		{
+			name:        "uppercase tag",
+			channel:     "tag:Chat",
+			status:      http.StatusOK,
+			channelName: "1234",
+			want:        `{"MessageID":"5678","ChannelID":"1234"}`,
+		},
+		{name: "unbound tag", channel: "tag:admin", status: http.StatusNotFound},
+		{
+			name:        "invalid embed",
+			channel:     "general",
+			status:      http.StatusBadRequest,
+			channelName: "general",
+			sendErr:     fmt.Errorf("%w: title is too long", types.ErrInvalidEmbed),
+		},
+		{
+			name:        "not permitted",
+			channel:     "general",
+			status:      http.StatusForbidden,
+			channelName: "general",
+			sendErr:     errors.New("could not send to channel"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dms := &gameMessageSenderMock{err: tt.sendErr}
+			gms := memory.NewMemory().GameMessages()
+			mh := messages{dms: dms, gms: gms, timeout: time.Second}
+
+			req := httptest.NewRequest(http.MethodPost, "/messages/"+tt.channel,
+				strings.NewReader(`{"MessageParts":[{"Content":"hello"}]}`))
+			req = mux.SetURLVars(req.WithContext(chatContext()), map[string]string{"channel": tt.channel})
+			rr := httptest.NewRecorder()
+			mh.channelHandler(rr, req)
+
+			assert.Equal(t, tt.status, rr.Code)
+			if len(tt.channelName) == 0 {
+				assert.Nil(t, dms.message)
+				return
+			}
+			if assert.NotNil(t, dms.message) {
+				assert.Equal(t, tt.channelName, dms.message.ChannelName)
+				assert.Equal(t, types.GameMessageActionSend, dms.message.Action)
+			}
+
+			sent, err := gms.Get("5678")
+			if len(tt.want) == 0 {
+				assert.True(t, errors.Is(err, storage.ErrNotFound), "failed sends should not be kept")
+				return
+			}
+			assert.JSONEq(t, tt.want, rr.Body.String())
+			assert.Nil(t, err)
+			assert.Equal(t, "bloop", sent.ServerKey)
+			assert.Equal(t, tt.channelName, sent.ChannelID)
+		})
+	}
+}
+
+func TestMessages_messageHandler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		method    string
+		channel   string
+		messageID string
+		sendErr   error
+		status    int
+		action    types.GameMessageAction
+		want      string
+		kept      bool
+	}{
+		{
+			name:      "edit",
+			method:    http.MethodPatch,
+			messageID: "1",
+			status:    http.StatusOK,
+			action:    types.GameMessageActionEdit,
+			want:      `{"MessageID":"1","ChannelID":"1234"}`,
+			kept:      true,
+		},
+		{
+			name:      "delete",
+			method:    http.MethodDelete,
+			messageID: "1",
+			status:    http.StatusNoContent,
+			action:    types.GameMessageActionDelete,
+		},
+		{
+			name:      "edit by channel ID",
+			method:    http.MethodPatch,
+			channel:   "1234",
+			messageID: "1",
+			status:    http.StatusOK,
+			action:    types.GameMessageActionEdit,
+			want:      `{"MessageID":"1","ChannelID":"1234"}`,
+			kept:      true,
+		},
+		{
+			name:      "delete by channel name",
+			method:    http.MethodDelete,
+			channel:   "general",
+			messageID: "1",
+			status:    http.StatusNoContent,
+			action:    types.GameMessageActionDelete,
+		},
+		{
+			name:      "other channel name",
+			method:    http.MethodDelete,
+			channel:   "random",
+			messageID: "1",
+			status:    http.StatusNotFound,
+		},
+		{name: "other channel ID", method: http.MethodPatch, channel: "4321", messageID: "1", status: http.StatusNotFound},
+		{name: "other tag", method: http.MethodPatch, channel: "tag:admin", messageID: "1", status: http.StatusNotFound},
+		{name: "unknown message", method: http.MethodPatch, messageID: "3", status: http.StatusNotFound},
+		{name: "other server's message", method: http.MethodDelete, messageID: "2", status: http.StatusNotFound},
+		{
+			name:      "deleted in discord",
+			method:    http.MethodPatch,
+			messageID: "1",
+			sendErr:   types.ErrMessageNotFound,
+			status:    http.StatusNotFound,
+			action:    types.GameMessageActionEdit,
+		},
+		{
+			name:      "edit failed",
+			method:    http.MethodPatch,
+			messageID: "1",
+			sendErr:   errors.New("could not send to channel"),
+			status:    http.StatusForbidden,
+			action:    types.GameMessageActionEdit,
+			kept:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			channel := tt.channel
+			if len(channel) == 0 {
+				channel = "tag:chat"
+			}
+			dms := &gameMessageSenderMock{err: tt.sendErr, channels: []types.ServerChannel{
+				{ID: "1234", Name: "general"},
+				{ID: "4321", Name: "random"},
+			}}
+			gms := memory.NewMemory().GameMessages()
+			gms.Add(types.SentGameMessage{MessageID: "1", ChannelID: "1234", ServerKey: "bloop"})
+			gms.Add(types.SentGameMessage{MessageID: "2", ChannelID: "1234", ServerKey: "other"})
+			mh := messages{dms: dms, gms: gms, timeout: time.Second}
+
+			req := httptest.NewRequest(tt.method, "/messages/"+channel+"/"+tt.messageID,
+				strings.NewReader(`{"MessageParts":[{"Content":"hello again"}]}`))
+			req = mux.SetURLVars(req.WithContext(chatContext()), map[string]string{
+				"channel":    channel,
+				"message_id": tt.messageID,
+			})
+			rr := httptest.NewRecorder()
+			mh.messageHandler(rr, req)
+
+			assert.Equal(t, tt.status, rr.Code)
+			if len(tt.want) != 0 {
+				assert.JSONEq(t, tt.want, rr.Body.String())
+			}
+			_, err := gms.Get("2")
+			assert.Nil(t, err, "other servers' messages should be kept")
+
+			if tt.action == types.GameMessageActionSend {
+				assert.Nil(t, dms.message)
+				return
+			}
+			if assert.NotNil(t, dms.message) {
+				assert.Equal(t, tt.action, dms.message.Action)
+				assert.Equal(t, tt.messageID, dms.message.MessageID)
+				assert.Equal(t, "1234", dms.message.ChannelName)
+			}
+
+			_, err = gms.Get(tt.messageID)
+			assert.Equal(t, tt.kept, err == nil)
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package gameapi
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/poundbot/poundbot/types"
+	"github.com/sirupsen/logrus"
+)
+
+// adminTag is the message tag for alerts to a guild's admins
+const adminTag = "admin"
+
+type offlineAlertAccountsGetter interface {
+	WithOfflineAlerts(*[]types.Account) error
+}
+
+type offlineAlertSender interface {
+	SendGameMessage(types.GameMessage, time.Duration) error
+	SendPlayerDM(types.PlayerDM, time.Duration) error
+}
+
+// An OfflineWatchdog alerts a guild's admins when one of its servers stops
+// checking in for longer than the server's offline alert, and again when it
+// recovers. Servers check in with every API request.
+//
+// Alerts go to the channel bound to the admin tag, or are sent to the
+// admins as DMs if there isn't one or it can't be sent to. Which servers
+// are offline is only kept in memory, so servers still offline after a
+// restart are alerted again.
+type OfflineWatchdog struct {
+	ag        offlineAlertAccountsGetter
+	as        offlineAlertSender
+	offline   map[string]time.Time // Server keys and when they were last seen
+	timeout   time.Duration
+	SleepTime time.Duration
+	done      <-chan struct{}
+}
+
+func newOfflineWatchdog(ag offlineAlertAccountsGetter, as offlineAlertSender, done <-chan struct{}) *OfflineWatchdog {
+	return &OfflineWatchdog{
+		ag:        ag,
+		as:        as,
+		offline:   map[string]time.Time{},
+		timeout:   10 * time.Second,
+		SleepTime: time.Minute,
+		done:      done,
+	}
+}
+
+// Run checks the servers every SleepTime until done
+func (w *OfflineWatchdog) Run() {
+	wLog := log.WithField("sys", "WATCHDOG")
+	wLog.Info("Starting")
+	for {
+		select {
+		case <-w.done:
+			wLog.Warn("Shutting down")
+			return
+		case <-time.After(w.SleepTime):
+			w.check()
+		}
+	}
+}
+
+func (w *OfflineWatchdog) check() {
+	wLog := log.WithField("sys", "WATCHDOG")
+
+	var accounts []types.Account
+	if err := w.ag.WithOfflineAlerts(&accounts); err != nil {
+		wLog.WithError(err).Error("storage: Could not get accounts")
+		return
+	}
+
+	now := iclock().Now().UTC()
+	watched := map[string]bool{}
+	for _, account := range accounts {
+		if account.Disabled {
+			continue
+		}
+		for _, server := range account.Servers {
+			after, on := server.OfflineAlertAfter()
+			// Servers that have never checked in aren't set up yet
+			if !on || server.UpdatedAt.IsZero() {
+				continue
+			}
+			watched[server.Key] = true
+
+			lastSeen, wasOffline := w.offline[server.Key]
+			isOffline := now.Sub(server.UpdatedAt) > after
+			switch {
+			case isOffline && !wasOffline:
+				w.offline[server.Key] = server.UpdatedAt
+				w.alert(account, server, offlineAlertParts(server.Name, server.UpdatedAt, now))
+			case !isOffline && wasOffline:
+				delete(w.offline, server.Key)
+				w.alert(account, server, recoveredAlertParts(server.Name, lastSeen, now))
+			}
+		}
+	}
+
+	// Forget servers that were removed or had their alerts turned off
+	for key := range w.offline {
+		if !watched[key] {
+			delete(w.offline, key)
+		}
+	}
+}
+
+// alert sends an alert to the admin channel, or to each admin
+func (w *OfflineWatchdog) alert(account types.Account, server types.AccountServer, parts []types.GameMessagePart) {
+	aLog := log.WithFields(logrus.Fields{"sys": "WATCHDOG", "gID": account.GuildSnowflake, "sKey": server.Key})
+
+	if channelID, found := server.ChannelIDForTag(adminTag); found {
+		rChan := make(chan types.GameMessageResponse)
+		err := w.as.SendGameMessage(types.GameMessage{
+			Snowflake:    account.GuildSnowflake,
+			ChannelName:  channelID,
+			MessageParts: parts,
+			Response:     rChan,
+		}, w.timeout)
+		if err == nil {
+			err = w.response(rChan)
+		}
+		if err == nil {
+			return
+		}
+		aLog.WithError(err).Error("could not send offline alert to admin channel, sending to admins")
+	}
+
+	sent := map[string]bool{}
+	for _, adminID := range account.GetAdminIDs() {
+		if len(adminID) == 0 || sent[adminID] {
+			continue
+		}
+		sent[adminID] = true
+
+		rChan := make(chan types.GameMessageResponse)
+		err := w.as.SendPlayerDM(types.PlayerDM{
+			Snowflake: adminID,
+			Message: types.GameMessage{
+				Snowflake:    account.GuildSnowflake,
+				MessageParts: parts,
+				Response:     rChan,
+			},
+		}, w.timeout)
+		if err == nil {
+			err = w.response(rChan)
+		}
+		if err != nil {
+			aLog.WithError(err).WithField("uID", adminID).Error("could not send offline alert to admin")
+		}
+	}
+}
+
+func (w *OfflineWatchdog) response(rChan <-chan types.GameMessageResponse) error {
+	select {
+	case response := <-rChan:
+		return response.Error
+	case <-time.After(w.timeout):
+		return errors.New("timed out")
+	}
+}
+
+func offlineAlertParts(serverName string, lastSeen, now time.Time) []types.GameMessagePart {
+	return []types.GameMessagePart{
+		{Content: "⚠️ **"},
+		{Content: serverName, Escape: true},
+		{Content: fmt.Sprintf("** hasn't checked in for %s. It was last seen %s.",
+			formatDuration(now.Sub(lastSeen)), lastSeen.Format("01-02 15:04 MST"))},
+	}
+}
+
+func recoveredAlertParts(serverName string, lastSeen, now time.Time) []types.GameMessagePart {
+	return []types.GameMessagePart{
+		{Content: "✅ **"},
+		{Content: serverName, Escape: true},
+		{Content: fmt.Sprintf("** is checking in again after %s offline.", formatDuration(now.Sub(lastSeen)))},
+	}
+}
@@ -0,0 +1,121 @@
+package gameapi
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/poundbot/poundbot/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// accountsMock returns its accounts for any query
+type accountsMock []types.Account
+
+func (m accountsMock) WithOfflineAlerts(accounts *[]types.Account) error {
+	*accounts = m
+	return nil
+}
+
+func (m accountsMock) WithScheduledWipes(after time.Time, accounts *[]types.Account) error {
+	*accounts = m
+	return nil
+}
+
+// offlineAlertsMock records where alerts were sent
+type offlineAlertsMock struct {
+	sent       []string
+	channelErr error
+}
+
+func (m *offlineAlertsMock) SendGameMessage(gm types.GameMessage, timeout time.Duration) error {
+	m.sent = append(m.sent, "channel "+gm.ChannelName+" "+gameMessageContent(gm.MessageParts))
+	go func(rChan chan<- types.GameMessageResponse) {
+		defer close(rChan)
+		rChan <- types.GameMessageResponse{Error: m.channelErr}
+	}(gm.Response)
+	return nil
+}
+
+func (m *offlineAlertsMock) SendPlayerDM(dm types.PlayerDM, timeout time.Duration) error {
+	m.sent = append(m.sent, "dm "+dm.Snowflake+" "+gameMessageContent(dm.Message.MessageParts))
+	go func(rChan chan<- types.GameMessageResponse) {
+		defer close(rChan)
+		rChan <- types.GameMessageResponse{}
+	}(dm.Message.Response)
+	return nil
+}
+
+func gameMessageContent(parts []types.GameMessagePart) string {
+	var s string
+	for _, part := range parts {
+		s += part.Content
+	}
+	return s
+}
+
+func TestOfflineWatchdog_check(t *testing.T) {
+	t.Parallel()
+
+	now := iclock().Now().UTC()
+	server := func(key string, lastSeen time.Duration, alert string) types.AccountServer {
+		s := types.AccountServer{Key: key, Name: "server " + key, OfflineAlert: alert}
+		s.UpdatedAt = now.Add(-lastSeen)
+		return s
+	}
+
+	dmAccount := types.Account{Servers: []types.AccountServer{
+		server("down", 20*time.Minute, "10m"),
+		server("up", time.Minute, "10m"),
+		server("off", 20*time.Minute, ""),
+	}}
+	dmAccount.OwnerSnowflake = "owner"
+	dmAccount.AdminSnowflakes = []string{"admin", "owner"}
+
+	channelServer := server("channel", time.Hour, "30m")
+	channelServer.SetChannelIDForTag("1234", adminTag)
+	channelAccount := types.Account{Servers: []types.AccountServer{channelServer}}
+
+	disabledAccount := types.Account{Servers: []types.AccountServer{server("disabled", time.Hour, "10m")}, Disabled: true}
+
+	am := &offlineAlertsMock{}
+	w := newOfflineWatchdog(accountsMock{dmAccount, channelAccount, disabledAccount}, am, nil)
+	w.check()
+
+	lastSeen := func(d time.Duration) string { return now.Add(-d).Format("01-02 15:04 MST") }
+	assert.Equal(t, []string{
+		"dm admin ⚠️ **server down** hasn't checked in for 20m. It was last seen " + lastSeen(20*time.Minute) + ".",
+		"dm owner ⚠️ **server down** hasn't checked in for 20m. It was last seen " + lastSeen(20*time.Minute) + ".",
+		"channel 1234 ⚠️ **server channel** hasn't checked in for 1h 0m. It was last seen " + lastSeen(time.Hour) + ".",
+	}, am.sent)
+
+	am.sent = nil
+	w.check()
+	assert.Empty(t, am.sent, "servers should only be alerted once")
+
+	// The server checks in again
+	dmAccount.Servers[0].UpdatedAt = now
+	w.ag = accountsMock{dmAccount}
+	w.check()
+	assert.Equal(t, []string{
+		"dm admin ✅ **server down** is checking in again after 20m offline.",
+		"dm owner ✅ **server down** is checking in again after 20m offline.",
+	}, am.sent)
+	assert.Empty(t, w.offline, "servers no longer watched should be forgotten")
+}
+
+func TestOfflineWatchdog_alertChannelFailure(t *testing.T) {
+	t.Parallel()
+
+	server := types.AccountServer{Key: "channel", Name: "server", OfflineAlert: "10m"}
+	server.SetChannelIDForTag("1234", adminTag)
+	account := types.Account{Servers: []types.AccountServer{server}}
+	account.AdminSnowflakes = []string{"admin"}
+
+	am := &offlineAlertsMock{channelErr: errors.New("could not send to channel")}
+	w := newOfflineWatchdog(nil, am, nil)
+	w.alert(account, server, []types.GameMessagePart{{Content: "alert"}})
+
+	assert.Equal(t, []string{"channel 1234 alert", "dm admin alert"}, am.sent,
+		"admins should get the alert if the admin channel can't be sent to")
+}
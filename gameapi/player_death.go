@@ -0,0 +1,127 @@
+package gameapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/poundbot/poundbot/types"
+)
+
+// killFeedTag is the message tag for the kill feed
+const killFeedTag = "killfeed"
+
+type playerDeath struct {
+	gms     gameMessageSender
+	timeout time.Duration
+}
+
+func initPlayerDeath(api *mux.Router, path string, gms gameMessageSender) {
+	pd := playerDeath{gms: gms, timeout: 10 * time.Second}
+	api.HandleFunc(path, pd.handle).Methods(http.MethodPost)
+}
+
+// handle posts player deaths to the channel bound to the killfeed tag, if
+// they pass the server's kill feed filter
+func (pd playerDeath) handle(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	sc, err := getServerContext(r.Context())
+	pdLog := logWithRequest(r.RequestURI, sc)
+
+	if err != nil {
+		pdLog.WithError(err).Info("Can't find server")
+		handleError(w, types.RESTError{
+			Error:      "Error finding server identity",
+			StatusCode: http.StatusInternalServerError,
+		})
+		return
+	}
+
+	var death types.PlayerDeath
+	if err := json.NewDecoder(r.Body).Decode(&death); err != nil {
+		pdLog.WithError(err).Info("Invalid JSON")
+		handleError(w, types.RESTError{
+			Error:      "Invalid request",
+			StatusCode: http.StatusBadRequest,
+		})
+		return
+	}
+	if len(death.Victim.PlayerID) == 0 || death.Distance < 0 {
+		handleError(w, types.RESTError{
+			Error:      "Victim.PlayerID is required and Distance can't be negative",
+			StatusCode: http.StatusBadRequest,
+		})
+		return
+	}
+
+	death.Victim.PlayerID = fmt.Sprintf("%s:%s", sc.game, death.Victim.PlayerID)
+	if death.Killer != nil && len(death.Killer.PlayerID) != 0 {
+		death.Killer.PlayerID = fmt.Sprintf("%s:%s", sc.game, death.Killer.PlayerID)
+	}
+
+	channelID, found := sc.server.ChannelIDForTag(killFeedTag)
+	if found && sc.server.KillFeed.Allows(death, linkedPlayers(sc.account)) {
+		pdLog = pdLog.WithField("pID", death.Victim.PlayerID)
+		go announce(pd.gms, sc.account.GuildSnowflake, channelID, playerDeathParts(death), pd.timeout, pdLog)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// linkedPlayers reports whether players are linked to members of the
+// account's guild
+func linkedPlayers(account types.Account) func(playerID string) bool {
+	linked := make(map[string]bool, len(account.RegisteredPlayerIDs))
+	for _, id := range account.RegisteredPlayerIDs {
+		linked[id] = true
+	}
+	return func(playerID string) bool { return linked[playerID] }
+}
+
+// playerDeathParts is the kill feed entry for a player death
+func playerDeathParts(death types.PlayerDeath) []types.GameMessagePart {
+	player := func(p types.PlayerDeathPlayer) []types.GameMessagePart {
+		name := p.DisplayName
+		if len(name) == 0 {
+			name = p.PlayerID
+		}
+		var parts []types.GameMessagePart
+		if len(p.ClanTag) != 0 {
+			parts = append(parts, types.GameMessagePart{Content: "["}, types.GameMessagePart{Content: p.ClanTag, Escape: true},
+				types.GameMessagePart{Content: "] "})
+		}
+		return append(parts,
+			types.GameMessagePart{Content: "**"},
+			types.GameMessagePart{Content: name, Escape: true},
+			types.GameMessagePart{Content: "**"},
+		)
+	}
+
+	parts := []types.GameMessagePart{{Content: "💀 "}}
+	if death.IsPvP() {
+		parts = append(parts, player(*death.Killer)...)
+		parts = append(parts, types.GameMessagePart{Content: " killed "})
+		parts = append(parts, player(death.Victim)...)
+		if len(death.Weapon) != 0 {
+			parts = append(parts, types.GameMessagePart{Content: " with "}, types.GameMessagePart{Content: death.Weapon, Escape: true})
+		}
+		if death.Distance > 0 {
+			parts = append(parts, types.GameMessagePart{Content: fmt.Sprintf(" from %.0fm", death.Distance)})
+		}
+	} else {
+		parts = append(parts, player(death.Victim)...)
+		if len(death.Weapon) != 0 {
+			parts = append(parts, types.GameMessagePart{Content: " was killed by "},
+				types.GameMessagePart{Content: death.Weapon, Escape: true})
+		} else {
+			parts = append(parts, types.GameMessagePart{Content: " died"})
+		}
+	}
+	if len(death.GridPos) != 0 {
+		parts = append(parts, types.GameMessagePart{Content: " at "}, types.GameMessagePart{Content: death.GridPos, Escape: true})
+	}
+	return parts
+}
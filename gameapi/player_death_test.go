@@ -0,0 +1,102 @@
+package gameapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/poundbot/poundbot/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlayerDeath_handle(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		body     string
+		killFeed bool
+		filter   types.KillFeedFilter
+		status   int
+		want     string
+	}{
+		{
+			name: "pvp",
+			body: `{"Victim":{"PlayerID":"1","DisplayName":"one","ClanTag":"A"},
+				"Killer":{"PlayerID":"2","DisplayName":"two"},"Weapon":"Bolt Action Rifle","Distance":152.4,"GridPos":"G12"}`,
+			killFeed: true,
+			status:   http.StatusNoContent,
+			want:     "💀 **two** killed [A] **one** with Bolt Action Rifle from 152m at G12",
+		},
+		{
+			name:     "npc",
+			body:     `{"Victim":{"PlayerID":"1","DisplayName":"one"},"Weapon":"Bear"}`,
+			killFeed: true,
+			status:   http.StatusNoContent,
+			want:     "💀 **one** was killed by Bear",
+		},
+		{
+			name:     "linked",
+			body:     `{"Victim":{"PlayerID":"1","DisplayName":"one"},"Killer":{"PlayerID":"1"}}`,
+			killFeed: true,
+			filter:   types.KillFeedFilter{LinkedOnly: true},
+			status:   http.StatusNoContent,
+			want:     "💀 **one** died",
+		},
+		{
+			name:     "filtered",
+			body:     `{"Victim":{"PlayerID":"1","DisplayName":"one"},"Weapon":"Bear"}`,
+			killFeed: true,
+			filter:   types.KillFeedFilter{PvPOnly: true},
+			status:   http.StatusNoContent,
+		},
+		{
+			name:   "no kill feed",
+			body:   `{"Victim":{"PlayerID":"1","DisplayName":"one"}}`,
+			status: http.StatusNoContent,
+		},
+		{name: "no victim", body: `{"Weapon":"Bear"}`, status: http.StatusBadRequest},
+		{name: "invalid", body: `{`, status: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := chatContext()
+			account := ctx.Value(contextKeyAccount).(types.Account)
+			account.GuildSnowflake = "guild"
+			account.RegisteredPlayerIDs = []string{"game:1"}
+			account.Servers[0].KillFeed = tt.filter
+			if tt.killFeed {
+				account.Servers[0].SetChannelIDForTag("5678", killFeedTag)
+			}
+			ctx = context.WithValue(ctx, contextKeyAccount, account)
+
+			gms := make(announcementsMock, 1)
+			pd := playerDeath{gms: gms, timeout: time.Second}
+
+			req := httptest.NewRequest(http.MethodPost, "/player_death", strings.NewReader(tt.body)).WithContext(ctx)
+			rr := httptest.NewRecorder()
+			pd.handle(rr, req)
+
+			assert.Equal(t, tt.status, rr.Code)
+			if len(tt.want) == 0 {
+				select {
+				case m := <-gms:
+					assert.Fail(t, "unexpected kill feed entry", "%v", m)
+				case <-time.After(10 * time.Millisecond):
+				}
+				return
+			}
+			select {
+			case m := <-gms:
+				assert.Equal(t, "guild", m.Snowflake)
+				assert.Equal(t, "5678", m.ChannelName)
+				assert.Equal(t, tt.want, gameMessageContent(m.MessageParts))
+			case <-time.After(time.Second):
+				assert.Fail(t, "no kill feed entry")
+			}
+		})
+	}
+}
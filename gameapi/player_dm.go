@@ -0,0 +1,178 @@
+package gameapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/types"
+)
+
+// Each Discord user can be sent playerDMLimit DMs per playerDMWindow
+const (
+	playerDMLimit  = 5
+	playerDMWindow = time.Minute
+)
+
+type playerDMSender interface {
+	SendPlayerDM(types.PlayerDM, time.Duration) error
+}
+
+type playerDMs struct {
+	us      playerUserFinder
+	pds     playerDMSender
+	limiter *dmLimiter
+	timeout time.Duration
+}
+
+// dmLimiter limits how many DMs are sent to each user in a sliding window
+type dmLimiter struct {
+	mu     sync.Mutex
+	limit  int
+	window time.Duration
+	sent   map[string][]time.Time
+}
+
+func newDMLimiter(limit int, window time.Duration) *dmLimiter {
+	return &dmLimiter{limit: limit, window: window, sent: map[string][]time.Time{}}
+}
+
+// allow records a DM to the user if they are under the limit. Otherwise it
+// returns how long until they can be sent another.
+func (l *dmLimiter) allow(snowflake string, now time.Time) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Forget DMs outside the window, for every user so the map doesn't grow
+	cutoff := now.Add(-l.window)
+	for key, times := range l.sent {
+		i := 0
+		for i < len(times) && !times[i].After(cutoff) {
+			i++
+		}
+		if i == len(times) {
+			delete(l.sent, key)
+			continue
+		}
+		l.sent[key] = times[i:]
+	}
+
+	times := l.sent[snowflake]
+	if len(times) >= l.limit {
+		return times[0].Add(l.window).Sub(now), false
+	}
+	l.sent[snowflake] = append(times, now)
+	return 0, true
+}
+
+// dmHandler sends a game message to a player's Discord user
+func (p *playerDMs) dmHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	sc, err := getServerContext(r.Context())
+
+	params := mux.Vars(r)
+	dmLog := logWithRequest(r.RequestURI, sc).WithField("pID", params["player_id"])
+
+	if err != nil {
+		dmLog.WithError(err).Info("Can't find server")
+		handleError(w, types.RESTError{
+			Error:      "Error finding server identity",
+			StatusCode: http.StatusInternalServerError,
+		})
+		return
+	}
+
+	user, err := p.us.GetByPlayerID(fmt.Sprintf("%s:%s", sc.game, params["player_id"]))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			handleError(w, types.RESTError{
+				Error:      "player is not linked to a Discord user",
+				StatusCode: http.StatusNotFound,
+			})
+			return
+		}
+		dmLog.WithError(err).Error("storage: Could not get user")
+		handleError(w, types.RESTError{
+			Error:      "Error finding player",
+			StatusCode: http.StatusInternalServerError,
+		})
+		return
+	}
+
+	var message types.GameMessage
+	if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+		dmLog.WithError(err).Info("Invalid JSON")
+		handleError(w, types.RESTError{
+			Error:      "Invalid request",
+			StatusCode: http.StatusBadRequest,
+		})
+		return
+	}
+
+	if wait, ok := p.limiter.allow(user.Snowflake, iclock().Now()); !ok {
+		dmLog.Info("DM rate limited")
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		handleError(w, types.RESTError{
+			Error:      types.ErrRateLimited.Error(),
+			StatusCode: http.StatusTooManyRequests,
+		})
+		return
+	}
+
+	rChan := make(chan types.GameMessageResponse)
+	message.Snowflake = sc.account.GuildSnowflake
+	message.Response = rChan
+
+	if err := p.pds.SendPlayerDM(types.PlayerDM{Snowflake: user.Snowflake, Message: message}, p.timeout); err != nil {
+		dmLog.WithError(err).Error("timed out sending DM")
+		handleError(w, types.RESTError{
+			Error:      "internal error sending message to discord handler",
+			StatusCode: http.StatusInternalServerError,
+		})
+		return
+	}
+
+	select {
+	case response := <-rChan:
+		if response.Error != nil {
+			dmLog.WithError(response.Error).Info("error from discord handler")
+			handleError(w, types.RESTError{
+				Error:      response.Error.Error(),
+				StatusCode: playerDMStatus(response.Error),
+			})
+			return
+		}
+		if err := json.NewEncoder(w).Encode(sentMessage{MessageID: response.MessageID}); err != nil {
+			dmLog.WithError(err).Error("http response failed to write")
+		}
+	case <-time.After(p.timeout):
+		dmLog.Error("timed out receiving discord response")
+		handleError(w, types.RESTError{
+			Error:      "internal error receiving discord response",
+			StatusCode: http.StatusInternalServerError,
+		})
+	}
+}
+
+// playerDMStatus is the HTTP status for an error sending a DM
+func playerDMStatus(err error) int {
+	switch {
+	case errors.Is(err, types.ErrNotGuildMember):
+		return http.StatusNotFound
+	case errors.Is(err, types.ErrDMsClosed):
+		return http.StatusForbidden
+	case errors.Is(err, types.ErrRateLimited):
+		return http.StatusTooManyRequests
+	case errors.Is(err, types.ErrInvalidEmbed):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
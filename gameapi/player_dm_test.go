@@ -0,0 +1,120 @@
+package gameapi
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/poundbot/poundbot/storage/memory"
+	"github.com/poundbot/poundbot/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type playerDMSenderMock struct {
+	dm  *types.PlayerDM
+	err error
+}
+
+func (m *playerDMSenderMock) SendPlayerDM(dm types.PlayerDM, timeout time.Duration) error {
+	go func(rChan chan<- types.GameMessageResponse) {
+		defer close(rChan)
+		rChan <- types.GameMessageResponse{MessageID: "5678", Error: m.err}
+	}(dm.Message.Response)
+	dm.Message.Response = nil
+	m.dm = &dm
+	return nil
+}
+
+type playerDMUser struct{}
+
+func (playerDMUser) GetPlayerID() string  { return "game:1" }
+func (playerDMUser) GetDiscordID() string { return "user-1" }
+
+func TestDMLimiter_allow(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	l := newDMLimiter(2, time.Minute)
+
+	_, ok := l.allow("one", now)
+	assert.True(t, ok)
+	_, ok = l.allow("one", now.Add(20*time.Second))
+	assert.True(t, ok)
+
+	wait, ok := l.allow("one", now.Add(30*time.Second))
+	assert.False(t, ok, "over the limit")
+	assert.Equal(t, 30*time.Second, wait)
+
+	_, ok = l.allow("two", now.Add(30*time.Second))
+	assert.True(t, ok, "users are limited separately")
+
+	_, ok = l.allow("one", now.Add(time.Minute))
+	assert.True(t, ok, "the first DM is outside the window")
+
+	_, ok = l.allow("two", now.Add(3*time.Minute))
+	assert.True(t, ok)
+	assert.Len(t, l.sent, 1, "users without recent DMs are forgotten")
+}
+
+func TestPlayerDMs_dmHandler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		playerID string
+		body     string
+		sendErr  error
+		limited  bool
+		status   int
+		want     string
+	}{
+		{name: "sent", playerID: "1", status: http.StatusOK, want: `{"MessageID":"5678"}`},
+		{name: "not linked", playerID: "2", status: http.StatusNotFound},
+		{name: "invalid JSON", playerID: "1", body: "{", status: http.StatusBadRequest},
+		{name: "left the guild", playerID: "1", sendErr: types.ErrNotGuildMember, status: http.StatusNotFound},
+		{name: "DMs closed", playerID: "1", sendErr: types.ErrDMsClosed, status: http.StatusForbidden},
+		{name: "discord rate limit", playerID: "1", sendErr: types.ErrRateLimited, status: http.StatusTooManyRequests},
+		{name: "rate limited", playerID: "1", limited: true, status: http.StatusTooManyRequests},
+		{name: "send failed", playerID: "1", sendErr: errors.New("could not send to user"), status: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			us := memory.NewMemory().Users()
+			us.UpsertPlayer(playerDMUser{})
+
+			pds := &playerDMSenderMock{err: tt.sendErr}
+			p := playerDMs{us: us, pds: pds, limiter: newDMLimiter(1, time.Hour), timeout: time.Second}
+			if tt.limited {
+				p.limiter.allow("user-1", iclock().Now())
+			}
+
+			body := tt.body
+			if len(body) == 0 {
+				body = `{"MessageParts":[{"Content":"hello"}]}`
+			}
+			req := httptest.NewRequest(http.MethodPost, "/players/"+tt.playerID+"/dm", strings.NewReader(body))
+			req = mux.SetURLVars(req.WithContext(chatContext()), map[string]string{"player_id": tt.playerID})
+			rr := httptest.NewRecorder()
+			p.dmHandler(rr, req)
+
+			assert.Equal(t, tt.status, rr.Code)
+			if tt.limited {
+				assert.Equal(t, "3600", rr.Header().Get("Retry-After"))
+				assert.Nil(t, pds.dm)
+				return
+			}
+			if len(tt.want) == 0 {
+				return
+			}
+			assert.JSONEq(t, tt.want, rr.Body.String())
+			if assert.NotNil(t, pds.dm) {
+				assert.Equal(t, "user-1", pds.dm.Snowflake)
+				assert.Equal(t, "hello", pds.dm.Message.MessageParts[0].Content)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package gameapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/poundbot/poundbot/types"
+	"github.com/sirupsen/logrus"
+)
+
+// joinsTag is the message tag for player join and leave announcements
+const joinsTag = "joins"
+
+type onlinePlayersStore interface {
+	Clear(serverKey string, before time.Time) error
+	Connect(types.OnlinePlayer) error
+	Disconnect(serverKey, playerID string, at time.Time) error
+}
+
+type gameMessageSender interface {
+	SendGameMessage(types.GameMessage, time.Duration) error
+}
+
+type playerEvents struct {
+	ops     onlinePlayersStore
+	gms     gameMessageSender
+	timeout time.Duration
+}
+
+func initPlayerEvents(api *mux.Router, path string, ops onlinePlayersStore, gms gameMessageSender) {
+	pe := playerEvents{ops: ops, gms: gms, timeout: 10 * time.Second}
+	api.HandleFunc(path, pe.handle).Methods(http.MethodPost)
+}
+
+// handle keeps the server's online roster from player connect and
+// disconnect events, and announces them to the channel bound to the joins
+// tag. Startup events clear the roster.
+func (pe playerEvents) handle(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	sc, err := getServerContext(r.Context())
+	peLog := logWithRequest(r.RequestURI, sc)
+
+	if err != nil {
+		peLog.WithError(err).Info("Can't find server")
+		handleError(w, types.RESTError{
+			Error:      "Error finding server identity",
+			StatusCode: http.StatusInternalServerError,
+		})
+		return
+	}
+
+	var event types.PlayerEvent
+	if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+		peLog.WithError(err).Info("Invalid JSON")
+		handleError(w, types.RESTError{
+			Error:      "Invalid request",
+			StatusCode: http.StatusBadRequest,
+		})
+		return
+	}
+	if len(event.PlayerID) == 0 && event.Type != types.PlayerEventStartup {
+		handleError(w, types.RESTError{
+			Error:      "PlayerID is required",
+			StatusCode: http.StatusBadRequest,
+		})
+		return
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = iclock().Now()
+	}
+	event.Timestamp = event.Timestamp.UTC()
+
+	peLog = peLog.WithFields(logrus.Fields{"pID": event.PlayerID, "event": event.Type})
+	playerID := fmt.Sprintf("%s:%s", sc.game, event.PlayerID)
+
+	switch event.Type {
+	case types.PlayerEventStartup:
+		if err := pe.ops.Clear(sc.serverKey, event.Timestamp); err != nil {
+			peLog.WithError(err).Error("storage: Could not clear online players")
+			handleError(w, types.RESTError{
+				Error:      "Error updating online players",
+				StatusCode: http.StatusInternalServerError,
+			})
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	case types.PlayerEventConnect:
+		err = pe.ops.Connect(types.OnlinePlayer{
+			ServerKey:   sc.serverKey,
+			PlayerID:    playerID,
+			DisplayName: event.DisplayName,
+			ConnectedAt: event.Timestamp,
+		})
+	case types.PlayerEventDisconnect:
+		err = pe.ops.Disconnect(sc.serverKey, playerID, event.Timestamp)
+	default:
+		handleError(w, types.RESTError{
+			Error:      fmt.Sprintf("unknown event type %q", event.Type),
+			StatusCode: http.StatusBadRequest,
+		})
+		return
+	}
+	if err != nil {
+		peLog.WithError(err).Error("storage: Could not update online players")
+		handleError(w, types.RESTError{
+			Error:      "Error updating online players",
+			StatusCode: http.StatusInternalServerError,
+		})
+		return
+	}
+
+	if channelID, found := sc.server.ChannelIDForTag(joinsTag); found {
+		go announce(pe.gms, sc.account.GuildSnowflake, channelID, playerEventParts(event), pe.timeout, peLog)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// announce sends a message to a channel in the background. The game doesn't
+// wait for it, so failures are only logged.
+func announce(gms gameMessageSender, guildID, channelID string, parts []types.GameMessagePart, timeout time.Duration,
+	l *logrus.Entry) {
+	rChan := make(chan types.GameMessageResponse)
+	err := gms.SendGameMessage(types.GameMessage{
+		Snowflake:    guildID,
+		ChannelName:  channelID,
+		MessageParts: parts,
+		Response:     rChan,
+	}, timeout)
+	if err != nil {
+		l.WithError(err).Error("could not send announcement")
+		return
+	}
+
+	select {
+	case response := <-rChan:
+		if response.Error != nil {
+			l.WithError(response.Error).Error("could not send announcement")
+		}
+	case <-time.After(timeout):
+		l.Error("timed out sending announcement")
+	}
+}
+
+// playerEventParts is the announcement for a player event
+func playerEventParts(event types.PlayerEvent) []types.GameMessagePart {
+	name := event.DisplayName
+	if len(name) == 0 {
+		name = event.PlayerID
+	}
+
+	action := "joined the server"
+	icon := "📥"
+	if event.Type == types.PlayerEventDisconnect {
+		action = "left the server"
+		icon = "📤"
+	}
+
+	return []types.GameMessagePart{
+		{Content: icon + " **"},
+		{Content: name, Escape: true},
+		{Content: "** " + action},
+	}
+}
@@ -0,0 +1,141 @@
+package gameapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/poundbot/poundbot/pbclock"
+	"github.com/poundbot/poundbot/storage/memory"
+	"github.com/poundbot/poundbot/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// announcementsMock sends game messages to a channel, since announcements
+// are sent in the background
+type announcementsMock chan types.GameMessage
+
+func (m announcementsMock) SendGameMessage(gm types.GameMessage, timeout time.Duration) error {
+	go func(rChan chan<- types.GameMessageResponse) {
+		defer close(rChan)
+		rChan <- types.GameMessageResponse{ChannelID: gm.ChannelName, MessageID: "5678"}
+	}(gm.Response)
+	gm.Response = nil
+	m <- gm
+	return nil
+}
+
+func TestPlayerEvents_handle(t *testing.T) {
+	pbclock.Mock()
+	t.Parallel()
+
+	connected := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		body     string
+		joins    bool
+		status   int
+		online   []types.OnlinePlayer
+		announce []types.GameMessagePart
+	}{
+		{
+			name:   "connect",
+			body:   `{"Type":"connect","PlayerID":"2","DisplayName":"two","Timestamp":"2020-06-01T12:00:00Z"}`,
+			status: http.StatusNoContent,
+			online: []types.OnlinePlayer{
+				{ServerKey: "bloop", PlayerID: "game:1", DisplayName: "one", ConnectedAt: connected.Add(-time.Hour)},
+				{ServerKey: "bloop", PlayerID: "game:2", DisplayName: "two", ConnectedAt: connected},
+			},
+		},
+		{
+			name:   "connect announced",
+			body:   `{"Type":"connect","PlayerID":"2","DisplayName":"*two*","Timestamp":"2020-06-01T12:00:00Z"}`,
+			joins:  true,
+			status: http.StatusNoContent,
+			online: []types.OnlinePlayer{
+				{ServerKey: "bloop", PlayerID: "game:1", DisplayName: "one", ConnectedAt: connected.Add(-time.Hour)},
+				{ServerKey: "bloop", PlayerID: "game:2", DisplayName: "*two*", ConnectedAt: connected},
+			},
+			announce: []types.GameMessagePart{
+				{Content: "📥 **"}, {Content: "*two*", Escape: true}, {Content: "** joined the server"},
+			},
+		},
+		{
+			name:   "disconnect announced",
+			body:   `{"Type":"disconnect","PlayerID":"1","DisplayName":"one","Timestamp":"2020-06-01T12:00:00Z"}`,
+			joins:  true,
+			status: http.StatusNoContent,
+			announce: []types.GameMessagePart{
+				{Content: "📤 **"}, {Content: "one", Escape: true}, {Content: "** left the server"},
+			},
+		},
+		{
+			name:   "startup",
+			body:   `{"Type":"startup","Timestamp":"2020-06-01T12:00:00Z"}`,
+			joins:  true,
+			status: http.StatusNoContent,
+		},
+		{
+			name:   "unknown type",
+			body:   `{"Type":"respawn","PlayerID":"1"}`,
+			status: http.StatusBadRequest,
+			online: []types.OnlinePlayer{
+				{ServerKey: "bloop", PlayerID: "game:1", DisplayName: "one", ConnectedAt: connected.Add(-time.Hour)},
+			},
+		},
+		{name: "no player", body: `{"Type":"connect"}`, status: http.StatusBadRequest},
+		{name: "invalid", body: `{`, status: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ops := memory.NewMemory().OnlinePlayers()
+			ops.Connect(types.OnlinePlayer{
+				ServerKey:   "bloop",
+				PlayerID:    "game:1",
+				DisplayName: "one",
+				ConnectedAt: connected.Add(-time.Hour),
+			})
+			gms := make(announcementsMock, 1)
+			pe := playerEvents{ops: ops, gms: gms, timeout: time.Second}
+
+			ctx := chatContext()
+			if tt.joins {
+				account := ctx.Value(contextKeyAccount).(types.Account)
+				account.GuildSnowflake = "guild"
+				account.Servers[0].SetChannelIDForTag("5678", joinsTag)
+				ctx = context.WithValue(ctx, contextKeyAccount, account)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/players/events", strings.NewReader(tt.body)).WithContext(ctx)
+			rr := httptest.NewRecorder()
+			pe.handle(rr, req)
+
+			assert.Equal(t, tt.status, rr.Code)
+			if tt.status == http.StatusNoContent {
+				online, _ := ops.Online("bloop")
+				assert.Equal(t, tt.online, online)
+			}
+
+			if tt.announce == nil {
+				select {
+				case m := <-gms:
+					assert.Fail(t, "unexpected announcement", "%v", m)
+				case <-time.After(10 * time.Millisecond):
+				}
+				return
+			}
+			select {
+			case m := <-gms:
+				assert.Equal(t, "guild", m.Snowflake)
+				assert.Equal(t, "5678", m.ChannelName)
+				assert.Equal(t, tt.announce, m.MessageParts)
+			case <-time.After(time.Second):
+				assert.Fail(t, "no announcement")
+			}
+		})
+	}
+}
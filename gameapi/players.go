@@ -2,20 +2,63 @@ package gameapi
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/poundbot/poundbot/storage"
 	"github.com/poundbot/poundbot/types"
 )
 
 type playerIDs []string
 
+type playerUserFinder interface {
+	GetByPlayerID(playerID string) (types.User, error)
+}
+
+type playerDiscordHandler interface {
+	playerDMSender
+	GuildMember(types.GuildMemberRequest, time.Duration) error
+}
+
 type registeredPlayers struct{}
 
-func initPlayers(api *mux.Router, path string) {
+type players struct {
+	us      playerUserFinder
+	dh      playerDiscordHandler
+	timeout time.Duration
+}
+
+// playerInfo is a player's Discord user. The guild fields are only set for
+// members of the server's guild.
+type playerInfo struct {
+	PlayerID      string
+	DiscordName   string
+	Snowflake     string
+	GuildMember   bool
+	Nick          string                  `json:",omitempty"`
+	Roles         []types.GuildMemberRole `json:",omitempty"`
+	JoinedAt      *time.Time              `json:",omitempty"`
+	Boosting      bool
+	BoostingSince *time.Time `json:",omitempty"`
+}
+
+func initPlayers(api *mux.Router, path string, us playerUserFinder, dh playerDiscordHandler) {
 	rp := registeredPlayers{}
 	api.HandleFunc(fmt.Sprintf("%s/registered", path), rp.handle).Methods(http.MethodGet)
+
+	p := players{us: us, dh: dh, timeout: 10 * time.Second}
+	api.HandleFunc(fmt.Sprintf("%s/{player_id}", path), p.playerHandler).Methods(http.MethodGet)
+
+	dms := playerDMs{
+		us:      us,
+		pds:     dh,
+		limiter: newDMLimiter(playerDMLimit, playerDMWindow),
+		timeout: 10 * time.Second,
+	}
+	api.HandleFunc(fmt.Sprintf("%s/{player_id}/dm", path), dms.dmHandler).Methods(http.MethodPost)
 }
 
 func (p *registeredPlayers) handle(w http.ResponseWriter, r *http.Request) {
@@ -44,3 +87,98 @@ func (p *registeredPlayers) handle(w http.ResponseWriter, r *http.Request) {
 		w.Write(b)
 	}
 }
+
+// playerHandler gets a player's Discord user and their membership of the
+// server's guild
+func (p *players) playerHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	sc, err := getServerContext(r.Context())
+
+	playerID := mux.Vars(r)["player_id"]
+	phLog := logWithRequest(r.RequestURI, sc).WithField("pID", playerID)
+
+	if err != nil {
+		phLog.WithError(err).Info("Can't find server")
+		handleError(w, types.RESTError{
+			Error:      "Error finding server identity",
+			StatusCode: http.StatusInternalServerError,
+		})
+		return
+	}
+
+	user, err := p.us.GetByPlayerID(fmt.Sprintf("%s:%s", sc.game, playerID))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			handleError(w, types.RESTError{
+				Error:      "player is not linked to a Discord user",
+				StatusCode: http.StatusNotFound,
+			})
+			return
+		}
+		phLog.WithError(err).Error("storage: Could not get user")
+		handleError(w, types.RESTError{
+			Error:      "Error finding player",
+			StatusCode: http.StatusInternalServerError,
+		})
+		return
+	}
+
+	rChan := make(chan types.GuildMemberResponse)
+	err = p.dh.GuildMember(types.GuildMemberRequest{
+		GuildID:      sc.account.GuildSnowflake,
+		Snowflake:    user.Snowflake,
+		ResponseChan: rChan,
+	}, p.timeout)
+	if err != nil {
+		phLog.WithError(err).Error("timed out requesting guild member")
+		handleError(w, types.RESTError{
+			Error:      "internal error sending request to discord handler",
+			StatusCode: http.StatusInternalServerError,
+		})
+		return
+	}
+
+	var response types.GuildMemberResponse
+	select {
+	case response = <-rChan:
+	case <-time.After(p.timeout):
+		response.Error = errors.New("timed out receiving discord response")
+	}
+	if response.Error != nil {
+		phLog.WithError(response.Error).Error("could not get guild member")
+		handleError(w, types.RESTError{
+			Error:      "internal error receiving discord response",
+			StatusCode: http.StatusInternalServerError,
+		})
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(newPlayerInfo(playerID, user, response.Member)); err != nil {
+		phLog.WithError(err).Error("http response failed to write")
+	}
+}
+
+func newPlayerInfo(playerID string, user types.User, member *types.GuildMember) playerInfo {
+	info := playerInfo{
+		PlayerID:    playerID,
+		DiscordName: user.DiscordName,
+		Snowflake:   user.Snowflake,
+	}
+	if member == nil {
+		return info
+	}
+
+	info.GuildMember = true
+	info.DiscordName = member.DiscordName
+	info.Nick = member.Nick
+	info.Roles = member.Roles
+	if !member.JoinedAt.IsZero() {
+		info.JoinedAt = &member.JoinedAt
+	}
+	if !member.BoostingSince.IsZero() {
+		info.Boosting = true
+		info.BoostingSince = &member.BoostingSince
+	}
+	return info
+}
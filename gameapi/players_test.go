@@ -0,0 +1,114 @@
+package gameapi
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type playerUsersMock map[string]types.User
+
+func (m playerUsersMock) GetByPlayerID(playerID string) (types.User, error) {
+	user, ok := m[playerID]
+	if !ok {
+		return types.User{}, storage.ErrNotFound
+	}
+	return user, nil
+}
+
+type guildMemberGetterMock struct {
+	playerDMSenderMock
+	request  *types.GuildMemberRequest
+	response types.GuildMemberResponse
+}
+
+func (m *guildMemberGetterMock) GuildMember(gmr types.GuildMemberRequest, timeout time.Duration) error {
+	go func(rChan chan<- types.GuildMemberResponse) {
+		defer close(rChan)
+		rChan <- m.response
+	}(gmr.ResponseChan)
+	gmr.ResponseChan = nil
+	m.request = &gmr
+	return nil
+}
+
+func TestPlayers_playerHandler(t *testing.T) {
+	t.Parallel()
+
+	joined := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	member := &types.GuildMember{
+		Snowflake:     "user-1",
+		DiscordName:   "player#0001",
+		Nick:          "nick",
+		Roles:         []types.GuildMemberRole{{ID: "10", Name: "VIP", Color: 0xff0000}},
+		JoinedAt:      joined,
+		BoostingSince: joined.Add(time.Hour),
+	}
+
+	tests := []struct {
+		name     string
+		playerID string
+		response types.GuildMemberResponse
+		status   int
+		want     string
+	}{
+		{
+			name:     "member",
+			playerID: "1",
+			response: types.GuildMemberResponse{Member: member},
+			status:   http.StatusOK,
+			want: `{"PlayerID":"1","DiscordName":"player#0001","Snowflake":"user-1","GuildMember":true,
+				"Nick":"nick","Roles":[{"ID":"10","Name":"VIP","Color":16711680}],
+				"JoinedAt":"2020-06-01T12:00:00Z","Boosting":true,"BoostingSince":"2020-06-01T13:00:00Z"}`,
+		},
+		{
+			name:     "not a member",
+			playerID: "1",
+			status:   http.StatusOK,
+			want: `{"PlayerID":"1","DiscordName":"player#1234","Snowflake":"user-1","GuildMember":false,
+				"Boosting":false}`,
+		},
+		{name: "not linked", playerID: "2", status: http.StatusNotFound},
+		{
+			name:     "discord error",
+			playerID: "1",
+			response: types.GuildMemberResponse{Error: errors.New("could not get guild member")},
+			status:   http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := types.User{}
+			user.PlayerIDs = []string{"game:1"}
+			user.DiscordName = "player#1234"
+			user.Snowflake = "user-1"
+
+			dh := &guildMemberGetterMock{response: tt.response}
+			p := players{us: playerUsersMock{"game:1": user}, dh: dh, timeout: time.Second}
+
+			req := httptest.NewRequest(http.MethodGet, "/players/"+tt.playerID, http.NoBody)
+			req = mux.SetURLVars(req.WithContext(chatContext()), map[string]string{"player_id": tt.playerID})
+			rr := httptest.NewRecorder()
+			p.playerHandler(rr, req)
+
+			assert.Equal(t, tt.status, rr.Code)
+			if len(tt.want) != 0 {
+				assert.JSONEq(t, tt.want, rr.Body.String())
+			}
+			if tt.status == http.StatusNotFound {
+				assert.Nil(t, dh.request)
+				return
+			}
+			if assert.NotNil(t, dh.request) {
+				assert.Equal(t, "user-1", dh.request.Snowflake)
+			}
+		})
+	}
+}
@@ -1,9 +1,10 @@
 package gameapi
 
 import (
+	"errors"
 	"time"
 
-	"github.com/globalsign/mgo"
+	"github.com/poundbot/poundbot/storage"
 	"github.com/poundbot/poundbot/types"
 )
 
@@ -19,6 +20,11 @@ type raidStore interface {
 	messageIDSetter
 }
 
+// A raidArchiver stores finished raids
+type raidArchiver interface {
+	Add(types.RaidHistory) error
+}
+
 type messageIDSetter interface {
 	SetMessageID(types.RaidAlert, string) error
 }
@@ -26,6 +32,7 @@ type messageIDSetter interface {
 // A RaidAlerter sends notifications on raids
 type RaidAlerter struct {
 	rs        raidStore
+	rha       raidArchiver
 	rn        raidNotifier
 	SleepTime time.Duration
 	done      <-chan struct{}
@@ -33,9 +40,10 @@ type RaidAlerter struct {
 }
 
 // NewRaidAlerter constructs a RaidAlerter
-func newRaidAlerter(ral raidStore, rn raidNotifier, done <-chan struct{}) *RaidAlerter {
+func newRaidAlerter(ral raidStore, rha raidArchiver, rn raidNotifier, done <-chan struct{}) *RaidAlerter {
 	return &RaidAlerter{
 		rs:        ral,
+		rha:       rha,
 		rn:        rn,
 		done:      done,
 		SleepTime: 1 * time.Second,
@@ -70,7 +78,7 @@ func (r *RaidAlerter) Run() {
 			return
 		case <-time.After(r.SleepTime):
 			alerts, err := r.rs.GetReady()
-			if err != nil && err != mgo.ErrNotFound {
+			if err != nil && !errors.Is(err, storage.ErrNotFound) {
 				raLog.WithError(err).Error("could not get raid alert")
 				continue
 			}
@@ -86,6 +94,16 @@ func (r *RaidAlerter) Run() {
 				}
 
 				if alert.ValidUntil.Before(time.Now()) {
+					raLog.Trace("archiving")
+					history := types.NewRaidHistory(alert)
+					if shouldNotify {
+						// The increment set the notify count to the item count
+						history.NotifyCount = history.ItemCount()
+					}
+					if err := r.rha.Add(history); err != nil {
+						raLog.WithError(err).Error("storage: Could not archive alert")
+					}
+
 					raLog.Trace("removing")
 					if err := r.rs.Remove(alert); err != nil {
 						raLog.Trace("coul not remove")
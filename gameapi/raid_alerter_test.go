@@ -46,6 +46,7 @@ func TestRaidAlerter_Run(t *testing.T) {
 			mockRH := &raidHandler{}
 
 			mockRA := mocks.RaidAlertsStore{}
+			mockRHS := mocks.RaidHistoryStore{}
 
 			mockRA.On("GetReady").
 				Return(func() []types.RaidAlert {
@@ -56,13 +57,15 @@ func TestRaidAlerter_Run(t *testing.T) {
 			if len(tt.raidAlerts) != 0 {
 				mockRA.On("IncrementNotifyCount", ra).Return(nil).Once()
 				mockRA.On("Remove", ra).Return(nil).Once()
+				mockRHS.On("Add", types.NewRaidHistory(ra)).Return(nil).Once()
 			}
 
-			raidAlerter := newRaidAlerter(&mockRA, mockRH, done)
+			raidAlerter := newRaidAlerter(&mockRA, &mockRHS, mockRH, done)
 			raidAlerter.SleepTime = 1 * time.Microsecond
 			raidAlerter.miu = miu
 			raidAlerter.Run()
 			mockRA.AssertExpectations(t)
+			mockRHS.AssertExpectations(t)
 			assert.EqualValues(t, tt.want, mockRH.RaidAlert, "They should be equal")
 		})
 	}
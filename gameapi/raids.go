@@ -0,0 +1,120 @@
+package gameapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/types"
+)
+
+const (
+	defaultRaidsLimit = 20
+	maxRaidsLimit     = 100
+)
+
+type raidFinder interface {
+	Find(storage.RaidHistoryQuery) ([]types.RaidHistory, error)
+}
+
+// serverRaid is a finished raid as sent to the game server
+type serverRaid struct {
+	PlayerID      string
+	GridPositions []string
+	Items         map[string]int
+	StartedAt     time.Time
+	EndedAt       time.Time
+	NotifyCount   int
+}
+
+func newServerRaid(game string, rh types.RaidHistory) serverRaid {
+	return serverRaid{
+		PlayerID:      strings.TrimPrefix(rh.PlayerID, game+":"),
+		GridPositions: rh.GridPositions,
+		Items:         rh.Items,
+		StartedAt:     rh.StartedAt,
+		EndedAt:       rh.EndedAt,
+		NotifyCount:   rh.NotifyCount,
+	}
+}
+
+type raids struct {
+	rf raidFinder
+}
+
+func initRaids(api *mux.Router, path string, rf raidFinder) {
+	rs := raids{rf: rf}
+	api.HandleFunc(path, rs.handle).Methods(http.MethodGet)
+}
+
+// handle returns the finished raids for the server, most recent first.
+// Results can be filtered with the player_id and since (RFC3339) query
+// parameters, and limited with limit.
+func (rs raids) handle(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	sc, err := getServerContext(r.Context())
+	rLog := logWithRequest(r.RequestURI, sc)
+
+	if err != nil {
+		rLog.WithError(err).Info("Can't find server")
+		handleError(w, types.RESTError{
+			Error:      "Error finding server identity",
+			StatusCode: http.StatusInternalServerError,
+		})
+		return
+	}
+
+	query := storage.RaidHistoryQuery{ServerKey: sc.serverKey, Limit: defaultRaidsLimit}
+	params := r.URL.Query()
+
+	if playerID := params.Get("player_id"); len(playerID) != 0 {
+		query.PlayerIDs = []string{fmt.Sprintf("%s:%s", sc.game, playerID)}
+	}
+
+	if since := params.Get("since"); len(since) != 0 {
+		query.Since, err = time.Parse(time.RFC3339, since)
+		if err != nil {
+			handleError(w, types.RESTError{
+				Error:      "Invalid since, must be RFC3339",
+				StatusCode: http.StatusBadRequest,
+			})
+			return
+		}
+	}
+
+	if limit := params.Get("limit"); len(limit) != 0 {
+		query.Limit, err = strconv.Atoi(limit)
+		if err != nil || query.Limit < 1 || query.Limit > maxRaidsLimit {
+			handleError(w, types.RESTError{
+				Error:      fmt.Sprintf("Invalid limit, must be 1 to %d", maxRaidsLimit),
+				StatusCode: http.StatusBadRequest,
+			})
+			return
+		}
+	}
+
+	history, err := rs.rf.Find(query)
+	if err != nil {
+		rLog.WithError(err).Error("storage: Could not find raids")
+		handleError(w, types.RESTError{
+			Error:      "Could not find raids",
+			StatusCode: http.StatusInternalServerError,
+		})
+		return
+	}
+
+	sRaids := make([]serverRaid, len(history))
+	for i := range history {
+		sRaids[i] = newServerRaid(sc.game, history[i])
+	}
+
+	if err := json.NewEncoder(w).Encode(sRaids); err != nil {
+		rLog.WithError(err).Error("Could not write raids")
+	}
+}
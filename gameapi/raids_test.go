@@ -0,0 +1,100 @@
+package gameapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/storage/mocks"
+	"github.com/poundbot/poundbot/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRaids_Handle(t *testing.T) {
+	t.Parallel()
+
+	endedAt := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+	raid := types.RaidHistory{
+		PlayerID:      "game:1",
+		ServerKey:     "bloop",
+		GridPositions: []string{"A1"},
+		Items:         map[string]int{"wall": 2},
+		StartedAt:     endedAt.Add(-time.Minute),
+		EndedAt:       endedAt,
+		NotifyCount:   2,
+	}
+
+	tests := []struct {
+		name   string
+		url    string
+		query  *storage.RaidHistoryQuery
+		status int
+		rBody  string
+	}{
+		{
+			name:   "defaults",
+			url:    "/raids",
+			query:  &storage.RaidHistoryQuery{ServerKey: "bloop", Limit: 20},
+			status: http.StatusOK,
+			rBody: `[{"PlayerID":"1","GridPositions":["A1"],"Items":{"wall":2},` +
+				`"StartedAt":"2001-02-03T04:04:06Z","EndedAt":"2001-02-03T04:05:06Z","NotifyCount":2}]` + "\n",
+		},
+		{
+			name: "filtered",
+			url:  "/raids?player_id=1&since=2001-02-03T00:00:00Z&limit=5",
+			query: &storage.RaidHistoryQuery{
+				ServerKey: "bloop",
+				PlayerIDs: []string{"game:1"},
+				Since:     time.Date(2001, 2, 3, 0, 0, 0, 0, time.UTC),
+				Limit:     5,
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:   "bad since",
+			url:    "/raids?since=yesterday",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "limit too large",
+			url:    "/raids?limit=101",
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, tt.url, http.NoBody)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rhs := mocks.RaidHistoryStore{}
+			if tt.query != nil {
+				rhs.On("Find", *tt.query).Return([]types.RaidHistory{raid}, nil).Once()
+			}
+
+			ctx := context.WithValue(context.Background(), contextKeyServerKey, "bloop")
+			ctx = context.WithValue(ctx, contextKeyRequestUUID, "request-1")
+			ctx = context.WithValue(ctx, contextKeyGame, "game")
+			ctx = context.WithValue(ctx, contextKeyAccount, types.Account{
+				ID:      bson.ObjectIdHex("5cafadc080e1a9498fea8f03"),
+				Servers: []types.AccountServer{{Key: "bloop", Name: "server1"}},
+			})
+
+			rr := httptest.NewRecorder()
+			rs := raids{rf: &rhs}
+			http.HandlerFunc(rs.handle).ServeHTTP(rr, req.WithContext(ctx))
+
+			assert.Equal(t, tt.status, rr.Code)
+			if tt.rBody != "" {
+				assert.Equal(t, tt.rBody, rr.Body.String())
+			}
+			rhs.AssertExpectations(t)
+		})
+	}
+}
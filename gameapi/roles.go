@@ -2,30 +2,150 @@ package gameapi
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/poundbot/poundbot/types"
+	"github.com/sirupsen/logrus"
 )
 
 type discordRoleSetter interface {
 	SetRole(types.RoleSet, time.Duration) error
+	RoleMembers(types.RoleMembersRequest, time.Duration) error
+	ChangeRoleMembers(types.RoleMemberChange, time.Duration) error
+}
+
+type rolePlayerIDsGetter interface {
+	GetPlayerIDsByDiscordIDs(snowflakes []string) ([]string, error)
 }
 
 type roles struct {
 	drs     discordRoleSetter
+	us      rolePlayerIDsGetter
+	results *roleSetResults
 	timeout time.Duration
 }
 
-func initRoles(api *mux.Router, path string, drs discordRoleSetter) {
-	r := roles{drs: drs, timeout: 10 * time.Second}
+// roleMap is a mapped Discord role and the linked players who hold it.
+// Roles deleted from Discord have no name or players.
+type roleMap struct {
+	Role      string
+	RoleID    string
+	Group     string
+	PlayerIDs []string
+}
 
+func initRoles(api *mux.Router, path string, us rolePlayerIDsGetter, drs discordRoleSetter) {
+	r := roles{drs: drs, us: us, results: newRoleSetResults(), timeout: 10 * time.Second}
+
+	api.HandleFunc(path, r.roleMapsHandler).
+		Methods(http.MethodGet)
 	api.HandleFunc(fmt.Sprintf("%s/{role_name}", path), r.roleHandler).
 		Methods(http.MethodPut)
+	api.HandleFunc(fmt.Sprintf("%s/{role_name}/status", path), r.roleStatusHandler).
+		Methods(http.MethodGet)
+	api.HandleFunc(fmt.Sprintf("%s/{role_name}/members", path), r.membersHandler).
+		Methods(http.MethodPost)
+	api.HandleFunc(fmt.Sprintf("%s/{role_name}/members/{player_id}", path), r.memberHandler).
+		Methods(http.MethodDelete)
+}
+
+// roleMapsHandler lists the server's mapped Discord roles with the game
+// player IDs of their linked members
+func (rs roles) roleMapsHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	sc, err := getServerContext(r.Context())
+	rmLog := logWithRequest(r.RequestURI, sc)
+
+	if err != nil {
+		rmLog.Info("Could not find server")
+		handleError(w, types.RESTError{
+			Error:      "Error finding server identity",
+			StatusCode: http.StatusInternalServerError,
+		})
+		return
+	}
+
+	maps := []roleMap{}
+	if len(sc.server.RoleMaps) == 0 {
+		if err := json.NewEncoder(w).Encode(maps); err != nil {
+			rmLog.WithError(err).Error("http response failed to write")
+		}
+		return
+	}
+
+	roleIDs := make([]string, len(sc.server.RoleMaps))
+	for i, rm := range sc.server.RoleMaps {
+		roleIDs[i] = rm.RoleID
+	}
+
+	rChan := make(chan types.RoleMembersResponse)
+	err = rs.drs.RoleMembers(types.RoleMembersRequest{
+		GuildID:      sc.account.GuildSnowflake,
+		RoleIDs:      roleIDs,
+		ResponseChan: rChan,
+	}, rs.timeout)
+	if err != nil {
+		rmLog.WithError(err).Error("timed out requesting role members")
+		handleError(w, types.RESTError{
+			Error:      "internal error sending request to discord handler",
+			StatusCode: http.StatusInternalServerError,
+		})
+		return
+	}
+
+	var response types.RoleMembersResponse
+	select {
+	case response = <-rChan:
+	case <-time.After(rs.timeout):
+		response.Error = errors.New("timed out receiving discord response")
+	}
+	if response.Error != nil {
+		rmLog.WithError(response.Error).Error("could not get role members")
+		handleError(w, types.RESTError{
+			Error:      "internal error receiving discord response",
+			StatusCode: http.StatusInternalServerError,
+		})
+		return
+	}
+
+	gamePrefix := sc.game + ":"
+	for i, rm := range sc.server.RoleMaps {
+		m := roleMap{RoleID: rm.RoleID, Group: rm.Group, PlayerIDs: []string{}}
+		if i < len(response.Roles) && response.Roles[i].Found {
+			m.Role = response.Roles[i].Name
+			if len(response.Roles[i].Snowflakes) != 0 {
+				playerIDs, err := rs.us.GetPlayerIDsByDiscordIDs(response.Roles[i].Snowflakes)
+				if err != nil {
+					rmLog.WithError(err).Error("storage: Could not get player IDs")
+					handleError(w, types.RESTError{
+						Error:      "Error finding players",
+						StatusCode: http.StatusInternalServerError,
+					})
+					return
+				}
+				for _, playerID := range playerIDs {
+					if strings.HasPrefix(playerID, gamePrefix) {
+						m.PlayerIDs = append(m.PlayerIDs, playerID[len(gamePrefix):])
+					}
+				}
+			}
+		}
+		maps = append(maps, m)
+	}
+
+	if err := json.NewEncoder(w).Encode(maps); err != nil {
+		rmLog.WithError(err).Error("http response failed to write")
+	}
 }
 
+// roleHandler sets a role's members to the players
 func (rs roles) roleHandler(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
@@ -63,16 +183,236 @@ func (rs roles) roleHandler(w http.ResponseWriter, r *http.Request) {
 	roleSet.GuildID = sc.account.GuildSnowflake
 	roleSet.SetGame(sc.game)
 
-	if err := rs.drs.SetRole(roleSet, rs.timeout); err != nil {
-		rhLog.Error("timed out sending message to channel")
+	rs.change(w, r, sc, role, rhLog, func(rChan chan<- types.RoleSetResult) error {
+		roleSet.ResultChan = rChan
+		return rs.drs.SetRole(roleSet, rs.timeout)
+	})
+}
+
+// membersHandler adds players to a role, leaving its other members alone
+func (rs roles) membersHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	role := mux.Vars(r)["role_name"]
+
+	sc, err := getServerContext(r.Context())
+	mhLog := logWithRequest(r.RequestURI, sc)
+
+	if err != nil {
+		mhLog.Info("Could not find server")
+		handleError(w, types.RESTError{
+			Error:      "Error finding server identity",
+			StatusCode: http.StatusInternalServerError,
+		})
+		return
+	}
+
+	var rmc types.RoleMemberChange
+	if err := json.NewDecoder(r.Body).Decode(&rmc); err != nil || len(rmc.PlayerIDs) == 0 {
+		mhLog.WithError(err).Info("Invalid request")
+		handleError(w, types.RESTError{
+			Error:      "Invalid request",
+			StatusCode: http.StatusBadRequest,
+		})
+		return
+	}
+
+	rmc.Role = role
+	rmc.GuildID = sc.account.GuildSnowflake
+	rmc.SetGame(sc.game)
+
+	rs.change(w, r, sc, role, mhLog, func(rChan chan<- types.RoleSetResult) error {
+		rmc.ResultChan = rChan
+		return rs.drs.ChangeRoleMembers(rmc, rs.timeout)
+	})
+}
+
+// memberHandler removes a player from a role
+func (rs roles) memberHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	vars := mux.Vars(r)
+	role := vars["role_name"]
+
+	sc, err := getServerContext(r.Context())
+	mhLog := logWithRequest(r.RequestURI, sc).WithField("pID", vars["player_id"])
+
+	if err != nil {
+		mhLog.Info("Could not find server")
+		handleError(w, types.RESTError{
+			Error:      "Error finding server identity",
+			StatusCode: http.StatusInternalServerError,
+		})
+		return
+	}
+
+	rmc := types.RoleMemberChange{
+		GuildID:   sc.account.GuildSnowflake,
+		Role:      role,
+		PlayerIDs: []string{vars["player_id"]},
+		Remove:    true,
+	}
+	rmc.SetGame(sc.game)
+
+	rs.change(w, r, sc, role, mhLog, func(rChan chan<- types.RoleSetResult) error {
+		rmc.ResultChan = rChan
+		return rs.drs.ChangeRoleMembers(rmc, rs.timeout)
+	})
+}
+
+// change sends a role change to discord with send and responds with its
+// status. With `?wait=true` it waits for the result, otherwise it returns
+// 202 and the result can be fetched from the role's status.
+func (rs roles) change(w http.ResponseWriter, r *http.Request, sc serverContext, role string, l *logrus.Entry,
+	send func(chan<- types.RoleSetResult) error) {
+	rChan := make(chan types.RoleSetResult)
+	key := roleSetKey{serverKey: sc.serverKey, role: role}
+	seq := rs.results.start(key, iclock().Now().UTC())
+
+	if err := send(rChan); err != nil {
+		l.Error("timed out sending message to channel")
+		rs.results.finish(key, seq, types.RoleSetResult{Role: role, Error: "could not send to discord"},
+			iclock().Now().UTC())
 		if err := handleError(w, types.RESTError{
 			Error:      "internal error sending message to discord handler",
 			StatusCode: http.StatusInternalServerError,
 		}); err != nil {
-			rhLog.WithError(err).Error("http response failed to write")
+			l.WithError(err).Error("http response failed to write")
+		}
+		return
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		result := types.RoleSetResult{Role: role, Error: "timed out waiting for discord"}
+		select {
+		case res, ok := <-rChan:
+			if ok {
+				result = res
+				result.RemoveGame(sc.game)
+			}
+		case <-time.After(roleSetResultTimeout):
+			l.Error("timed out waiting for role set result")
+		}
+		rs.results.finish(key, seq, result, iclock().Now().UTC())
+	}()
+
+	if r.URL.Query().Get("wait") == "true" {
+		select {
+		case <-done:
+		case <-time.After(rs.timeout):
 		}
+	}
+
+	status, _ := rs.results.get(key)
+	writeRoleSetStatus(w, status, l)
+}
+
+// roleStatusHandler gets the status of the last change to a role
+func (rs roles) roleStatusHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	role := mux.Vars(r)["role_name"]
+
+	sc, err := getServerContext(r.Context())
+	rsLog := logWithRequest(r.RequestURI, sc)
+
+	if err != nil {
+		rsLog.Info("Could not find server")
+		handleError(w, types.RESTError{
+			Error:      "Error finding server identity",
+			StatusCode: http.StatusInternalServerError,
+		})
+		return
+	}
+
+	status, ok := rs.results.get(roleSetKey{serverKey: sc.serverKey, role: role})
+	if !ok {
+		handleError(w, types.RESTError{
+			Error:      "role has not been set",
+			StatusCode: http.StatusNotFound,
+		})
+		return
+	}
+
+	writeRoleSetStatus(w, status, rsLog)
+}
+
+func writeRoleSetStatus(w http.ResponseWriter, status roleSetStatus, l *logrus.Entry) {
+	w.Header().Set("Content-Type", "application/json")
+	if status.Status == roleSetPending {
+		w.WriteHeader(http.StatusAccepted)
+	}
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		l.WithError(err).Error("http response failed to write")
+	}
+}
+
+const (
+	roleSetPending = "pending"
+	roleSetDone    = "done"
+
+	// roleSetResultTimeout is how long to wait for Discord to finish a role
+	// set. Large guilds can take a while because of rate limits.
+	roleSetResultTimeout = 5 * time.Minute
+)
+
+// roleSetStatus is the status of the last role set for a role. Result is
+// only set when it is done.
+type roleSetStatus struct {
+	Status      string
+	RequestedAt time.Time
+	CompletedAt *time.Time           `json:",omitempty"`
+	Result      *types.RoleSetResult `json:",omitempty"`
+	seq         uint64
+}
+
+type roleSetKey struct {
+	serverKey string
+	role      string
+}
+
+// roleSetResults keeps the status of the last role set for each server's
+// roles
+type roleSetResults struct {
+	mu       sync.Mutex
+	seq      uint64
+	statuses map[roleSetKey]roleSetStatus
+}
+
+func newRoleSetResults() *roleSetResults {
+	return &roleSetResults{statuses: map[roleSetKey]roleSetStatus{}}
+}
+
+// start marks a role set as pending, replacing any earlier status, and
+// returns the sequence number to finish it with
+func (r *roleSetResults) start(key roleSetKey, now time.Time) uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seq++
+	r.statuses[key] = roleSetStatus{Status: roleSetPending, RequestedAt: now, seq: r.seq}
+	return r.seq
+}
+
+// finish records a role set's result, unless a newer role set for the role
+// has started
+func (r *roleSetResults) finish(key roleSetKey, seq uint64, result types.RoleSetResult, now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	status, ok := r.statuses[key]
+	if !ok || status.seq != seq {
 		return
 	}
+	status.Status = roleSetDone
+	status.CompletedAt = &now
+	status.Result = &result
+	r.statuses[key] = status
+}
 
-	w.WriteHeader(http.StatusAccepted)
+func (r *roleSetResults) get(key roleSetKey) (roleSetStatus, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	status, ok := r.statuses[key]
+	return status, ok
 }
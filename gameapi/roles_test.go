@@ -0,0 +1,316 @@
+package gameapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/poundbot/poundbot/pbclock"
+	"github.com/poundbot/poundbot/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type roleUsersMock map[string][]string
+
+func (m roleUsersMock) GetPlayerIDsByDiscordIDs(snowflakes []string) ([]string, error) {
+	var playerIDs []string
+	for _, snowflake := range snowflakes {
+		playerIDs = append(playerIDs, m[snowflake]...)
+	}
+	return playerIDs, nil
+}
+
+type roleMembersMock struct {
+	request  *types.RoleMembersRequest
+	response types.RoleMembersResponse
+	roleSet  *types.RoleSet
+	change   *types.RoleMemberChange
+	result   *types.RoleSetResult // nil to never respond
+}
+
+func (m *roleMembersMock) SetRole(rs types.RoleSet, timeout time.Duration) error {
+	if m.result != nil {
+		go func(rChan chan<- types.RoleSetResult) {
+			defer close(rChan)
+			rChan <- *m.result
+		}(rs.ResultChan)
+	}
+	rs.ResultChan = nil
+	m.roleSet = &rs
+	return nil
+}
+
+func (m *roleMembersMock) ChangeRoleMembers(rmc types.RoleMemberChange, timeout time.Duration) error {
+	if m.result != nil {
+		go func(rChan chan<- types.RoleSetResult) {
+			defer close(rChan)
+			rChan <- *m.result
+		}(rmc.ResultChan)
+	}
+	rmc.ResultChan = nil
+	m.change = &rmc
+	return nil
+}
+
+func (m *roleMembersMock) RoleMembers(rmr types.RoleMembersRequest, timeout time.Duration) error {
+	go func(rChan chan<- types.RoleMembersResponse) {
+		defer close(rChan)
+		rChan <- m.response
+	}(rmr.ResponseChan)
+	rmr.ResponseChan = nil
+	m.request = &rmr
+	return nil
+}
+
+func TestRoles_roleMapsHandler(t *testing.T) {
+	t.Parallel()
+
+	us := roleUsersMock{
+		"user-1": {"game:1", "other:1"},
+		"user-2": {"game:2"},
+	}
+
+	tests := []struct {
+		name     string
+		roleMaps []types.RoleMap
+		response types.RoleMembersResponse
+		status   int
+		want     string
+	}{
+		{name: "no role maps", status: http.StatusOK, want: `[]`},
+		{
+			name: "role maps",
+			roleMaps: []types.RoleMap{
+				{RoleID: "10", Group: "vip"},
+				{RoleID: "11", Group: "mods"},
+				{RoleID: "12", Group: "gone"},
+			},
+			response: types.RoleMembersResponse{Roles: []types.RoleMembers{
+				{RoleID: "10", Name: "VIP", Found: true, Snowflakes: []string{"user-1", "user-2", "user-3"}},
+				{RoleID: "11", Name: "Moderator", Found: true},
+				{RoleID: "12"},
+			}},
+			status: http.StatusOK,
+			want: `[
+				{"Role":"VIP","RoleID":"10","Group":"vip","PlayerIDs":["1","2"]},
+				{"Role":"Moderator","RoleID":"11","Group":"mods","PlayerIDs":[]},
+				{"Role":"","RoleID":"12","Group":"gone","PlayerIDs":[]}
+			]`,
+		},
+		{
+			name:     "discord error",
+			roleMaps: []types.RoleMap{{RoleID: "10", Group: "vip"}},
+			response: types.RoleMembersResponse{Error: errors.New("server not found")},
+			status:   http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := chatContext()
+			account := ctx.Value(contextKeyAccount).(types.Account)
+			account.GuildSnowflake = "guild"
+			account.Servers[0].RoleMaps = tt.roleMaps
+			ctx = context.WithValue(ctx, contextKeyAccount, account)
+
+			drs := &roleMembersMock{response: tt.response}
+			rs := roles{drs: drs, us: us, timeout: time.Second}
+
+			req := httptest.NewRequest(http.MethodGet, "/roles", http.NoBody).WithContext(ctx)
+			rr := httptest.NewRecorder()
+			rs.roleMapsHandler(rr, req)
+
+			assert.Equal(t, tt.status, rr.Code)
+			if len(tt.want) != 0 {
+				assert.JSONEq(t, tt.want, rr.Body.String())
+			}
+			if len(tt.roleMaps) == 0 {
+				assert.Nil(t, drs.request, "discord should not be asked without role maps")
+				return
+			}
+			if assert.NotNil(t, drs.request) {
+				assert.Equal(t, "guild", drs.request.GuildID)
+				assert.Len(t, drs.request.RoleIDs, len(tt.roleMaps))
+			}
+		})
+	}
+}
+
+func TestRoles_roleHandler(t *testing.T) {
+	pbclock.Mock()
+	t.Parallel()
+
+	result := types.RoleSetResult{
+		Role:         "vip",
+		RoleID:       "10",
+		RoleFound:    true,
+		PermissionOK: true,
+		Added:        []types.RoleSetMember{{Snowflake: "user-1", DiscordName: "player#0001"}},
+		Removed:      []types.RoleSetMember{},
+		Failures: []types.RoleSetFailure{
+			{RoleSetMember: types.RoleSetMember{Snowflake: "user-2", DiscordName: "player#0002"}, Error: "could not add role"},
+		},
+	}
+	resultJSON := `{"Role":"vip","RoleID":"10","RoleFound":true,"PermissionOK":true,
+		"Added":[{"Snowflake":"user-1","DiscordName":"player#0001"}],"Removed":[],
+		"Failures":[{"Snowflake":"user-2","DiscordName":"player#0002","Error":"could not add role"}]}`
+	requestedAt := `"RequestedAt":"1970-01-01T00:00:00Z"`
+
+	tests := []struct {
+		name       string
+		query      string
+		result     *types.RoleSetResult
+		status     int
+		want       string
+		wantStatus string
+	}{
+		{
+			name:       "wait",
+			query:      "?wait=true",
+			result:     &result,
+			status:     http.StatusOK,
+			want:       `{"Status":"done",` + requestedAt + `,"CompletedAt":"1970-01-01T00:00:00Z","Result":` + resultJSON + `}`,
+			wantStatus: `{"Status":"done",` + requestedAt + `,"CompletedAt":"1970-01-01T00:00:00Z","Result":` + resultJSON + `}`,
+		},
+		{
+			name:       "no wait",
+			status:     http.StatusAccepted,
+			want:       `{"Status":"pending",` + requestedAt + `}`,
+			wantStatus: `{"Status":"pending",` + requestedAt + `}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			drs := &roleMembersMock{result: tt.result}
+			rs := roles{drs: drs, results: newRoleSetResults(), timeout: time.Second}
+
+			req := httptest.NewRequest(http.MethodPut, "/roles/vip"+tt.query, strings.NewReader(`{"PlayerIDs":["1","2"]}`))
+			req = mux.SetURLVars(req.WithContext(chatContext()), map[string]string{"role_name": "vip"})
+			rr := httptest.NewRecorder()
+			rs.roleHandler(rr, req)
+
+			assert.Equal(t, tt.status, rr.Code)
+			assert.JSONEq(t, tt.want, rr.Body.String())
+			if assert.NotNil(t, drs.roleSet) {
+				assert.Equal(t, []string{"game:1", "game:2"}, drs.roleSet.PlayerIDs)
+				assert.Equal(t, "vip", drs.roleSet.Role)
+			}
+
+			req = httptest.NewRequest(http.MethodGet, "/roles/vip/status", http.NoBody)
+			req = mux.SetURLVars(req.WithContext(chatContext()), map[string]string{"role_name": "vip"})
+			rr = httptest.NewRecorder()
+			rs.roleStatusHandler(rr, req)
+			assert.JSONEq(t, tt.wantStatus, rr.Body.String())
+
+			req = httptest.NewRequest(http.MethodGet, "/roles/other/status", http.NoBody)
+			req = mux.SetURLVars(req.WithContext(chatContext()), map[string]string{"role_name": "other"})
+			rr = httptest.NewRecorder()
+			rs.roleStatusHandler(rr, req)
+			assert.Equal(t, http.StatusNotFound, rr.Code)
+		})
+	}
+}
+
+func TestRoleSetResults(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	key := roleSetKey{serverKey: "bloop", role: "vip"}
+	r := newRoleSetResults()
+
+	first := r.start(key, now)
+	second := r.start(key, now.Add(time.Second))
+	r.finish(key, first, types.RoleSetResult{Role: "first"}, now)
+
+	status, ok := r.get(key)
+	assert.True(t, ok)
+	assert.Equal(t, roleSetPending, status.Status, "an older role set should not finish a newer one")
+
+	r.finish(key, second, types.RoleSetResult{Role: "second"}, now.Add(time.Minute))
+	status, _ = r.get(key)
+	assert.Equal(t, roleSetDone, status.Status)
+	if assert.NotNil(t, status.Result) {
+		assert.Equal(t, "second", status.Result.Role)
+	}
+
+	_, ok = r.get(roleSetKey{serverKey: "other", role: "vip"})
+	assert.False(t, ok)
+}
+
+func TestRoles_membersHandlers(t *testing.T) {
+	t.Parallel()
+
+	result := types.NewRoleSetResult("vip")
+	result.Added = []types.RoleSetMember{{PlayerID: "game:1", Snowflake: "user-1", DiscordName: "player#0001"}}
+	result.Failures = []types.RoleSetFailure{{RoleSetMember: types.RoleSetMember{PlayerID: "game:2"}, Error: "player is not linked"}}
+	resultJSON := `{"Role":"vip","RoleFound":false,"PermissionOK":false,
+		"Added":[{"PlayerID":"1","Snowflake":"user-1","DiscordName":"player#0001"}],"Removed":[],
+		"Failures":[{"PlayerID":"2","Snowflake":"","DiscordName":"","Error":"player is not linked"}]}`
+
+	tests := []struct {
+		name       string
+		method     string
+		body       string
+		playerID   string
+		status     int
+		want       string
+		wantChange *types.RoleMemberChange
+	}{
+		{
+			name:       "add",
+			method:     http.MethodPost,
+			body:       `{"PlayerIDs":["1","2"]}`,
+			status:     http.StatusOK,
+			want:       resultJSON,
+			wantChange: &types.RoleMemberChange{GuildID: "guild", Role: "vip", PlayerIDs: []string{"game:1", "game:2"}},
+		},
+		{name: "add nobody", method: http.MethodPost, body: `{"PlayerIDs":[]}`, status: http.StatusBadRequest},
+		{name: "invalid", method: http.MethodPost, body: `{`, status: http.StatusBadRequest},
+		{
+			name:       "remove",
+			method:     http.MethodDelete,
+			playerID:   "1",
+			status:     http.StatusOK,
+			want:       resultJSON,
+			wantChange: &types.RoleMemberChange{GuildID: "guild", Role: "vip", PlayerIDs: []string{"game:1"}, Remove: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := chatContext()
+			account := ctx.Value(contextKeyAccount).(types.Account)
+			account.GuildSnowflake = "guild"
+			ctx = context.WithValue(ctx, contextKeyAccount, account)
+
+			result := result
+			result.Added = append([]types.RoleSetMember{}, result.Added...)
+			result.Failures = append([]types.RoleSetFailure{}, result.Failures...)
+			drs := &roleMembersMock{result: &result}
+			rs := roles{drs: drs, results: newRoleSetResults(), timeout: time.Second}
+
+			req := httptest.NewRequest(tt.method, "/roles/vip/members?wait=true", strings.NewReader(tt.body))
+			req = mux.SetURLVars(req.WithContext(ctx), map[string]string{"role_name": "vip", "player_id": tt.playerID})
+			rr := httptest.NewRecorder()
+			if tt.method == http.MethodDelete {
+				rs.memberHandler(rr, req)
+			} else {
+				rs.membersHandler(rr, req)
+			}
+
+			assert.Equal(t, tt.status, rr.Code)
+			assert.Equal(t, tt.wantChange, drs.change)
+			if tt.status != http.StatusOK {
+				return
+			}
+			var status struct{ Result json.RawMessage }
+			if assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &status)) {
+				assert.JSONEq(t, tt.want, string(status.Result))
+			}
+		})
+	}
+}
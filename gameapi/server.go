@@ -17,22 +17,28 @@ const upgradeURL = "https://umod.org/plugins/pound-bot"
 type discordHandler interface {
 	RaidNotify(types.RaiAlertWithMessageChannel)
 	AuthDiscord(types.DiscordAuth)
-	SendChatMessage(types.ChatMessage)
+	SendChatMessage(types.ChatMessage, time.Duration) error
 	SendGameMessage(types.GameMessage, time.Duration) error
+	SendPlayerDM(types.PlayerDM, time.Duration) error
+	GuildMember(types.GuildMemberRequest, time.Duration) error
 	ServerChannels(types.ServerChannelsRequest)
 	SetRole(types.RoleSet, time.Duration) error
+	RoleMembers(types.RoleMembersRequest, time.Duration) error
+	ChangeRoleMembers(types.RoleMemberChange, time.Duration) error
 }
 
 // ServerConfig contains the base Server configuration
 type ServerConfig struct {
-	BindAddr string
-	Port     int
-	Storage  storage.Storage
+	BindAddr         string
+	Port             int
+	Storage          storage.Storage
+	ChatLogRetention time.Duration // Zero keeps the chat log forever
+	OfflineAfter     time.Duration // Zero never marks servers offline
 }
 
 type ServerChannels struct {
 	AuthSuccess <-chan types.DiscordAuth
-	ChatQueue   storage.ChatQueueStore
+	ChatQueue   *ChatDispatcher
 }
 
 // A Server runs the HTTP server, notification channels, and DB writing.
@@ -42,6 +48,7 @@ type Server struct {
 	channels        ServerChannels
 	shutdownRequest chan struct{}
 	dh              discordHandler
+	statusMessages  *statusMessages
 }
 
 // NewServer creates a Server
@@ -57,6 +64,7 @@ func NewServer(sc *ServerConfig, dh discordHandler, channels ServerChannels) *Se
 
 	rUUID := requestUUID{}
 	sa := serverAuth{as: sc.Storage.Accounts()}
+	s.statusMessages = newStatusMessages(sc.Storage.ServerStatuses(), dh)
 	r := mux.NewRouter()
 
 	// Handles all /api requests, and sets the server auth handler
@@ -65,12 +73,17 @@ func NewServer(sc *ServerConfig, dh discordHandler, channels ServerChannels) *Se
 	api.Use(rUUID.handle)
 
 	initEntityDeath(api, "/entity_death", sc.Storage.RaidAlerts())
+	initPlayerDeath(api, "/player_death", dh)
+	initRaids(api, "/raids", sc.Storage.RaidHistory())
 	initDiscordAuth(api, "/discord_auth", sc.Storage.DiscordAuths(), sc.Storage.Users(), dh)
-	initChat(api, "/chat", channels.ChatQueue)
-	initMessages(api, "/messages", dh)
+	initChat(api, "/chat", channels.ChatQueue, dh)
+	initMessages(api, "/messages", dh, sc.Storage.GameMessages())
 	initClans(api, "/clans", sc.Storage.Accounts(), sc.Storage.Users())
-	initRoles(api, "/roles", dh)
-	initPlayers(api, "/players")
+	initRoles(api, "/roles", sc.Storage.Users(), dh)
+	initPlayerEvents(api, "/players/events", sc.Storage.OnlinePlayers(), dh)
+	initPlayers(api, "/players", sc.Storage.Users(), dh)
+	initStatus(api, "/status", sc.Storage.ServerStatuses(), s.statusMessages)
+	initWipe(api, "/wipe", sc.Storage.Accounts(), sc.Storage.RaidAlerts(), dh)
 
 	s.Handler = r
 
@@ -95,10 +108,54 @@ func (s *Server) Start() error {
 		var newConn = s.sc.Storage.Copy()
 		defer newConn.Close()
 
-		var ra = newRaidAlerter(newConn.RaidAlerts(), s.dh, s.shutdownRequest)
+		var ra = newRaidAlerter(newConn.RaidAlerts(), newConn.RaidHistory(), s.dh, s.shutdownRequest)
 		ra.Run()
 	}()
 
+	// Start the ChatDispatcher watcher
+	go s.channels.ChatQueue.Run(s.shutdownRequest)
+
+	// Start the ChatLogPruner
+	if s.sc.ChatLogRetention > 0 {
+		go func() {
+			var newConn = s.sc.Storage.Copy()
+			defer newConn.Close()
+
+			var clp = newChatLogPruner(newConn.ChatLog(), s.sc.ChatLogRetention, s.shutdownRequest)
+			clp.Run()
+		}()
+	}
+
+	// Start the OfflineWatchdog
+	go func() {
+		var newConn = s.sc.Storage.Copy()
+		defer newConn.Close()
+
+		var ow = newOfflineWatchdog(newConn.Accounts(), s.dh, s.shutdownRequest)
+		ow.Run()
+	}()
+
+	// Start the WipeReminder
+	go func() {
+		var newConn = s.sc.Storage.Copy()
+		defer newConn.Close()
+
+		var wr = newWipeReminder(newConn.Accounts(), s.dh, s.shutdownRequest)
+		wr.Run()
+	}()
+
+	// Start the StatusWatcher
+	if s.sc.OfflineAfter > 0 {
+		go func() {
+			var newConn = s.sc.Storage.Copy()
+			defer newConn.Close()
+
+			var sw = newStatusWatcher(newConn.ServerStatuses(), newConn.Accounts(), newConn.OnlinePlayers(),
+				s.statusMessages, s.sc.OfflineAfter, s.shutdownRequest)
+			sw.Run()
+		}()
+	}
+
 	go func() {
 		log.Printf("Starting HTTP Server on %s:%d", s.sc.BindAddr, s.sc.Port)
 		if err := s.ListenAndServe(); err != http.ErrServerClosed {
@@ -131,5 +188,14 @@ func (s *Server) Stop() {
 	}()
 	s.shutdownRequest <- struct{}{} // AuthSaver
 	s.shutdownRequest <- struct{}{} // RaidAlerter
+	s.shutdownRequest <- struct{}{} // ChatDispatcher
+	s.shutdownRequest <- struct{}{} // OfflineWatchdog
+	s.shutdownRequest <- struct{}{} // WipeReminder
+	if s.sc.ChatLogRetention > 0 {
+		s.shutdownRequest <- struct{}{} // ChatLogPruner
+	}
+	if s.sc.OfflineAfter > 0 {
+		s.shutdownRequest <- struct{}{} // StatusWatcher
+	}
 	wg.Wait()
 }
@@ -0,0 +1,263 @@
+package gameapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/poundbot/poundbot/types"
+	"github.com/sirupsen/logrus"
+)
+
+// statusTag is the message tag for the server status message
+const statusTag = "status"
+
+type serverStatusStore interface {
+	Get(serverKey string) (types.ServerStatus, error)
+	Report(types.ServerStatus) error
+	SetMessage(serverKey, channelID, messageID string) error
+}
+
+// statusReport is the status sent by a game server. Uptime is in seconds.
+type statusReport struct {
+	Players    int
+	MaxPlayers int
+	Map        string
+	Seed       int64
+	Uptime     int64
+	FPS        float64
+}
+
+type status struct {
+	sss serverStatusStore
+	sm  *statusMessages
+}
+
+func initStatus(api *mux.Router, path string, sss serverStatusStore, sm *statusMessages) {
+	st := status{sss: sss, sm: sm}
+	api.HandleFunc(path, st.handle).Methods(http.MethodPut)
+}
+
+// handle saves the status reported by the game server, and updates the
+// status message in the channel bound to the status tag
+func (st status) handle(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	sc, err := getServerContext(r.Context())
+	stLog := logWithRequest(r.RequestURI, sc)
+
+	if err != nil {
+		stLog.WithError(err).Info("Can't find server")
+		handleError(w, types.RESTError{
+			Error:      "Error finding server identity",
+			StatusCode: http.StatusForbidden,
+		})
+		return
+	}
+
+	var report statusReport
+	if err := json.NewDecoder(r.Body).Decode(&report); err != nil {
+		stLog.WithError(err).Info("Invalid JSON")
+		handleError(w, types.RESTError{
+			Error:      "Invalid request",
+			StatusCode: http.StatusBadRequest,
+		})
+		return
+	}
+	if report.Players < 0 || report.MaxPlayers < 0 || report.Uptime < 0 || report.FPS < 0 {
+		handleError(w, types.RESTError{
+			Error:      "Status values can't be negative",
+			StatusCode: http.StatusBadRequest,
+		})
+		return
+	}
+
+	err = st.sss.Report(types.ServerStatus{
+		ServerKey:  sc.serverKey,
+		Players:    report.Players,
+		MaxPlayers: report.MaxPlayers,
+		Map:        report.Map,
+		Seed:       report.Seed,
+		Uptime:     report.Uptime,
+		FPS:        report.FPS,
+		ReportedAt: iclock().Now().UTC(),
+	})
+	if err != nil {
+		stLog.WithError(err).Error("storage: Could not save server status")
+		handleError(w, types.RESTError{
+			Error:      "Error saving server status",
+			StatusCode: http.StatusInternalServerError,
+		})
+		return
+	}
+
+	go st.sm.update(sc.account.GuildSnowflake, sc.server)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// statusMessages keeps the status message of each server up to date. One
+// server's message is only updated by one goroutine at a time, so a new
+// message isn't sent while another is being sent.
+type statusMessages struct {
+	sss     serverStatusStore
+	gms     gameMessageSender
+	timeout time.Duration
+
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+func newStatusMessages(sss serverStatusStore, gms gameMessageSender) *statusMessages {
+	return &statusMessages{
+		sss:     sss,
+		gms:     gms,
+		timeout: 10 * time.Second,
+		locks:   map[string]*sync.Mutex{},
+	}
+}
+
+func (sm *statusMessages) lock(serverKey string) *sync.Mutex {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	l, ok := sm.locks[serverKey]
+	if !ok {
+		l = &sync.Mutex{}
+		sm.locks[serverKey] = l
+	}
+	return l
+}
+
+// update edits the server's status message to show its stored status. A new
+// message is sent and pinned if the server has none in the channel bound to
+// the status tag, or it was deleted.
+func (sm *statusMessages) update(guildID string, server types.AccountServer) {
+	channelID, found := server.ChannelIDForTag(statusTag)
+	if !found {
+		return
+	}
+
+	l := sm.lock(server.Key)
+	l.Lock()
+	defer l.Unlock()
+
+	smLog := log.WithFields(logrus.Fields{"sys": "STATUS", "gID": guildID, "sKey": server.Key})
+
+	status, err := sm.sss.Get(server.Key)
+	if err != nil {
+		smLog.WithError(err).Error("storage: Could not get server status")
+		return
+	}
+
+	message := statusMessage(server.Name, status)
+	message.Snowflake = guildID
+	message.ChannelName = channelID
+
+	if len(status.MessageID) != 0 && status.ChannelID == channelID {
+		message.Action = types.GameMessageActionEdit
+		message.MessageID = status.MessageID
+		_, err := sm.send(message)
+		if err == nil {
+			return
+		}
+		if !errors.Is(err, types.ErrMessageNotFound) {
+			smLog.WithError(err).Error("could not edit status message")
+			return
+		}
+		message.Action = types.GameMessageActionSend
+		message.MessageID = ""
+	}
+
+	message.Pin = true
+	response, err := sm.send(message)
+	if err != nil {
+		smLog.WithError(err).Error("could not send status message")
+		return
+	}
+	if err := sm.sss.SetMessage(server.Key, response.ChannelID, response.MessageID); err != nil {
+		smLog.WithError(err).Error("storage: Could not save status message")
+	}
+}
+
+func (sm *statusMessages) send(message types.GameMessage) (types.GameMessageResponse, error) {
+	rChan := make(chan types.GameMessageResponse)
+	message.Response = rChan
+	if err := sm.gms.SendGameMessage(message, sm.timeout); err != nil {
+		return types.GameMessageResponse{}, err
+	}
+
+	select {
+	case response := <-rChan:
+		return response, response.Error
+	case <-time.After(sm.timeout):
+		return types.GameMessageResponse{}, errors.New("timed out sending status message")
+	}
+}
+
+// statusMessage is the embed showing a server's status
+func statusMessage(serverName string, status types.ServerStatus) types.GameMessage {
+	field := func(name, value string) types.GameMessageEmbedField {
+		// Discord rejects fields without a value
+		if len(value) == 0 {
+			value = "unknown"
+		}
+		return types.GameMessageEmbedField{
+			Name:   []types.GameMessagePart{{Content: name}},
+			Value:  []types.GameMessagePart{{Content: value, Escape: true}},
+			Inline: true,
+		}
+	}
+
+	embed := types.GameMessageEmbed{
+		Title:     []types.GameMessagePart{{Content: serverName, Escape: true}},
+		Timestamp: status.ReportedAt,
+	}
+
+	if status.Offline {
+		embed.Fields = []types.GameMessageEmbedField{
+			field("Map", status.Map),
+			field("Seed", fmt.Sprint(status.Seed)),
+		}
+		embed.Footer.Text = []types.GameMessagePart{{Content: "Last heartbeat"}}
+		return types.GameMessage{
+			Type:         types.GameMessageTypeEmbed,
+			EmbedStyle:   types.GameMessageEmbedStyle{Color: "red"},
+			Embed:        &embed,
+			MessageParts: []types.GameMessagePart{{Content: "🔴 Offline"}},
+		}
+	}
+
+	embed.Fields = []types.GameMessageEmbedField{
+		field("Players", fmt.Sprintf("%d/%d", status.Players, status.MaxPlayers)),
+		field("Map", status.Map),
+		field("Seed", fmt.Sprint(status.Seed)),
+		field("Uptime", formatDuration(time.Duration(status.Uptime)*time.Second)),
+		field("FPS", fmt.Sprintf("%.0f", status.FPS)),
+	}
+	embed.Footer.Text = []types.GameMessagePart{{Content: "Last updated"}}
+	return types.GameMessage{
+		Type:         types.GameMessageTypeEmbed,
+		EmbedStyle:   types.GameMessageEmbedStyle{Color: "green"},
+		Embed:        &embed,
+		MessageParts: []types.GameMessagePart{{Content: "🟢 Online"}},
+	}
+}
+
+// formatDuration formats a duration as days, hours and minutes
+func formatDuration(d time.Duration) string {
+	days := int64(d / (24 * time.Hour))
+	hours := int64(d % (24 * time.Hour) / time.Hour)
+	minutes := int64(d % time.Hour / time.Minute)
+	if days > 0 {
+		return fmt.Sprintf("%dd %dh %dm", days, hours, minutes)
+	}
+	if hours > 0 {
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	}
+	return fmt.Sprintf("%dm", minutes)
+}
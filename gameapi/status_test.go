@@ -0,0 +1,198 @@
+package gameapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/poundbot/poundbot/pbclock"
+	"github.com/poundbot/poundbot/storage/memory"
+	"github.com/poundbot/poundbot/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// statusSenderMock answers game messages right away, failing edits with
+// editErr
+type statusSenderMock struct {
+	mu       sync.Mutex
+	messages []types.GameMessage
+	editErr  error
+}
+
+func (m *statusSenderMock) SendGameMessage(gm types.GameMessage, timeout time.Duration) error {
+	response := types.GameMessageResponse{ChannelID: gm.ChannelName, MessageID: gm.MessageID}
+	switch gm.Action {
+	case types.GameMessageActionEdit:
+		response.Error = m.editErr
+	case types.GameMessageActionSend:
+		response.MessageID = "new"
+	}
+	go func(rChan chan<- types.GameMessageResponse) {
+		defer close(rChan)
+		rChan <- response
+	}(gm.Response)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	gm.Response = nil
+	m.messages = append(m.messages, gm)
+	return nil
+}
+
+func TestStatus_handle(t *testing.T) {
+	pbclock.Mock()
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		body          string
+		unknownServer bool
+		status        int
+		want          *types.ServerStatus
+	}{
+		{
+			name:   "report",
+			body:   `{"Players":12,"MaxPlayers":50,"Map":"Procedural Map","Seed":1234,"Uptime":3600,"FPS":59.5}`,
+			status: http.StatusNoContent,
+			want: &types.ServerStatus{
+				ServerKey:  "bloop",
+				Players:    12,
+				MaxPlayers: 50,
+				Map:        "Procedural Map",
+				Seed:       1234,
+				Uptime:     3600,
+				FPS:        59.5,
+				ReportedAt: iclock().Now().UTC(),
+			},
+		},
+		{name: "negative", body: `{"Players":-1}`, status: http.StatusBadRequest},
+		{name: "invalid", body: `{`, status: http.StatusBadRequest},
+		{name: "unknown server", body: `{"Players":12}`, unknownServer: true, status: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sss := memory.NewMemory().ServerStatuses()
+			st := status{sss: sss, sm: newStatusMessages(sss, &statusSenderMock{})}
+
+			ctx := chatContext()
+			if tt.unknownServer {
+				ctx = context.WithValue(ctx, contextKeyServerKey, "unknown")
+			}
+			req := httptest.NewRequest(http.MethodPut, "/status", strings.NewReader(tt.body)).WithContext(ctx)
+			rr := httptest.NewRecorder()
+			st.handle(rr, req)
+
+			assert.Equal(t, tt.status, rr.Code)
+			got, err := sss.Get("bloop")
+			if tt.want == nil {
+				assert.NotNil(t, err, "status should not be saved")
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, *tt.want, got)
+		})
+	}
+}
+
+func TestStatusMessages_update(t *testing.T) {
+	t.Parallel()
+
+	server := types.AccountServer{Key: "bloop", Name: "server-name"}
+	server.SetChannelIDForTag("1234", statusTag)
+
+	tests := []struct {
+		name        string
+		server      types.AccountServer
+		message     [2]string // channel and message ID of the current status message
+		editErr     error
+		wantActions []types.GameMessageAction
+		wantMessage string
+	}{
+		{name: "no status channel", server: types.AccountServer{Key: "bloop"}},
+		{
+			name:        "first message",
+			server:      server,
+			wantActions: []types.GameMessageAction{types.GameMessageActionSend},
+			wantMessage: "new",
+		},
+		{
+			name:        "edit",
+			server:      server,
+			message:     [2]string{"1234", "1"},
+			wantActions: []types.GameMessageAction{types.GameMessageActionEdit},
+			wantMessage: "1",
+		},
+		{
+			name:        "deleted",
+			server:      server,
+			message:     [2]string{"1234", "1"},
+			editErr:     types.ErrMessageNotFound,
+			wantActions: []types.GameMessageAction{types.GameMessageActionEdit, types.GameMessageActionSend},
+			wantMessage: "new",
+		},
+		{
+			name:        "channel changed",
+			server:      server,
+			message:     [2]string{"5678", "1"},
+			wantActions: []types.GameMessageAction{types.GameMessageActionSend},
+			wantMessage: "new",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sss := memory.NewMemory().ServerStatuses()
+			sss.Report(types.ServerStatus{ServerKey: "bloop", Players: 1, MaxPlayers: 10})
+			sss.SetMessage("bloop", tt.message[0], tt.message[1])
+			ms := &statusSenderMock{editErr: tt.editErr}
+			sm := newStatusMessages(sss, ms)
+
+			sm.update("guild", tt.server)
+
+			var actions []types.GameMessageAction
+			for _, m := range ms.messages {
+				actions = append(actions, m.Action)
+				assert.Equal(t, "guild", m.Snowflake)
+				assert.Equal(t, "1234", m.ChannelName)
+				assert.Equal(t, m.Action == types.GameMessageActionSend, m.Pin, "only new messages should be pinned")
+			}
+			assert.Equal(t, tt.wantActions, actions)
+
+			got, _ := sss.Get("bloop")
+			if len(tt.wantMessage) != 0 {
+				assert.Equal(t, "1234", got.ChannelID)
+				assert.Equal(t, tt.wantMessage, got.MessageID)
+			}
+		})
+	}
+}
+
+func TestStatusMessage(t *testing.T) {
+	t.Parallel()
+
+	status := types.ServerStatus{Players: 3, MaxPlayers: 50, Seed: 42, Uptime: 90061, FPS: 29.6}
+	m := statusMessage("*server*", status)
+	assert.Equal(t, "green", m.EmbedStyle.Color)
+	assert.Equal(t, []types.GameMessagePart{{Content: "*server*", Escape: true}}, m.Embed.Title)
+	var values []string
+	for _, f := range m.Embed.Fields {
+		values = append(values, f.Value[0].Content)
+	}
+	assert.Equal(t, []string{"3/50", "unknown", "42", "1d 1h 1m", "30"}, values)
+
+	status.Offline = true
+	m = statusMessage("server", status)
+	assert.Equal(t, "red", m.EmbedStyle.Color)
+	assert.Len(t, m.Embed.Fields, 2)
+}
+
+func TestFormatDuration(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "0m", formatDuration(59*time.Second))
+	assert.Equal(t, "2h 5m", formatDuration(125*time.Minute))
+	assert.Equal(t, "3d 0h 0m", formatDuration(72*time.Hour))
+}
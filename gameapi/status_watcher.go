@@ -0,0 +1,90 @@
+package gameapi
+
+import (
+	"time"
+
+	"github.com/poundbot/poundbot/types"
+)
+
+type offlineMarker interface {
+	MarkOffline(before time.Time) ([]types.ServerStatus, error)
+}
+
+type statusAccountGetter interface {
+	GetByServerKey(serverKey string) (types.Account, error)
+}
+
+type onlinePlayersClearer interface {
+	Clear(serverKey string, before time.Time) error
+}
+
+// A StatusWatcher marks servers offline when they haven't reported their
+// status within the offline window, clears their online players, and
+// updates their status messages
+type StatusWatcher struct {
+	om           offlineMarker
+	ag           statusAccountGetter
+	opc          onlinePlayersClearer
+	sm           *statusMessages
+	offlineAfter time.Duration
+	SleepTime    time.Duration
+	done         <-chan struct{}
+}
+
+func newStatusWatcher(om offlineMarker, ag statusAccountGetter, opc onlinePlayersClearer, sm *statusMessages,
+	offlineAfter time.Duration, done <-chan struct{}) *StatusWatcher {
+	return &StatusWatcher{
+		om:           om,
+		ag:           ag,
+		opc:          opc,
+		sm:           sm,
+		offlineAfter: offlineAfter,
+		SleepTime:    time.Minute,
+		done:         done,
+	}
+}
+
+// Run checks for offline servers every SleepTime until done
+func (w *StatusWatcher) Run() {
+	wLog := log.WithField("sys", "STATUS")
+	wLog.Info("Starting")
+	for {
+		select {
+		case <-w.done:
+			wLog.Warn("Shutting down")
+			return
+		case <-time.After(w.SleepTime):
+			w.check()
+		}
+	}
+}
+
+func (w *StatusWatcher) check() {
+	wLog := log.WithField("sys", "STATUS")
+	now := iclock().Now().UTC()
+	offline, err := w.om.MarkOffline(now.Add(-w.offlineAfter))
+	if err != nil {
+		wLog.WithError(err).Error("storage: Could not mark servers offline")
+		return
+	}
+
+	for _, status := range offline {
+		// Players can't be online on a server that is down, and it won't
+		// send their disconnect events
+		if err := w.opc.Clear(status.ServerKey, now); err != nil {
+			wLog.WithError(err).WithField("sKey", status.ServerKey).Error("storage: Could not clear online players")
+		}
+
+		account, err := w.ag.GetByServerKey(status.ServerKey)
+		if err != nil {
+			wLog.WithError(err).WithField("sKey", status.ServerKey).Info("Could not find offline server's account")
+			continue
+		}
+		for _, server := range account.Servers {
+			if server.Key == status.ServerKey {
+				w.sm.update(account.GuildSnowflake, server)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package gameapi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/poundbot/poundbot/storage/memory"
+	"github.com/poundbot/poundbot/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatusWatcher_check(t *testing.T) {
+	t.Parallel()
+
+	mem := memory.NewMemory()
+	server := types.AccountServer{Key: "bloop", Name: "server-name"}
+	server.SetChannelIDForTag("1234", statusTag)
+	account := types.Account{Servers: []types.AccountServer{server}}
+	account.GuildSnowflake = "guild"
+	mem.Accounts().UpsertBase(account.BaseAccount)
+	mem.Accounts().AddServer("guild", server)
+
+	now := iclock().Now().UTC()
+	sss := mem.ServerStatuses()
+	sss.Report(types.ServerStatus{ServerKey: "bloop", ReportedAt: now.Add(-10 * time.Minute)})
+	sss.SetMessage("bloop", "1234", "1")
+	sss.Report(types.ServerStatus{ServerKey: "fresh", ReportedAt: now})
+	ops := mem.OnlinePlayers()
+	ops.Connect(types.OnlinePlayer{ServerKey: "bloop", PlayerID: "game:1", ConnectedAt: now.Add(-time.Hour)})
+	ops.Connect(types.OnlinePlayer{ServerKey: "fresh", PlayerID: "game:1", ConnectedAt: now.Add(-time.Hour)})
+
+	ms := &statusSenderMock{}
+	w := newStatusWatcher(sss, mem.Accounts(), ops, newStatusMessages(sss, ms), 5*time.Minute, nil)
+	w.check()
+
+	if assert.Len(t, ms.messages, 1) {
+		assert.Equal(t, types.GameMessageActionEdit, ms.messages[0].Action)
+		assert.Equal(t, "1", ms.messages[0].MessageID)
+		assert.Equal(t, "red", ms.messages[0].EmbedStyle.Color)
+	}
+	status, _ := sss.Get("fresh")
+	assert.False(t, status.Offline, "servers that reported in the window should stay online")
+	players, _ := ops.Online("bloop")
+	assert.Empty(t, players, "offline servers should have no players online")
+	players, _ = ops.Online("fresh")
+	assert.Len(t, players, 1)
+
+	w.check()
+	assert.Len(t, ms.messages, 1, "offline servers should only be updated once")
+}
@@ -0,0 +1,95 @@
+package gameapi
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/poundbot/poundbot/types"
+)
+
+// wipeTag is the message tag for wipe announcements and reminders
+const wipeTag = "wipe"
+
+// wipeScheduleWindow is how close to a scheduled wipe a server has to wipe
+// for it to count as that wipe
+const wipeScheduleWindow = 24 * time.Hour
+
+type wipeRecorder interface {
+	RecordWipe(serverKey string, wipedAt, scheduledBefore time.Time) error
+}
+
+type wipeRaidAlertsRemover interface {
+	RemoveByServer(serverKey string) error
+}
+
+type wipe struct {
+	wr      wipeRecorder
+	rar     wipeRaidAlertsRemover
+	gms     gameMessageSender
+	timeout time.Duration
+}
+
+func initWipe(api *mux.Router, path string, wr wipeRecorder, rar wipeRaidAlertsRemover, gms gameMessageSender) {
+	wp := wipe{wr: wr, rar: rar, gms: gms, timeout: 10 * time.Second}
+	api.HandleFunc(path, wp.handle).Methods(http.MethodPost)
+}
+
+// handle clears the pending raid alerts and clans of a server that wiped,
+// records when it wiped, and announces the wipe in the channel bound to the
+// wipe tag. A wipe scheduled within wipeScheduleWindow is considered done.
+func (wp wipe) handle(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	sc, err := getServerContext(r.Context())
+	wLog := logWithRequest(r.RequestURI, sc)
+
+	if err != nil {
+		wLog.WithError(err).Info("Can't find server")
+		handleError(w, types.RESTError{
+			Error:      "Error finding server identity",
+			StatusCode: http.StatusInternalServerError,
+		})
+		return
+	}
+
+	if err := wp.rar.RemoveByServer(sc.serverKey); err != nil {
+		wLog.WithError(err).Error("storage: Could not remove raid alerts")
+		handleError(w, types.RESTError{
+			Error:      "Error clearing raid alerts",
+			StatusCode: http.StatusInternalServerError,
+		})
+		return
+	}
+
+	now := iclock().Now().UTC()
+	if err := wp.wr.RecordWipe(sc.serverKey, now, now.Add(wipeScheduleWindow)); err != nil {
+		wLog.WithError(err).Error("storage: Could not record wipe")
+		handleError(w, types.RESTError{
+			Error:      "Error saving wipe",
+			StatusCode: http.StatusInternalServerError,
+		})
+		return
+	}
+
+	if channelID, found := sc.server.ChannelIDForTag(wipeTag); found {
+		go announce(wp.gms, sc.account.GuildSnowflake, channelID, wipeParts(sc.server), wp.timeout, wLog)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// wipeParts is the announcement for a server wipe, followed by the server's
+// wipe message if it has one
+func wipeParts(server types.AccountServer) []types.GameMessagePart {
+	parts := []types.GameMessagePart{
+		{Content: "🧹 **"},
+		{Content: server.Name, Escape: true},
+		{Content: "** has wiped!"},
+	}
+	if len(server.WipeMessage) != 0 {
+		// Wipe messages are set by admins and can use markdown
+		parts = append(parts, types.GameMessagePart{Content: "\n" + server.WipeMessage})
+	}
+	return parts
+}
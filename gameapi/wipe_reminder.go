@@ -0,0 +1,137 @@
+package gameapi
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/poundbot/poundbot/types"
+	"github.com/sirupsen/logrus"
+)
+
+// wipeReminders are when reminders are sent before a scheduled wipe, from
+// the earliest to the latest
+var wipeReminders = []struct {
+	before time.Duration
+	label  string
+}{
+	{24 * time.Hour, "1 day"},
+	{time.Hour, "1 hour"},
+	{10 * time.Minute, "10 minutes"},
+}
+
+type scheduledWipeAccountsGetter interface {
+	WithScheduledWipes(after time.Time, accounts *[]types.Account) error
+}
+
+type wipeReminderState struct {
+	wipe time.Time
+	sent int // Index of the last reminder sent
+}
+
+// A WipeReminder counts down to scheduled wipes in the channel bound to the
+// wipe tag.
+//
+// Only the latest reminder due is sent, and only if it is on time. Reminders
+// that came due while PoundBot was down, or before the wipe was scheduled,
+// are skipped because their countdown would be wrong. Which reminders were
+// sent is only kept in memory.
+type WipeReminder struct {
+	ag        scheduledWipeAccountsGetter
+	gms       gameMessageSender
+	sent      map[string]wipeReminderState // Server keys and their reminders
+	timeout   time.Duration
+	SleepTime time.Duration
+	done      <-chan struct{}
+}
+
+func newWipeReminder(ag scheduledWipeAccountsGetter, gms gameMessageSender, done <-chan struct{}) *WipeReminder {
+	return &WipeReminder{
+		ag:        ag,
+		gms:       gms,
+		sent:      map[string]wipeReminderState{},
+		timeout:   10 * time.Second,
+		SleepTime: time.Minute,
+		done:      done,
+	}
+}
+
+// Run checks the scheduled wipes every SleepTime until done
+func (w *WipeReminder) Run() {
+	wLog := log.WithField("sys", "WIPE")
+	wLog.Info("Starting")
+	for {
+		select {
+		case <-w.done:
+			wLog.Warn("Shutting down")
+			return
+		case <-time.After(w.SleepTime):
+			w.check()
+		}
+	}
+}
+
+func (w *WipeReminder) check() {
+	now := iclock().Now().UTC()
+	var accounts []types.Account
+	if err := w.ag.WithScheduledWipes(now, &accounts); err != nil {
+		log.WithField("sys", "WIPE").WithError(err).Error("storage: Could not get accounts")
+		return
+	}
+
+	scheduled := map[string]bool{}
+	for _, account := range accounts {
+		if account.Disabled {
+			continue
+		}
+		for _, server := range account.Servers {
+			if !server.NextWipe.After(now) {
+				continue
+			}
+			channelID, found := server.ChannelIDForTag(wipeTag)
+			if !found {
+				continue
+			}
+			scheduled[server.Key] = true
+
+			until := server.NextWipe.Sub(now)
+			due := -1
+			for i := range wipeReminders {
+				if until <= wipeReminders[i].before {
+					due = i
+				}
+			}
+			if due == -1 {
+				continue
+			}
+
+			state, ok := w.sent[server.Key]
+			if ok && state.wipe.Equal(server.NextWipe) && state.sent >= due {
+				continue
+			}
+			w.sent[server.Key] = wipeReminderState{wipe: server.NextWipe, sent: due}
+
+			if wipeReminders[due].before-until > 2*w.SleepTime {
+				continue
+			}
+
+			wLog := log.WithFields(logrus.Fields{"sys": "WIPE", "gID": account.GuildSnowflake, "sKey": server.Key})
+			announce(w.gms, account.GuildSnowflake, channelID,
+				wipeReminderParts(server.Name, wipeReminders[due].label, server.NextWipe), w.timeout, wLog)
+		}
+	}
+
+	// Forget servers that wiped or had their wipe cancelled
+	for key := range w.sent {
+		if !scheduled[key] {
+			delete(w.sent, key)
+		}
+	}
+}
+
+func wipeReminderParts(serverName, label string, wipe time.Time) []types.GameMessagePart {
+	return []types.GameMessagePart{
+		{Content: "⏳ **"},
+		{Content: serverName, Escape: true},
+		{Content: fmt.Sprintf("** wipes in %s, at %s.", label, wipe.UTC().Format("2006-01-02 15:04 MST"))},
+	}
+}
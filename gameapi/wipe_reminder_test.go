@@ -0,0 +1,59 @@
+package gameapi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/poundbot/poundbot/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWipeReminder_check(t *testing.T) {
+	t.Parallel()
+
+	now := iclock().Now().UTC()
+	server := func(key string, until time.Duration) types.AccountServer {
+		s := types.AccountServer{Key: key, Name: "server " + key, NextWipe: now.Add(until)}
+		s.SetChannelIDForTag("channel-"+key, wipeTag)
+		return s
+	}
+	at := func(until time.Duration) string { return now.Add(until).Format("2006-01-02 15:04 MST") }
+
+	noChannel := types.AccountServer{Key: "nochannel", NextWipe: now.Add(time.Hour)}
+	account := types.Account{Servers: []types.AccountServer{
+		server("day", 24*time.Hour),
+		server("hour", 59*time.Minute),
+		server("late", 30*time.Minute),
+		server("later", 7*24*time.Hour),
+		server("wiped", -time.Hour),
+		noChannel,
+	}}
+	disabled := types.Account{Servers: []types.AccountServer{server("disabled", time.Hour)}, Disabled: true}
+
+	am := &offlineAlertsMock{}
+	w := newWipeReminder(accountsMock{account, disabled}, am, nil)
+	w.check()
+
+	assert.Equal(t, []string{
+		"channel channel-day ⏳ **server day** wipes in 1 day, at " + at(24*time.Hour) + ".",
+		"channel channel-hour ⏳ **server hour** wipes in 1 hour, at " + at(59*time.Minute) + ".",
+	}, am.sent, "reminders should be sent when due, unless they are late")
+
+	am.sent = nil
+	w.check()
+	assert.Empty(t, am.sent, "reminders should only be sent once")
+
+	// Time passes, and the wipe is rescheduled
+	account.Servers[1].NextWipe = now.Add(9 * time.Minute)
+	account.Servers[2].NextWipe = now.Add(3 * time.Hour)
+	w.ag = accountsMock{account}
+	w.check()
+	assert.Equal(t, []string{
+		"channel channel-hour ⏳ **server hour** wipes in 10 minutes, at " + at(9*time.Minute) + ".",
+	}, am.sent)
+
+	// The servers wipe
+	w.ag = accountsMock{}
+	w.check()
+	assert.Empty(t, w.sent, "servers without a scheduled wipe should be forgotten")
+}
@@ -0,0 +1,100 @@
+package gameapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/poundbot/poundbot/storage/mocks"
+	"github.com/poundbot/poundbot/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWipe_handle(t *testing.T) {
+	t.Parallel()
+
+	now := iclock().Now().UTC()
+
+	tests := []struct {
+		name        string
+		nextWipe    time.Time
+		wipeMessage string
+		wipeChannel bool
+		removeErr   error
+		recordErr   error
+		status      int
+		want        string
+	}{
+		{
+			name:        "scheduled wipe",
+			nextWipe:    now.Add(2 * time.Hour),
+			wipeMessage: "Fresh map, **go go go**",
+			wipeChannel: true,
+			status:      http.StatusNoContent,
+			want:        "🧹 **server-name** has wiped!\nFresh map, **go go go**",
+		},
+		{
+			name:        "late scheduled wipe",
+			nextWipe:    now.Add(-time.Hour),
+			wipeChannel: true,
+			status:      http.StatusNoContent,
+			want:        "🧹 **server-name** has wiped!",
+		},
+		{name: "no wipe channel", status: http.StatusNoContent},
+		{name: "remove error", removeErr: errors.New("remove"), status: http.StatusInternalServerError},
+		{name: "record error", recordErr: errors.New("record"), status: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := chatContext()
+			account := ctx.Value(contextKeyAccount).(types.Account)
+			account.GuildSnowflake = "guild"
+			account.Servers[0].NextWipe = tt.nextWipe
+			account.Servers[0].WipeMessage = tt.wipeMessage
+			if tt.wipeChannel {
+				account.Servers[0].SetChannelIDForTag("5678", wipeTag)
+			}
+			ctx = context.WithValue(ctx, contextKeyAccount, account)
+
+			rar := &mocks.RaidAlertsStore{}
+			rar.On("RemoveByServer", "bloop").Return(tt.removeErr)
+
+			wr := &mocks.AccountsStore{}
+			wr.On("RecordWipe", "bloop", now, now.Add(wipeScheduleWindow)).Return(tt.recordErr)
+
+			gms := make(announcementsMock, 1)
+			wp := wipe{wr: wr, rar: rar, gms: gms, timeout: time.Second}
+
+			req := httptest.NewRequest(http.MethodPost, "/wipe", nil).WithContext(ctx)
+			rr := httptest.NewRecorder()
+			wp.handle(rr, req)
+
+			assert.Equal(t, tt.status, rr.Code)
+			if tt.removeErr != nil {
+				wr.AssertNotCalled(t, "RecordWipe", "bloop", now, now.Add(wipeScheduleWindow))
+			} else {
+				wr.AssertExpectations(t)
+			}
+
+			if len(tt.want) == 0 {
+				select {
+				case m := <-gms:
+					assert.Fail(t, "unexpected wipe announcement", "%v", m)
+				case <-time.After(10 * time.Millisecond):
+				}
+				return
+			}
+			select {
+			case m := <-gms:
+				assert.Equal(t, "guild", m.Snowflake)
+				assert.Equal(t, "5678", m.ChannelName)
+				assert.Equal(t, tt.want, gameMessageContent(m.MessageParts))
+			case <-time.After(time.Second):
+				assert.Fail(t, "no wipe announcement")
+			}
+		})
+	}
+}
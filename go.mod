@@ -12,6 +12,7 @@ require (
 	github.com/gofrs/uuid v3.3.0+incompatible
 	github.com/gorilla/mux v1.7.4
 	github.com/jmshal/go-locale v0.0.0-20190124211249-eb00fb25cc61
+	github.com/mattn/go-sqlite3 v1.14.0
 	github.com/mitchellh/mapstructure v1.3.0 // indirect
 	github.com/nicksnyder/go-i18n/v2 v2.0.3
 	github.com/pelletier/go-toml v1.7.0 // indirect
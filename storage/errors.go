@@ -0,0 +1,18 @@
+package storage
+
+import "errors"
+
+// Errors returned by every storage backend. Backends may wrap them with more
+// detail, so check for them with errors.Is.
+var (
+	// ErrNotFound is returned when the requested record does not exist
+	ErrNotFound = errors.New("not found")
+
+	// ErrConflict is returned when a write would break a uniqueness rule,
+	// such as a player ID already belonging to another user
+	ErrConflict = errors.New("conflict")
+
+	// ErrStale is returned when a conditional update did not apply because
+	// the record changed since it was read
+	ErrStale = errors.New("stale")
+)
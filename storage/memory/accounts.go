@@ -0,0 +1,369 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/types"
+)
+
+// An Accounts implements storage.AccountsStore
+type Accounts struct {
+	mu       sync.RWMutex
+	accounts []types.Account
+}
+
+func newAccounts() *Accounts {
+	return &Accounts{}
+}
+
+// guildIndex returns the index of the account for a guild, or -1
+func (s *Accounts) guildIndex(snowflake string) int {
+	for i := range s.accounts {
+		if s.accounts[i].GuildSnowflake == snowflake {
+			return i
+		}
+	}
+	return -1
+}
+
+// serverIndex returns the account and server index for a server key,
+// or -1, -1
+func (s *Accounts) serverIndex(serverKey string) (int, int) {
+	for i := range s.accounts {
+		for j := range s.accounts[i].Servers {
+			if s.accounts[i].Servers[j].Key == serverKey {
+				return i, j
+			}
+		}
+	}
+	return -1, -1
+}
+
+// setBase copies the base account the same way a MongoDB $set would,
+// skipping empty omitempty fields.
+func setBase(to *types.BaseAccount, from types.BaseAccount) {
+	to.GuildSnowflake = from.GuildSnowflake
+	to.OwnerSnowflake = from.OwnerSnowflake
+	to.CommandPrefix = from.CommandPrefix
+	if len(from.AdminSnowflakes) != 0 {
+		to.AdminSnowflakes = append([]string{}, from.AdminSnowflakes...)
+	}
+	if len(from.RegisteredPlayerIDs) != 0 {
+		to.RegisteredPlayerIDs = append([]string{}, from.RegisteredPlayerIDs...)
+	}
+}
+
+func (s *Accounts) All(accounts *[]types.Account) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var out []types.Account
+	for i := range s.accounts {
+		var account types.Account
+		clone(s.accounts[i], &account)
+		out = append(out, account)
+	}
+	*accounts = out
+	return nil
+}
+
+// WithOfflineAlerts implements storage.AccountsStore.WithOfflineAlerts
+func (s *Accounts) WithOfflineAlerts(accounts *[]types.Account) error {
+	*accounts = s.withServer(func(server types.AccountServer) bool { return server.OfflineAlert != "" })
+	return nil
+}
+
+// WithScheduledWipes implements storage.AccountsStore.WithScheduledWipes
+func (s *Accounts) WithScheduledWipes(after time.Time, accounts *[]types.Account) error {
+	*accounts = s.withServer(func(server types.AccountServer) bool { return server.NextWipe.After(after) })
+	return nil
+}
+
+// withServer copies the enabled accounts with a server that matches
+func (s *Accounts) withServer(match func(types.AccountServer) bool) []types.Account {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var out []types.Account
+	for i := range s.accounts {
+		if s.accounts[i].Disabled {
+			continue
+		}
+		for _, server := range s.accounts[i].Servers {
+			if match(server) {
+				var account types.Account
+				clone(s.accounts[i], &account)
+				out = append(out, account)
+				break
+			}
+		}
+	}
+	return out
+}
+
+func (s *Accounts) GetByDiscordGuild(key string) (types.Account, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var account types.Account
+	i := s.guildIndex(key)
+	if i == -1 {
+		return account, storage.ErrNotFound
+	}
+	clone(s.accounts[i], &account)
+	return account, nil
+}
+
+func (s *Accounts) GetByServerKey(key string) (types.Account, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var account types.Account
+	i, _ := s.serverIndex(key)
+	if i == -1 {
+		return account, storage.ErrNotFound
+	}
+	clone(s.accounts[i], &account)
+	return account, nil
+}
+
+func (s *Accounts) UpsertBase(account types.BaseAccount) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.guildIndex(account.GuildSnowflake)
+	if i == -1 {
+		a := types.Account{ID: bson.NewObjectId(), Timestamp: *types.NewTimestamp()}
+		setBase(&a.BaseAccount, account)
+		s.accounts = append(s.accounts, a)
+		return nil
+	}
+	setBase(&s.accounts[i].BaseAccount, account)
+	return nil
+}
+
+func (s *Accounts) Remove(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.guildIndex(key)
+	if i == -1 {
+		return storage.ErrNotFound
+	}
+	s.accounts[i].Disabled = true
+	return nil
+}
+
+func (s *Accounts) AddClan(serverKey string, clan types.Clan) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, j := s.serverIndex(serverKey)
+	if i == -1 {
+		return storage.ErrNotFound
+	}
+	var c types.Clan
+	clone(clan, &c)
+	s.accounts[i].Servers[j].Clans = append(s.accounts[i].Servers[j].Clans, c)
+	return nil
+}
+
+func (s *Accounts) RemoveClan(serverKey, clanTag string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, j := s.serverIndex(serverKey)
+	if i == -1 {
+		return storage.ErrNotFound
+	}
+
+	server := &s.accounts[i].Servers[j]
+	clans := []types.Clan{}
+	for _, clan := range server.Clans {
+		if clan.Tag != clanTag {
+			clans = append(clans, clan)
+		}
+	}
+	if len(clans) == len(server.Clans) {
+		return storage.ErrNotFound
+	}
+	server.Clans = clans
+	return nil
+}
+
+func (s *Accounts) SetClans(serverKey string, clans []types.Clan) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, j := s.serverIndex(serverKey)
+	if i == -1 {
+		return storage.ErrNotFound
+	}
+	var c struct{ Clans []types.Clan }
+	clone(struct{ Clans []types.Clan }{clans}, &c)
+	s.accounts[i].Servers[j].Clans = c.Clans
+	return nil
+}
+
+// RecordWipe implements storage.AccountsStore.RecordWipe
+func (s *Accounts) RecordWipe(serverKey string, wipedAt, scheduledBefore time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, j := s.serverIndex(serverKey)
+	if i == -1 {
+		return storage.ErrNotFound
+	}
+	server := &s.accounts[i].Servers[j]
+	server.Clans = nil
+	server.LastWipe = wipedAt
+	if !server.NextWipe.IsZero() && server.NextWipe.Before(scheduledBefore) {
+		server.NextWipe = time.Time{}
+	}
+	return nil
+}
+
+func (s *Accounts) AddServer(snowflake string, server types.AccountServer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.guildIndex(snowflake)
+	if i == -1 {
+		return storage.ErrNotFound
+	}
+	server.CreatedAt = iclock().Now().UTC()
+	var as types.AccountServer
+	clone(server, &as)
+	s.accounts[i].Servers = append(s.accounts[i].Servers, as)
+	return nil
+}
+
+func (s *Accounts) RemoveServer(snowflake, serverKey string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, _ := s.serverIndex(serverKey)
+	if i == -1 {
+		return storage.ErrNotFound
+	}
+	servers := []types.AccountServer{}
+	for _, server := range s.accounts[i].Servers {
+		if server.Key != serverKey {
+			servers = append(servers, server)
+		}
+	}
+	s.accounts[i].Servers = servers
+	return nil
+}
+
+func (s *Accounts) UpdateServer(snowflake, oldKey string, server types.AccountServer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, j := s.serverIndex(oldKey)
+	if i == -1 || s.accounts[i].GuildSnowflake != snowflake {
+		return storage.ErrNotFound
+	}
+	var as types.AccountServer
+	clone(server, &as)
+	s.accounts[i].Servers[j] = as
+	return nil
+}
+
+func (s *Accounts) RemoveNotInDiscordGuildList(guilds []types.BaseAccount) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	insertTS := types.NewTimestamp()
+	insertTS.CreatedAt = iclock().Now().UTC()
+	guildIDs := make([]string, len(guilds))
+
+	for i, guild := range guilds {
+		// Collect the IDs for disabling later
+		guildIDs[i] = guild.GuildSnowflake
+
+		j := s.guildIndex(guild.GuildSnowflake)
+		if j == -1 {
+			s.accounts = append(s.accounts, types.Account{
+				ID:        bson.NewObjectId(),
+				Timestamp: types.Timestamp{CreatedAt: insertTS.CreatedAt},
+			})
+			j = len(s.accounts) - 1
+		}
+		setBase(&s.accounts[j].BaseAccount, guild)
+		s.accounts[j].Disabled = false
+		s.accounts[j].UpdatedAt = insertTS.UpdatedAt
+	}
+
+	// Now disable all the guilds not in the list
+	for i := range s.accounts {
+		if !containsString(guildIDs, s.accounts[i].GuildSnowflake) {
+			s.accounts[i].Disabled = true
+		}
+	}
+
+	return nil
+}
+
+func (s *Accounts) SetRegisteredPlayerIDs(accountID string, playerIDs []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.guildIndex(accountID)
+	if i == -1 {
+		return storage.ErrNotFound
+	}
+	s.accounts[i].RegisteredPlayerIDs = append([]string{}, playerIDs...)
+	return nil
+}
+
+func (s *Accounts) AddRegisteredPlayerIDs(accountID string, playerIDs []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.guildIndex(accountID)
+	if i == -1 {
+		return storage.ErrNotFound
+	}
+	for _, pID := range playerIDs {
+		if !containsString(s.accounts[i].RegisteredPlayerIDs, pID) {
+			s.accounts[i].RegisteredPlayerIDs = append(s.accounts[i].RegisteredPlayerIDs, pID)
+		}
+	}
+	return nil
+}
+
+func (s *Accounts) RemoveRegisteredPlayerIDs(accountID string, playerIDs []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.guildIndex(accountID)
+	if i == -1 {
+		return storage.ErrNotFound
+	}
+	ids := []string{}
+	for _, pID := range s.accounts[i].RegisteredPlayerIDs {
+		if !containsString(playerIDs, pID) {
+			ids = append(ids, pID)
+		}
+	}
+	s.accounts[i].RegisteredPlayerIDs = ids
+	return nil
+}
+
+func (s *Accounts) Touch(serverKey string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, j := s.serverIndex(serverKey)
+	if i == -1 {
+		return storage.ErrNotFound
+	}
+	now := iclock().Now().UTC()
+	s.accounts[i].UpdatedAt = now
+	s.accounts[i].Servers[j].UpdatedAt = now
+	return nil
+}
@@ -0,0 +1,86 @@
+package memory
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/types"
+)
+
+// A ChatLog implements storage.ChatLogStore
+type ChatLog struct {
+	mu      sync.RWMutex
+	entries []types.ChatLogEntry
+}
+
+func newChatLog() *ChatLog {
+	return &ChatLog{}
+}
+
+// Add implements storage.ChatLogStore.Add
+func (c *ChatLog) Add(entry types.ChatLogEntry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !entry.ID.Valid() {
+		entry.ID = bson.NewObjectId()
+	}
+
+	var stored types.ChatLogEntry
+	clone(entry, &stored)
+	c.entries = append(c.entries, stored)
+	return nil
+}
+
+// Find implements storage.ChatLogStore.Find
+func (c *ChatLog) Find(q storage.ChatLogQuery) ([]types.ChatLogEntry, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	fromUser := func(entry types.ChatLogEntry) bool {
+		if len(q.Snowflake) == 0 && len(q.PlayerIDs) == 0 {
+			return true
+		}
+		return (len(q.Snowflake) != 0 && entry.Snowflake == q.Snowflake) ||
+			containsString(q.PlayerIDs, entry.PlayerID)
+	}
+
+	var entries []types.ChatLogEntry
+	for _, entry := range c.entries {
+		switch {
+		case len(q.ServerKeys) != 0 && !containsString(q.ServerKeys, entry.ServerKey),
+			!fromUser(entry),
+			entry.SentAt.Before(q.Since):
+			continue
+		}
+		var e types.ChatLogEntry
+		clone(entry, &e)
+		entries = append(entries, e)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].SentAt.After(entries[j].SentAt) })
+	if q.Limit > 0 && len(entries) > q.Limit {
+		entries = entries[:q.Limit]
+	}
+	return entries, nil
+}
+
+// RemoveBefore implements storage.ChatLogStore.RemoveBefore
+func (c *ChatLog) RemoveBefore(t time.Time) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	kept := c.entries[:0]
+	for _, entry := range c.entries {
+		if entry.SentAt.Before(t) {
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	removed := len(c.entries) - len(kept)
+	c.entries = kept
+	return removed, nil
+}
@@ -0,0 +1,152 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/types"
+)
+
+// chatQueueRecheck is how often waiting requests check for expired leases
+const chatQueueRecheck = time.Second
+
+// A ChatQueue implements storage.ChatQueueStore
+type ChatQueue struct {
+	mu       sync.Mutex
+	messages []types.ChatMessage // in the order they were queued
+	inserted chan struct{}       // closed and replaced on every insert
+}
+
+func newChatQueue() *ChatQueue {
+	return &ChatQueue{inserted: make(chan struct{})}
+}
+
+// InsertMessage implements storage.ChatQueueStore.InsertMessage
+func (cq *ChatQueue) InsertMessage(m types.ChatMessage) error {
+	cq.mu.Lock()
+	defer cq.mu.Unlock()
+
+	if len(m.ID) == 0 {
+		m.ID = bson.NewObjectId()
+	}
+	m.QueuedAt = iclock().Now().UTC()
+	m.LeasedUntil = time.Time{}
+
+	var stored types.ChatMessage
+	clone(m, &stored)
+	cq.messages = append(cq.messages, stored)
+	cq.dropOldest(m.ServerKey)
+
+	close(cq.inserted)
+	cq.inserted = make(chan struct{})
+	return nil
+}
+
+// dropOldest removes the oldest messages for the server while it has more
+// than storage.ChatQueueServerLimit
+func (cq *ChatQueue) dropOldest(sk string) {
+	count := 0
+	for _, m := range cq.messages {
+		if m.ServerKey == sk {
+			count++
+		}
+	}
+
+	kept := cq.messages[:0]
+	for _, m := range cq.messages {
+		if m.ServerKey == sk && count > storage.ChatQueueServerLimit {
+			count--
+			continue
+		}
+		kept = append(kept, m)
+	}
+	cq.messages = kept
+}
+
+// next leases the oldest available message for the server and tag
+func (cq *ChatQueue) next(sk, tag string, lease time.Duration) (types.ChatMessage, bool) {
+	cq.mu.Lock()
+	defer cq.mu.Unlock()
+
+	now := iclock().Now().UTC()
+	for i := range cq.messages {
+		m := &cq.messages[i]
+		if m.ServerKey != sk || m.Tag != tag || m.LeasedUntil.After(now) {
+			continue
+		}
+
+		var cm types.ChatMessage
+		if lease == 0 {
+			clone(*m, &cm)
+			cq.messages = append(cq.messages[:i], cq.messages[i+1:]...)
+			return cm, true
+		}
+
+		m.LeasedUntil = now.Add(lease)
+		clone(*m, &cm)
+		return cm, true
+	}
+	return types.ChatMessage{}, false
+}
+
+// GetGameServerMessage implements storage.ChatQueueStore.GetGameServerMessage
+func (cq *ChatQueue) GetGameServerMessage(sk, tag string, lease, to time.Duration) (types.ChatMessage, bool) {
+	timer := time.NewTimer(to)
+	defer timer.Stop()
+	ticker := time.NewTicker(chatQueueRecheck)
+	defer ticker.Stop()
+
+	for {
+		// Grab the channel before looking so an insert in between is not
+		// missed.
+		cq.mu.Lock()
+		inserted := cq.inserted
+		cq.mu.Unlock()
+
+		if cm, found := cq.next(sk, tag, lease); found {
+			return cm, true
+		}
+
+		select {
+		case <-inserted:
+		case <-ticker.C:
+		case <-timer.C:
+			return types.ChatMessage{}, false
+		}
+	}
+}
+
+// ReadyKeys implements storage.ChatQueueStore.ReadyKeys
+func (cq *ChatQueue) ReadyKeys() ([]storage.ChatQueueKey, error) {
+	cq.mu.Lock()
+	defer cq.mu.Unlock()
+
+	now := iclock().Now().UTC()
+	seen := map[storage.ChatQueueKey]bool{}
+	var keys []storage.ChatQueueKey
+	for _, m := range cq.messages {
+		key := storage.ChatQueueKey{ServerKey: m.ServerKey, Tag: m.Tag}
+		if m.LeasedUntil.After(now) || seen[key] {
+			continue
+		}
+		seen[key] = true
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// AckMessage implements storage.ChatQueueStore.AckMessage
+func (cq *ChatQueue) AckMessage(sk, id string) error {
+	cq.mu.Lock()
+	defer cq.mu.Unlock()
+
+	for i, m := range cq.messages {
+		if m.ServerKey == sk && m.ID.Hex() == id {
+			cq.messages = append(cq.messages[:i], cq.messages[i+1:]...)
+			return nil
+		}
+	}
+	return storage.ErrNotFound
+}
@@ -0,0 +1,87 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/poundbot/poundbot/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChatQueue_GetGameServerMessage(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		messages []types.ChatMessage
+		want     types.ChatMessage
+		found    bool
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name: "other server and tag",
+			messages: []types.ChatMessage{
+				{ServerKey: "other", Tag: "chat", Message: "one"},
+				{ServerKey: "key", Tag: "other", Message: "two"},
+			},
+		},
+		{
+			name: "found",
+			messages: []types.ChatMessage{
+				{ServerKey: "other", Tag: "chat", Message: "one"},
+				{ServerKey: "key", Tag: "chat", Message: "two"},
+				{ServerKey: "key", Tag: "chat", Message: "three"},
+			},
+			want:  types.ChatMessage{ServerKey: "key", Tag: "chat", Message: "two"},
+			found: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cq := newChatQueue()
+			for _, m := range tt.messages {
+				cq.InsertMessage(m)
+			}
+
+			got, found := cq.GetGameServerMessage("key", "chat", time.Minute, time.Millisecond)
+			assert.Equal(t, tt.found, found)
+			got.ID = ""
+			got.QueuedAt = time.Time{}
+			got.LeasedUntil = time.Time{}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestChatQueue_GetGameServerMessage_leased(t *testing.T) {
+	t.Parallel()
+
+	cq := newChatQueue()
+	cq.InsertMessage(types.ChatMessage{ServerKey: "key", Tag: "chat", Message: "one"})
+
+	got, found := cq.GetGameServerMessage("key", "chat", time.Minute, time.Millisecond)
+	assert.True(t, found, "first request should get the message")
+
+	_, found = cq.GetGameServerMessage("key", "chat", time.Minute, time.Millisecond)
+	assert.False(t, found, "leased message should not be delivered again")
+
+	assert.Nil(t, cq.AckMessage("key", got.ID.Hex()))
+	assert.Empty(t, cq.messages, "acked message should be removed")
+}
+
+func TestChatQueue_GetGameServerMessage_waits(t *testing.T) {
+	t.Parallel()
+
+	cq := newChatQueue()
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cq.InsertMessage(types.ChatMessage{ServerKey: "key", Tag: "chat", Message: "late"})
+	}()
+
+	got, found := cq.GetGameServerMessage("key", "chat", time.Minute, time.Second)
+	assert.True(t, found)
+	assert.Equal(t, "late", got.Message)
+}
@@ -0,0 +1,127 @@
+package memory
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/types"
+)
+
+// A DiscordAuths implements storage.DiscordAuthsStore
+type DiscordAuths struct {
+	mu    sync.RWMutex
+	auths []types.DiscordAuth
+}
+
+func newDiscordAuths() *DiscordAuths {
+	return &DiscordAuths{}
+}
+
+func (d *DiscordAuths) find(match func(types.DiscordAuth) bool) (types.DiscordAuth, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	var da types.DiscordAuth
+	for i := range d.auths {
+		if match(d.auths[i]) {
+			clone(d.auths[i], &da)
+			return da, nil
+		}
+	}
+	return da, storage.ErrNotFound
+}
+
+// All implements storage.DiscordAuthsStore.All
+func (d *DiscordAuths) All(auths *[]types.DiscordAuth) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	var out []types.DiscordAuth
+	for i := range d.auths {
+		var da types.DiscordAuth
+		clone(d.auths[i], &da)
+		out = append(out, da)
+	}
+	*auths = out
+	return nil
+}
+
+func (d *DiscordAuths) GetByDiscordName(discordName string) (types.DiscordAuth, error) {
+	return d.find(func(da types.DiscordAuth) bool { return da.DiscordName == discordName })
+}
+
+func (d *DiscordAuths) GetByDiscordID(snowflake string) (types.DiscordAuth, error) {
+	da, err := d.find(func(da types.DiscordAuth) bool { return da.Snowflake == snowflake })
+	if err != nil {
+		return types.DiscordAuth{}, fmt.Errorf("memory could not find snowflake %s (%w)", snowflake, err)
+	}
+	return da, nil
+}
+
+// GetByPlayerID implements storage.DiscordAuthsStore.GetByPlayerID
+func (d *DiscordAuths) GetByPlayerID(playerID string) (types.DiscordAuth, error) {
+	return d.find(func(da types.DiscordAuth) bool { return da.PlayerID == playerID })
+}
+
+// Remove implements storage.DiscordAuthsStore.Remove
+func (d *DiscordAuths) Remove(u storage.UserInfoGetter) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for i := range d.auths {
+		if d.auths[i].PlayerID == u.GetPlayerID() {
+			d.auths = append(d.auths[:i], d.auths[i+1:]...)
+			return nil
+		}
+	}
+	return storage.ErrNotFound
+}
+
+// Upsert implements storage.DiscordAuthsStore.Upsert
+func (d *DiscordAuths) Upsert(da types.DiscordAuth) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.purge()
+
+	var stored types.DiscordAuth
+	clone(da, &stored)
+
+	for i := range d.auths {
+		if d.auths[i].PlayerID == da.PlayerID {
+			d.auths[i] = stored
+			return nil
+		}
+	}
+	d.auths = append(d.auths, stored)
+	return nil
+}
+
+// IncrementAttempts implements storage.DiscordAuthsStore.IncrementAttempts
+func (d *DiscordAuths) IncrementAttempts(playerID string) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for i := range d.auths {
+		if d.auths[i].PlayerID == playerID {
+			d.auths[i].Attempts++
+			return d.auths[i].Attempts, nil
+		}
+	}
+	return 0, storage.ErrNotFound
+}
+
+// purge removes auths that expired more than DiscordAuthPurgeAfter ago. The
+// caller must hold the write lock.
+func (d *DiscordAuths) purge() {
+	cutoff := iclock().Now().Add(-storage.DiscordAuthPurgeAfter)
+	kept := d.auths[:0]
+	for _, da := range d.auths {
+		if da.ExpiresAt.Before(cutoff) {
+			continue
+		}
+		kept = append(kept, da)
+	}
+	d.auths = kept
+}
@@ -0,0 +1,76 @@
+package memory
+
+import (
+	"sync"
+
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/types"
+)
+
+// A GameMessages implements storage.GameMessagesStore
+type GameMessages struct {
+	mu       sync.RWMutex
+	messages []types.SentGameMessage // oldest first
+}
+
+func newGameMessages() *GameMessages {
+	return &GameMessages{}
+}
+
+// Add implements storage.GameMessagesStore.Add
+func (gm *GameMessages) Add(m types.SentGameMessage) error {
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+
+	var stored types.SentGameMessage
+	clone(m, &stored)
+	gm.remove(m.MessageID)
+	gm.messages = append(gm.messages, stored)
+
+	count := 0
+	for i := len(gm.messages) - 1; i >= 0; i-- {
+		if gm.messages[i].ServerKey != m.ServerKey {
+			continue
+		}
+		count++
+		if count > storage.GameMessagesServerLimit {
+			gm.messages = append(gm.messages[:i], gm.messages[i+1:]...)
+		}
+	}
+	return nil
+}
+
+// Get implements storage.GameMessagesStore.Get
+func (gm *GameMessages) Get(messageID string) (types.SentGameMessage, error) {
+	gm.mu.RLock()
+	defer gm.mu.RUnlock()
+
+	for _, m := range gm.messages {
+		if m.MessageID == messageID {
+			return m, nil
+		}
+	}
+	return types.SentGameMessage{}, storage.ErrNotFound
+}
+
+// Remove implements storage.GameMessagesStore.Remove
+func (gm *GameMessages) Remove(messageID string) error {
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+
+	if !gm.remove(messageID) {
+		return storage.ErrNotFound
+	}
+	return nil
+}
+
+// remove removes a message, returning false if there is none
+func (gm *GameMessages) remove(messageID string) bool {
+	for i, m := range gm.messages {
+		if m.MessageID == messageID {
+			gm.messages = append(gm.messages[:i], gm.messages[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+package memory
+
+import (
+	"fmt"
+
+	"github.com/globalsign/mgo/bson"
+	pblog "github.com/poundbot/poundbot/log"
+	"github.com/poundbot/poundbot/pbclock"
+	"github.com/poundbot/poundbot/storage"
+)
+
+var log = pblog.Log.WithField("sys", "MEMORY")
+
+var iclock = pbclock.Clock
+
+// A Memory implements storage.Storage in process memory. Nothing is
+// persisted, so it is only suitable for local development and tests.
+//
+// All copies of a Memory share the same data.
+type Memory struct {
+	accounts       *Accounts
+	users          *Users
+	discordAuths   *DiscordAuths
+	raidAlerts     *RaidAlerts
+	raidHistory    *RaidHistory
+	chatLog        *ChatLog
+	chatQueue      *ChatQueue
+	gameMessages   *GameMessages
+	onlinePlayers  *OnlinePlayers
+	serverStatuses *ServerStatuses
+	messageLocks   *MessageLocks
+}
+
+// NewMemory returns an empty Memory
+func NewMemory() *Memory {
+	users := newUsers()
+	return &Memory{
+		accounts:       newAccounts(),
+		users:          users,
+		discordAuths:   newDiscordAuths(),
+		raidAlerts:     newRaidAlerts(users),
+		raidHistory:    newRaidHistory(),
+		chatLog:        newChatLog(),
+		chatQueue:      newChatQueue(),
+		gameMessages:   newGameMessages(),
+		onlinePlayers:  newOnlinePlayers(),
+		serverStatuses: newServerStatuses(),
+		messageLocks:   newMessageLocks(),
+	}
+}
+
+// Copy implements storage.Storage.Copy
+func (m *Memory) Copy() storage.Storage {
+	return m
+}
+
+// Close implements storage.Storage.Close
+func (m *Memory) Close() {}
+
+// Init implements storage.Storage.Init
+func (m *Memory) Init() {
+	log.Warn("Using in-memory storage. Nothing will be saved on shutdown.")
+}
+
+// Accounts implements storage.Storage.Accounts
+func (m *Memory) Accounts() storage.AccountsStore {
+	return m.accounts
+}
+
+// Users implements storage.Storage.Users
+func (m *Memory) Users() storage.UsersStore {
+	return m.users
+}
+
+// DiscordAuths implements storage.Storage.DiscordAuths
+func (m *Memory) DiscordAuths() storage.DiscordAuthsStore {
+	return m.discordAuths
+}
+
+// RaidAlerts implements storage.Storage.RaidAlerts
+func (m *Memory) RaidAlerts() storage.RaidAlertsStore {
+	return m.raidAlerts
+}
+
+// RaidHistory implements storage.Storage.RaidHistory
+func (m *Memory) RaidHistory() storage.RaidHistoryStore {
+	return m.raidHistory
+}
+
+// ChatLog implements storage.Storage.ChatLog
+func (m *Memory) ChatLog() storage.ChatLogStore {
+	return m.chatLog
+}
+
+// ChatQueue implements storage.Storage.ChatQueue
+func (m *Memory) ChatQueue() storage.ChatQueueStore {
+	return m.chatQueue
+}
+
+// GameMessages implements storage.Storage.GameMessages
+func (m *Memory) GameMessages() storage.GameMessagesStore {
+	return m.gameMessages
+}
+
+// OnlinePlayers implements storage.Storage.OnlinePlayers
+func (m *Memory) OnlinePlayers() storage.OnlinePlayersStore {
+	return m.onlinePlayers
+}
+
+// ServerStatuses implements storage.Storage.ServerStatuses
+func (m *Memory) ServerStatuses() storage.ServerStatusStore {
+	return m.serverStatuses
+}
+
+// MessageLocks implements storage.Storage.MessageLocks
+func (m *Memory) MessageLocks() storage.MessageLocksStore {
+	return m.messageLocks
+}
+
+// clone deep copies in to out by round tripping through BSON. This keeps
+// stored documents isolated from callers and gives the same results as
+// reading them back from MongoDB (millisecond times, omitempty fields).
+func clone(in, out interface{}) {
+	b, err := bson.Marshal(in)
+	if err != nil {
+		panic(fmt.Sprintf("memory: could not marshal %T: %s", in, err))
+	}
+	if err := bson.Unmarshal(b, out); err != nil {
+		panic(fmt.Sprintf("memory: could not unmarshal %T: %s", out, err))
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for i := range list {
+		if list[i] == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,14 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/storage/storagetest"
+)
+
+func TestMemory(t *testing.T) {
+	t.Parallel()
+
+	storagetest.Run(t, func() storage.Storage { return NewMemory() })
+}
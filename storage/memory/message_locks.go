@@ -0,0 +1,37 @@
+package memory
+
+import (
+	"sync"
+	"time"
+)
+
+// messageLocksMax mirrors the size of the capped MongoDB collection
+const messageLocksMax = 1000
+
+// A MessageLocks implements storage.MessageLocksStore
+type MessageLocks struct {
+	mu    sync.Mutex
+	locks map[string]time.Time
+	order []string // oldest first, for expiring locks past messageLocksMax
+}
+
+func newMessageLocks() *MessageLocks {
+	return &MessageLocks{locks: map[string]time.Time{}}
+}
+
+func (ml *MessageLocks) Obtain(mID, mType string) bool {
+	ml.mu.Lock()
+	defer ml.mu.Unlock()
+
+	if _, ok := ml.locks[mID]; ok {
+		return false
+	}
+
+	ml.locks[mID] = iclock().Now().UTC()
+	ml.order = append(ml.order, mID)
+	if len(ml.order) > messageLocksMax {
+		delete(ml.locks, ml.order[0])
+		ml.order = ml.order[1:]
+	}
+	return true
+}
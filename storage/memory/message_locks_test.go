@@ -0,0 +1,25 @@
+package memory
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageLocks_Obtain(t *testing.T) {
+	t.Parallel()
+
+	ml := newMessageLocks()
+
+	assert.True(t, ml.Obtain("1", "discord"), "first lock should be obtained")
+	assert.False(t, ml.Obtain("1", "discord"), "second lock should fail")
+	assert.True(t, ml.Obtain("2", "discord"), "other message should lock")
+
+	for i := 0; i < messageLocksMax; i++ {
+		ml.Obtain(fmt.Sprintf("fill-%d", i), "discord")
+	}
+
+	assert.Len(t, ml.locks, messageLocksMax)
+	assert.True(t, ml.Obtain("1", "discord"), "expired lock should be obtained again")
+}
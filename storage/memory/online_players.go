@@ -0,0 +1,84 @@
+package memory
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/poundbot/poundbot/types"
+)
+
+type onlinePlayerKey struct {
+	serverKey string
+	playerID  string
+}
+
+// An OnlinePlayers implements storage.OnlinePlayersStore
+type OnlinePlayers struct {
+	mu      sync.RWMutex
+	players map[onlinePlayerKey]types.OnlinePlayer
+}
+
+func newOnlinePlayers() *OnlinePlayers {
+	return &OnlinePlayers{players: map[onlinePlayerKey]types.OnlinePlayer{}}
+}
+
+// Clear implements storage.OnlinePlayersStore.Clear
+func (op *OnlinePlayers) Clear(serverKey string, before time.Time) error {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+
+	for key, player := range op.players {
+		if key.serverKey == serverKey && player.ConnectedAt.Before(before) {
+			delete(op.players, key)
+		}
+	}
+	return nil
+}
+
+// Connect implements storage.OnlinePlayersStore.Connect
+func (op *OnlinePlayers) Connect(player types.OnlinePlayer) error {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+
+	key := onlinePlayerKey{serverKey: player.ServerKey, playerID: player.PlayerID}
+	if existing, ok := op.players[key]; ok && existing.ConnectedAt.After(player.ConnectedAt) {
+		return nil
+	}
+	var stored types.OnlinePlayer
+	clone(player, &stored)
+	op.players[key] = stored
+	return nil
+}
+
+// Disconnect implements storage.OnlinePlayersStore.Disconnect
+func (op *OnlinePlayers) Disconnect(serverKey, playerID string, at time.Time) error {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+
+	key := onlinePlayerKey{serverKey: serverKey, playerID: playerID}
+	if existing, ok := op.players[key]; ok && !existing.ConnectedAt.After(at) {
+		delete(op.players, key)
+	}
+	return nil
+}
+
+// Online implements storage.OnlinePlayersStore.Online
+func (op *OnlinePlayers) Online(serverKey string) ([]types.OnlinePlayer, error) {
+	op.mu.RLock()
+	defer op.mu.RUnlock()
+
+	var players []types.OnlinePlayer
+	for key, player := range op.players {
+		if key.serverKey == serverKey {
+			players = append(players, player)
+		}
+	}
+	sort.Slice(players, func(i, j int) bool {
+		if players[i].ConnectedAt.Equal(players[j].ConnectedAt) {
+			return players[i].PlayerID < players[j].PlayerID
+		}
+		return players[i].ConnectedAt.Before(players[j].ConnectedAt)
+	})
+	return players, nil
+}
@@ -0,0 +1,154 @@
+package memory
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/types"
+)
+
+// A RaidAlerts implements storage.RaidAlertsStore
+type RaidAlerts struct {
+	mu     sync.RWMutex
+	alerts []types.RaidAlert
+	users  storage.UsersStore
+}
+
+func newRaidAlerts(users storage.UsersStore) *RaidAlerts {
+	return &RaidAlerts{users: users}
+}
+
+func (r *RaidAlerts) index(id bson.ObjectId) int {
+	for i := range r.alerts {
+		if r.alerts[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// AddInfo implements storage.RaidAlertsStore.AddInfo
+func (r *RaidAlerts) AddInfo(alertIn, invalidIn time.Duration, ed types.EntityDeath) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, pid := range ed.OwnerIDs {
+		// Checking if the user exists, just bail if not
+		_, err := r.users.GetByPlayerID(pid)
+		if err != nil {
+			continue
+		}
+
+		now := time.Now().UTC()
+
+		i := -1
+		for j := range r.alerts {
+			a := r.alerts[j]
+			if a.PlayerID == pid && a.ServerKey == ed.ServerKey && a.ValidUntil.After(now) {
+				i = j
+				break
+			}
+		}
+
+		if i == -1 {
+			r.alerts = append(r.alerts, types.RaidAlert{
+				ID:         bson.NewObjectId(),
+				PlayerID:   pid,
+				AlertAt:    now.Add(alertIn),
+				ServerName: ed.ServerName,
+				ServerKey:  ed.ServerKey,
+				Items:      map[string]int{},
+				StartedAt:  now,
+			})
+			i = len(r.alerts) - 1
+		}
+
+		alert := &r.alerts[i]
+		alert.ValidUntil = now.Add(invalidIn)
+		alert.LastEventAt = now
+		alert.Items[ed.Name]++
+		if !containsString(alert.GridPositions, ed.GridPos) {
+			alert.GridPositions = append(alert.GridPositions, ed.GridPos)
+		}
+	}
+	return nil
+}
+
+// GetReady implements storage.RaidAlertsStore.GetReady
+func (r *RaidAlerts) GetReady() ([]types.RaidAlert, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var alerts []types.RaidAlert
+	now := time.Now().UTC()
+	for i := range r.alerts {
+		if r.alerts[i].AlertAt.After(now) {
+			continue
+		}
+		var alert types.RaidAlert
+		clone(r.alerts[i], &alert)
+		alerts = append(alerts, alert)
+	}
+	return alerts, nil
+}
+
+// Remove implements storage.RaidAlertsStore.Remove
+func (r *RaidAlerts) Remove(alert types.RaidAlert) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.index(alert.ID)
+	if i == -1 {
+		return storage.ErrNotFound
+	}
+	r.alerts = append(r.alerts[:i], r.alerts[i+1:]...)
+	return nil
+}
+
+// RemoveByServer implements storage.RaidAlertsStore.RemoveByServer
+func (r *RaidAlerts) RemoveByServer(serverKey string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	alerts := r.alerts[:0]
+	for _, alert := range r.alerts {
+		if alert.ServerKey != serverKey {
+			alerts = append(alerts, alert)
+		}
+	}
+	r.alerts = alerts
+	return nil
+}
+
+func (r *RaidAlerts) IncrementNotifyCount(ra types.RaidAlert) error {
+	icount := ra.ItemCount()
+
+	if icount == ra.NotifyCount {
+		return errors.New("notification count does not need to be incremented")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.index(ra.ID)
+	if i == -1 || r.alerts[i].NotifyCount != ra.NotifyCount {
+		return storage.ErrStale
+	}
+	r.alerts[i].NotifyCount = icount
+	return nil
+}
+
+func (r *RaidAlerts) SetMessageID(ra types.RaidAlert, messageID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.index(ra.ID)
+	if i == -1 {
+		return storage.ErrNotFound
+	}
+	r.alerts[i].MessageID = messageID
+	return nil
+}
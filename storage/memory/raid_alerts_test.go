@@ -0,0 +1,57 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/poundbot/poundbot/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRaidAlerts_AddInfo(t *testing.T) {
+	t.Parallel()
+
+	users := newUsers()
+	users.UpsertPlayer(types.DiscordAuth{PlayerID: "2", DiscordInfo: types.DiscordInfo{Snowflake: "did2"}})
+	users.UpsertPlayer(types.DiscordAuth{PlayerID: "3", DiscordInfo: types.DiscordInfo{Snowflake: "did3"}})
+
+	ra := newRaidAlerts(users)
+
+	ra.AddInfo(0, time.Hour, types.EntityDeath{ServerKey: "abcd", Name: "thing", GridPos: "D8", OwnerIDs: []string{"1", "2"}})
+	ra.AddInfo(0, time.Hour, types.EntityDeath{ServerKey: "abcd", Name: "thing", GridPos: "D7", OwnerIDs: []string{"2"}})
+	ra.AddInfo(0, time.Hour, types.EntityDeath{ServerKey: "abcd", Name: "other", GridPos: "D7", OwnerIDs: []string{"2", "3"}})
+
+	alerts, err := ra.GetReady()
+	assert.Nil(t, err)
+	if !assert.Len(t, alerts, 2) {
+		return
+	}
+
+	assert.Equal(t, "2", alerts[0].PlayerID)
+	assert.Equal(t, []string{"D8", "D7"}, alerts[0].GridPositions)
+	assert.Equal(t, map[string]int{"thing": 2, "other": 1}, alerts[0].Items)
+
+	assert.Equal(t, "3", alerts[1].PlayerID)
+	assert.Equal(t, []string{"D7"}, alerts[1].GridPositions)
+	assert.Equal(t, map[string]int{"other": 1}, alerts[1].Items)
+}
+
+func TestRaidAlerts_IncrementNotifyCount(t *testing.T) {
+	t.Parallel()
+
+	users := newUsers()
+	users.UpsertPlayer(types.DiscordAuth{PlayerID: "1", DiscordInfo: types.DiscordInfo{Snowflake: "did1"}})
+
+	ra := newRaidAlerts(users)
+	ra.AddInfo(0, time.Hour, types.EntityDeath{ServerKey: "abcd", Name: "thing", OwnerIDs: []string{"1"}})
+
+	alerts, _ := ra.GetReady()
+	alert := alerts[0]
+
+	assert.Nil(t, ra.IncrementNotifyCount(alert), "first increment should succeed")
+	assert.NotNil(t, ra.IncrementNotifyCount(alert), "stale increment should fail")
+
+	alerts, _ = ra.GetReady()
+	assert.Equal(t, 1, alerts[0].NotifyCount)
+	assert.NotNil(t, ra.IncrementNotifyCount(alerts[0]), "increment with nothing new should fail")
+}
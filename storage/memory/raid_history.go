@@ -0,0 +1,64 @@
+package memory
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/types"
+)
+
+// A RaidHistory implements storage.RaidHistoryStore
+type RaidHistory struct {
+	mu    sync.RWMutex
+	raids []types.RaidHistory
+}
+
+func newRaidHistory() *RaidHistory {
+	return &RaidHistory{}
+}
+
+// Add implements storage.RaidHistoryStore.Add
+func (r *RaidHistory) Add(rh types.RaidHistory) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var stored types.RaidHistory
+	clone(rh, &stored)
+
+	for i := range r.raids {
+		if r.raids[i].ID == rh.ID {
+			r.raids[i] = stored
+			return nil
+		}
+	}
+	r.raids = append(r.raids, stored)
+	return nil
+}
+
+// Find implements storage.RaidHistoryStore.Find
+func (r *RaidHistory) Find(q storage.RaidHistoryQuery) ([]types.RaidHistory, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var raids []types.RaidHistory
+	for _, rh := range r.raids {
+		switch {
+		case len(q.ServerKey) != 0 && rh.ServerKey != q.ServerKey,
+			len(q.ServerName) != 0 && !strings.EqualFold(rh.ServerName, q.ServerName),
+			q.PlayerIDs != nil && !containsString(q.PlayerIDs, rh.PlayerID),
+			rh.EndedAt.Before(q.Since):
+			continue
+		}
+		var raid types.RaidHistory
+		clone(rh, &raid)
+		raids = append(raids, raid)
+	}
+
+	sort.SliceStable(raids, func(i, j int) bool { return raids[i].EndedAt.After(raids[j].EndedAt) })
+	if q.Limit > 0 && len(raids) > q.Limit {
+		raids = raids[:q.Limit]
+	}
+	return raids, nil
+}
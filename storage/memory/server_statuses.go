@@ -0,0 +1,80 @@
+package memory
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/types"
+)
+
+// A ServerStatuses implements storage.ServerStatusStore
+type ServerStatuses struct {
+	mu       sync.RWMutex
+	statuses map[string]types.ServerStatus
+}
+
+func newServerStatuses() *ServerStatuses {
+	return &ServerStatuses{statuses: map[string]types.ServerStatus{}}
+}
+
+// Get implements storage.ServerStatusStore.Get
+func (ss *ServerStatuses) Get(serverKey string) (types.ServerStatus, error) {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+
+	status, ok := ss.statuses[serverKey]
+	if !ok {
+		return types.ServerStatus{}, storage.ErrNotFound
+	}
+	return status, nil
+}
+
+// MarkOffline implements storage.ServerStatusStore.MarkOffline
+func (ss *ServerStatuses) MarkOffline(before time.Time) ([]types.ServerStatus, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	var marked []types.ServerStatus
+	for key, status := range ss.statuses {
+		if status.Offline || !status.ReportedAt.Before(before) {
+			continue
+		}
+		status.Offline = true
+		ss.statuses[key] = status
+		marked = append(marked, status)
+	}
+	sort.Slice(marked, func(i, j int) bool { return marked[i].ServerKey < marked[j].ServerKey })
+	return marked, nil
+}
+
+// Report implements storage.ServerStatusStore.Report
+func (ss *ServerStatuses) Report(status types.ServerStatus) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	existing := ss.statuses[status.ServerKey]
+	var stored types.ServerStatus
+	clone(status, &stored)
+	stored.Offline = false
+	stored.ChannelID = existing.ChannelID
+	stored.MessageID = existing.MessageID
+	ss.statuses[status.ServerKey] = stored
+	return nil
+}
+
+// SetMessage implements storage.ServerStatusStore.SetMessage
+func (ss *ServerStatuses) SetMessage(serverKey, channelID, messageID string) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	status, ok := ss.statuses[serverKey]
+	if !ok {
+		return storage.ErrNotFound
+	}
+	status.ChannelID = channelID
+	status.MessageID = messageID
+	ss.statuses[serverKey] = status
+	return nil
+}
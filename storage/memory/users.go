@@ -0,0 +1,148 @@
+package memory
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/types"
+)
+
+// A Users implements storage.UsersStore
+type Users struct {
+	mu    sync.RWMutex
+	users []types.User
+}
+
+func newUsers() *Users {
+	return &Users{}
+}
+
+func (u *Users) playerIndex(playerID string) int {
+	for i := range u.users {
+		if containsString(u.users[i].PlayerIDs, playerID) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (u *Users) discordIndex(snowflake string) int {
+	for i := range u.users {
+		if u.users[i].Snowflake == snowflake {
+			return i
+		}
+	}
+	return -1
+}
+
+// All implements storage.UsersStore.All
+func (u *Users) All(users *[]types.User) error {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	var out []types.User
+	for i := range u.users {
+		var user types.User
+		clone(u.users[i], &user)
+		out = append(out, user)
+	}
+	*users = out
+	return nil
+}
+
+func (u *Users) GetByPlayerID(gameUserID string) (types.User, error) {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	var user types.User
+	i := u.playerIndex(gameUserID)
+	if i == -1 {
+		return user, storage.ErrNotFound
+	}
+	clone(u.users[i], &user)
+	return user, nil
+}
+
+func (u *Users) GetByDiscordID(snowflake string) (types.User, error) {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	var user types.User
+	i := u.discordIndex(snowflake)
+	if i == -1 {
+		return user, storage.ErrNotFound
+	}
+	clone(u.users[i], &user)
+	return user, nil
+}
+
+func (u *Users) GetPlayerIDsByDiscordIDs(snowflakes []string) ([]string, error) {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	playerIDs := []string{}
+	for i := range u.users {
+		if !containsString(snowflakes, u.users[i].Snowflake) {
+			continue
+		}
+		for _, pID := range u.users[i].PlayerIDs {
+			if !containsString(playerIDs, pID) {
+				playerIDs = append(playerIDs, pID)
+			}
+		}
+	}
+	return playerIDs, nil
+}
+
+func (u *Users) UpsertPlayer(info storage.UserInfoGetter) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	// Player IDs are unique across users
+	if i := u.playerIndex(info.GetPlayerID()); i != -1 && u.users[i].Snowflake != info.GetDiscordID() {
+		return fmt.Errorf("memory: player id is already registered to another user: %w", storage.ErrConflict)
+	}
+
+	now := time.Now().UTC()
+	i := u.discordIndex(info.GetDiscordID())
+	if i == -1 {
+		user := types.User{Timestamp: types.Timestamp{CreatedAt: now}}
+		user.Snowflake = info.GetDiscordID()
+		u.users = append(u.users, user)
+		i = len(u.users) - 1
+	}
+
+	u.users[i].UpdatedAt = now
+	if !containsString(u.users[i].PlayerIDs, info.GetPlayerID()) {
+		u.users[i].PlayerIDs = append(u.users[i].PlayerIDs, info.GetPlayerID())
+	}
+
+	return nil
+}
+
+func (u *Users) RemovePlayerID(snowflake, playerID string) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	i := u.discordIndex(snowflake)
+	if i == -1 {
+		return storage.ErrNotFound
+	}
+
+	if playerID == "all" {
+		u.users = append(u.users[:i], u.users[i+1:]...)
+		return nil
+	}
+
+	playerIDs := []string{}
+	for _, pID := range u.users[i].PlayerIDs {
+		if pID != playerID {
+			playerIDs = append(playerIDs, pID)
+		}
+	}
+	u.users[i].PlayerIDs = playerIDs
+	u.users[i].UpdatedAt = time.Now().UTC()
+	return nil
+}
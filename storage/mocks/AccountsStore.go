@@ -4,6 +4,7 @@ package mocks
 
 import mock "github.com/stretchr/testify/mock"
 
+import time "time"
 import types "github.com/poundbot/poundbot/types"
 
 // AccountsStore is an autogenerated mock type for the AccountsStore type
@@ -109,6 +110,20 @@ func (_m *AccountsStore) GetByServerKey(serverKey string) (types.Account, error)
 	return r0, r1
 }
 
+// RecordWipe provides a mock function with given fields: serverKey, wipedAt, scheduledBefore
+func (_m *AccountsStore) RecordWipe(serverKey string, wipedAt time.Time, scheduledBefore time.Time) error {
+	ret := _m.Called(serverKey, wipedAt, scheduledBefore)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, time.Time, time.Time) error); ok {
+		r0 = rf(serverKey, wipedAt, scheduledBefore)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Remove provides a mock function with given fields: snowflake
 func (_m *AccountsStore) Remove(snowflake string) error {
 	ret := _m.Called(snowflake)
@@ -248,3 +263,31 @@ func (_m *AccountsStore) UpsertBase(_a0 types.BaseAccount) error {
 
 	return r0
 }
+
+// WithOfflineAlerts provides a mock function with given fields: _a0
+func (_m *AccountsStore) WithOfflineAlerts(_a0 *[]types.Account) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*[]types.Account) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// WithScheduledWipes provides a mock function with given fields: after, accounts
+func (_m *AccountsStore) WithScheduledWipes(after time.Time, accounts *[]types.Account) error {
+	ret := _m.Called(after, accounts)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(time.Time, *[]types.Account) error); ok {
+		r0 = rf(after, accounts)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
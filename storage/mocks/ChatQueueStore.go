@@ -3,6 +3,7 @@
 package mocks
 
 import mock "github.com/stretchr/testify/mock"
+import storage "github.com/poundbot/poundbot/storage"
 
 import time "time"
 import types "github.com/poundbot/poundbot/types"
@@ -12,20 +13,34 @@ type ChatQueueStore struct {
 	mock.Mock
 }
 
-// GetGameServerMessage provides a mock function with given fields: serverKey, tag, timeout
-func (_m *ChatQueueStore) GetGameServerMessage(serverKey string, tag string, timeout time.Duration) (types.ChatMessage, bool) {
-	ret := _m.Called(serverKey, tag, timeout)
+// AckMessage provides a mock function with given fields: serverKey, id
+func (_m *ChatQueueStore) AckMessage(serverKey string, id string) error {
+	ret := _m.Called(serverKey, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(serverKey, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetGameServerMessage provides a mock function with given fields: serverKey, tag, lease, timeout
+func (_m *ChatQueueStore) GetGameServerMessage(serverKey string, tag string, lease time.Duration, timeout time.Duration) (types.ChatMessage, bool) {
+	ret := _m.Called(serverKey, tag, lease, timeout)
 
 	var r0 types.ChatMessage
-	if rf, ok := ret.Get(0).(func(string, string, time.Duration) types.ChatMessage); ok {
-		r0 = rf(serverKey, tag, timeout)
+	if rf, ok := ret.Get(0).(func(string, string, time.Duration, time.Duration) types.ChatMessage); ok {
+		r0 = rf(serverKey, tag, lease, timeout)
 	} else {
 		r0 = ret.Get(0).(types.ChatMessage)
 	}
 
 	var r1 bool
-	if rf, ok := ret.Get(1).(func(string, string, time.Duration) bool); ok {
-		r1 = rf(serverKey, tag, timeout)
+	if rf, ok := ret.Get(1).(func(string, string, time.Duration, time.Duration) bool); ok {
+		r1 = rf(serverKey, tag, lease, timeout)
 	} else {
 		r1 = ret.Get(1).(bool)
 	}
@@ -46,3 +61,26 @@ func (_m *ChatQueueStore) InsertMessage(message types.ChatMessage) error {
 
 	return r0
 }
+
+// ReadyKeys provides a mock function with given fields:
+func (_m *ChatQueueStore) ReadyKeys() ([]storage.ChatQueueKey, error) {
+	ret := _m.Called()
+
+	var r0 []storage.ChatQueueKey
+	if rf, ok := ret.Get(0).(func() []storage.ChatQueueKey); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.ChatQueueKey)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -11,6 +11,20 @@ type DiscordAuthsStore struct {
 	mock.Mock
 }
 
+// All provides a mock function with given fields: _a0
+func (_m *DiscordAuthsStore) All(_a0 *[]types.DiscordAuth) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*[]types.DiscordAuth) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // GetByDiscordID provides a mock function with given fields: snowflake
 func (_m *DiscordAuthsStore) GetByDiscordID(snowflake string) (types.DiscordAuth, error) {
 	ret := _m.Called(snowflake)
@@ -53,6 +67,48 @@ func (_m *DiscordAuthsStore) GetByDiscordName(discordName string) (types.Discord
 	return r0, r1
 }
 
+// GetByPlayerID provides a mock function with given fields: playerID
+func (_m *DiscordAuthsStore) GetByPlayerID(playerID string) (types.DiscordAuth, error) {
+	ret := _m.Called(playerID)
+
+	var r0 types.DiscordAuth
+	if rf, ok := ret.Get(0).(func(string) types.DiscordAuth); ok {
+		r0 = rf(playerID)
+	} else {
+		r0 = ret.Get(0).(types.DiscordAuth)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(playerID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// IncrementAttempts provides a mock function with given fields: playerID
+func (_m *DiscordAuthsStore) IncrementAttempts(playerID string) (int, error) {
+	ret := _m.Called(playerID)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(string) int); ok {
+		r0 = rf(playerID)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(playerID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Remove provides a mock function with given fields: _a0
 func (_m *DiscordAuthsStore) Remove(_a0 storage.UserInfoGetter) error {
 	ret := _m.Called(_a0)
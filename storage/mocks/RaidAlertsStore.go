@@ -77,6 +77,20 @@ func (_m *RaidAlertsStore) Remove(_a0 types.RaidAlert) error {
 	return r0
 }
 
+// RemoveByServer provides a mock function with given fields: serverKey
+func (_m *RaidAlertsStore) RemoveByServer(serverKey string) error {
+	ret := _m.Called(serverKey)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(serverKey)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // SetMessageID provides a mock function with given fields: _a0, _a1
 func (_m *RaidAlertsStore) SetMessageID(_a0 types.RaidAlert, _a1 string) error {
 	ret := _m.Called(_a0, _a1)
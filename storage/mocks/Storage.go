@@ -26,6 +26,22 @@ func (_m *Storage) Accounts() storage.AccountsStore {
 	return r0
 }
 
+// ChatLog provides a mock function with given fields:
+func (_m *Storage) ChatLog() storage.ChatLogStore {
+	ret := _m.Called()
+
+	var r0 storage.ChatLogStore
+	if rf, ok := ret.Get(0).(func() storage.ChatLogStore); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(storage.ChatLogStore)
+		}
+	}
+
+	return r0
+}
+
 // ChatQueue provides a mock function with given fields:
 func (_m *Storage) ChatQueue() storage.ChatQueueStore {
 	ret := _m.Called()
@@ -79,11 +95,59 @@ func (_m *Storage) DiscordAuths() storage.DiscordAuthsStore {
 	return r0
 }
 
+// GameMessages provides a mock function with given fields:
+func (_m *Storage) GameMessages() storage.GameMessagesStore {
+	ret := _m.Called()
+
+	var r0 storage.GameMessagesStore
+	if rf, ok := ret.Get(0).(func() storage.GameMessagesStore); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(storage.GameMessagesStore)
+		}
+	}
+
+	return r0
+}
+
 // Init provides a mock function with given fields:
 func (_m *Storage) Init() {
 	_m.Called()
 }
 
+// MessageLocks provides a mock function with given fields:
+func (_m *Storage) MessageLocks() storage.MessageLocksStore {
+	ret := _m.Called()
+
+	var r0 storage.MessageLocksStore
+	if rf, ok := ret.Get(0).(func() storage.MessageLocksStore); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(storage.MessageLocksStore)
+		}
+	}
+
+	return r0
+}
+
+// OnlinePlayers provides a mock function with given fields:
+func (_m *Storage) OnlinePlayers() storage.OnlinePlayersStore {
+	ret := _m.Called()
+
+	var r0 storage.OnlinePlayersStore
+	if rf, ok := ret.Get(0).(func() storage.OnlinePlayersStore); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(storage.OnlinePlayersStore)
+		}
+	}
+
+	return r0
+}
+
 // RaidAlerts provides a mock function with given fields:
 func (_m *Storage) RaidAlerts() storage.RaidAlertsStore {
 	ret := _m.Called()
@@ -100,6 +164,38 @@ func (_m *Storage) RaidAlerts() storage.RaidAlertsStore {
 	return r0
 }
 
+// RaidHistory provides a mock function with given fields:
+func (_m *Storage) RaidHistory() storage.RaidHistoryStore {
+	ret := _m.Called()
+
+	var r0 storage.RaidHistoryStore
+	if rf, ok := ret.Get(0).(func() storage.RaidHistoryStore); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(storage.RaidHistoryStore)
+		}
+	}
+
+	return r0
+}
+
+// ServerStatuses provides a mock function with given fields:
+func (_m *Storage) ServerStatuses() storage.ServerStatusStore {
+	ret := _m.Called()
+
+	var r0 storage.ServerStatusStore
+	if rf, ok := ret.Get(0).(func() storage.ServerStatusStore); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(storage.ServerStatusStore)
+		}
+	}
+
+	return r0
+}
+
 // Users provides a mock function with given fields:
 func (_m *Storage) Users() storage.UsersStore {
 	ret := _m.Called()
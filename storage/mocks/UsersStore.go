@@ -11,6 +11,20 @@ type UsersStore struct {
 	mock.Mock
 }
 
+// All provides a mock function with given fields: _a0
+func (_m *UsersStore) All(_a0 *[]types.User) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*[]types.User) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // GetByDiscordID provides a mock function with given fields: snowflake
 func (_m *UsersStore) GetByDiscordID(snowflake string) (types.User, error) {
 	ret := _m.Called(snowflake)
@@ -20,19 +20,35 @@ type Accounts struct {
 }
 
 func (s Accounts) All(accounts *[]types.Account) error {
-	return s.collection.Find(bson.M{}).All(accounts)
+	return storageError(s.collection.Find(bson.M{}).All(accounts))
+}
+
+// WithOfflineAlerts implements storage.AccountsStore.WithOfflineAlerts
+func (s Accounts) WithOfflineAlerts(accounts *[]types.Account) error {
+	return storageError(s.collection.Find(bson.M{
+		"disabled":             bson.M{"$ne": true},
+		"servers.offlinealert": bson.M{"$exists": true, "$ne": ""},
+	}).All(accounts))
+}
+
+// WithScheduledWipes implements storage.AccountsStore.WithScheduledWipes
+func (s Accounts) WithScheduledWipes(after time.Time, accounts *[]types.Account) error {
+	return storageError(s.collection.Find(bson.M{
+		"disabled":         bson.M{"$ne": true},
+		"servers.nextwipe": bson.M{"$gt": after},
+	}).All(accounts))
 }
 
 func (s Accounts) GetByDiscordGuild(key string) (types.Account, error) {
 	var account types.Account
 	err := s.collection.Find(bson.M{accountsKeyField: key}).One(&account)
-	return account, err
+	return account, storageError(err)
 }
 
 func (s Accounts) GetByServerKey(key string) (types.Account, error) {
 	var account types.Account
 	err := s.collection.Find(bson.M{serverKeyField: key}).One(&account)
-	return account, err
+	return account, storageError(err)
 }
 
 func (s Accounts) UpsertBase(account types.BaseAccount) error {
@@ -43,62 +59,90 @@ func (s Accounts) UpsertBase(account types.BaseAccount) error {
 			"$set":         account,
 		},
 	)
-	return err
+	return storageError(err)
 }
 
 func (s Accounts) Remove(key string) error {
-	return s.collection.Update(
+	err := s.collection.Update(
 		bson.M{accountsKeyField: key},
 		bson.M{"$set": bson.M{"disabled": true}},
 	)
+	return storageError(err)
 }
 
 func (s Accounts) AddClan(serverKey string, clan types.Clan) error {
-	return s.collection.Update(
+	err := s.collection.Update(
 		bson.M{serverKeyField: serverKey},
 		bson.M{
 			"$push": bson.M{"servers.$.clans": clan},
 		},
 	)
+	return storageError(err)
 }
 
 func (s Accounts) RemoveClan(serverKey, clanTag string) error {
-	return s.collection.Update(
+	err := s.collection.Update(
 		bson.M{serverKeyField: serverKey, "servers.clans.tag": clanTag},
 		bson.M{"$pull": bson.M{"servers.$.clans": bson.M{"tag": clanTag}}},
 	)
+	return storageError(err)
 }
 
 func (s Accounts) SetClans(serverKey string, clans []types.Clan) error {
-	return s.collection.Update(
+	err := s.collection.Update(
 		bson.M{serverKeyField: serverKey},
 		bson.M{"$set": bson.M{"servers.$.clans": clans}},
 	)
+	return storageError(err)
+}
+
+// RecordWipe implements storage.AccountsStore.RecordWipe
+func (s Accounts) RecordWipe(serverKey string, wipedAt, scheduledBefore time.Time) error {
+	err := s.collection.Update(
+		bson.M{serverKeyField: serverKey},
+		bson.M{"$set": bson.M{"servers.$.clans": []types.Clan{}, "servers.$.lastwipe": wipedAt}},
+	)
+	if err != nil {
+		return storageError(err)
+	}
+
+	err = s.collection.Update(
+		bson.M{"servers": bson.M{"$elemMatch": bson.M{"key": serverKey, "nextwipe": bson.M{"$lt": scheduledBefore}}}},
+		bson.M{"$unset": bson.M{"servers.$.nextwipe": ""}},
+	)
+	if err == mgo.ErrNotFound {
+		// No wipe was scheduled before then
+		return nil
+	}
+	return storageError(err)
 }
 
 func (s Accounts) AddServer(snowflake string, server types.AccountServer) error {
 	server.CreatedAt = iclock().Now().UTC()
-	return s.collection.Update(
+	err := s.collection.Update(
 		bson.M{accountsKeyField: snowflake},
 		bson.M{"$push": bson.M{"servers": server}},
 	)
+	return storageError(err)
 }
 
 func (s Accounts) RemoveServer(snowflake, serverKey string) error {
-	return s.collection.Update(
+	err := s.collection.Update(
 		bson.M{serverKeyField: serverKey},
 		bson.M{"$pull": bson.M{"servers": bson.M{"key": serverKey}}},
 	)
+	return storageError(err)
 }
 
 func (s Accounts) UpdateServer(snowflake, oldKey string, server types.AccountServer) error {
-	return s.collection.Update(
+	err := s.collection.Update(
 		bson.M{
 			accountsKeyField: snowflake,
 			serverKeyField:   oldKey,
 		},
 		bson.M{"$set": bson.M{"servers.$": server}},
 	)
+	return storageError(err)
 }
 
 func (s Accounts) RemoveNotInDiscordGuildList(guilds []types.BaseAccount) error {
@@ -126,7 +170,7 @@ func (s Accounts) RemoveNotInDiscordGuildList(guilds []types.BaseAccount) error
 			},
 		)
 		if err != nil {
-			return fmt.Errorf("error updating guild: %s", err)
+			return fmt.Errorf("error updating guild: %w", storageError(err))
 		}
 	}
 
@@ -142,7 +186,7 @@ func (s Accounts) RemoveNotInDiscordGuildList(guilds []types.BaseAccount) error
 }
 
 func (s Accounts) SetRegisteredPlayerIDs(accoutID string, playerIDs []string) error {
-	return s.collection.Update(
+	err := s.collection.Update(
 		bson.M{accountsKeyField: accoutID},
 		bson.M{
 			"$set": bson.M{
@@ -150,10 +194,11 @@ func (s Accounts) SetRegisteredPlayerIDs(accoutID string, playerIDs []string) er
 			},
 		},
 	)
+	return storageError(err)
 }
 
 func (s Accounts) AddRegisteredPlayerIDs(accoutID string, playerIDs []string) error {
-	return s.collection.Update(
+	err := s.collection.Update(
 		bson.M{accountsKeyField: accoutID},
 		bson.M{
 			"$addToSet": bson.M{
@@ -163,18 +208,20 @@ func (s Accounts) AddRegisteredPlayerIDs(accoutID string, playerIDs []string) er
 			},
 		},
 	)
+	return storageError(err)
 }
 
 func (s Accounts) RemoveRegisteredPlayerIDs(accoutID string, playerIDs []string) error {
-	return s.collection.Update(
+	err := s.collection.Update(
 		bson.M{accountsKeyField: accoutID},
 		bson.M{"$pullAll": bson.M{"registeredplayerids": playerIDs}},
 	)
+	return storageError(err)
 }
 
 func (s Accounts) Touch(serverKey string) error {
 	now := iclock().Now().UTC()
-	return s.collection.Update(
+	err := s.collection.Update(
 		bson.M{serverKeyField: serverKey},
 		bson.M{
 			"$set": bson.M{
@@ -183,4 +230,5 @@ func (s Accounts) Touch(serverKey string) error {
 			},
 		},
 	)
+	return storageError(err)
 }
@@ -0,0 +1,56 @@
+package mongodb
+
+import (
+	"time"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/types"
+)
+
+// A ChatLog implements storage.ChatLogStore
+type ChatLog struct {
+	collection *mgo.Collection
+}
+
+// Add implements storage.ChatLogStore.Add
+func (c ChatLog) Add(entry types.ChatLogEntry) error {
+	return storageError(c.collection.Insert(entry))
+}
+
+// Find implements storage.ChatLogStore.Find
+func (c ChatLog) Find(q storage.ChatLogQuery) ([]types.ChatLogEntry, error) {
+	selector := bson.M{}
+	if len(q.ServerKeys) != 0 {
+		selector["serverkey"] = bson.M{"$in": q.ServerKeys}
+	}
+
+	var from []bson.M
+	if len(q.Snowflake) != 0 {
+		from = append(from, bson.M{"snowflake": q.Snowflake})
+	}
+	if len(q.PlayerIDs) != 0 {
+		from = append(from, bson.M{"playerid": bson.M{"$in": q.PlayerIDs}})
+	}
+	if len(from) != 0 {
+		selector["$or"] = from
+	}
+
+	if !q.Since.IsZero() {
+		selector["sentat"] = bson.M{"$gte": q.Since}
+	}
+
+	var entries []types.ChatLogEntry
+	err := c.collection.Find(selector).Sort("-sentat").Limit(q.Limit).All(&entries)
+	return entries, storageError(err)
+}
+
+// RemoveBefore implements storage.ChatLogStore.RemoveBefore
+func (c ChatLog) RemoveBefore(t time.Time) (int, error) {
+	info, err := c.collection.RemoveAll(bson.M{"sentat": bson.M{"$lt": t}})
+	if err != nil {
+		return 0, storageError(err)
+	}
+	return info.Removed, nil
+}
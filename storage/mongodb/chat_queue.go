@@ -5,49 +5,133 @@ import (
 
 	"github.com/globalsign/mgo"
 	"github.com/globalsign/mgo/bson"
+	"github.com/poundbot/poundbot/storage"
 	"github.com/poundbot/poundbot/types"
 )
 
+// chatQueuePoll is how often waiting requests check for new messages
+const chatQueuePoll = 500 * time.Millisecond
+
 type ChatQueue struct {
 	collection *mgo.Collection
 }
 
+// InsertMessage implements storage.ChatQueueStore.InsertMessage
 func (cq ChatQueue) InsertMessage(m types.ChatMessage) error {
-	return cq.collection.Insert(m)
+	if len(m.ID) == 0 {
+		m.ID = bson.NewObjectId()
+	}
+	m.QueuedAt = time.Now().UTC()
+	m.LeasedUntil = time.Time{}
+
+	if err := cq.collection.Insert(m); err != nil {
+		return storageError(err)
+	}
+
+	// Drop the server's oldest messages over the limit
+	var oldest []types.ChatMessage
+	err := cq.collection.Find(bson.M{"serverkey": m.ServerKey}).
+		Sort("-queuedat", "-_id").
+		Skip(storage.ChatQueueServerLimit).
+		Select(bson.M{"_id": 1}).
+		All(&oldest)
+	if err != nil {
+		return storageError(err)
+	}
+	if len(oldest) == 0 {
+		return nil
+	}
+
+	ids := make([]bson.ObjectId, len(oldest))
+	for i := range oldest {
+		ids[i] = oldest[i].ID
+	}
+	_, err = cq.collection.RemoveAll(bson.M{"_id": bson.M{"$in": ids}})
+	return storageError(err)
 }
 
-func (cq ChatQueue) GetGameServerMessage(sk, tag string, to time.Duration) (types.ChatMessage, bool) {
+// next leases the oldest available message for the server and tag
+func (cq ChatQueue) next(sk, tag string, lease time.Duration) (types.ChatMessage, bool, error) {
+	now := time.Now().UTC()
+	change := mgo.Change{Remove: true}
+	if lease != 0 {
+		change = mgo.Change{
+			Update:    bson.M{"$set": bson.M{"leaseduntil": now.Add(lease)}},
+			ReturnNew: true,
+		}
+	}
+
+	var cm types.ChatMessage
+	_, err := cq.collection.Find(bson.M{
+		"serverkey":   sk,
+		"tag":         tag,
+		"leaseduntil": bson.M{"$lte": now},
+	}).Sort("queuedat", "_id").Apply(change, &cm)
+	if err == mgo.ErrNotFound {
+		return cm, false, nil
+	}
+	if err != nil {
+		return cm, false, err
+	}
+	return cm, true, nil
+}
+
+// GetGameServerMessage implements storage.ChatQueueStore.GetGameServerMessage
+func (cq ChatQueue) GetGameServerMessage(sk, tag string, lease, to time.Duration) (types.ChatMessage, bool) {
 	sess := cq.collection.Database.Session.Copy()
 	defer sess.Close()
+	cq.collection = cq.collection.With(sess)
 
-	iter := cq.collection.With(sess).Find(
-		bson.M{
-			"serverkey":    sk,
-			"tag":          tag,
-			"senttoserver": false,
-		},
-	).Tail(to)
-	defer iter.Close()
+	timer := time.NewTimer(to)
+	defer timer.Stop()
+	ticker := time.NewTicker(chatQueuePoll)
+	defer ticker.Stop()
 
-	var cm types.ChatMessage
-	for iter.Next(&cm) {
-		err := cq.collection.Update(
-			bson.M{"_id": cm.ID, "senttoserver": false},
-			bson.M{"$set": bson.M{"senttoserver": true}},
-		)
+	for {
+		cm, found, err := cq.next(sk, tag, lease)
 		if err != nil {
-			if err != mgo.ErrNotFound {
-				log.Printf("MongoDB Error updating message: %v", err)
-			}
-			continue
+			log.Printf("MongoDB: error getting message: %v", err)
+			return types.ChatMessage{}, false
+		}
+		if found {
+			return cm, true
+		}
+
+		select {
+		case <-ticker.C:
+		case <-timer.C:
+			return types.ChatMessage{}, false
 		}
-		return cm, true
+	}
+}
+
+// ReadyKeys implements storage.ChatQueueStore.ReadyKeys
+func (cq ChatQueue) ReadyKeys() ([]storage.ChatQueueKey, error) {
+	var results []struct {
+		Key struct {
+			ServerKey string `bson:"serverkey"`
+			Tag       string `bson:"tag"`
+		} `bson:"_id"`
+	}
+	err := cq.collection.Pipe([]bson.M{
+		{"$match": bson.M{"leaseduntil": bson.M{"$lte": time.Now().UTC()}}},
+		{"$group": bson.M{"_id": bson.M{"serverkey": "$serverkey", "tag": "$tag"}}},
+	}).All(&results)
+	if err != nil {
+		return nil, storageError(err)
 	}
 
-	if iter.Err() != nil {
-		log.Printf("MongoDB: error getting message: %v", iter.Err())
-		return cm, false
+	keys := make([]storage.ChatQueueKey, len(results))
+	for i, result := range results {
+		keys[i] = storage.ChatQueueKey{ServerKey: result.Key.ServerKey, Tag: result.Key.Tag}
 	}
+	return keys, nil
+}
 
-	return cm, false
+// AckMessage implements storage.ChatQueueStore.AckMessage
+func (cq ChatQueue) AckMessage(sk, id string) error {
+	if !bson.IsObjectIdHex(id) {
+		return storage.ErrNotFound
+	}
+	return storageError(cq.collection.Remove(bson.M{"_id": bson.ObjectIdHex(id), "serverkey": sk}))
 }
@@ -14,24 +14,36 @@ type DiscordAuths struct {
 	collection *mgo.Collection
 }
 
+// All implements db.DiscordAuthsStore.All
+func (d DiscordAuths) All(auths *[]types.DiscordAuth) error {
+	return storageError(d.collection.Find(bson.M{}).All(auths))
+}
+
 func (d DiscordAuths) GetByDiscordName(discordName string) (types.DiscordAuth, error) {
 	var da types.DiscordAuth
 	err := d.collection.Find(bson.M{"discordname": discordName}).One(&da)
-	return da, err
+	return da, storageError(err)
 }
 
 func (d DiscordAuths) GetByDiscordID(snowflake string) (types.DiscordAuth, error) {
 	var da types.DiscordAuth
 	err := d.collection.Find(bson.M{"snowflake": snowflake}).One(&da)
 	if err != nil {
-		return types.DiscordAuth{}, fmt.Errorf("mongodb could not find snowflake %s (%s)", snowflake, err)
+		return types.DiscordAuth{}, fmt.Errorf("mongodb could not find snowflake %s (%w)", snowflake, storageError(err))
 	}
 	return da, nil
 }
 
+// GetByPlayerID implements db.DiscordAuthsStore.GetByPlayerID
+func (d DiscordAuths) GetByPlayerID(playerID string) (types.DiscordAuth, error) {
+	var da types.DiscordAuth
+	err := d.collection.Find(bson.M{"playerid": playerID}).One(&da)
+	return da, storageError(err)
+}
+
 // Remove implements db.DiscordAuthsStore.Remove
 func (d DiscordAuths) Remove(u storage.UserInfoGetter) error {
-	return d.collection.Remove(bson.M{"playerid": u.GetPlayerID()})
+	return storageError(d.collection.Remove(bson.M{"playerid": u.GetPlayerID()}))
 }
 
 // Upsert implements db.DiscordAuthsStore.Upsert
@@ -40,5 +52,15 @@ func (d DiscordAuths) Upsert(da types.DiscordAuth) error {
 		bson.M{"playerid": da.PlayerID},
 		da,
 	)
-	return err
+	return storageError(err)
+}
+
+// IncrementAttempts implements db.DiscordAuthsStore.IncrementAttempts
+func (d DiscordAuths) IncrementAttempts(playerID string) (int, error) {
+	var da types.DiscordAuth
+	_, err := d.collection.Find(bson.M{"playerid": playerID}).Apply(mgo.Change{
+		Update:    bson.M{"$inc": bson.M{"attempts": 1}},
+		ReturnNew: true,
+	}, &da)
+	return da.Attempts, storageError(err)
 }
@@ -0,0 +1,53 @@
+package mongodb
+
+import (
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/types"
+)
+
+// A GameMessages implements storage.GameMessagesStore
+type GameMessages struct {
+	collection *mgo.Collection
+}
+
+// Add implements storage.GameMessagesStore.Add
+func (gm GameMessages) Add(m types.SentGameMessage) error {
+	if _, err := gm.collection.Upsert(bson.M{"messageid": m.MessageID}, m); err != nil {
+		return storageError(err)
+	}
+
+	// Forget the server's oldest messages over the limit
+	var oldest []types.SentGameMessage
+	err := gm.collection.Find(bson.M{"serverkey": m.ServerKey}).
+		Sort("-sentat", "-_id").
+		Skip(storage.GameMessagesServerLimit).
+		Select(bson.M{"messageid": 1}).
+		All(&oldest)
+	if err != nil {
+		return storageError(err)
+	}
+	if len(oldest) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(oldest))
+	for i := range oldest {
+		ids[i] = oldest[i].MessageID
+	}
+	_, err = gm.collection.RemoveAll(bson.M{"messageid": bson.M{"$in": ids}})
+	return storageError(err)
+}
+
+// Get implements storage.GameMessagesStore.Get
+func (gm GameMessages) Get(messageID string) (types.SentGameMessage, error) {
+	var m types.SentGameMessage
+	err := gm.collection.Find(bson.M{"messageid": messageID}).One(&m)
+	return m, storageError(err)
+}
+
+// Remove implements storage.GameMessagesStore.Remove
+func (gm GameMessages) Remove(messageID string) error {
+	return storageError(gm.collection.Remove(bson.M{"messageid": messageID}))
+}
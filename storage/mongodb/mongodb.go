@@ -8,20 +8,26 @@ import (
 	"net/url"
 
 	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
 	pblog "github.com/poundbot/poundbot/log"
 	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/types"
 )
 
 var log = pblog.Log.WithField("sys", "MONGO")
 
 const (
-	accountsCollection     = "accounts"
-	chatsCollection        = "chats"
-	discordAuthsCollection = "discord_auths"
-	raidAlertsCollection   = "raid_alerts"
-	usersCollection        = "users"
-	messageLocksCollection = "message_locks"
-	chatQueueCollection    = "chat_queue"
+	accountsCollection       = "accounts"
+	chatsCollection          = "chats"
+	discordAuthsCollection   = "discord_auths"
+	raidAlertsCollection     = "raid_alerts"
+	raidHistoryCollection    = "raid_history"
+	usersCollection          = "users"
+	messageLocksCollection   = "message_locks"
+	chatQueueCollection      = "chat_queue"
+	gameMessagesCollection   = "game_messages"
+	onlinePlayersCollection  = "online_players"
+	serverStatusesCollection = "server_statuses"
 )
 
 // A Config is exactly what it sounds like.
@@ -85,6 +91,21 @@ func (m *MongoDB) ChatQueue() storage.ChatQueueStore {
 	return ChatQueue{collection: m.session.DB(m.dbname).C(chatQueueCollection)}
 }
 
+// GameMessages implements storage.Storage.GameMessages
+func (m *MongoDB) GameMessages() storage.GameMessagesStore {
+	return GameMessages{collection: m.session.DB(m.dbname).C(gameMessagesCollection)}
+}
+
+// OnlinePlayers implements storage.Storage.OnlinePlayers
+func (m *MongoDB) OnlinePlayers() storage.OnlinePlayersStore {
+	return OnlinePlayers{collection: m.session.DB(m.dbname).C(onlinePlayersCollection)}
+}
+
+// ServerStatuses implements storage.Storage.ServerStatuses
+func (m *MongoDB) ServerStatuses() storage.ServerStatusStore {
+	return ServerStatuses{collection: m.session.DB(m.dbname).C(serverStatusesCollection)}
+}
+
 // MessageLocks implements MessageLocks
 func (m *MongoDB) MessageLocks() storage.MessageLocksStore {
 	return MessageLocks{collection: m.session.DB(m.dbname).C(messageLocksCollection)}
@@ -113,6 +134,16 @@ func (m *MongoDB) RaidAlerts() storage.RaidAlertsStore {
 	}
 }
 
+// RaidHistory implements storage.Storage.RaidHistory
+func (m *MongoDB) RaidHistory() storage.RaidHistoryStore {
+	return RaidHistory{collection: m.session.DB(m.dbname).C(raidHistoryCollection)}
+}
+
+// ChatLog implements storage.Storage.ChatLog
+func (m *MongoDB) ChatLog() storage.ChatLogStore {
+	return ChatLog{collection: m.session.DB(m.dbname).C(chatsCollection)}
+}
+
 // Accounts implements storage.Storage.ServerAccounts
 func (m *MongoDB) Accounts() storage.AccountsStore {
 	return Accounts{collection: m.session.DB(m.dbname).C(accountsCollection)}
@@ -127,12 +158,22 @@ func (m *MongoDB) Init() {
 	accountColl := mongoDB.C(accountsCollection)
 	messageLocksColl := mongoDB.C(messageLocksCollection)
 	chatQueueColl := mongoDB.C(chatQueueCollection)
-
-	chatQueueColl.Create(&mgo.CollectionInfo{
-		Capped:   true,
-		MaxBytes: 16384,
-		MaxDocs:  1000,
-	})
+	raidHistoryColl := mongoDB.C(raidHistoryCollection)
+	chatsColl := mongoDB.C(chatsCollection)
+	gameMessagesColl := mongoDB.C(gameMessagesCollection)
+	onlinePlayersColl := mongoDB.C(onlinePlayersCollection)
+	serverStatusesColl := mongoDB.C(serverStatusesCollection)
+
+	// The chat queue used to be a capped collection, which can't have
+	// messages removed when they are acknowledged. Its messages were
+	// already handed out, so it is dropped rather than converted.
+	var chatQueueStats struct{ Capped bool }
+	if err := mongoDB.Run(bson.D{{Name: "collStats", Value: chatQueueCollection}}, &chatQueueStats); err == nil && chatQueueStats.Capped {
+		log.Printf("Dropping capped %s collection", chatQueueCollection)
+		if err := chatQueueColl.DropCollection(); err != nil {
+			log.Printf("Could not drop %s: %v", chatQueueCollection, err)
+		}
+	}
 
 	messageLocksColl.Create(&mgo.CollectionInfo{
 		Capped:   true,
@@ -158,10 +199,92 @@ func (m *MongoDB) Init() {
 		DropDups: true,
 	})
 
+	// PINs requested before expiry existed get the default lifetime, so
+	// they can still be used and are purged once they expire
+	if _, err := discordAuthColl.UpdateAll(
+		bson.M{"expiresat": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"expiresat": iclock().Now().UTC().Add(types.DiscordAuthTTL)}},
+	); err != nil {
+		log.Printf("Could not set %s expiry: %v", discordAuthsCollection, err)
+	}
+
+	// Purges discord auths DiscordAuthPurgeAfter after they expire
+	discordAuthColl.EnsureIndex(mgo.Index{
+		Key:         []string{"expiresat"},
+		ExpireAfter: storage.DiscordAuthPurgeAfter,
+	})
+
 	accountColl.EnsureIndex(mgo.Index{
 		Key:    []string{"servers.key"},
 		Unique: false,
 	})
+
+	raidHistoryColl.EnsureIndex(mgo.Index{
+		Key: []string{"playerid", "-endedat"},
+	})
+
+	raidHistoryColl.EnsureIndex(mgo.Index{
+		Key: []string{"serverkey", "-endedat"},
+	})
+
+	chatQueueColl.EnsureIndex(mgo.Index{
+		Key: []string{"serverkey", "tag", "queuedat"},
+	})
+	chatQueueColl.EnsureIndex(mgo.Index{
+		Key: []string{"leaseduntil"},
+	})
+
+	chatsColl.EnsureIndex(mgo.Index{
+		Key: []string{"serverkey", "-sentat"},
+	})
+
+	chatsColl.EnsureIndex(mgo.Index{
+		Key: []string{"snowflake", "-sentat"},
+	})
+
+	chatsColl.EnsureIndex(mgo.Index{
+		Key: []string{"playerid", "-sentat"},
+	})
+
+	chatsColl.EnsureIndex(mgo.Index{
+		Key: []string{"sentat"},
+	})
+
+	gameMessagesColl.EnsureIndex(mgo.Index{
+		Key:    []string{"messageid"},
+		Unique: true,
+	})
+
+	gameMessagesColl.EnsureIndex(mgo.Index{
+		Key: []string{"serverkey", "-sentat"},
+	})
+
+	onlinePlayersColl.EnsureIndex(mgo.Index{
+		Key:    []string{"serverkey", "playerid"},
+		Unique: true,
+	})
+
+	serverStatusesColl.EnsureIndex(mgo.Index{
+		Key:    []string{"serverkey"},
+		Unique: true,
+	})
+
+	serverStatusesColl.EnsureIndex(mgo.Index{
+		Key: []string{"offline", "reportedat"},
+	})
+}
+
+// storageError translates mgo errors into the storage errors
+func storageError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case err == mgo.ErrNotFound:
+		return storage.ErrNotFound
+	case mgo.IsDup(err):
+		return fmt.Errorf("%s: %w", err, storage.ErrConflict)
+	}
+	return err
 }
 
 func parseDialURL(dialURL string) (*Config, error) {
@@ -0,0 +1,54 @@
+package mongodb
+
+import (
+	"time"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+	"github.com/poundbot/poundbot/types"
+)
+
+// An OnlinePlayers implements storage.OnlinePlayersStore
+type OnlinePlayers struct {
+	collection *mgo.Collection
+}
+
+// Clear implements storage.OnlinePlayersStore.Clear
+func (op OnlinePlayers) Clear(serverKey string, before time.Time) error {
+	_, err := op.collection.RemoveAll(bson.M{
+		"serverkey":   serverKey,
+		"connectedat": bson.M{"$not": bson.M{"$gte": before}},
+	})
+	return storageError(err)
+}
+
+// Connect implements storage.OnlinePlayersStore.Connect
+func (op OnlinePlayers) Connect(player types.OnlinePlayer) error {
+	_, err := op.collection.Upsert(bson.M{
+		"serverkey":   player.ServerKey,
+		"playerid":    player.PlayerID,
+		"connectedat": bson.M{"$not": bson.M{"$gt": player.ConnectedAt}},
+	}, player)
+	if mgo.IsDup(err) {
+		// The player is online from a later connection
+		return nil
+	}
+	return storageError(err)
+}
+
+// Disconnect implements storage.OnlinePlayersStore.Disconnect
+func (op OnlinePlayers) Disconnect(serverKey, playerID string, at time.Time) error {
+	_, err := op.collection.RemoveAll(bson.M{
+		"serverkey":   serverKey,
+		"playerid":    playerID,
+		"connectedat": bson.M{"$not": bson.M{"$gt": at}},
+	})
+	return storageError(err)
+}
+
+// Online implements storage.OnlinePlayersStore.Online
+func (op OnlinePlayers) Online(serverKey string) ([]types.OnlinePlayer, error) {
+	var players []types.OnlinePlayer
+	err := op.collection.Find(bson.M{"serverkey": serverKey}).Sort("connectedat", "playerid").All(&players)
+	return players, storageError(err)
+}
@@ -26,7 +26,8 @@ func (r RaidAlerts) AddInfo(alertIn, invalidIn time.Duration, ed types.EntityDea
 			continue
 		}
 
-		validUntil := time.Now().UTC().Add(invalidIn)
+		now := time.Now().UTC()
+		validUntil := now.Add(invalidIn)
 
 		_, err = r.collection.Upsert(
 			bson.M{
@@ -40,9 +41,11 @@ func (r RaidAlerts) AddInfo(alertIn, invalidIn time.Duration, ed types.EntityDea
 					"servername":  ed.ServerName,
 					"serverkey":   ed.ServerKey,
 					"notifycount": 0,
+					"startedat":   now,
 				},
 				"$set": bson.M{
-					"validuntil": validUntil,
+					"validuntil":  validUntil,
+					"lasteventat": now,
 				},
 				"$inc": bson.M{
 					fmt.Sprintf("items.%s", ed.Name): 1,
@@ -71,12 +74,18 @@ func (r RaidAlerts) GetReady() ([]types.RaidAlert, error) {
 			},
 		},
 	).All(&alerts)
-	return alerts, err
+	return alerts, storageError(err)
 }
 
 // Remove implements storage.RaidAlertsStore.Remove
 func (r RaidAlerts) Remove(alert types.RaidAlert) error {
-	return r.collection.Remove(bson.M{"_id": alert.ID})
+	return storageError(r.collection.Remove(bson.M{"_id": alert.ID}))
+}
+
+// RemoveByServer implements storage.RaidAlertsStore.RemoveByServer
+func (r RaidAlerts) RemoveByServer(serverKey string) error {
+	_, err := r.collection.RemoveAll(bson.M{"serverkey": serverKey})
+	return storageError(err)
 }
 
 func (r RaidAlerts) IncrementNotifyCount(ra types.RaidAlert) error {
@@ -86,7 +95,7 @@ func (r RaidAlerts) IncrementNotifyCount(ra types.RaidAlert) error {
 		return errors.New("notification count does not need to be incremented")
 	}
 
-	return r.collection.Update(
+	err := r.collection.Update(
 		bson.M{
 			"_id":         ra.ID,
 			"notifycount": ra.NotifyCount,
@@ -97,10 +106,15 @@ func (r RaidAlerts) IncrementNotifyCount(ra types.RaidAlert) error {
 			},
 		},
 	)
+	if err == mgo.ErrNotFound {
+		// Another node already notified, or the alert was removed
+		return storage.ErrStale
+	}
+	return err
 }
 
 func (r RaidAlerts) SetMessageID(ra types.RaidAlert, messageID string) error {
-	return r.collection.Update(
+	err := r.collection.Update(
 		bson.M{"_id": ra.ID},
 		bson.M{
 			"$set": bson.M{
@@ -108,4 +122,5 @@ func (r RaidAlerts) SetMessageID(ra types.RaidAlert, messageID string) error {
 			},
 		},
 	)
+	return storageError(err)
 }
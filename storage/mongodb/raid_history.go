@@ -0,0 +1,46 @@
+package mongodb
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/types"
+)
+
+// A RaidHistory implements storage.RaidHistoryStore
+type RaidHistory struct {
+	collection *mgo.Collection
+}
+
+// Add implements storage.RaidHistoryStore.Add
+func (r RaidHistory) Add(rh types.RaidHistory) error {
+	_, err := r.collection.UpsertId(rh.ID, rh)
+	return storageError(err)
+}
+
+// Find implements storage.RaidHistoryStore.Find
+func (r RaidHistory) Find(q storage.RaidHistoryQuery) ([]types.RaidHistory, error) {
+	selector := bson.M{}
+	if len(q.ServerKey) != 0 {
+		selector["serverkey"] = q.ServerKey
+	}
+	if len(q.ServerName) != 0 {
+		selector["servername"] = bson.RegEx{
+			Pattern: fmt.Sprintf("^%s$", regexp.QuoteMeta(q.ServerName)),
+			Options: "i",
+		}
+	}
+	if q.PlayerIDs != nil {
+		selector["playerid"] = bson.M{"$in": q.PlayerIDs}
+	}
+	if !q.Since.IsZero() {
+		selector["endedat"] = bson.M{"$gte": q.Since}
+	}
+
+	var raids []types.RaidHistory
+	err := r.collection.Find(selector).Sort("-endedat").Limit(q.Limit).All(&raids)
+	return raids, storageError(err)
+}
@@ -0,0 +1,71 @@
+package mongodb
+
+import (
+	"time"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+	"github.com/poundbot/poundbot/types"
+)
+
+// A ServerStatuses implements storage.ServerStatusStore
+type ServerStatuses struct {
+	collection *mgo.Collection
+}
+
+// Get implements storage.ServerStatusStore.Get
+func (ss ServerStatuses) Get(serverKey string) (types.ServerStatus, error) {
+	var status types.ServerStatus
+	err := ss.collection.Find(bson.M{"serverkey": serverKey}).One(&status)
+	return status, storageError(err)
+}
+
+// MarkOffline implements storage.ServerStatusStore.MarkOffline
+func (ss ServerStatuses) MarkOffline(before time.Time) ([]types.ServerStatus, error) {
+	selector := bson.M{"offline": false, "reportedat": bson.M{"$lt": before}}
+
+	var stale []types.ServerStatus
+	if err := ss.collection.Find(selector).Sort("serverkey").All(&stale); err != nil {
+		return nil, storageError(err)
+	}
+
+	var marked []types.ServerStatus
+	for _, status := range stale {
+		selector["serverkey"] = status.ServerKey
+		err := ss.collection.Update(selector, bson.M{"$set": bson.M{"offline": true}})
+		if err == mgo.ErrNotFound {
+			// The server reported since it was found
+			continue
+		}
+		if err != nil {
+			return nil, storageError(err)
+		}
+		status.Offline = true
+		marked = append(marked, status)
+	}
+	return marked, nil
+}
+
+// Report implements storage.ServerStatusStore.Report
+func (ss ServerStatuses) Report(status types.ServerStatus) error {
+	_, err := ss.collection.Upsert(bson.M{"serverkey": status.ServerKey}, bson.M{"$set": bson.M{
+		"players":    status.Players,
+		"maxplayers": status.MaxPlayers,
+		"map":        status.Map,
+		"seed":       status.Seed,
+		"uptime":     status.Uptime,
+		"fps":        status.FPS,
+		"reportedat": status.ReportedAt,
+		"offline":    false,
+	}})
+	return storageError(err)
+}
+
+// SetMessage implements storage.ServerStatusStore.SetMessage
+func (ss ServerStatuses) SetMessage(serverKey, channelID, messageID string) error {
+	err := ss.collection.Update(
+		bson.M{"serverkey": serverKey},
+		bson.M{"$set": bson.M{"channelid": channelID, "messageid": messageID}},
+	)
+	return storageError(err)
+}
@@ -0,0 +1,53 @@
+// +build integration
+
+package mongodb
+
+import (
+	"testing"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/storage/mongodb/mongotest"
+	"github.com/poundbot/poundbot/storage/storagetest"
+	"github.com/poundbot/poundbot/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMongoDB(t *testing.T) {
+	t.Parallel()
+
+	storagetest.Run(t, func() storage.Storage {
+		coll, err := mongotest.NewCollection(accountsCollection)
+		if err != nil {
+			panic(err)
+		}
+		t.Cleanup(coll.Close)
+
+		db := coll.C.Database
+		return &MongoDB{dbname: db.Name, session: db.Session.Copy()}
+	})
+}
+
+func TestMongoDB_InitDiscordAuthExpiry(t *testing.T) {
+	t.Parallel()
+
+	coll, err := mongotest.NewCollection(discordAuthsCollection)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer coll.Close()
+
+	// A PIN requested before expiry existed
+	if err := coll.C.Insert(bson.M{"playerid": "game:1", "snowflake": "one", "pin": 1234}); err != nil {
+		t.Fatal(err)
+	}
+
+	db := coll.C.Database
+	m := &MongoDB{dbname: db.Name, session: db.Session.Copy()}
+	defer m.Close()
+	m.Init()
+
+	got, err := m.DiscordAuths().GetByPlayerID("game:1")
+	assert.Nil(t, err)
+	assert.Equal(t, types.DiscordAuthPending, got.Status(iclock().Now()), "the PIN should still be usable")
+}
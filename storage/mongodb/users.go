@@ -17,24 +17,29 @@ type Users struct {
 	collection *mgo.Collection
 }
 
+// All implements db.UsersStore.All
+func (u Users) All(users *[]types.User) error {
+	return storageError(u.collection.Find(bson.M{}).All(users))
+}
+
 // Get implements db.UsersStore.Get
 func (u Users) GetByPlayerID(gameUserID string) (types.User, error) {
 	var user types.User
 	err := u.collection.Find(bson.M{userPlayerIDsField: gameUserID}).One(&user)
-	return user, err
+	return user, storageError(err)
 }
 
 func (u Users) GetByDiscordID(snowflake string) (types.User, error) {
 	var user types.User
 	err := u.collection.Find(bson.M{userSnowflakeField: snowflake}).One(&user)
-	return user, err
+	return user, storageError(err)
 }
 
 func (u Users) GetPlayerIDsByDiscordIDs(snowflakes []string) ([]string, error) {
 	var playerIDs []string
 	err := u.collection.Find(bson.M{userSnowflakeField: bson.M{"$in": snowflakes}}).
 		Distinct(userPlayerIDsField, &playerIDs)
-	return playerIDs, err
+	return playerIDs, storageError(err)
 }
 
 func (u Users) UpsertPlayer(info storage.UserInfoGetter) error {
@@ -50,7 +55,7 @@ func (u Users) UpsertPlayer(info storage.UserInfoGetter) error {
 		},
 	)
 
-	return err
+	return storageError(err)
 }
 
 func (u Users) RemovePlayerID(snowflake, playerID string) error {
@@ -58,7 +63,7 @@ func (u Users) RemovePlayerID(snowflake, playerID string) error {
 		err := u.collection.Remove(
 			bson.M{userSnowflakeField: snowflake},
 		)
-		return err
+		return storageError(err)
 	}
 
 	err := u.collection.Update(
@@ -70,5 +75,5 @@ func (u Users) RemovePlayerID(snowflake, playerID string) error {
 			"$pull": bson.M{userPlayerIDsField: playerID},
 		},
 	)
-	return err
+	return storageError(err)
 }
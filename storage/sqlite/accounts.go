@@ -0,0 +1,556 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/types"
+)
+
+const accountColumns = `id, guild_snowflake, owner_snowflake, command_prefix,
+	admin_snowflakes, registered_player_ids, disabled, created_at, updated_at`
+
+// An Accounts implements storage.AccountsStore
+type Accounts struct {
+	db *sql.DB
+}
+
+// loadAccounts reads the accounts matching where, including their servers
+func loadAccounts(q queryer, where string, args ...interface{}) ([]types.Account, error) {
+	rows, err := q.Query(fmt.Sprintf("SELECT %s FROM accounts %s ORDER BY rowid", accountColumns, where), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var accounts []types.Account
+	for rows.Next() {
+		var a types.Account
+		var id, admins, registered string
+		var disabled int
+		var createdAt, updatedAt sql.NullInt64
+		if err := rows.Scan(&id, &a.GuildSnowflake, &a.OwnerSnowflake, &a.CommandPrefix,
+			&admins, &registered, &disabled, &createdAt, &updatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		a.ID = bson.ObjectIdHex(id)
+		a.Disabled = disabled != 0
+		a.CreatedAt = scanTime(createdAt)
+		a.UpdatedAt = scanTime(updatedAt)
+		if a.AdminSnowflakes, err = scanStrings(admins); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if a.RegisteredPlayerIDs, err = scanStrings(registered); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		accounts = append(accounts, a)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Servers are loaded after the rows are closed, as the pool only has
+	// a single connection.
+	for i := range accounts {
+		servers, err := loadServers(q, accounts[i].ID.Hex())
+		if err != nil {
+			return nil, err
+		}
+		accounts[i].Servers = servers
+	}
+
+	return accounts, nil
+}
+
+func loadAccount(q queryer, where string, args ...interface{}) (types.Account, error) {
+	accounts, err := loadAccounts(q, where, args...)
+	if err != nil {
+		return types.Account{}, err
+	}
+	if len(accounts) == 0 {
+		return types.Account{}, storage.ErrNotFound
+	}
+	return accounts[0], nil
+}
+
+// loadServers reads the servers for an account, in the order they were added
+func loadServers(q queryer, accountID string) ([]types.AccountServer, error) {
+	rows, err := q.Query(`SELECT id, key, name, address, raid_delay, raid_cooldown, offline_alert, role_maps, kill_feed,
+		last_wipe, next_wipe, wipe_message, created_at, updated_at
+		FROM servers WHERE account_id = ? ORDER BY position`, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []int64
+	var servers []types.AccountServer
+	for rows.Next() {
+		var id int64
+		var s types.AccountServer
+		var roleMaps, killFeed string
+		var lastWipe, nextWipe, createdAt, updatedAt sql.NullInt64
+		if err := rows.Scan(&id, &s.Key, &s.Name, &s.Address, &s.RaidDelay, &s.RaidCooldown,
+			&s.OfflineAlert, &roleMaps, &killFeed, &lastWipe, &nextWipe, &s.WipeMessage, &createdAt, &updatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if s.RoleMaps, err = scanRoleMaps(roleMaps); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(killFeed), &s.KillFeed); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		s.LastWipe = scanTime(lastWipe)
+		s.NextWipe = scanTime(nextWipe)
+		s.CreatedAt = scanTime(createdAt)
+		s.UpdatedAt = scanTime(updatedAt)
+		ids = append(ids, id)
+		servers = append(servers, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range servers {
+		if servers[i].Channels, err = loadChannels(q, ids[i]); err != nil {
+			return nil, err
+		}
+		if servers[i].Clans, err = loadClans(q, ids[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return servers, nil
+}
+
+// scanRoleMaps decodes a server's role maps, returning nil when empty
+func scanRoleMaps(s string) ([]types.RoleMap, error) {
+	var roleMaps []types.RoleMap
+	if err := json.Unmarshal([]byte(s), &roleMaps); err != nil {
+		return nil, err
+	}
+	if len(roleMaps) == 0 {
+		return nil, nil
+	}
+	return roleMaps, nil
+}
+
+func loadChannels(q queryer, serverID int64) ([]types.AccountServerChannel, error) {
+	rows, err := q.Query(`SELECT channel_id, tags FROM server_channels WHERE server_id = ? ORDER BY position`, serverID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var channels []types.AccountServerChannel
+	for rows.Next() {
+		var c types.AccountServerChannel
+		var tags string
+		if err := rows.Scan(&c.ChannelID, &tags); err != nil {
+			return nil, err
+		}
+		if c.Tags, err = scanStrings(tags); err != nil {
+			return nil, err
+		}
+		channels = append(channels, c)
+	}
+	return channels, rows.Err()
+}
+
+func loadClans(q queryer, serverID int64) ([]types.Clan, error) {
+	rows, err := q.Query(`SELECT tag, owner_id, members, moderators FROM clans WHERE server_id = ? ORDER BY position`, serverID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var clans []types.Clan
+	for rows.Next() {
+		var c types.Clan
+		var members, moderators string
+		if err := rows.Scan(&c.Tag, &c.OwnerID, &members, &moderators); err != nil {
+			return nil, err
+		}
+		if c.Members, err = scanStrings(members); err != nil {
+			return nil, err
+		}
+		if c.Moderators, err = scanStrings(moderators); err != nil {
+			return nil, err
+		}
+		clans = append(clans, c)
+	}
+	return clans, rows.Err()
+}
+
+// serverID finds the row ID for the first server with the key
+func serverID(q queryer, serverKey string) (int64, error) {
+	var id int64
+	err := q.QueryRow(`SELECT id FROM servers WHERE key = ? ORDER BY id LIMIT 1`, serverKey).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, storage.ErrNotFound
+	}
+	return id, err
+}
+
+// writeServer inserts a server or, if id is not 0, replaces it
+func writeServer(tx *sql.Tx, id int64, accountID string, server types.AccountServer) error {
+	if id == 0 {
+		var position int
+		if err := tx.QueryRow(`SELECT COALESCE(MAX(position), 0) + 1 FROM servers WHERE account_id = ?`, accountID).
+			Scan(&position); err != nil {
+			return err
+		}
+		res, err := tx.Exec(`INSERT INTO servers (account_id, position, key, name, address, raid_delay, raid_cooldown, offline_alert, role_maps, kill_feed,
+			last_wipe, next_wipe, wipe_message, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			accountID, position, server.Key, server.Name, server.Address, server.RaidDelay, server.RaidCooldown,
+			server.OfflineAlert, jsonValue(server.RoleMaps), jsonValue(server.KillFeed),
+			timeValue(server.LastWipe), timeValue(server.NextWipe), server.WipeMessage, timeValue(server.CreatedAt), timeValue(server.UpdatedAt))
+		if err != nil {
+			return err
+		}
+		if id, err = res.LastInsertId(); err != nil {
+			return err
+		}
+	} else {
+		_, err := tx.Exec(`UPDATE servers SET key = ?, name = ?, address = ?, raid_delay = ?, raid_cooldown = ?,
+			offline_alert = ?, role_maps = ?, kill_feed = ?, last_wipe = ?, next_wipe = ?, wipe_message = ?,
+			created_at = ?, updated_at = ? WHERE id = ?`,
+			server.Key, server.Name, server.Address, server.RaidDelay, server.RaidCooldown,
+			server.OfflineAlert, jsonValue(server.RoleMaps), jsonValue(server.KillFeed),
+			timeValue(server.LastWipe), timeValue(server.NextWipe), server.WipeMessage, timeValue(server.CreatedAt), timeValue(server.UpdatedAt), id)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM server_channels WHERE server_id = ?`, id); err != nil {
+			return err
+		}
+	}
+
+	for i, c := range server.Channels {
+		if _, err := tx.Exec(`INSERT INTO server_channels (server_id, position, channel_id, tags) VALUES (?, ?, ?, ?)`,
+			id, i, c.ChannelID, jsonValue(c.Tags)); err != nil {
+			return err
+		}
+	}
+
+	return writeClans(tx, id, server.Clans)
+}
+
+// writeClans replaces all clans for a server
+func writeClans(tx *sql.Tx, serverID int64, clans []types.Clan) error {
+	if _, err := tx.Exec(`DELETE FROM clans WHERE server_id = ?`, serverID); err != nil {
+		return err
+	}
+	for i, c := range clans {
+		if err := insertClan(tx, serverID, i, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func insertClan(tx *sql.Tx, serverID int64, position int, c types.Clan) error {
+	_, err := tx.Exec(`INSERT INTO clans (server_id, position, tag, owner_id, members, moderators) VALUES (?, ?, ?, ?, ?, ?)`,
+		serverID, position, c.Tag, c.OwnerID, jsonValue(c.Members), jsonValue(c.Moderators))
+	return err
+}
+
+// upsertBase creates or updates the base account the same way a MongoDB
+// $set would, leaving existing values for empty omitempty fields.
+func upsertBase(tx *sql.Tx, account types.BaseAccount, ts types.Timestamp) (string, error) {
+	var id string
+	err := tx.QueryRow(`SELECT id FROM accounts WHERE guild_snowflake = ?`, account.GuildSnowflake).Scan(&id)
+	if err == sql.ErrNoRows {
+		id = bson.NewObjectId().Hex()
+		_, err = tx.Exec(`INSERT INTO accounts (id, guild_snowflake, owner_snowflake, command_prefix,
+			admin_snowflakes, registered_player_ids, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			id, account.GuildSnowflake, account.OwnerSnowflake, account.CommandPrefix,
+			jsonValue(account.AdminSnowflakes), jsonValue(account.RegisteredPlayerIDs),
+			timeValue(ts.CreatedAt), timeValue(ts.UpdatedAt))
+		return id, err
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := tx.Exec(`UPDATE accounts SET owner_snowflake = ?, command_prefix = ? WHERE id = ?`,
+		account.OwnerSnowflake, account.CommandPrefix, id); err != nil {
+		return "", err
+	}
+	if len(account.AdminSnowflakes) != 0 {
+		if _, err := tx.Exec(`UPDATE accounts SET admin_snowflakes = ? WHERE id = ?`,
+			jsonValue(account.AdminSnowflakes), id); err != nil {
+			return "", err
+		}
+	}
+	if len(account.RegisteredPlayerIDs) != 0 {
+		if _, err := tx.Exec(`UPDATE accounts SET registered_player_ids = ? WHERE id = ?`,
+			jsonValue(account.RegisteredPlayerIDs), id); err != nil {
+			return "", err
+		}
+	}
+	return id, nil
+}
+
+// updatePlayerIDs sets the registered player IDs to the result of f
+func (s Accounts) updatePlayerIDs(accountID string, f func([]string) []string) error {
+	return withTx(s.db, func(tx *sql.Tx) error {
+		var registered string
+		err := tx.QueryRow(`SELECT registered_player_ids FROM accounts WHERE guild_snowflake = ?`, accountID).
+			Scan(&registered)
+		if err == sql.ErrNoRows {
+			return storage.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		playerIDs, err := scanStrings(registered)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`UPDATE accounts SET registered_player_ids = ? WHERE guild_snowflake = ?`,
+			jsonValue(f(playerIDs)), accountID)
+		return err
+	})
+}
+
+func (s Accounts) All(accounts *[]types.Account) error {
+	all, err := loadAccounts(s.db, "")
+	if err != nil {
+		return err
+	}
+	*accounts = all
+	return nil
+}
+
+// WithOfflineAlerts implements storage.AccountsStore.WithOfflineAlerts
+func (s Accounts) WithOfflineAlerts(accounts *[]types.Account) error {
+	found, err := loadAccounts(s.db,
+		"WHERE disabled = 0 AND id IN (SELECT account_id FROM servers WHERE offline_alert != '')")
+	if err != nil {
+		return err
+	}
+	*accounts = found
+	return nil
+}
+
+// WithScheduledWipes implements storage.AccountsStore.WithScheduledWipes
+func (s Accounts) WithScheduledWipes(after time.Time, accounts *[]types.Account) error {
+	found, err := loadAccounts(s.db,
+		"WHERE disabled = 0 AND id IN (SELECT account_id FROM servers WHERE next_wipe > ?)", timeValue(after))
+	if err != nil {
+		return err
+	}
+	*accounts = found
+	return nil
+}
+
+func (s Accounts) GetByDiscordGuild(key string) (types.Account, error) {
+	return loadAccount(s.db, "WHERE guild_snowflake = ?", key)
+}
+
+func (s Accounts) GetByServerKey(key string) (types.Account, error) {
+	return loadAccount(s.db, "WHERE id IN (SELECT account_id FROM servers WHERE key = ?)", key)
+}
+
+func (s Accounts) UpsertBase(account types.BaseAccount) error {
+	return withTx(s.db, func(tx *sql.Tx) error {
+		_, err := upsertBase(tx, account, *types.NewTimestamp())
+		return err
+	})
+}
+
+func (s Accounts) Remove(key string) error {
+	res, err := s.db.Exec(`UPDATE accounts SET disabled = 1 WHERE guild_snowflake = ?`, key)
+	return notFoundIfNone(res, err)
+}
+
+func (s Accounts) AddClan(serverKey string, clan types.Clan) error {
+	return withTx(s.db, func(tx *sql.Tx) error {
+		id, err := serverID(tx, serverKey)
+		if err != nil {
+			return err
+		}
+		var position int
+		if err := tx.QueryRow(`SELECT COALESCE(MAX(position), 0) + 1 FROM clans WHERE server_id = ?`, id).
+			Scan(&position); err != nil {
+			return err
+		}
+		return insertClan(tx, id, position, clan)
+	})
+}
+
+func (s Accounts) RemoveClan(serverKey, clanTag string) error {
+	return withTx(s.db, func(tx *sql.Tx) error {
+		id, err := serverID(tx, serverKey)
+		if err != nil {
+			return err
+		}
+		res, err := tx.Exec(`DELETE FROM clans WHERE server_id = ? AND tag = ?`, id, clanTag)
+		return notFoundIfNone(res, err)
+	})
+}
+
+func (s Accounts) SetClans(serverKey string, clans []types.Clan) error {
+	return withTx(s.db, func(tx *sql.Tx) error {
+		id, err := serverID(tx, serverKey)
+		if err != nil {
+			return err
+		}
+		return writeClans(tx, id, clans)
+	})
+}
+
+// RecordWipe implements storage.AccountsStore.RecordWipe
+func (s Accounts) RecordWipe(serverKey string, wipedAt, scheduledBefore time.Time) error {
+	return withTx(s.db, func(tx *sql.Tx) error {
+		id, err := serverID(tx, serverKey)
+		if err != nil {
+			return err
+		}
+		if err := writeClans(tx, id, nil); err != nil {
+			return err
+		}
+		_, err = tx.Exec(`UPDATE servers SET last_wipe = ?,
+			next_wipe = CASE WHEN next_wipe < ? THEN NULL ELSE next_wipe END WHERE id = ?`,
+			timeValue(wipedAt), timeValue(scheduledBefore), id)
+		return err
+	})
+}
+
+func (s Accounts) AddServer(snowflake string, server types.AccountServer) error {
+	server.CreatedAt = iclock().Now().UTC()
+	return withTx(s.db, func(tx *sql.Tx) error {
+		var accountID string
+		err := tx.QueryRow(`SELECT id FROM accounts WHERE guild_snowflake = ?`, snowflake).Scan(&accountID)
+		if err == sql.ErrNoRows {
+			return storage.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		return writeServer(tx, 0, accountID, server)
+	})
+}
+
+func (s Accounts) RemoveServer(snowflake, serverKey string) error {
+	res, err := s.db.Exec(`DELETE FROM servers WHERE key = ?`, serverKey)
+	return notFoundIfNone(res, err)
+}
+
+func (s Accounts) UpdateServer(snowflake, oldKey string, server types.AccountServer) error {
+	return withTx(s.db, func(tx *sql.Tx) error {
+		var id int64
+		var accountID string
+		err := tx.QueryRow(`SELECT servers.id, servers.account_id FROM servers
+			JOIN accounts ON accounts.id = servers.account_id
+			WHERE accounts.guild_snowflake = ? AND servers.key = ?`, snowflake, oldKey).Scan(&id, &accountID)
+		if err == sql.ErrNoRows {
+			return storage.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		return writeServer(tx, id, accountID, server)
+	})
+}
+
+func (s Accounts) RemoveNotInDiscordGuildList(guilds []types.BaseAccount) error {
+	insertTS := types.NewTimestamp()
+	insertTS.CreatedAt = iclock().Now().UTC()
+	guildIDs := make([]interface{}, len(guilds))
+
+	return withTx(s.db, func(tx *sql.Tx) error {
+		for i, guild := range guilds {
+			// Collect the IDs for disabling later
+			guildIDs[i] = guild.GuildSnowflake
+
+			id, err := upsertBase(tx, guild, *insertTS)
+			if err != nil {
+				return fmt.Errorf("error updating guild: %s", err)
+			}
+			if _, err := tx.Exec(`UPDATE accounts SET disabled = 0, updated_at = ? WHERE id = ?`,
+				timeValue(insertTS.UpdatedAt), id); err != nil {
+				return fmt.Errorf("error updating guild: %s", err)
+			}
+		}
+
+		// Now disable all the guilds not in the list
+		query := `UPDATE accounts SET disabled = 1`
+		if len(guildIDs) != 0 {
+			query += fmt.Sprintf(" WHERE guild_snowflake NOT IN (%s)", placeholders(len(guildIDs)))
+		}
+		_, err := tx.Exec(query, guildIDs...)
+		return err
+	})
+}
+
+func (s Accounts) SetRegisteredPlayerIDs(accountID string, playerIDs []string) error {
+	return s.updatePlayerIDs(accountID, func([]string) []string {
+		return playerIDs
+	})
+}
+
+func (s Accounts) AddRegisteredPlayerIDs(accountID string, playerIDs []string) error {
+	return s.updatePlayerIDs(accountID, func(existing []string) []string {
+		for _, pID := range playerIDs {
+			if !containsString(existing, pID) {
+				existing = append(existing, pID)
+			}
+		}
+		return existing
+	})
+}
+
+func (s Accounts) RemoveRegisteredPlayerIDs(accountID string, playerIDs []string) error {
+	return s.updatePlayerIDs(accountID, func(existing []string) []string {
+		ids := []string{}
+		for _, pID := range existing {
+			if !containsString(playerIDs, pID) {
+				ids = append(ids, pID)
+			}
+		}
+		return ids
+	})
+}
+
+func (s Accounts) Touch(serverKey string) error {
+	now := timeValue(iclock().Now().UTC())
+	return withTx(s.db, func(tx *sql.Tx) error {
+		id, err := serverID(tx, serverKey)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`UPDATE servers SET updated_at = ? WHERE id = ?`, now, id); err != nil {
+			return err
+		}
+		_, err = tx.Exec(`UPDATE accounts SET updated_at = ? WHERE id = (SELECT account_id FROM servers WHERE id = ?)`, now, id)
+		return err
+	})
+}
+
+// notFoundIfNone returns storage.ErrNotFound when a statement changed no rows
+func notFoundIfNone(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return storage.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package sqlite
+
+import (
+	"testing"
+
+	"github.com/poundbot/poundbot/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccounts_UpsertBase(t *testing.T) {
+	t.Parallel()
+
+	accounts := newTestSQLite(t).Accounts()
+
+	assert.Nil(t, accounts.UpsertBase(types.BaseAccount{
+		GuildSnowflake:  "guild",
+		OwnerSnowflake:  "owner",
+		AdminSnowflakes: []string{"admin"},
+	}))
+	assert.Nil(t, accounts.UpsertBase(types.BaseAccount{GuildSnowflake: "guild", OwnerSnowflake: "owner2"}))
+
+	account, err := accounts.GetByDiscordGuild("guild")
+	assert.Nil(t, err)
+	assert.Equal(t, "owner2", account.OwnerSnowflake)
+	assert.Equal(t, []string{"admin"}, account.AdminSnowflakes, "empty admins should not be overwritten")
+	assert.NotEmpty(t, account.ID)
+	assert.False(t, account.CreatedAt.IsZero())
+}
+
+func TestAccounts_RemoveNotInDiscordGuildList(t *testing.T) {
+	t.Parallel()
+
+	accounts := newTestSQLite(t).Accounts()
+	accounts.UpsertBase(types.BaseAccount{GuildSnowflake: "one"})
+	accounts.UpsertBase(types.BaseAccount{GuildSnowflake: "two"})
+
+	err := accounts.RemoveNotInDiscordGuildList([]types.BaseAccount{
+		{GuildSnowflake: "two"},
+		{GuildSnowflake: "three"},
+	})
+	assert.Nil(t, err)
+
+	var all []types.Account
+	assert.Nil(t, accounts.All(&all))
+
+	disabled := map[string]bool{}
+	for _, account := range all {
+		disabled[account.GuildSnowflake] = account.Disabled
+	}
+	assert.Equal(t, map[string]bool{"one": true, "two": false, "three": false}, disabled)
+}
+
+func TestAccounts_Servers(t *testing.T) {
+	t.Parallel()
+
+	accounts := newTestSQLite(t).Accounts()
+	accounts.UpsertBase(types.BaseAccount{GuildSnowflake: "guild"})
+
+	assert.Nil(t, accounts.AddServer("guild", types.AccountServer{Key: "key", Name: "server"}))
+	assert.Nil(t, accounts.AddClan("key", types.Clan{Tag: "FoF", Members: []string{"game:1"}}))
+
+	account, err := accounts.GetByServerKey("key")
+	assert.Nil(t, err)
+	assert.Equal(t, []types.Clan{{Tag: "FoF", Members: []string{"game:1"}}}, account.Servers[0].Clans)
+
+	server := types.AccountServer{Key: "newkey", Name: "renamed"}
+	server.SetChannelIDForTag("1234", "chat")
+	assert.Nil(t, accounts.UpdateServer("guild", "key", server))
+	assert.NotNil(t, accounts.UpdateServer("other", "newkey", server), "guild must match")
+
+	account, err = accounts.GetByServerKey("newkey")
+	assert.Nil(t, err)
+	assert.Equal(t, "renamed", account.Servers[0].Name)
+	channelID, _ := account.Servers[0].ChannelIDForTag("chat")
+	assert.Equal(t, "1234", channelID)
+
+	assert.Nil(t, accounts.RemoveServer("guild", "newkey"))
+	_, err = accounts.GetByServerKey("newkey")
+	assert.NotNil(t, err)
+}
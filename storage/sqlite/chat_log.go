@@ -0,0 +1,105 @@
+package sqlite
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/types"
+)
+
+// A ChatLog implements storage.ChatLogStore
+type ChatLog struct {
+	db *sql.DB
+}
+
+const chatLogColumns = `id, source, server_key, tag, snowflake, discord_name, player_id,
+	display_name, clan_tag, message, sent_at`
+
+// Add implements storage.ChatLogStore.Add
+func (c ChatLog) Add(entry types.ChatLogEntry) error {
+	if !entry.ID.Valid() {
+		entry.ID = bson.NewObjectId()
+	}
+	_, err := c.db.Exec(`INSERT INTO chat_log (`+chatLogColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		entry.ID.Hex(), entry.Source, entry.ServerKey, entry.Tag, entry.Snowflake, entry.DiscordName,
+		entry.PlayerID, entry.DisplayName, entry.ClanTag, entry.Message, timeValue(entry.SentAt))
+	return err
+}
+
+// Find implements storage.ChatLogStore.Find
+func (c ChatLog) Find(q storage.ChatLogQuery) ([]types.ChatLogEntry, error) {
+	var where []string
+	var args []interface{}
+	if len(q.ServerKeys) != 0 {
+		where = append(where, "server_key IN ("+placeholders(len(q.ServerKeys))+")")
+		for _, key := range q.ServerKeys {
+			args = append(args, key)
+		}
+	}
+
+	var from []string
+	if len(q.Snowflake) != 0 {
+		from = append(from, "snowflake = ?")
+		args = append(args, q.Snowflake)
+	}
+	if len(q.PlayerIDs) != 0 {
+		from = append(from, "player_id IN ("+placeholders(len(q.PlayerIDs))+")")
+		for _, pID := range q.PlayerIDs {
+			args = append(args, pID)
+		}
+	}
+	if len(from) != 0 {
+		where = append(where, "("+strings.Join(from, " OR ")+")")
+	}
+
+	if !q.Since.IsZero() {
+		where = append(where, "sent_at >= ?")
+		args = append(args, timeValue(q.Since))
+	}
+
+	query := `SELECT ` + chatLogColumns + ` FROM chat_log`
+	if len(where) != 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY sent_at DESC"
+	if q.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, q.Limit)
+	}
+
+	rows, err := c.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []types.ChatLogEntry
+	for rows.Next() {
+		var entry types.ChatLogEntry
+		var id string
+		var sentAt sql.NullInt64
+		err := rows.Scan(&id, &entry.Source, &entry.ServerKey, &entry.Tag, &entry.Snowflake,
+			&entry.DiscordName, &entry.PlayerID, &entry.DisplayName, &entry.ClanTag, &entry.Message, &sentAt)
+		if err != nil {
+			return nil, err
+		}
+		entry.ID = bson.ObjectIdHex(id)
+		entry.SentAt = scanTime(sentAt)
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// RemoveBefore implements storage.ChatLogStore.RemoveBefore
+func (c ChatLog) RemoveBefore(t time.Time) (int, error) {
+	res, err := c.db.Exec(`DELETE FROM chat_log WHERE sent_at < ?`, timeValue(t))
+	if err != nil {
+		return 0, err
+	}
+	removed, err := res.RowsAffected()
+	return int(removed), err
+}
@@ -0,0 +1,148 @@
+package sqlite
+
+import (
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/types"
+)
+
+// chatQueueRecheck is how often waiting requests check for expired leases
+const chatQueueRecheck = time.Second
+
+// A ChatQueue implements storage.ChatQueueStore
+type ChatQueue struct {
+	db       *sql.DB
+	mu       sync.Mutex
+	inserted chan struct{} // closed and replaced on every insert
+}
+
+func newChatQueue(db *sql.DB) *ChatQueue {
+	return &ChatQueue{db: db, inserted: make(chan struct{})}
+}
+
+// InsertMessage implements storage.ChatQueueStore.InsertMessage
+func (cq *ChatQueue) InsertMessage(m types.ChatMessage) error {
+	if len(m.ID) == 0 {
+		m.ID = bson.NewObjectId()
+	}
+
+	err := withTx(cq.db, func(tx *sql.Tx) error {
+		_, err := tx.Exec(`INSERT INTO chat_queue (id, server_key, tag, channel_id, clan_tag, display_name,
+			message, player_id, discord_name, snowflake, queued_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			m.ID.Hex(), m.ServerKey, m.Tag, m.ChannelID, m.ClanTag, m.DisplayName,
+			m.Message, m.PlayerID, m.DiscordName, m.Snowflake, timeValue(iclock().Now()))
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`DELETE FROM chat_queue WHERE server_key = ? AND seq NOT IN
+			(SELECT seq FROM chat_queue WHERE server_key = ? ORDER BY seq DESC LIMIT ?)`,
+			m.ServerKey, m.ServerKey, storage.ChatQueueServerLimit)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	cq.mu.Lock()
+	close(cq.inserted)
+	cq.inserted = make(chan struct{})
+	cq.mu.Unlock()
+	return nil
+}
+
+// next leases the oldest available message for the server and tag
+func (cq *ChatQueue) next(sk, tag string, lease time.Duration) (types.ChatMessage, bool, error) {
+	var m types.ChatMessage
+	found := false
+	err := withTx(cq.db, func(tx *sql.Tx) error {
+		now := iclock().Now()
+		var seq int64
+		var id string
+		var queuedAt sql.NullInt64
+		err := tx.QueryRow(`SELECT seq, id, server_key, tag, channel_id, clan_tag, display_name, message,
+			player_id, discord_name, snowflake, queued_at FROM chat_queue
+			WHERE server_key = ? AND tag = ? AND (leased_until IS NULL OR leased_until <= ?)
+			ORDER BY seq LIMIT 1`, sk, tag, timeValue(now)).
+			Scan(&seq, &id, &m.ServerKey, &m.Tag, &m.ChannelID, &m.ClanTag, &m.DisplayName, &m.Message,
+				&m.PlayerID, &m.DiscordName, &m.Snowflake, &queuedAt)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		m.ID = bson.ObjectIdHex(id)
+		m.QueuedAt = scanTime(queuedAt)
+		found = true
+
+		if lease == 0 {
+			_, err = tx.Exec(`DELETE FROM chat_queue WHERE seq = ?`, seq)
+			return err
+		}
+		m.LeasedUntil = now.Add(lease).Truncate(time.Millisecond).UTC()
+		_, err = tx.Exec(`UPDATE chat_queue SET leased_until = ? WHERE seq = ?`, timeValue(m.LeasedUntil), seq)
+		return err
+	})
+	return m, found, err
+}
+
+// GetGameServerMessage implements storage.ChatQueueStore.GetGameServerMessage
+func (cq *ChatQueue) GetGameServerMessage(sk, tag string, lease, to time.Duration) (types.ChatMessage, bool) {
+	timer := time.NewTimer(to)
+	defer timer.Stop()
+	ticker := time.NewTicker(chatQueueRecheck)
+	defer ticker.Stop()
+
+	for {
+		// Grab the channel before querying so an insert between the query
+		// and the wait is not missed.
+		cq.mu.Lock()
+		inserted := cq.inserted
+		cq.mu.Unlock()
+
+		m, found, err := cq.next(sk, tag, lease)
+		if err != nil {
+			log.WithError(err).Error("could not get chat message")
+			return types.ChatMessage{}, false
+		}
+		if found {
+			return m, true
+		}
+
+		select {
+		case <-inserted:
+		case <-ticker.C:
+		case <-timer.C:
+			return types.ChatMessage{}, false
+		}
+	}
+}
+
+// ReadyKeys implements storage.ChatQueueStore.ReadyKeys
+func (cq *ChatQueue) ReadyKeys() ([]storage.ChatQueueKey, error) {
+	rows, err := cq.db.Query(`SELECT DISTINCT server_key, tag FROM chat_queue
+		WHERE leased_until IS NULL OR leased_until <= ?`, timeValue(iclock().Now()))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []storage.ChatQueueKey
+	for rows.Next() {
+		var key storage.ChatQueueKey
+		if err := rows.Scan(&key.ServerKey, &key.Tag); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// AckMessage implements storage.ChatQueueStore.AckMessage
+func (cq *ChatQueue) AckMessage(sk, id string) error {
+	return notFoundIfNone(cq.db.Exec(`DELETE FROM chat_queue WHERE server_key = ? AND id = ?`, sk, id))
+}
@@ -0,0 +1,62 @@
+package sqlite
+
+import (
+	"testing"
+	"time"
+
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChatQueue_GetGameServerMessage(t *testing.T) {
+	t.Parallel()
+
+	cq := newTestSQLite(t).ChatQueue()
+	cq.InsertMessage(types.ChatMessage{ServerKey: "other", Tag: "chat", Message: "one"})
+	cq.InsertMessage(types.ChatMessage{ServerKey: "key", Tag: "other", Message: "two"})
+	cq.InsertMessage(types.ChatMessage{ServerKey: "key", Tag: "chat", Message: "three"})
+
+	got, found := cq.GetGameServerMessage("key", "chat", time.Minute, time.Millisecond)
+	assert.True(t, found)
+	assert.NotEmpty(t, got.ID)
+	assert.False(t, got.LeasedUntil.IsZero(), "message should be leased")
+	got.ID = ""
+	got.QueuedAt = time.Time{}
+	got.LeasedUntil = time.Time{}
+	assert.Equal(t, types.ChatMessage{ServerKey: "key", Tag: "chat", Message: "three"}, got)
+
+	_, found = cq.GetGameServerMessage("key", "chat", time.Minute, time.Millisecond)
+	assert.False(t, found, "leased message should not be delivered again")
+}
+
+func TestChatQueue_GetGameServerMessage_waits(t *testing.T) {
+	t.Parallel()
+
+	cq := newTestSQLite(t).ChatQueue()
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cq.InsertMessage(types.ChatMessage{ServerKey: "key", Tag: "chat", Message: "late"})
+	}()
+
+	got, found := cq.GetGameServerMessage("key", "chat", 0, time.Second)
+	assert.True(t, found)
+	assert.Equal(t, "late", got.Message)
+}
+
+func TestChatQueue_InsertMessage_limit(t *testing.T) {
+	t.Parallel()
+
+	s := newTestSQLite(t)
+	assert.Nil(t, s.ChatQueue().InsertMessage(types.ChatMessage{ServerKey: "other", Tag: "chat"}))
+	for i := 0; i < storage.ChatQueueServerLimit+5; i++ {
+		assert.Nil(t, s.ChatQueue().InsertMessage(types.ChatMessage{ServerKey: "key", Tag: "chat"}))
+	}
+
+	var count int
+	s.db.QueryRow(`SELECT COUNT(*) FROM chat_queue WHERE server_key = 'key'`).Scan(&count)
+	assert.Equal(t, storage.ChatQueueServerLimit, count)
+	s.db.QueryRow(`SELECT COUNT(*) FROM chat_queue WHERE server_key = 'other'`).Scan(&count)
+	assert.Equal(t, 1, count, "other servers should keep their messages")
+}
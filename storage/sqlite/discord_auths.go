@@ -0,0 +1,110 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/types"
+)
+
+// A DiscordAuths implements storage.DiscordAuthsStore
+type DiscordAuths struct {
+	db *sql.DB
+}
+
+const discordAuthColumns = `player_id, guild_snowflake, discord_name, snowflake, pin, expires_at, attempts`
+
+func scanDiscordAuth(row scanner) (types.DiscordAuth, error) {
+	var da types.DiscordAuth
+	var expiresAt sql.NullInt64
+	err := row.Scan(&da.PlayerID, &da.GuildSnowflake, &da.DiscordName, &da.Snowflake, &da.Pin,
+		&expiresAt, &da.Attempts)
+	da.ExpiresAt = scanTime(expiresAt)
+	return da, err
+}
+
+// All implements storage.DiscordAuthsStore.All
+func (d DiscordAuths) All(auths *[]types.DiscordAuth) error {
+	rows, err := d.db.Query(`SELECT ` + discordAuthColumns + ` FROM discord_auths ORDER BY rowid`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var out []types.DiscordAuth
+	for rows.Next() {
+		da, err := scanDiscordAuth(rows)
+		if err != nil {
+			return err
+		}
+		out = append(out, da)
+	}
+	*auths = out
+	return rows.Err()
+}
+
+func (d DiscordAuths) get(column, value string) (types.DiscordAuth, error) {
+	da, err := scanDiscordAuth(d.db.QueryRow(`SELECT `+discordAuthColumns+` FROM discord_auths WHERE `+
+		column+` = ? LIMIT 1`, value))
+	if err == sql.ErrNoRows {
+		return types.DiscordAuth{}, storage.ErrNotFound
+	}
+	if err != nil {
+		return types.DiscordAuth{}, err
+	}
+	return da, nil
+}
+
+func (d DiscordAuths) GetByDiscordName(discordName string) (types.DiscordAuth, error) {
+	return d.get("discord_name", discordName)
+}
+
+func (d DiscordAuths) GetByDiscordID(snowflake string) (types.DiscordAuth, error) {
+	da, err := d.get("snowflake", snowflake)
+	if err != nil {
+		return types.DiscordAuth{}, fmt.Errorf("sqlite could not find snowflake %s (%w)", snowflake, err)
+	}
+	return da, nil
+}
+
+// GetByPlayerID implements storage.DiscordAuthsStore.GetByPlayerID
+func (d DiscordAuths) GetByPlayerID(playerID string) (types.DiscordAuth, error) {
+	return d.get("player_id", playerID)
+}
+
+// Remove implements storage.DiscordAuthsStore.Remove
+func (d DiscordAuths) Remove(u storage.UserInfoGetter) error {
+	res, err := d.db.Exec(`DELETE FROM discord_auths WHERE player_id = ?`, u.GetPlayerID())
+	return notFoundIfNone(res, err)
+}
+
+// Upsert implements storage.DiscordAuthsStore.Upsert
+func (d DiscordAuths) Upsert(da types.DiscordAuth) error {
+	return withTx(d.db, func(tx *sql.Tx) error {
+		// Purge auths that expired more than DiscordAuthPurgeAfter ago
+		cutoff := iclock().Now().UTC().Add(-storage.DiscordAuthPurgeAfter)
+		_, err := tx.Exec(`DELETE FROM discord_auths WHERE expires_at IS NULL OR expires_at < ?`, timeValue(cutoff))
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`INSERT OR REPLACE INTO discord_auths (`+discordAuthColumns+`)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`, da.PlayerID, da.GuildSnowflake, da.DiscordName, da.Snowflake, da.Pin,
+			timeValue(da.ExpiresAt), da.Attempts)
+		return err
+	})
+}
+
+// IncrementAttempts implements storage.DiscordAuthsStore.IncrementAttempts
+func (d DiscordAuths) IncrementAttempts(playerID string) (int, error) {
+	var attempts int
+	err := withTx(d.db, func(tx *sql.Tx) error {
+		res, err := tx.Exec(`UPDATE discord_auths SET attempts = attempts + 1 WHERE player_id = ?`, playerID)
+		if err := notFoundIfNone(res, err); err != nil {
+			return err
+		}
+		return tx.QueryRow(`SELECT attempts FROM discord_auths WHERE player_id = ?`, playerID).Scan(&attempts)
+	})
+	return attempts, err
+}
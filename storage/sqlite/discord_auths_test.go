@@ -0,0 +1,59 @@
+package sqlite
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiscordAuths_UpsertPurges(t *testing.T) {
+	t.Parallel()
+
+	auths := newTestSQLite(t).DiscordAuths()
+	now := time.Now().UTC()
+
+	assert.Nil(t, auths.Upsert(types.DiscordAuth{PlayerID: "game:stale", ExpiresAt: now.Add(-storage.DiscordAuthPurgeAfter - time.Minute)}))
+	assert.Nil(t, auths.Upsert(types.DiscordAuth{PlayerID: "game:recent", ExpiresAt: now.Add(-time.Minute)}))
+
+	_, err := auths.GetByPlayerID("game:stale")
+	assert.Equal(t, storage.ErrNotFound, err, "auths expired longer than the purge time should be purged")
+	_, err = auths.GetByPlayerID("game:recent")
+	assert.Nil(t, err, "recently expired auths should be kept")
+}
+
+func TestDiscordAuths_migrateExpiry(t *testing.T) {
+	t.Parallel()
+
+	s, err := NewSQLite(filepath.Join(tempDir(t), "poundbot.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Close)
+
+	// A PIN requested before expiry existed, at schema version 3
+	if _, err := s.db.Exec(`CREATE TABLE schema_migrations (version INTEGER PRIMARY KEY)`); err != nil {
+		t.Fatal(err)
+	}
+	for version, m := range migrations[:3] {
+		if _, err := s.db.Exec(m); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.db.Exec(`INSERT INTO schema_migrations (version) VALUES (?)`, version+1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := s.db.Exec(`INSERT INTO discord_auths (player_id, guild_snowflake, discord_name, snowflake, pin)
+		VALUES ('game:1', 'guild', 'name#1234', 'one', 1234)`); err != nil {
+		t.Fatal(err)
+	}
+	s.Init()
+
+	got, err := s.DiscordAuths().GetByPlayerID("game:1")
+	assert.Nil(t, err)
+	assert.Equal(t, types.DiscordAuthPending, got.Status(time.Now()), "the PIN should still be usable")
+	assert.WithinDuration(t, time.Now().Add(types.DiscordAuthTTL), got.ExpiresAt, time.Minute)
+}
@@ -0,0 +1,49 @@
+package sqlite
+
+import (
+	"database/sql"
+
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/types"
+)
+
+// A GameMessages implements storage.GameMessagesStore
+type GameMessages struct {
+	db *sql.DB
+}
+
+// Add implements storage.GameMessagesStore.Add
+func (gm GameMessages) Add(m types.SentGameMessage) error {
+	return withTx(gm.db, func(tx *sql.Tx) error {
+		_, err := tx.Exec(`INSERT OR REPLACE INTO game_messages (message_id, channel_id, server_key, sent_at)
+			VALUES (?, ?, ?, ?)`, m.MessageID, m.ChannelID, m.ServerKey, timeValue(m.SentAt))
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`DELETE FROM game_messages WHERE server_key = ? AND seq NOT IN
+			(SELECT seq FROM game_messages WHERE server_key = ? ORDER BY seq DESC LIMIT ?)`,
+			m.ServerKey, m.ServerKey, storage.GameMessagesServerLimit)
+		return err
+	})
+}
+
+// Get implements storage.GameMessagesStore.Get
+func (gm GameMessages) Get(messageID string) (types.SentGameMessage, error) {
+	var m types.SentGameMessage
+	var sentAt sql.NullInt64
+	err := gm.db.QueryRow(`SELECT message_id, channel_id, server_key, sent_at FROM game_messages
+		WHERE message_id = ?`, messageID).Scan(&m.MessageID, &m.ChannelID, &m.ServerKey, &sentAt)
+	if err == sql.ErrNoRows {
+		return types.SentGameMessage{}, storage.ErrNotFound
+	}
+	if err != nil {
+		return types.SentGameMessage{}, err
+	}
+	m.SentAt = scanTime(sentAt)
+	return m, nil
+}
+
+// Remove implements storage.GameMessagesStore.Remove
+func (gm GameMessages) Remove(messageID string) error {
+	return notFoundIfNone(gm.db.Exec(`DELETE FROM game_messages WHERE message_id = ?`, messageID))
+}
@@ -0,0 +1,40 @@
+package sqlite
+
+import (
+	"database/sql"
+)
+
+// messageLocksMax mirrors the size of the capped MongoDB collection
+const messageLocksMax = 1000
+
+// A MessageLocks implements storage.MessageLocksStore
+type MessageLocks struct {
+	db *sql.DB
+}
+
+func (ml MessageLocks) Obtain(mID, mType string) bool {
+	obtained := false
+	err := withTx(ml.db, func(tx *sql.Tx) error {
+		res, err := tx.Exec(`INSERT OR IGNORE INTO message_locks (message_id, locked_at) VALUES (?, ?)`,
+			mID, timeValue(iclock().Now().UTC()))
+		if err != nil {
+			return err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return nil
+		}
+		obtained = true
+		_, err = tx.Exec(`DELETE FROM message_locks WHERE rowid <= (SELECT MAX(rowid) FROM message_locks) - ?`,
+			messageLocksMax)
+		return err
+	})
+	if err != nil {
+		log.WithError(err).Error("could not obtain message lock")
+		return false
+	}
+	return obtained
+}
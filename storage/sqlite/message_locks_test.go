@@ -0,0 +1,17 @@
+package sqlite
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageLocks_Obtain(t *testing.T) {
+	t.Parallel()
+
+	ml := newTestSQLite(t).MessageLocks()
+
+	assert.True(t, ml.Obtain("one", "raid"))
+	assert.False(t, ml.Obtain("one", "raid"), "lock should only be obtained once")
+	assert.True(t, ml.Obtain("two", "raid"))
+}
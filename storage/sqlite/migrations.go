@@ -0,0 +1,269 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// migrations are applied in order and recorded in schema_migrations. Never
+// edit a migration that has been released; add a new one instead.
+var migrations = []string{
+	// 1: initial schema
+	`
+CREATE TABLE accounts (
+	id                    TEXT PRIMARY KEY,
+	guild_snowflake       TEXT NOT NULL UNIQUE,
+	owner_snowflake       TEXT NOT NULL DEFAULT '',
+	command_prefix        TEXT NOT NULL DEFAULT '',
+	admin_snowflakes      TEXT NOT NULL DEFAULT '[]',
+	registered_player_ids TEXT NOT NULL DEFAULT '[]',
+	disabled              INTEGER NOT NULL DEFAULT 0,
+	created_at            INTEGER,
+	updated_at            INTEGER
+);
+
+CREATE TABLE servers (
+	id            INTEGER PRIMARY KEY AUTOINCREMENT,
+	account_id    TEXT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+	position      INTEGER NOT NULL,
+	key           TEXT NOT NULL,
+	name          TEXT NOT NULL DEFAULT '',
+	address       TEXT NOT NULL DEFAULT '',
+	raid_delay    TEXT NOT NULL DEFAULT '',
+	raid_cooldown TEXT NOT NULL DEFAULT '',
+	created_at    INTEGER,
+	updated_at    INTEGER
+);
+CREATE INDEX servers_key ON servers(key);
+
+CREATE TABLE server_channels (
+	server_id  INTEGER NOT NULL REFERENCES servers(id) ON DELETE CASCADE,
+	position   INTEGER NOT NULL,
+	channel_id TEXT NOT NULL,
+	tags       TEXT NOT NULL DEFAULT '[]'
+);
+CREATE INDEX server_channels_server_id ON server_channels(server_id);
+
+CREATE TABLE clans (
+	server_id  INTEGER NOT NULL REFERENCES servers(id) ON DELETE CASCADE,
+	position   INTEGER NOT NULL,
+	tag        TEXT NOT NULL,
+	owner_id   TEXT NOT NULL DEFAULT '',
+	members    TEXT NOT NULL DEFAULT '[]',
+	moderators TEXT NOT NULL DEFAULT '[]'
+);
+CREATE INDEX clans_server_id ON clans(server_id);
+
+CREATE TABLE users (
+	snowflake    TEXT PRIMARY KEY,
+	discord_name TEXT NOT NULL DEFAULT '',
+	player_name  TEXT NOT NULL DEFAULT '',
+	created_at   INTEGER,
+	updated_at   INTEGER
+);
+
+CREATE TABLE user_player_ids (
+	player_id TEXT PRIMARY KEY,
+	snowflake TEXT NOT NULL REFERENCES users(snowflake) ON DELETE CASCADE
+);
+CREATE INDEX user_player_ids_snowflake ON user_player_ids(snowflake);
+
+CREATE TABLE discord_auths (
+	player_id       TEXT PRIMARY KEY,
+	guild_snowflake TEXT NOT NULL DEFAULT '',
+	discord_name    TEXT NOT NULL DEFAULT '',
+	snowflake       TEXT NOT NULL DEFAULT '',
+	pin             INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE raid_alerts (
+	id             TEXT PRIMARY KEY,
+	player_id      TEXT NOT NULL,
+	server_name    TEXT NOT NULL DEFAULT '',
+	server_key     TEXT NOT NULL,
+	grid_positions TEXT NOT NULL DEFAULT '[]',
+	items          TEXT NOT NULL DEFAULT '{}',
+	alert_at       INTEGER,
+	valid_until    INTEGER,
+	message_id     TEXT NOT NULL DEFAULT '',
+	notify_count   INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX raid_alerts_player_server ON raid_alerts(player_id, server_key);
+
+CREATE TABLE chat_queue (
+	seq            INTEGER PRIMARY KEY AUTOINCREMENT,
+	id             TEXT NOT NULL UNIQUE,
+	server_key     TEXT NOT NULL,
+	tag            TEXT NOT NULL DEFAULT '',
+	channel_id     TEXT NOT NULL DEFAULT '',
+	clan_tag       TEXT NOT NULL DEFAULT '',
+	display_name   TEXT NOT NULL DEFAULT '',
+	message        TEXT NOT NULL DEFAULT '',
+	player_id      TEXT NOT NULL DEFAULT '',
+	discord_name   TEXT NOT NULL DEFAULT '',
+	snowflake      TEXT NOT NULL DEFAULT '',
+	sent_to_server INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX chat_queue_unsent ON chat_queue(server_key, tag, sent_to_server);
+
+CREATE TABLE message_locks (
+	message_id TEXT PRIMARY KEY,
+	locked_at  INTEGER
+);
+`,
+	// 2: raid history
+	`
+ALTER TABLE raid_alerts ADD COLUMN started_at INTEGER;
+ALTER TABLE raid_alerts ADD COLUMN last_event_at INTEGER;
+
+CREATE TABLE raid_history (
+	id             TEXT PRIMARY KEY,
+	player_id      TEXT NOT NULL,
+	server_name    TEXT NOT NULL DEFAULT '',
+	server_key     TEXT NOT NULL,
+	grid_positions TEXT NOT NULL DEFAULT '[]',
+	items          TEXT NOT NULL DEFAULT '{}',
+	started_at     INTEGER,
+	ended_at       INTEGER,
+	notify_count   INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX raid_history_player_id ON raid_history(player_id, ended_at);
+CREATE INDEX raid_history_server_key ON raid_history(server_key, ended_at);
+`,
+	// 3: chat log
+	`
+CREATE TABLE chat_log (
+	id           TEXT PRIMARY KEY,
+	source       TEXT NOT NULL,
+	server_key   TEXT NOT NULL,
+	tag          TEXT NOT NULL DEFAULT '',
+	snowflake    TEXT NOT NULL DEFAULT '',
+	discord_name TEXT NOT NULL DEFAULT '',
+	player_id    TEXT NOT NULL DEFAULT '',
+	display_name TEXT NOT NULL DEFAULT '',
+	clan_tag     TEXT NOT NULL DEFAULT '',
+	message      TEXT NOT NULL DEFAULT '',
+	sent_at      INTEGER NOT NULL
+);
+CREATE INDEX chat_log_server_key ON chat_log(server_key, sent_at);
+CREATE INDEX chat_log_snowflake ON chat_log(snowflake, sent_at);
+CREATE INDEX chat_log_player_id ON chat_log(player_id, sent_at);
+CREATE INDEX chat_log_sent_at ON chat_log(sent_at);
+`,
+	// 4: discord auth expiry and attempts. PINs requested before expiry
+	// existed get the 15 minute types.DiscordAuthTTL from now.
+	`
+ALTER TABLE discord_auths ADD COLUMN expires_at INTEGER;
+ALTER TABLE discord_auths ADD COLUMN attempts INTEGER NOT NULL DEFAULT 0;
+UPDATE discord_auths SET expires_at = (CAST(strftime('%s', 'now') AS INTEGER) + 900) * 1000;
+`,
+	// 5: leased chat queue. Messages already sent to a server are dropped.
+	`
+CREATE TABLE chat_queue_leased (
+	seq          INTEGER PRIMARY KEY AUTOINCREMENT,
+	id           TEXT NOT NULL UNIQUE,
+	server_key   TEXT NOT NULL,
+	tag          TEXT NOT NULL DEFAULT '',
+	channel_id   TEXT NOT NULL DEFAULT '',
+	clan_tag     TEXT NOT NULL DEFAULT '',
+	display_name TEXT NOT NULL DEFAULT '',
+	message      TEXT NOT NULL DEFAULT '',
+	player_id    TEXT NOT NULL DEFAULT '',
+	discord_name TEXT NOT NULL DEFAULT '',
+	snowflake    TEXT NOT NULL DEFAULT '',
+	queued_at    INTEGER,
+	leased_until INTEGER
+);
+INSERT INTO chat_queue_leased (id, server_key, tag, channel_id, clan_tag, display_name, message,
+	player_id, discord_name, snowflake)
+	SELECT id, server_key, tag, channel_id, clan_tag, display_name, message,
+	player_id, discord_name, snowflake FROM chat_queue WHERE sent_to_server = 0 ORDER BY seq;
+DROP TABLE chat_queue;
+ALTER TABLE chat_queue_leased RENAME TO chat_queue;
+CREATE INDEX chat_queue_server_tag ON chat_queue(server_key, tag, seq);
+`,
+	// 6: messages sent by game servers
+	`
+CREATE TABLE game_messages (
+	seq        INTEGER PRIMARY KEY AUTOINCREMENT,
+	message_id TEXT NOT NULL UNIQUE,
+	channel_id TEXT NOT NULL DEFAULT '',
+	server_key TEXT NOT NULL,
+	sent_at    INTEGER
+);
+CREATE INDEX game_messages_server_key ON game_messages(server_key, seq);
+`,
+	// 7: discord roles mapped to game groups
+	`
+ALTER TABLE servers ADD COLUMN role_maps TEXT NOT NULL DEFAULT '[]';
+`,
+	// 8: players online on game servers
+	`
+CREATE TABLE online_players (
+	server_key   TEXT NOT NULL,
+	player_id    TEXT NOT NULL,
+	display_name TEXT NOT NULL DEFAULT '',
+	connected_at INTEGER,
+	PRIMARY KEY (server_key, player_id)
+);
+`,
+	// 9: game server status and the discord message showing it
+	`
+CREATE TABLE server_statuses (
+	server_key  TEXT PRIMARY KEY,
+	players     INTEGER NOT NULL DEFAULT 0,
+	max_players INTEGER NOT NULL DEFAULT 0,
+	map         TEXT NOT NULL DEFAULT '',
+	seed        INTEGER NOT NULL DEFAULT 0,
+	uptime      INTEGER NOT NULL DEFAULT 0,
+	fps         REAL NOT NULL DEFAULT 0,
+	reported_at INTEGER,
+	offline     INTEGER NOT NULL DEFAULT 0,
+	channel_id  TEXT NOT NULL DEFAULT '',
+	message_id  TEXT NOT NULL DEFAULT ''
+);
+`,
+	// 10: server offline alert thresholds
+	`
+ALTER TABLE servers ADD COLUMN offline_alert TEXT NOT NULL DEFAULT '';
+`,
+	// 11: server kill feed filters
+	`
+ALTER TABLE servers ADD COLUMN kill_feed TEXT NOT NULL DEFAULT '{}';
+`,
+	// 12: server wipes
+	`
+ALTER TABLE servers ADD COLUMN last_wipe INTEGER;
+ALTER TABLE servers ADD COLUMN next_wipe INTEGER;
+ALTER TABLE servers ADD COLUMN wipe_message TEXT NOT NULL DEFAULT '';
+`,
+}
+
+// migrate applies any migrations that have not been run yet
+func migrate(db *sql.DB) error {
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY)`); err != nil {
+		return fmt.Errorf("could not create schema_migrations: %w", err)
+	}
+
+	var current int
+	if err := db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return fmt.Errorf("could not read schema version: %w", err)
+	}
+
+	for i := current; i < len(migrations); i++ {
+		version := i + 1
+		log.Printf("Migrating schema to version %d", version)
+		err := withTx(db, func(tx *sql.Tx) error {
+			if _, err := tx.Exec(migrations[i]); err != nil {
+				return err
+			}
+			_, err := tx.Exec(`INSERT INTO schema_migrations (version) VALUES (?)`, version)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("migration %d failed: %w", version, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package sqlite
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/poundbot/poundbot/types"
+)
+
+// An OnlinePlayers implements storage.OnlinePlayersStore
+type OnlinePlayers struct {
+	db *sql.DB
+}
+
+// Clear implements storage.OnlinePlayersStore.Clear
+func (op OnlinePlayers) Clear(serverKey string, before time.Time) error {
+	_, err := op.db.Exec(`DELETE FROM online_players WHERE server_key = ?
+		AND (connected_at IS NULL OR connected_at < ?)`, serverKey, timeValue(before))
+	return err
+}
+
+// Connect implements storage.OnlinePlayersStore.Connect
+func (op OnlinePlayers) Connect(player types.OnlinePlayer) error {
+	_, err := op.db.Exec(`INSERT INTO online_players (server_key, player_id, display_name, connected_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (server_key, player_id) DO UPDATE
+		SET display_name = excluded.display_name, connected_at = excluded.connected_at
+		WHERE online_players.connected_at IS NULL OR excluded.connected_at >= online_players.connected_at`,
+		player.ServerKey, player.PlayerID, player.DisplayName, timeValue(player.ConnectedAt))
+	return err
+}
+
+// Disconnect implements storage.OnlinePlayersStore.Disconnect
+func (op OnlinePlayers) Disconnect(serverKey, playerID string, at time.Time) error {
+	_, err := op.db.Exec(`DELETE FROM online_players WHERE server_key = ? AND player_id = ?
+		AND (connected_at IS NULL OR connected_at <= ?)`, serverKey, playerID, timeValue(at))
+	return err
+}
+
+// Online implements storage.OnlinePlayersStore.Online
+func (op OnlinePlayers) Online(serverKey string) ([]types.OnlinePlayer, error) {
+	rows, err := op.db.Query(`SELECT server_key, player_id, display_name, connected_at FROM online_players
+		WHERE server_key = ? ORDER BY connected_at, player_id`, serverKey)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var players []types.OnlinePlayer
+	for rows.Next() {
+		var p types.OnlinePlayer
+		var connectedAt sql.NullInt64
+		if err := rows.Scan(&p.ServerKey, &p.PlayerID, &p.DisplayName, &connectedAt); err != nil {
+			return nil, err
+		}
+		p.ConnectedAt = scanTime(connectedAt)
+		players = append(players, p)
+	}
+	return players, rows.Err()
+}
@@ -0,0 +1,161 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/types"
+)
+
+// A RaidAlerts implements storage.RaidAlertsStore
+type RaidAlerts struct {
+	db    *sql.DB
+	users storage.UsersStore
+}
+
+const raidAlertColumns = `id, player_id, server_name, server_key, grid_positions, items,
+	alert_at, valid_until, started_at, last_event_at, message_id, notify_count`
+
+func scanRaidAlert(rows *sql.Rows) (types.RaidAlert, error) {
+	var ra types.RaidAlert
+	var id, gridPositions, items string
+	var alertAt, validUntil, startedAt, lastEventAt sql.NullInt64
+	err := rows.Scan(&id, &ra.PlayerID, &ra.ServerName, &ra.ServerKey, &gridPositions, &items,
+		&alertAt, &validUntil, &startedAt, &lastEventAt, &ra.MessageID, &ra.NotifyCount)
+	if err != nil {
+		return ra, err
+	}
+	ra.ID = bson.ObjectIdHex(id)
+	ra.AlertAt = scanTime(alertAt)
+	ra.ValidUntil = scanTime(validUntil)
+	ra.StartedAt = scanTime(startedAt)
+	ra.LastEventAt = scanTime(lastEventAt)
+	if ra.GridPositions, err = scanStrings(gridPositions); err != nil {
+		return ra, err
+	}
+	err = json.Unmarshal([]byte(items), &ra.Items)
+	return ra, err
+}
+
+// AddInfo implements storage.RaidAlertsStore.AddInfo
+func (r RaidAlerts) AddInfo(alertIn, invalidIn time.Duration, ed types.EntityDeath) error {
+	for _, pid := range ed.OwnerIDs {
+		// Checking if the user exists, just bail if not
+		_, err := r.users.GetByPlayerID(pid)
+		if err != nil {
+			continue
+		}
+
+		now := time.Now().UTC()
+
+		err = withTx(r.db, func(tx *sql.Tx) error {
+			rows, err := tx.Query(`SELECT `+raidAlertColumns+` FROM raid_alerts
+				WHERE player_id = ? AND server_key = ? AND valid_until > ? LIMIT 1`,
+				pid, ed.ServerKey, timeValue(now))
+			if err != nil {
+				return err
+			}
+			var alert types.RaidAlert
+			found := rows.Next()
+			if found {
+				alert, err = scanRaidAlert(rows)
+			}
+			rows.Close()
+			if err != nil {
+				return err
+			}
+
+			if !found {
+				alert = types.RaidAlert{
+					ID:         bson.NewObjectId(),
+					PlayerID:   pid,
+					AlertAt:    now.Add(alertIn),
+					ServerName: ed.ServerName,
+					ServerKey:  ed.ServerKey,
+					StartedAt:  now,
+				}
+			}
+			if alert.Items == nil {
+				alert.Items = map[string]int{}
+			}
+
+			alert.ValidUntil = now.Add(invalidIn)
+			alert.LastEventAt = now
+			alert.Items[ed.Name]++
+			if !containsString(alert.GridPositions, ed.GridPos) {
+				alert.GridPositions = append(alert.GridPositions, ed.GridPos)
+			}
+
+			_, err = tx.Exec(`INSERT OR REPLACE INTO raid_alerts (`+raidAlertColumns+`)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				alert.ID.Hex(), alert.PlayerID, alert.ServerName, alert.ServerKey,
+				jsonValue(alert.GridPositions), jsonValue(alert.Items), timeValue(alert.AlertAt),
+				timeValue(alert.ValidUntil), timeValue(alert.StartedAt), timeValue(alert.LastEventAt),
+				alert.MessageID, alert.NotifyCount)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetReady implements storage.RaidAlertsStore.GetReady
+func (r RaidAlerts) GetReady() ([]types.RaidAlert, error) {
+	rows, err := r.db.Query(`SELECT `+raidAlertColumns+` FROM raid_alerts WHERE alert_at <= ? ORDER BY rowid`,
+		timeValue(time.Now().UTC()))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var alerts []types.RaidAlert
+	for rows.Next() {
+		alert, err := scanRaidAlert(rows)
+		if err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, alert)
+	}
+	return alerts, rows.Err()
+}
+
+// Remove implements storage.RaidAlertsStore.Remove
+func (r RaidAlerts) Remove(alert types.RaidAlert) error {
+	res, err := r.db.Exec(`DELETE FROM raid_alerts WHERE id = ?`, alert.ID.Hex())
+	return notFoundIfNone(res, err)
+}
+
+// RemoveByServer implements storage.RaidAlertsStore.RemoveByServer
+func (r RaidAlerts) RemoveByServer(serverKey string) error {
+	_, err := r.db.Exec(`DELETE FROM raid_alerts WHERE server_key = ?`, serverKey)
+	return err
+}
+
+func (r RaidAlerts) IncrementNotifyCount(ra types.RaidAlert) error {
+	icount := ra.ItemCount()
+
+	if icount == ra.NotifyCount {
+		return errors.New("notification count does not need to be incremented")
+	}
+
+	res, err := r.db.Exec(`UPDATE raid_alerts SET notify_count = ? WHERE id = ? AND notify_count = ?`,
+		icount, ra.ID.Hex(), ra.NotifyCount)
+	if err := notFoundIfNone(res, err); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return storage.ErrStale
+		}
+		return err
+	}
+	return nil
+}
+
+func (r RaidAlerts) SetMessageID(ra types.RaidAlert, messageID string) error {
+	res, err := r.db.Exec(`UPDATE raid_alerts SET message_id = ? WHERE id = ?`, messageID, ra.ID.Hex())
+	return notFoundIfNone(res, err)
+}
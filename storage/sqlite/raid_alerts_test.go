@@ -0,0 +1,39 @@
+package sqlite
+
+import (
+	"testing"
+	"time"
+
+	"github.com/poundbot/poundbot/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRaidAlerts_AddInfo(t *testing.T) {
+	t.Parallel()
+
+	s := newTestSQLite(t)
+	s.Users().UpsertPlayer(types.DiscordAuth{PlayerID: "game:1", DiscordInfo: types.DiscordInfo{Snowflake: "one"}})
+	raidAlerts := s.RaidAlerts()
+
+	ed := types.EntityDeath{ServerName: "server", ServerKey: "key", Name: "wall", GridPos: "A1",
+		OwnerIDs: []string{"game:1", "game:unregistered"}}
+	assert.Nil(t, raidAlerts.AddInfo(0, time.Minute, ed))
+	ed.GridPos = "B2"
+	assert.Nil(t, raidAlerts.AddInfo(0, time.Minute, ed))
+
+	alerts, err := raidAlerts.GetReady()
+	assert.Nil(t, err)
+	if assert.Len(t, alerts, 1) {
+		alert := alerts[0]
+		assert.Equal(t, "game:1", alert.PlayerID)
+		assert.Equal(t, []string{"A1", "B2"}, alert.GridPositions)
+		assert.Equal(t, map[string]int{"wall": 2}, alert.Items)
+
+		assert.Nil(t, raidAlerts.SetMessageID(alert, "message"))
+		assert.Nil(t, raidAlerts.IncrementNotifyCount(alert))
+		assert.NotNil(t, raidAlerts.IncrementNotifyCount(alert), "stale notify count should not update")
+
+		assert.Nil(t, raidAlerts.Remove(alert))
+		assert.NotNil(t, raidAlerts.Remove(alert))
+	}
+}
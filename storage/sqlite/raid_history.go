@@ -0,0 +1,95 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/types"
+)
+
+// A RaidHistory implements storage.RaidHistoryStore
+type RaidHistory struct {
+	db *sql.DB
+}
+
+const raidHistoryColumns = `id, player_id, server_name, server_key, grid_positions, items,
+	started_at, ended_at, notify_count`
+
+// Add implements storage.RaidHistoryStore.Add
+func (r RaidHistory) Add(rh types.RaidHistory) error {
+	_, err := r.db.Exec(`INSERT OR REPLACE INTO raid_history (`+raidHistoryColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		rh.ID.Hex(), rh.PlayerID, rh.ServerName, rh.ServerKey, jsonValue(rh.GridPositions),
+		jsonValue(rh.Items), timeValue(rh.StartedAt), timeValue(rh.EndedAt), rh.NotifyCount)
+	return err
+}
+
+// Find implements storage.RaidHistoryStore.Find
+func (r RaidHistory) Find(q storage.RaidHistoryQuery) ([]types.RaidHistory, error) {
+	if q.PlayerIDs != nil && len(q.PlayerIDs) == 0 {
+		return nil, nil
+	}
+
+	var where []string
+	var args []interface{}
+	if len(q.ServerKey) != 0 {
+		where = append(where, "server_key = ?")
+		args = append(args, q.ServerKey)
+	}
+	if len(q.ServerName) != 0 {
+		where = append(where, "server_name = ? COLLATE NOCASE")
+		args = append(args, q.ServerName)
+	}
+	if len(q.PlayerIDs) != 0 {
+		where = append(where, "player_id IN ("+placeholders(len(q.PlayerIDs))+")")
+		for _, pID := range q.PlayerIDs {
+			args = append(args, pID)
+		}
+	}
+	if !q.Since.IsZero() {
+		where = append(where, "ended_at >= ?")
+		args = append(args, timeValue(q.Since))
+	}
+
+	query := `SELECT ` + raidHistoryColumns + ` FROM raid_history`
+	if len(where) != 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY ended_at DESC"
+	if q.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, q.Limit)
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var raids []types.RaidHistory
+	for rows.Next() {
+		var rh types.RaidHistory
+		var id, gridPositions, items string
+		var startedAt, endedAt sql.NullInt64
+		err := rows.Scan(&id, &rh.PlayerID, &rh.ServerName, &rh.ServerKey, &gridPositions, &items,
+			&startedAt, &endedAt, &rh.NotifyCount)
+		if err != nil {
+			return nil, err
+		}
+		rh.ID = bson.ObjectIdHex(id)
+		rh.StartedAt = scanTime(startedAt)
+		rh.EndedAt = scanTime(endedAt)
+		if rh.GridPositions, err = scanStrings(gridPositions); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(items), &rh.Items); err != nil {
+			return nil, err
+		}
+		raids = append(raids, rh)
+	}
+	return raids, rows.Err()
+}
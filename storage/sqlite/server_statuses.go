@@ -0,0 +1,92 @@
+package sqlite
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/types"
+)
+
+const serverStatusColumns = `server_key, players, max_players, map, seed, uptime, fps, reported_at,
+	offline, channel_id, message_id`
+
+// A ServerStatuses implements storage.ServerStatusStore
+type ServerStatuses struct {
+	db *sql.DB
+}
+
+// Get implements storage.ServerStatusStore.Get
+func (ss ServerStatuses) Get(serverKey string) (types.ServerStatus, error) {
+	status, err := scanServerStatus(ss.db.QueryRow(
+		`SELECT `+serverStatusColumns+` FROM server_statuses WHERE server_key = ?`, serverKey))
+	if err == sql.ErrNoRows {
+		return types.ServerStatus{}, storage.ErrNotFound
+	}
+	return status, err
+}
+
+// MarkOffline implements storage.ServerStatusStore.MarkOffline
+func (ss ServerStatuses) MarkOffline(before time.Time) ([]types.ServerStatus, error) {
+	var marked []types.ServerStatus
+	err := withTx(ss.db, func(tx *sql.Tx) error {
+		rows, err := tx.Query(`SELECT `+serverStatusColumns+` FROM server_statuses
+			WHERE offline = 0 AND (reported_at IS NULL OR reported_at < ?) ORDER BY server_key`, timeValue(before))
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			status, err := scanServerStatus(rows)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			status.Offline = true
+			marked = append(marked, status)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for _, status := range marked {
+			if _, err := tx.Exec(`UPDATE server_statuses SET offline = 1 WHERE server_key = ?`, status.ServerKey); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return marked, nil
+}
+
+// Report implements storage.ServerStatusStore.Report
+func (ss ServerStatuses) Report(status types.ServerStatus) error {
+	_, err := ss.db.Exec(`INSERT INTO server_statuses
+		(server_key, players, max_players, map, seed, uptime, fps, reported_at, offline)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, 0)
+		ON CONFLICT (server_key) DO UPDATE
+		SET players = excluded.players, max_players = excluded.max_players, map = excluded.map,
+			seed = excluded.seed, uptime = excluded.uptime, fps = excluded.fps,
+			reported_at = excluded.reported_at, offline = 0`,
+		status.ServerKey, status.Players, status.MaxPlayers, status.Map, status.Seed, status.Uptime,
+		status.FPS, timeValue(status.ReportedAt))
+	return err
+}
+
+// SetMessage implements storage.ServerStatusStore.SetMessage
+func (ss ServerStatuses) SetMessage(serverKey, channelID, messageID string) error {
+	return notFoundIfNone(ss.db.Exec(`UPDATE server_statuses SET channel_id = ?, message_id = ? WHERE server_key = ?`,
+		channelID, messageID, serverKey))
+}
+
+func scanServerStatus(row scanner) (types.ServerStatus, error) {
+	var status types.ServerStatus
+	var reportedAt sql.NullInt64
+	err := row.Scan(&status.ServerKey, &status.Players, &status.MaxPlayers, &status.Map, &status.Seed,
+		&status.Uptime, &status.FPS, &reportedAt, &status.Offline, &status.ChannelID, &status.MessageID)
+	status.ReportedAt = scanTime(reportedAt)
+	return status, err
+}
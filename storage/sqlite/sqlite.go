@@ -0,0 +1,202 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	// Registers the sqlite3 database/sql driver
+	_ "github.com/mattn/go-sqlite3"
+
+	pblog "github.com/poundbot/poundbot/log"
+	"github.com/poundbot/poundbot/pbclock"
+	"github.com/poundbot/poundbot/storage"
+)
+
+var log = pblog.Log.WithField("sys", "SQLITE")
+
+var iclock = pbclock.Clock
+
+// queryer is implemented by both *sql.DB and *sql.Tx
+type queryer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// scanner is implemented by both *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// A SQLite implements storage.Storage for an SQLite database file
+type SQLite struct {
+	path      string
+	db        *sql.DB
+	chatQueue *ChatQueue
+	isCopy    bool
+}
+
+// NewSQLite opens the SQLite database at path, creating it if needed
+func NewSQLite(path string) (*SQLite, error) {
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?_busy_timeout=5000&_foreign_keys=on", path))
+	if err != nil {
+		return nil, fmt.Errorf("could not open %s: %w", path, err)
+	}
+
+	// SQLite only allows one writer at a time. Sharing a single connection
+	// avoids "database is locked" errors between goroutines.
+	db.SetMaxOpenConns(1)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("could not open %s: %w", path, err)
+	}
+
+	return &SQLite{path: path, db: db, chatQueue: newChatQueue(db)}, nil
+}
+
+// Copy implements storage.Storage.Copy. The connection pool is shared, so
+// closing a copy does not close the database.
+func (s *SQLite) Copy() storage.Storage {
+	return &SQLite{path: s.path, db: s.db, chatQueue: s.chatQueue, isCopy: true}
+}
+
+// Close implements storage.Storage.Close
+func (s *SQLite) Close() {
+	if s.isCopy {
+		return
+	}
+	s.db.Close()
+}
+
+// Init implements storage.Storage.Init by creating or migrating the schema
+func (s *SQLite) Init() {
+	log.Printf("Database is %s", s.path)
+	if err := migrate(s.db); err != nil {
+		log.WithError(err).Panic("could not migrate database")
+	}
+}
+
+// Accounts implements storage.Storage.Accounts
+func (s *SQLite) Accounts() storage.AccountsStore {
+	return Accounts{db: s.db}
+}
+
+// Users implements storage.Storage.Users
+func (s *SQLite) Users() storage.UsersStore {
+	return Users{db: s.db}
+}
+
+// DiscordAuths implements storage.Storage.DiscordAuths
+func (s *SQLite) DiscordAuths() storage.DiscordAuthsStore {
+	return DiscordAuths{db: s.db}
+}
+
+// RaidAlerts implements storage.Storage.RaidAlerts
+func (s *SQLite) RaidAlerts() storage.RaidAlertsStore {
+	return RaidAlerts{db: s.db, users: s.Users()}
+}
+
+// RaidHistory implements storage.Storage.RaidHistory
+func (s *SQLite) RaidHistory() storage.RaidHistoryStore {
+	return RaidHistory{db: s.db}
+}
+
+// ChatLog implements storage.Storage.ChatLog
+func (s *SQLite) ChatLog() storage.ChatLogStore {
+	return ChatLog{db: s.db}
+}
+
+// ChatQueue implements storage.Storage.ChatQueue
+func (s *SQLite) ChatQueue() storage.ChatQueueStore {
+	return s.chatQueue
+}
+
+// GameMessages implements storage.Storage.GameMessages
+func (s *SQLite) GameMessages() storage.GameMessagesStore {
+	return GameMessages{db: s.db}
+}
+
+// OnlinePlayers implements storage.Storage.OnlinePlayers
+func (s *SQLite) OnlinePlayers() storage.OnlinePlayersStore {
+	return OnlinePlayers{db: s.db}
+}
+
+// ServerStatuses implements storage.Storage.ServerStatuses
+func (s *SQLite) ServerStatuses() storage.ServerStatusStore {
+	return ServerStatuses{db: s.db}
+}
+
+// MessageLocks implements storage.Storage.MessageLocks
+func (s *SQLite) MessageLocks() storage.MessageLocksStore {
+	return MessageLocks{db: s.db}
+}
+
+// withTx runs f in a transaction, committing if f returns nil
+func withTx(db *sql.DB, f func(tx *sql.Tx) error) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := f(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// timeValue converts a time to unix milliseconds, the same precision
+// MongoDB stores. Zero times are stored as NULL.
+func timeValue(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+// scanTime converts a column written by timeValue back to a time
+func scanTime(ms sql.NullInt64) time.Time {
+	if !ms.Valid {
+		return time.Time{}
+	}
+	return time.Unix(0, ms.Int64*int64(time.Millisecond)).UTC()
+}
+
+// jsonValue encodes v as JSON for storing lists and maps in a column
+func jsonValue(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("sqlite: could not marshal %T: %s", v, err))
+	}
+	return string(b)
+}
+
+// scanStrings decodes a JSON list of strings, returning nil when empty to
+// match omitempty MongoDB fields.
+func scanStrings(s string) ([]string, error) {
+	var list []string
+	if err := json.Unmarshal([]byte(s), &list); err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, nil
+	}
+	return list, nil
+}
+
+// placeholders returns n comma separated query placeholders
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+func containsString(list []string, s string) bool {
+	for i := range list {
+		if list[i] == s {
+			return true
+		}
+	}
+	return false
+}
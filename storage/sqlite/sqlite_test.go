@@ -0,0 +1,67 @@
+package sqlite
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/storage/storagetest"
+)
+
+// tempDir creates a temporary directory that is removed when the test
+// finishes
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "poundbot-sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+// newTestSQLite creates an initialized database that is closed and removed
+// when the test finishes.
+func newTestSQLite(t *testing.T) *SQLite {
+	s, err := NewSQLite(filepath.Join(tempDir(t), "poundbot.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Init()
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestSQLite(t *testing.T) {
+	t.Parallel()
+
+	dir := tempDir(t)
+	var count int
+	storagetest.Run(t, func() storage.Storage {
+		count++
+		s, err := NewSQLite(filepath.Join(dir, fmt.Sprintf("%d.db", count)))
+		if err != nil {
+			panic(err)
+		}
+		return s
+	})
+}
+
+func TestSQLite_Init(t *testing.T) {
+	t.Parallel()
+
+	s := newTestSQLite(t)
+
+	// Running the migrations again must be a no-op
+	s.Init()
+
+	var version int
+	if err := s.db.QueryRow(`SELECT MAX(version) FROM schema_migrations`).Scan(&version); err != nil {
+		t.Fatal(err)
+	}
+	if version != len(migrations) {
+		t.Errorf("schema version is %d, want %d", version, len(migrations))
+	}
+}
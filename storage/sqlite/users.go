@@ -0,0 +1,159 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/types"
+)
+
+// A Users implements storage.UsersStore
+type Users struct {
+	db *sql.DB
+}
+
+func loadUser(q queryer, snowflake string) (types.User, error) {
+	var u types.User
+	var createdAt, updatedAt sql.NullInt64
+	err := q.QueryRow(`SELECT snowflake, discord_name, player_name, created_at, updated_at FROM users WHERE snowflake = ?`,
+		snowflake).Scan(&u.Snowflake, &u.DiscordName, &u.PlayerName, &createdAt, &updatedAt)
+	if err == sql.ErrNoRows {
+		return types.User{}, storage.ErrNotFound
+	}
+	if err != nil {
+		return types.User{}, err
+	}
+	u.CreatedAt = scanTime(createdAt)
+	u.UpdatedAt = scanTime(updatedAt)
+
+	rows, err := q.Query(`SELECT player_id FROM user_player_ids WHERE snowflake = ? ORDER BY rowid`, snowflake)
+	if err != nil {
+		return types.User{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var pID string
+		if err := rows.Scan(&pID); err != nil {
+			return types.User{}, err
+		}
+		u.PlayerIDs = append(u.PlayerIDs, pID)
+	}
+	return u, rows.Err()
+}
+
+// All implements storage.UsersStore.All
+func (u Users) All(users *[]types.User) error {
+	rows, err := u.db.Query(`SELECT snowflake FROM users ORDER BY rowid`)
+	if err != nil {
+		return err
+	}
+	var snowflakes []string
+	for rows.Next() {
+		var snowflake string
+		if err := rows.Scan(&snowflake); err != nil {
+			rows.Close()
+			return err
+		}
+		snowflakes = append(snowflakes, snowflake)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	var out []types.User
+	for _, snowflake := range snowflakes {
+		user, err := loadUser(u.db, snowflake)
+		if err != nil {
+			return err
+		}
+		out = append(out, user)
+	}
+	*users = out
+	return nil
+}
+
+func (u Users) GetByPlayerID(gameUserID string) (types.User, error) {
+	var snowflake string
+	err := u.db.QueryRow(`SELECT snowflake FROM user_player_ids WHERE player_id = ?`, gameUserID).Scan(&snowflake)
+	if err == sql.ErrNoRows {
+		return types.User{}, storage.ErrNotFound
+	}
+	if err != nil {
+		return types.User{}, err
+	}
+	return loadUser(u.db, snowflake)
+}
+
+func (u Users) GetByDiscordID(snowflake string) (types.User, error) {
+	return loadUser(u.db, snowflake)
+}
+
+func (u Users) GetPlayerIDsByDiscordIDs(snowflakes []string) ([]string, error) {
+	playerIDs := []string{}
+	if len(snowflakes) == 0 {
+		return playerIDs, nil
+	}
+
+	args := make([]interface{}, len(snowflakes))
+	for i := range snowflakes {
+		args[i] = snowflakes[i]
+	}
+	rows, err := u.db.Query(`SELECT player_id FROM user_player_ids WHERE snowflake IN (`+
+		placeholders(len(args))+`) ORDER BY rowid`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var pID string
+		if err := rows.Scan(&pID); err != nil {
+			return nil, err
+		}
+		playerIDs = append(playerIDs, pID)
+	}
+	return playerIDs, rows.Err()
+}
+
+func (u Users) UpsertPlayer(info storage.UserInfoGetter) error {
+	now := timeValue(time.Now().UTC())
+	return withTx(u.db, func(tx *sql.Tx) error {
+		var owner string
+		err := tx.QueryRow(`SELECT snowflake FROM user_player_ids WHERE player_id = ?`, info.GetPlayerID()).Scan(&owner)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		if err == nil && owner != info.GetDiscordID() {
+			return fmt.Errorf("sqlite: player id is already registered to another user: %w", storage.ErrConflict)
+		}
+
+		if _, err := tx.Exec(`INSERT INTO users (snowflake, created_at, updated_at) VALUES (?, ?, ?)
+			ON CONFLICT (snowflake) DO UPDATE SET updated_at = excluded.updated_at`,
+			info.GetDiscordID(), now, now); err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`INSERT OR IGNORE INTO user_player_ids (player_id, snowflake) VALUES (?, ?)`,
+			info.GetPlayerID(), info.GetDiscordID())
+		return err
+	})
+}
+
+func (u Users) RemovePlayerID(snowflake, playerID string) error {
+	if playerID == "all" {
+		res, err := u.db.Exec(`DELETE FROM users WHERE snowflake = ?`, snowflake)
+		return notFoundIfNone(res, err)
+	}
+
+	return withTx(u.db, func(tx *sql.Tx) error {
+		res, err := tx.Exec(`UPDATE users SET updated_at = ? WHERE snowflake = ?`, timeValue(time.Now().UTC()), snowflake)
+		if err := notFoundIfNone(res, err); err != nil {
+			return err
+		}
+		_, err = tx.Exec(`DELETE FROM user_player_ids WHERE snowflake = ? AND player_id = ?`, snowflake, playerID)
+		return err
+	})
+}
@@ -0,0 +1,39 @@
+package sqlite
+
+import (
+	"testing"
+
+	"github.com/poundbot/poundbot/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUsers_UpsertPlayer(t *testing.T) {
+	t.Parallel()
+
+	users := newTestSQLite(t).Users()
+
+	assert.Nil(t, users.UpsertPlayer(types.DiscordAuth{PlayerID: "game:1", DiscordInfo: types.DiscordInfo{Snowflake: "one"}}))
+	assert.Nil(t, users.UpsertPlayer(types.DiscordAuth{PlayerID: "game:2", DiscordInfo: types.DiscordInfo{Snowflake: "one"}}))
+	assert.Nil(t, users.UpsertPlayer(types.DiscordAuth{PlayerID: "game:2", DiscordInfo: types.DiscordInfo{Snowflake: "one"}}))
+	assert.NotNil(t,
+		users.UpsertPlayer(types.DiscordAuth{PlayerID: "game:1", DiscordInfo: types.DiscordInfo{Snowflake: "two"}}),
+		"player IDs can only belong to one user",
+	)
+
+	user, err := users.GetByPlayerID("game:2")
+	assert.Nil(t, err)
+	assert.Equal(t, "one", user.Snowflake)
+	assert.Equal(t, []string{"game:1", "game:2"}, user.PlayerIDs)
+
+	playerIDs, err := users.GetPlayerIDsByDiscordIDs([]string{"one", "two"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"game:1", "game:2"}, playerIDs)
+
+	assert.Nil(t, users.RemovePlayerID("one", "game:1"))
+	user, _ = users.GetByDiscordID("one")
+	assert.Equal(t, []string{"game:2"}, user.PlayerIDs)
+
+	assert.Nil(t, users.RemovePlayerID("one", "all"))
+	_, err = users.GetByPlayerID("game:2")
+	assert.NotNil(t, err, "removing all should remove the player IDs")
+}
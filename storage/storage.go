@@ -11,9 +11,104 @@ type UserInfoGetter interface {
 	GetDiscordID() string
 }
 
+// ChatQueueServerLimit is how many messages can be queued for one server.
+// Queueing more drops the server's oldest messages.
+const ChatQueueServerLimit = 100
+
+// ChatQueueStore queues chat from Discord for the game servers. Messages
+// are delivered at least once, in the order they were queued for each
+// server and tag.
+//
+// GetGameServerMessage waits up to timeout for the oldest message for the
+// server and tag that isn't leased, and leases it to the game server.
+// Messages that aren't acknowledged before their lease ends are handed out
+// again. A zero lease removes the message as it is handed out, for game
+// servers that can't acknowledge messages. A zero timeout checks once
+// without waiting.
+//
+// AckMessage removes a message leased to the server. It returns ErrNotFound
+// if the server has no message with that ID.
+//
+// InsertMessage queues a message, dropping the oldest messages for the
+// server if it has more than ChatQueueServerLimit.
+//
+// ReadyKeys lists the servers and tags that have messages ready to hand
+// out, either never leased or with an expired lease.
 type ChatQueueStore interface {
-	GetGameServerMessage(serverKey, tag string, timeout time.Duration) (message types.ChatMessage, success bool)
+	AckMessage(serverKey, id string) error
+	GetGameServerMessage(serverKey, tag string, lease, timeout time.Duration) (message types.ChatMessage, success bool)
 	InsertMessage(message types.ChatMessage) error
+	ReadyKeys() ([]ChatQueueKey, error)
+}
+
+// A ChatQueueKey is a server key and tag that chat is queued for
+type ChatQueueKey struct {
+	ServerKey string
+	Tag       string
+}
+
+// GameMessagesServerLimit is how many sent messages are kept for one
+// server. Adding more forgets the server's oldest messages, which can then
+// no longer be edited or deleted.
+const GameMessagesServerLimit = 1000
+
+// GameMessagesStore keeps the Discord messages sent by game servers, so a
+// message can only be edited or deleted by the server that sent it.
+//
+// Add keeps a sent message, forgetting the server's oldest messages if it
+// has more than GameMessagesServerLimit.
+//
+// Get gets a sent message by its Discord message ID.
+//
+// Remove forgets a sent message. It returns ErrNotFound if there is no
+// message with that ID.
+type GameMessagesStore interface {
+	Add(types.SentGameMessage) error
+	Get(messageID string) (types.SentGameMessage, error)
+	Remove(messageID string) error
+}
+
+// OnlinePlayersStore keeps the players connected to each game server.
+// Player IDs include the game.
+//
+// Connect adds a player to the server's roster, or updates them if they
+// are already online. It is ignored if the player is online from a later
+// connection.
+//
+// Disconnect removes a player from the server's roster, unless they
+// connected after at. It is not an error if the player isn't online.
+//
+// Clear removes the players who connected to a server before a time, for
+// when the server restarts, goes offline or is removed. It is not an error
+// if the server has no players online.
+//
+// Online lists a server's online players, the earliest connected first.
+type OnlinePlayersStore interface {
+	Clear(serverKey string, before time.Time) error
+	Connect(types.OnlinePlayer) error
+	Disconnect(serverKey, playerID string, at time.Time) error
+	Online(serverKey string) ([]types.OnlinePlayer, error)
+}
+
+// ServerStatusStore keeps the status last reported by each game server, and
+// the Discord message showing it.
+//
+// Get gets a server's status. It returns ErrNotFound if the server has never
+// reported its status.
+//
+// MarkOffline marks the online servers that haven't reported since before
+// as offline, and returns them.
+//
+// Report saves a server's status and marks it online, keeping its status
+// message.
+//
+// SetMessage sets the Discord message showing a server's status. It returns
+// ErrNotFound if the server has never reported its status.
+type ServerStatusStore interface {
+	Get(serverKey string) (types.ServerStatus, error)
+	MarkOffline(before time.Time) ([]types.ServerStatus, error)
+	Report(types.ServerStatus) error
+	SetMessage(serverKey, channelID, messageID string) error
 }
 
 type MessageLocksStore interface {
@@ -22,6 +117,8 @@ type MessageLocksStore interface {
 
 // UsersStore is for accessing the user store.
 //
+// All gets every user, for backups.
+//
 // Get gets a user from store.
 //
 // UpsertBase updates or creates a user in the store
@@ -33,6 +130,7 @@ type MessageLocksStore interface {
 //
 // SetClanIn sets the clan tag on all users who have the provided steam IDs.
 type UsersStore interface {
+	All(*[]types.User) error
 	GetByPlayerID(PlayerID string) (types.User, error)
 	GetByDiscordID(snowflake string) (types.User, error)
 	GetPlayerIDsByDiscordIDs(snowflakes []string) ([]string, error)
@@ -40,16 +138,29 @@ type UsersStore interface {
 	RemovePlayerID(snowflake, playerID string) error
 }
 
+// DiscordAuthPurgeAfter is how long a discord auth is kept after it
+// expires, so its status can still be checked
+const DiscordAuthPurgeAfter = 24 * time.Hour
+
 // DiscordAuthsStore is for accessing the discord -> user authentications
-// in the store.
+// in the store. Stores purge auths automatically once they have been
+// expired for DiscordAuthPurgeAfter.
+//
+// All gets every pending discord auth, for backups.
 //
 // Upsert created or updates a discord auth
 //
+// IncrementAttempts atomically counts a wrong PIN for a player's auth and
+// returns the attempts made, so concurrent guesses are all counted
+//
 // Remove removes a discord auth
 type DiscordAuthsStore interface {
+	All(*[]types.DiscordAuth) error
 	GetByDiscordName(discordName string) (types.DiscordAuth, error)
 	GetByDiscordID(snowflake string) (types.DiscordAuth, error)
+	GetByPlayerID(playerID string) (types.DiscordAuth, error)
 	Upsert(types.DiscordAuth) error
+	IncrementAttempts(playerID string) (int, error)
 	Remove(UserInfoGetter) error
 }
 
@@ -61,17 +172,78 @@ type DiscordAuthsStore interface {
 // AddInfo adds or updated raid information to a raid alert
 //
 // Remove deletes a raid alert
+//
+// RemoveByServer deletes every raid alert for a server
 type RaidAlertsStore interface {
 	GetReady() ([]types.RaidAlert, error)
 	AddInfo(alertIn, validUntil time.Duration, ed types.EntityDeath) error
 	Remove(types.RaidAlert) error
+	RemoveByServer(serverKey string) error
 	IncrementNotifyCount(types.RaidAlert) error
 	SetMessageID(types.RaidAlert, string) error
 }
 
+// RaidHistoryStore is for finished raids
+//
+// Add archives a raid. Adding a raid with the same ID replaces it.
+//
+// Find gets the raids matching a query, most recently ended first
+type RaidHistoryStore interface {
+	Add(types.RaidHistory) error
+	Find(RaidHistoryQuery) ([]types.RaidHistory, error)
+}
+
+// A RaidHistoryQuery selects raids from a RaidHistoryStore. Empty fields
+// match everything, except PlayerIDs, which only matches everything when
+// nil.
+type RaidHistoryQuery struct {
+	ServerKey  string
+	ServerName string    // Case insensitive
+	PlayerIDs  []string  // Any of the player IDs. Empty but not nil matches nothing.
+	Since      time.Time // Raids that ended at or after
+	Limit      int
+}
+
+// ChatLogStore is the archive of relayed chat messages
+//
+// Add archives a message.
+//
+// Find gets the messages matching a query, most recent first.
+//
+// RemoveBefore removes messages sent before a time and returns how many
+// were removed.
+type ChatLogStore interface {
+	Add(types.ChatLogEntry) error
+	Find(ChatLogQuery) ([]types.ChatLogEntry, error)
+	RemoveBefore(time.Time) (int, error)
+}
+
+// A ChatLogQuery selects messages from a ChatLogStore. Empty fields match
+// everything. When both Snowflake and PlayerIDs are set, messages matching
+// either are returned.
+type ChatLogQuery struct {
+	ServerKeys []string  // Any of the server keys
+	Snowflake  string    // Sent by the Discord user
+	PlayerIDs  []string  // Sent by any of the players
+	Since      time.Time // Sent at or after
+	Limit      int
+}
+
 // AccountsStore is for accounts storage
+//
+// WithOfflineAlerts gets the enabled accounts with a server that has an
+// offline alert, so they can be watched without loading every account
+//
+// WithScheduledWipes gets the enabled accounts with a server that has a
+// wipe scheduled after a time
+//
+// RecordWipe clears a server's clans and sets when it last wiped. Its next
+// wipe is cleared if it was scheduled before scheduledBefore, as that is
+// the wipe that happened.
 type AccountsStore interface {
 	All(*[]types.Account) error
+	WithOfflineAlerts(*[]types.Account) error
+	WithScheduledWipes(after time.Time, accounts *[]types.Account) error
 	GetByDiscordGuild(snowflake string) (types.Account, error)
 	GetByServerKey(serverKey string) (types.Account, error)
 	UpsertBase(types.BaseAccount) error
@@ -84,6 +256,7 @@ type AccountsStore interface {
 	AddClan(serverKey string, clan types.Clan) error
 	RemoveClan(serverKey, clanTag string) error
 	SetClans(serverKey string, clans []types.Clan) error
+	RecordWipe(serverKey string, wipedAt, scheduledBefore time.Time) error
 
 	SetRegisteredPlayerIDs(accountID string, playerIDsList []string) error
 	AddRegisteredPlayerIDs(accountID string, playerIDs []string) error
@@ -111,5 +284,11 @@ type Storage interface {
 	Users() UsersStore
 	DiscordAuths() DiscordAuthsStore
 	RaidAlerts() RaidAlertsStore
+	RaidHistory() RaidHistoryStore
+	ChatLog() ChatLogStore
 	ChatQueue() ChatQueueStore
+	GameMessages() GameMessagesStore
+	OnlinePlayers() OnlinePlayersStore
+	ServerStatuses() ServerStatusStore
+	MessageLocks() MessageLocksStore
 }
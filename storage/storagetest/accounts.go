@@ -0,0 +1,264 @@
+package storagetest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func testAccounts(t *testing.T, s storage.Storage) {
+	t.Run("UpsertBase", func(t *testing.T) { accountsUpsertBase(t, s.Accounts()) })
+	t.Run("Remove", func(t *testing.T) { accountsRemove(t, s.Accounts()) })
+	t.Run("Servers", func(t *testing.T) { accountsServers(t, s.Accounts()) })
+	t.Run("WithOfflineAlerts", func(t *testing.T) { accountsWithOfflineAlerts(t, s.Accounts()) })
+	t.Run("WithScheduledWipes", func(t *testing.T) { accountsWithScheduledWipes(t, s.Accounts()) })
+	t.Run("RecordWipe", func(t *testing.T) { accountsRecordWipe(t, s.Accounts()) })
+	t.Run("Clans", func(t *testing.T) { accountsClans(t, s.Accounts()) })
+	t.Run("RegisteredPlayerIDs", func(t *testing.T) { accountsRegisteredPlayerIDs(t, s.Accounts()) })
+	t.Run("RemoveNotInDiscordGuildList", func(t *testing.T) { accountsRemoveNotInDiscordGuildList(t, s.Accounts()) })
+}
+
+func accountsUpsertBase(t *testing.T, accounts storage.AccountsStore) {
+	_, err := accounts.GetByDiscordGuild("upsert")
+	assertErrorIs(t, err, storage.ErrNotFound, "missing guild should not be found")
+
+	assert.Nil(t, accounts.UpsertBase(types.BaseAccount{
+		GuildSnowflake:  "upsert",
+		OwnerSnowflake:  "owner",
+		CommandPrefix:   "!",
+		AdminSnowflakes: []string{"admin"},
+	}))
+
+	account, err := accounts.GetByDiscordGuild("upsert")
+	assert.Nil(t, err)
+	assert.NotEmpty(t, account.ID)
+	assert.False(t, account.CreatedAt.IsZero(), "CreatedAt should be set on insert")
+	assert.Equal(t, "owner", account.OwnerSnowflake)
+	assert.Equal(t, "!", account.CommandPrefix)
+
+	assert.Nil(t, accounts.UpsertBase(types.BaseAccount{GuildSnowflake: "upsert", OwnerSnowflake: "owner2"}))
+
+	updated, err := accounts.GetByDiscordGuild("upsert")
+	assert.Nil(t, err)
+	assert.Equal(t, account.ID, updated.ID, "upsert should update the existing account")
+	assert.Equal(t, "owner2", updated.OwnerSnowflake)
+	assert.Equal(t, []string{"admin"}, updated.AdminSnowflakes, "empty admins should not be overwritten")
+}
+
+func accountsRemove(t *testing.T, accounts storage.AccountsStore) {
+	assertErrorIs(t, accounts.Remove("remove"), storage.ErrNotFound, "missing guild should not be found")
+
+	accounts.UpsertBase(types.BaseAccount{GuildSnowflake: "remove"})
+	assert.Nil(t, accounts.Remove("remove"))
+
+	account, err := accounts.GetByDiscordGuild("remove")
+	assert.Nil(t, err, "removed accounts are disabled, not deleted")
+	assert.True(t, account.Disabled)
+}
+
+func accountsServers(t *testing.T, accounts storage.AccountsStore) {
+	assertErrorIs(t, accounts.AddServer("servers", types.AccountServer{Key: "key"}), storage.ErrNotFound, "missing guild should not be found")
+
+	accounts.UpsertBase(types.BaseAccount{GuildSnowflake: "servers"})
+	assert.Nil(t, accounts.AddServer("servers", types.AccountServer{Key: "key", Name: "server"}))
+	assert.Nil(t, accounts.AddServer("servers", types.AccountServer{Key: "key2", Name: "server2"}))
+
+	account, err := accounts.GetByServerKey("key2")
+	assert.Nil(t, err)
+	assert.Equal(t, "servers", account.GuildSnowflake)
+	if assert.Len(t, account.Servers, 2) {
+		assert.Equal(t, "server", account.Servers[0].Name, "servers should keep their order")
+		assert.False(t, account.Servers[0].CreatedAt.IsZero(), "CreatedAt should be set on AddServer")
+	}
+
+	_, err = accounts.GetByServerKey("missing")
+	assertErrorIs(t, err, storage.ErrNotFound, "missing server should not be found")
+
+	assert.Nil(t, accounts.Touch("key"))
+	account, _ = accounts.GetByServerKey("key")
+	assert.False(t, account.Servers[0].UpdatedAt.IsZero(), "Touch should set the server UpdatedAt")
+	assertErrorIs(t, accounts.Touch("missing"), storage.ErrNotFound, "missing server should not be found")
+
+	server := account.Servers[0]
+	server.Key = "newkey"
+	server.Name = "renamed"
+	server.SetChannelIDForTag("1234", "chat")
+	server.SetChannelIDForTag("1234", "serverchat")
+	server.SetChannelIDForTag("5678", "raids")
+	server.SetRoleMap("role", "vip")
+	server.OfflineAlert = "10m"
+	server.KillFeed = types.KillFeedFilter{PvPOnly: true, MinDistance: 50}
+	lastWipe := time.Date(2020, 6, 4, 18, 0, 0, 0, time.UTC)
+	server.LastWipe = lastWipe
+	server.NextWipe = lastWipe.AddDate(0, 0, 7)
+	server.WipeMessage = "Fresh map!"
+	assert.Nil(t, accounts.UpdateServer("servers", "key", server))
+	assertErrorIs(t, accounts.UpdateServer("other", "newkey", server), storage.ErrNotFound, "guild must match")
+
+	account, err = accounts.GetByServerKey("newkey")
+	assert.Nil(t, err)
+	got, err := account.ServerFromKey("newkey")
+	assert.Nil(t, err)
+	assert.Equal(t, "renamed", got.Name)
+	assert.Equal(t, []types.RoleMap{{RoleID: "role", Group: "vip"}}, got.RoleMaps)
+	assert.Equal(t, "10m", got.OfflineAlert)
+	assert.Equal(t, types.KillFeedFilter{PvPOnly: true, MinDistance: 50}, got.KillFeed)
+	assert.True(t, lastWipe.Equal(got.LastWipe), "last wipe should be saved")
+	assert.True(t, lastWipe.AddDate(0, 0, 7).Equal(got.NextWipe), "next wipe should be saved")
+	assert.Equal(t, "Fresh map!", got.WipeMessage)
+	for tag, want := range map[string]string{"chat": "1234", "serverchat": "1234", "raids": "5678"} {
+		channelID, found := got.ChannelIDForTag(tag)
+		assert.True(t, found, tag)
+		assert.Equal(t, want, channelID, tag)
+	}
+
+	assert.Nil(t, accounts.RemoveServer("servers", "newkey"))
+	_, err = accounts.GetByServerKey("newkey")
+	assertErrorIs(t, err, storage.ErrNotFound, "removed server should not be found")
+	account, _ = accounts.GetByServerKey("key2")
+	assert.Len(t, account.Servers, 1, "other servers should be kept")
+}
+
+func accountsWithOfflineAlerts(t *testing.T, accounts storage.AccountsStore) {
+	for _, guild := range []string{"alerts", "no-alerts", "disabled-alerts"} {
+		accounts.UpsertBase(types.BaseAccount{GuildSnowflake: guild})
+	}
+	accounts.AddServer("alerts", types.AccountServer{Key: "alerts-quiet", Name: "Quiet"})
+	accounts.AddServer("alerts", types.AccountServer{Key: "alerts-watched", Name: "Watched", OfflineAlert: "10m"})
+	accounts.AddServer("no-alerts", types.AccountServer{Key: "no-alerts", Name: "Quiet"})
+	accounts.AddServer("disabled-alerts", types.AccountServer{Key: "disabled-alerts", Name: "Watched", OfflineAlert: "10m"})
+	accounts.Remove("disabled-alerts")
+
+	var found []types.Account
+	assert.Nil(t, accounts.WithOfflineAlerts(&found))
+	guilds := map[string]int{}
+	for _, account := range found {
+		guilds[account.GuildSnowflake] = len(account.Servers)
+	}
+	assert.Equal(t, map[string]int{"alerts": 2}, guilds, "only enabled accounts with offline alerts should be found, with all their servers")
+}
+
+func accountsWithScheduledWipes(t *testing.T, accounts storage.AccountsStore) {
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	for _, guild := range []string{"wipes", "past-wipes", "disabled-wipes"} {
+		accounts.UpsertBase(types.BaseAccount{GuildSnowflake: guild})
+	}
+	accounts.AddServer("wipes", types.AccountServer{Key: "wipes-none", Name: "None"})
+	accounts.AddServer("wipes", types.AccountServer{Key: "wipes-next", Name: "Next", NextWipe: now.Add(time.Hour)})
+	accounts.AddServer("past-wipes", types.AccountServer{Key: "past-wipes", Name: "Past", NextWipe: now.Add(-time.Hour)})
+	accounts.AddServer("disabled-wipes", types.AccountServer{Key: "disabled-wipes", Name: "Next", NextWipe: now.Add(time.Hour)})
+	accounts.Remove("disabled-wipes")
+
+	var found []types.Account
+	assert.Nil(t, accounts.WithScheduledWipes(now, &found))
+	guilds := map[string]int{}
+	for _, account := range found {
+		guilds[account.GuildSnowflake] = len(account.Servers)
+	}
+	assert.Equal(t, map[string]int{"wipes": 2}, guilds, "only enabled accounts with wipes scheduled should be found, with all their servers")
+}
+
+func accountsRecordWipe(t *testing.T, accounts storage.AccountsStore) {
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	accounts.UpsertBase(types.BaseAccount{GuildSnowflake: "wiped"})
+	accounts.AddServer("wiped", types.AccountServer{Key: "wiped-soon", Name: "Soon", NextWipe: now.Add(time.Hour),
+		Clans: []types.Clan{{Tag: "FoF"}}, WipeMessage: "Fresh map"})
+	accounts.AddServer("wiped", types.AccountServer{Key: "wiped-later", Name: "Later", NextWipe: now.AddDate(0, 0, 7)})
+	accounts.AddServer("wiped", types.AccountServer{Key: "wiped-unscheduled", Name: "Unscheduled"})
+
+	for _, key := range []string{"wiped-soon", "wiped-later", "wiped-unscheduled"} {
+		assert.Nil(t, accounts.RecordWipe(key, now, now.Add(24*time.Hour)))
+	}
+	assertErrorIs(t, accounts.RecordWipe("missing", now, now), storage.ErrNotFound, "missing server should not be found")
+
+	account, err := accounts.GetByDiscordGuild("wiped")
+	assert.Nil(t, err)
+	if !assert.Len(t, account.Servers, 3) {
+		return
+	}
+	soon, later, unscheduled := account.Servers[0], account.Servers[1], account.Servers[2]
+	assert.Empty(t, soon.Clans)
+	assert.True(t, now.Equal(soon.LastWipe), "last wipe %s, want %s", soon.LastWipe, now)
+	assert.True(t, soon.NextWipe.IsZero(), "the scheduled wipe should be cleared")
+	assert.Equal(t, "Soon", soon.Name, "other fields should be kept")
+	assert.Equal(t, "Fresh map", soon.WipeMessage, "other fields should be kept")
+	assert.True(t, now.AddDate(0, 0, 7).Equal(later.NextWipe), "later wipes should stay scheduled")
+	assert.True(t, now.Equal(unscheduled.LastWipe), "last wipe %s, want %s", unscheduled.LastWipe, now)
+	assert.True(t, unscheduled.NextWipe.IsZero())
+}
+
+func accountsClans(t *testing.T, accounts storage.AccountsStore) {
+	accounts.UpsertBase(types.BaseAccount{GuildSnowflake: "clans"})
+	accounts.AddServer("clans", types.AccountServer{Key: "clans"})
+
+	assertErrorIs(t, accounts.AddClan("missing", types.Clan{Tag: "FoF"}), storage.ErrNotFound, "missing server should not be found")
+	assert.Nil(t, accounts.AddClan("clans", types.Clan{Tag: "FoF", OwnerID: "game:1", Members: []string{"game:1", "game:2"}}))
+	assert.Nil(t, accounts.AddClan("clans", types.Clan{Tag: "BAR", OwnerID: "game:3"}))
+
+	account, _ := accounts.GetByServerKey("clans")
+	assert.Equal(t, []types.Clan{
+		{Tag: "FoF", OwnerID: "game:1", Members: []string{"game:1", "game:2"}},
+		{Tag: "BAR", OwnerID: "game:3"},
+	}, account.Servers[0].Clans)
+
+	assert.Nil(t, accounts.RemoveClan("clans", "FoF"))
+	assertErrorIs(t, accounts.RemoveClan("clans", "FoF"), storage.ErrNotFound, "removed clan should not be found")
+	account, _ = accounts.GetByServerKey("clans")
+	assert.Equal(t, []types.Clan{{Tag: "BAR", OwnerID: "game:3"}}, account.Servers[0].Clans)
+
+	assert.Nil(t, accounts.SetClans("clans", []types.Clan{{Tag: "BAZ", Moderators: []string{"game:4"}}}))
+	account, _ = accounts.GetByServerKey("clans")
+	assert.Equal(t, []types.Clan{{Tag: "BAZ", Moderators: []string{"game:4"}}}, account.Servers[0].Clans)
+
+	assert.Nil(t, accounts.SetClans("clans", []types.Clan{}))
+	account, _ = accounts.GetByServerKey("clans")
+	assert.Empty(t, account.Servers[0].Clans)
+}
+
+func accountsRegisteredPlayerIDs(t *testing.T, accounts storage.AccountsStore) {
+	accounts.UpsertBase(types.BaseAccount{GuildSnowflake: "registered"})
+
+	assert.Nil(t, accounts.SetRegisteredPlayerIDs("registered", []string{"game:1", "game:2"}))
+	assert.Nil(t, accounts.AddRegisteredPlayerIDs("registered", []string{"game:2", "game:3"}))
+
+	account, _ := accounts.GetByDiscordGuild("registered")
+	assert.Equal(t, []string{"game:1", "game:2", "game:3"}, account.RegisteredPlayerIDs, "added IDs should be unique")
+
+	assert.Nil(t, accounts.RemoveRegisteredPlayerIDs("registered", []string{"game:1", "game:3"}))
+	account, _ = accounts.GetByDiscordGuild("registered")
+	assert.Equal(t, []string{"game:2"}, account.RegisteredPlayerIDs)
+
+	assertErrorIs(t, accounts.SetRegisteredPlayerIDs("missing", []string{"game:1"}), storage.ErrNotFound, "missing guild should not be found")
+}
+
+func accountsRemoveNotInDiscordGuildList(t *testing.T, accounts storage.AccountsStore) {
+	accounts.UpsertBase(types.BaseAccount{GuildSnowflake: "one"})
+	accounts.UpsertBase(types.BaseAccount{GuildSnowflake: "two"})
+	accounts.Remove("two")
+
+	err := accounts.RemoveNotInDiscordGuildList([]types.BaseAccount{
+		{GuildSnowflake: "two", OwnerSnowflake: "owner"},
+		{GuildSnowflake: "three"},
+	})
+	assert.Nil(t, err)
+
+	var all []types.Account
+	assert.Nil(t, accounts.All(&all))
+
+	disabled := map[string]bool{}
+	for _, account := range all {
+		disabled[account.GuildSnowflake] = account.Disabled
+	}
+	assert.Equal(t, false, disabled["two"], "listed guilds should be enabled")
+	assert.Equal(t, false, disabled["three"], "listed guilds should be created")
+	assert.Equal(t, true, disabled["one"], "guilds not listed should be disabled")
+
+	account, _ := accounts.GetByDiscordGuild("two")
+	assert.Equal(t, "owner", account.OwnerSnowflake)
+	account, _ = accounts.GetByDiscordGuild("three")
+	assert.NotEmpty(t, account.ID)
+	assert.False(t, account.CreatedAt.IsZero(), "CreatedAt should be set on insert")
+}
@@ -0,0 +1,103 @@
+package storagetest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func testChatLog(t *testing.T, s storage.Storage) {
+	chatLog := s.ChatLog()
+
+	entries, err := chatLog.Find(storage.ChatLogQuery{})
+	assert.Nil(t, err)
+	assert.Empty(t, entries)
+
+	sent := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	fromDiscord := types.ChatLogEntry{
+		Source:      types.ChatSourceDiscord,
+		ServerKey:   "key1",
+		Tag:         "chat",
+		Snowflake:   "one",
+		DiscordName: "one#0001",
+		DisplayName: "one",
+		Message:     "hello game",
+		SentAt:      sent.Add(-2 * time.Hour),
+	}
+	fromGame := types.ChatLogEntry{
+		Source:      types.ChatSourceGame,
+		ServerKey:   "key1",
+		Tag:         "chat",
+		PlayerID:    "game:1",
+		DisplayName: "player one",
+		ClanTag:     "FoF",
+		Message:     "hello discord",
+		SentAt:      sent.Add(-time.Hour),
+	}
+	otherServer := types.ChatLogEntry{
+		Source:    types.ChatSourceGame,
+		ServerKey: "key2",
+		PlayerID:  "game:2",
+		Message:   "elsewhere",
+		SentAt:    sent,
+	}
+	for _, entry := range []types.ChatLogEntry{fromDiscord, fromGame, otherServer} {
+		assert.Nil(t, chatLog.Add(entry))
+	}
+
+	messages := func(entries []types.ChatLogEntry) []string {
+		var messages []string
+		for _, entry := range entries {
+			messages = append(messages, entry.Message)
+		}
+		return messages
+	}
+
+	tests := []struct {
+		name  string
+		query storage.ChatLogQuery
+		want  []string
+	}{
+		{"all newest first", storage.ChatLogQuery{}, []string{"elsewhere", "hello discord", "hello game"}},
+		{"server keys", storage.ChatLogQuery{ServerKeys: []string{"key1"}}, []string{"hello discord", "hello game"}},
+		{"snowflake", storage.ChatLogQuery{Snowflake: "one"}, []string{"hello game"}},
+		{"player IDs", storage.ChatLogQuery{PlayerIDs: []string{"game:1", "game:2"}}, []string{"elsewhere", "hello discord"}},
+		{
+			"snowflake or player IDs",
+			storage.ChatLogQuery{Snowflake: "one", PlayerIDs: []string{"game:1"}},
+			[]string{"hello discord", "hello game"},
+		},
+		{"since", storage.ChatLogQuery{Since: sent.Add(-time.Hour)}, []string{"elsewhere", "hello discord"}},
+		{"limit", storage.ChatLogQuery{Limit: 1}, []string{"elsewhere"}},
+		{"no match", storage.ChatLogQuery{ServerKeys: []string{"key3"}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := chatLog.Find(tt.query)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, messages(entries))
+		})
+	}
+
+	entries, _ = chatLog.Find(storage.ChatLogQuery{PlayerIDs: []string{"game:1"}})
+	if assert.Len(t, entries, 1) {
+		got := entries[0]
+		assert.NotEmpty(t, got.ID)
+		assert.Equal(t, types.ChatSourceGame, got.Source)
+		assert.Equal(t, "key1", got.ServerKey)
+		assert.Equal(t, "chat", got.Tag)
+		assert.Equal(t, "player one", got.DisplayName)
+		assert.Equal(t, "FoF", got.ClanTag)
+		assert.True(t, fromGame.SentAt.Equal(got.SentAt))
+	}
+
+	removed, err := chatLog.RemoveBefore(sent.Add(-time.Hour))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, removed)
+
+	entries, _ = chatLog.Find(storage.ChatLogQuery{})
+	assert.Equal(t, []string{"elsewhere", "hello discord"}, messages(entries))
+}
@@ -0,0 +1,110 @@
+package storagetest
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func testChatQueue(t *testing.T, s storage.Storage) {
+	cq := s.ChatQueue()
+
+	_, found := cq.GetGameServerMessage("key", "chat", time.Minute, time.Millisecond)
+	assert.False(t, found, "empty queue should time out")
+
+	assert.Nil(t, cq.InsertMessage(types.ChatMessage{ServerKey: "other", Tag: "chat", Message: "one"}))
+	assert.Nil(t, cq.InsertMessage(types.ChatMessage{ServerKey: "key", Tag: "other", Message: "two"}))
+	assert.Nil(t, cq.InsertMessage(types.ChatMessage{
+		ServerKey:   "key",
+		Tag:         "chat",
+		ChannelID:   "1234",
+		ClanTag:     "FoF",
+		DisplayName: "Bob",
+		Message:     "three",
+		PlayerID:    "game:1",
+		DiscordInfo: types.DiscordInfo{DiscordName: "bob", Snowflake: "one"},
+	}))
+	assert.Nil(t, cq.InsertMessage(types.ChatMessage{ServerKey: "key", Tag: "chat", Message: "four"}))
+
+	got, found := cq.GetGameServerMessage("key", "chat", time.Minute, time.Second)
+	assert.True(t, found)
+	assert.NotEmpty(t, got.ID)
+	assert.False(t, got.QueuedAt.IsZero(), "queued time should be set")
+	assert.True(t, got.LeasedUntil.After(time.Now()), "message should be leased")
+	three := got.ID
+	got.ID = ""
+	got.QueuedAt = time.Time{}
+	got.LeasedUntil = time.Time{}
+	assert.Equal(t, types.ChatMessage{
+		ServerKey:   "key",
+		Tag:         "chat",
+		ChannelID:   "1234",
+		ClanTag:     "FoF",
+		DisplayName: "Bob",
+		Message:     "three",
+		PlayerID:    "game:1",
+		DiscordInfo: types.DiscordInfo{DiscordName: "bob", Snowflake: "one"},
+	}, got, "oldest message for the server and tag should be returned first")
+
+	got, found = cq.GetGameServerMessage("key", "chat", 50*time.Millisecond, time.Second)
+	assert.True(t, found)
+	assert.Equal(t, "four", got.Message)
+	four := got.ID
+
+	_, found = cq.GetGameServerMessage("key", "chat", time.Minute, time.Millisecond)
+	assert.False(t, found, "leased messages should not be handed out again")
+
+	keys, err := cq.ReadyKeys()
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []storage.ChatQueueKey{{ServerKey: "other", Tag: "chat"}, {ServerKey: "key", Tag: "other"}},
+		keys, "keys with only leased messages should not be ready")
+
+	time.Sleep(100 * time.Millisecond)
+	keys, _ = cq.ReadyKeys()
+	assert.Contains(t, keys, storage.ChatQueueKey{ServerKey: "key", Tag: "chat"}, "expired leases should be ready")
+	got, found = cq.GetGameServerMessage("key", "chat", time.Minute, time.Millisecond)
+	assert.True(t, found, "message should be handed out again when its lease expires")
+	assert.Equal(t, four, got.ID)
+
+	assert.Equal(t, storage.ErrNotFound, cq.AckMessage("other", three.Hex()), "only the server can ack its messages")
+	assert.Equal(t, storage.ErrNotFound, cq.AckMessage("key", "nope"))
+	assert.Nil(t, cq.AckMessage("key", three.Hex()))
+	assert.Nil(t, cq.AckMessage("key", four.Hex()))
+	assert.Equal(t, storage.ErrNotFound, cq.AckMessage("key", four.Hex()), "acked messages should be removed")
+
+	got, found = cq.GetGameServerMessage("key", "other", 0, time.Second)
+	assert.True(t, found)
+	assert.Equal(t, "two", got.Message)
+	_, found = cq.GetGameServerMessage("key", "other", 0, time.Millisecond)
+	assert.False(t, found, "messages without a lease should only be delivered once")
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cq.InsertMessage(types.ChatMessage{ServerKey: "key", Tag: "chat", Message: "late"})
+	}()
+	got, found = cq.GetGameServerMessage("key", "chat", 0, 5*time.Second)
+	assert.True(t, found, "should wait for new messages")
+	assert.Equal(t, "late", got.Message)
+
+	// Each server has its own limit
+	for i := 0; i < storage.ChatQueueServerLimit+2; i++ {
+		assert.Nil(t, cq.InsertMessage(types.ChatMessage{ServerKey: "key", Tag: "chat", Message: fmt.Sprint(i)}))
+	}
+	for i := 2; i < storage.ChatQueueServerLimit+2; i++ {
+		got, found = cq.GetGameServerMessage("key", "chat", 0, time.Millisecond)
+		if !assert.True(t, found) {
+			break
+		}
+		assert.Equal(t, fmt.Sprint(i), got.Message, "oldest messages should be dropped")
+	}
+	_, found = cq.GetGameServerMessage("key", "chat", 0, time.Millisecond)
+	assert.False(t, found)
+
+	got, found = cq.GetGameServerMessage("other", "chat", 0, time.Millisecond)
+	assert.True(t, found, "other servers' messages should be kept")
+	assert.Equal(t, "one", got.Message)
+}
@@ -0,0 +1,88 @@
+package storagetest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// assertDiscordAuth asserts that two discord auths are the same. Stores
+// may return times in a different location.
+func assertDiscordAuth(t *testing.T, want, got types.DiscordAuth) {
+	t.Helper()
+	assert.True(t, want.ExpiresAt.Equal(got.ExpiresAt), "expires at %s, want %s", got.ExpiresAt, want.ExpiresAt)
+	want.ExpiresAt, got.ExpiresAt = time.Time{}, time.Time{}
+	assert.Equal(t, want, got)
+}
+
+func testDiscordAuths(t *testing.T, s storage.Storage) {
+	auths := s.DiscordAuths()
+
+	var all []types.DiscordAuth
+	assert.Nil(t, auths.All(&all))
+	assert.Empty(t, all)
+
+	da := types.DiscordAuth{
+		GuildSnowflake: "guild",
+		PlayerID:       "game:1",
+		DiscordInfo:    types.DiscordInfo{DiscordName: "name#1234", Snowflake: "one"},
+		Pin:            1234,
+		ExpiresAt:      time.Now().UTC().Add(time.Hour).Truncate(time.Millisecond),
+	}
+
+	_, err := auths.GetByDiscordName("name#1234")
+	assertErrorIs(t, err, storage.ErrNotFound, "missing auth should not be found")
+	_, err = auths.GetByDiscordID("one")
+	assertErrorIs(t, err, storage.ErrNotFound, "missing auth should not be found")
+	_, err = auths.GetByPlayerID("game:1")
+	assertErrorIs(t, err, storage.ErrNotFound, "missing auth should not be found")
+
+	assert.Nil(t, auths.Upsert(da))
+
+	got, err := auths.GetByDiscordName("name#1234")
+	assert.Nil(t, err)
+	assertDiscordAuth(t, da, got)
+
+	da.Pin = 4321
+	da.Attempts = 2
+	assert.Nil(t, auths.Upsert(da), "upsert should replace the auth for a player ID")
+
+	got, err = auths.GetByDiscordID("one")
+	assert.Nil(t, err)
+	assert.Equal(t, 4321, got.Pin)
+	assert.Equal(t, 2, got.Attempts)
+
+	got, err = auths.GetByPlayerID("game:1")
+	assert.Nil(t, err)
+	assertDiscordAuth(t, da, got)
+
+	attempts, err := auths.IncrementAttempts("game:1")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, attempts)
+	da.Attempts = 3
+	got, err = auths.GetByPlayerID("game:1")
+	assert.Nil(t, err)
+	assertDiscordAuth(t, da, got)
+	_, err = auths.IncrementAttempts("game:3")
+	assertErrorIs(t, err, storage.ErrNotFound, "missing auth should not be found")
+
+	assert.Nil(t, auths.All(&all))
+	if assert.Len(t, all, 1) {
+		assertDiscordAuth(t, da, all[0])
+	}
+
+	// Recently expired auths are kept so their status can be checked
+	expired := types.DiscordAuth{PlayerID: "game:2", ExpiresAt: time.Now().UTC().Add(-time.Hour)}
+	assert.Nil(t, auths.Upsert(expired))
+	got, err = auths.GetByPlayerID("game:2")
+	assert.Nil(t, err)
+	assert.Equal(t, types.DiscordAuthExpired, got.Status(time.Now()))
+
+	assert.Nil(t, auths.Remove(da))
+	assertErrorIs(t, auths.Remove(da), storage.ErrNotFound, "removed auth should not be found")
+	_, err = auths.GetByDiscordID("one")
+	assertErrorIs(t, err, storage.ErrNotFound, "removed auth should not be found")
+}
@@ -0,0 +1,52 @@
+package storagetest
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func testGameMessages(t *testing.T, s storage.Storage) {
+	gm := s.GameMessages()
+
+	_, err := gm.Get("1")
+	assertErrorIs(t, err, storage.ErrNotFound, "get before add")
+	assertErrorIs(t, gm.Remove("1"), storage.ErrNotFound, "remove before add")
+
+	sent := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	message := types.SentGameMessage{MessageID: "1", ChannelID: "1234", ServerKey: "key1", SentAt: sent}
+	assert.Nil(t, gm.Add(message))
+
+	got, err := gm.Get("1")
+	assert.Nil(t, err)
+	assert.Equal(t, message, got)
+
+	c := s.Copy()
+	defer c.Close()
+	_, err = c.GameMessages().Get("1")
+	assert.Nil(t, err, "messages should be shared between copies")
+
+	assert.Nil(t, gm.Remove("1"))
+	_, err = gm.Get("1")
+	assertErrorIs(t, err, storage.ErrNotFound, "get after remove")
+
+	// Each server keeps its newest messages
+	assert.Nil(t, gm.Add(types.SentGameMessage{MessageID: "other", ServerKey: "key2", SentAt: sent}))
+	for i := 0; i <= storage.GameMessagesServerLimit; i++ {
+		assert.Nil(t, gm.Add(types.SentGameMessage{
+			MessageID: fmt.Sprintf("m%d", i),
+			ServerKey: "key1",
+			SentAt:    sent.Add(time.Duration(i) * time.Second),
+		}))
+	}
+	_, err = gm.Get("m0")
+	assertErrorIs(t, err, storage.ErrNotFound, "oldest message over the limit")
+	_, err = gm.Get("m1")
+	assert.Nil(t, err)
+	_, err = gm.Get("other")
+	assert.Nil(t, err, "other servers' messages should be kept")
+}
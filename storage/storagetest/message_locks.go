@@ -0,0 +1,20 @@
+package storagetest
+
+import (
+	"testing"
+
+	"github.com/poundbot/poundbot/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func testMessageLocks(t *testing.T, s storage.Storage) {
+	ml := s.MessageLocks()
+
+	assert.True(t, ml.Obtain("one", "raid"))
+	assert.False(t, ml.Obtain("one", "raid"), "lock should only be obtained once")
+
+	c := s.Copy()
+	defer c.Close()
+	assert.False(t, c.MessageLocks().Obtain("one", "raid"), "locks should be shared between copies")
+	assert.True(t, ml.Obtain("two", "raid"))
+}
@@ -0,0 +1,68 @@
+package storagetest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func testOnlinePlayers(t *testing.T, s storage.Storage) {
+	op := s.OnlinePlayers()
+
+	players, err := op.Online("key1")
+	assert.Nil(t, err)
+	assert.Empty(t, players)
+
+	at := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	first := types.OnlinePlayer{ServerKey: "key1", PlayerID: "game:1", DisplayName: "one", ConnectedAt: at.Add(time.Minute)}
+	second := types.OnlinePlayer{ServerKey: "key1", PlayerID: "game:2", DisplayName: "two", ConnectedAt: at}
+	assert.Nil(t, op.Connect(first))
+	assert.Nil(t, op.Connect(second))
+	assert.Nil(t, op.Connect(types.OnlinePlayer{ServerKey: "key2", PlayerID: "game:1", ConnectedAt: at}))
+
+	players, err = op.Online("key1")
+	assert.Nil(t, err)
+	assert.Equal(t, []types.OnlinePlayer{second, first}, players, "earliest connected first")
+
+	// A late connect event from an earlier connection is ignored
+	assert.Nil(t, op.Connect(types.OnlinePlayer{ServerKey: "key1", PlayerID: "game:1", DisplayName: "old", ConnectedAt: at}))
+	players, _ = op.Online("key1")
+	assert.Equal(t, []types.OnlinePlayer{second, first}, players)
+
+	// Reconnecting updates the player
+	renamed := first
+	renamed.DisplayName = "renamed"
+	renamed.ConnectedAt = at.Add(time.Hour)
+	assert.Nil(t, op.Connect(renamed))
+	players, _ = op.Online("key1")
+	assert.Equal(t, []types.OnlinePlayer{second, renamed}, players)
+
+	// A late disconnect event from an earlier connection is ignored
+	assert.Nil(t, op.Disconnect("key1", "game:1", at.Add(time.Minute)))
+	players, _ = op.Online("key1")
+	assert.Len(t, players, 2)
+
+	assert.Nil(t, op.Disconnect("key1", "game:1", at.Add(2*time.Hour)))
+	assert.Nil(t, op.Disconnect("key1", "game:3", at), "disconnecting an offline player")
+	players, _ = op.Online("key1")
+	assert.Equal(t, []types.OnlinePlayer{second}, players)
+
+	players, _ = op.Online("key2")
+	assert.Len(t, players, 1, "other servers' players should be kept")
+
+	// The server restarts
+	later := second
+	later.PlayerID = "game:4"
+	later.ConnectedAt = at.Add(3 * time.Hour)
+	assert.Nil(t, op.Connect(later))
+	assert.Nil(t, op.Clear("key1", at.Add(3*time.Hour)))
+	players, _ = op.Online("key1")
+	assert.Equal(t, []types.OnlinePlayer{later}, players, "players who connected after the clear should be kept")
+	assert.Nil(t, op.Clear("empty", at), "clearing a server without players")
+
+	players, _ = op.Online("key2")
+	assert.Len(t, players, 1, "other servers' players should be kept")
+}
@@ -0,0 +1,85 @@
+package storagetest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func testRaidAlerts(t *testing.T, s storage.Storage) {
+	s.Users().UpsertPlayer(userInfo("game:1", "one"))
+	s.Users().UpsertPlayer(userInfo("game:2", "two"))
+	raidAlerts := s.RaidAlerts()
+
+	alerts, err := raidAlerts.GetReady()
+	assert.Nil(t, err)
+	assert.Empty(t, alerts)
+
+	ed := types.EntityDeath{
+		ServerName: "server",
+		ServerKey:  "key",
+		Name:       "wall",
+		GridPos:    "A1",
+		OwnerIDs:   []string{"game:1", "game:unregistered"},
+	}
+	assert.Nil(t, raidAlerts.AddInfo(0, time.Minute, ed))
+	ed.GridPos = "B2"
+	assert.Nil(t, raidAlerts.AddInfo(0, time.Minute, ed))
+	ed.Name = "door"
+	assert.Nil(t, raidAlerts.AddInfo(0, time.Minute, ed))
+
+	// Not ready until the alert time
+	ed.OwnerIDs = []string{"game:2"}
+	assert.Nil(t, raidAlerts.AddInfo(time.Hour, time.Hour, ed))
+
+	alerts, err = raidAlerts.GetReady()
+	assert.Nil(t, err)
+	if !assert.Len(t, alerts, 1, "alerts should be aggregated per player and server") {
+		return
+	}
+	alert := alerts[0]
+	assert.NotEmpty(t, alert.ID)
+	assert.Equal(t, "game:1", alert.PlayerID)
+	assert.Equal(t, "server", alert.ServerName)
+	assert.Equal(t, "key", alert.ServerKey)
+	assert.Equal(t, []string{"A1", "B2"}, alert.GridPositions)
+	assert.Equal(t, map[string]int{"wall": 2, "door": 1}, alert.Items)
+	assert.Equal(t, 0, alert.NotifyCount)
+	assert.False(t, alert.StartedAt.IsZero(), "started at should be set")
+	assert.False(t, alert.LastEventAt.Before(alert.StartedAt), "last event should not be before the start")
+
+	assert.Nil(t, raidAlerts.SetMessageID(alert, "message"))
+
+	assert.Nil(t, raidAlerts.IncrementNotifyCount(alert))
+	assertErrorIs(t, raidAlerts.IncrementNotifyCount(alert), storage.ErrStale, "stale notify count should not update")
+
+	alerts, _ = raidAlerts.GetReady()
+	alert = alerts[0]
+	assert.Equal(t, "message", alert.MessageID)
+	assert.Equal(t, 3, alert.NotifyCount)
+	assert.NotNil(t, raidAlerts.IncrementNotifyCount(alert), "notify count matching the item count should not update")
+
+	assert.Nil(t, raidAlerts.Remove(alert))
+	assertErrorIs(t, raidAlerts.Remove(alert), storage.ErrNotFound, "removed alert should not be found")
+	assertErrorIs(t, raidAlerts.SetMessageID(alert, "message"), storage.ErrNotFound, "removed alert should not be found")
+
+	alerts, _ = raidAlerts.GetReady()
+	assert.Empty(t, alerts)
+
+	ed.OwnerIDs = []string{"game:1", "game:2"}
+	assert.Nil(t, raidAlerts.AddInfo(0, time.Minute, ed))
+	ed.ServerKey = "other"
+	assert.Nil(t, raidAlerts.AddInfo(0, time.Minute, ed))
+
+	assert.Nil(t, raidAlerts.RemoveByServer("key"))
+	assert.Nil(t, raidAlerts.RemoveByServer("none"), "removing alerts for a server without any should not fail")
+	alerts, _ = raidAlerts.GetReady()
+	if assert.Len(t, alerts, 2, "only the server's alerts should be removed") {
+		for _, alert := range alerts {
+			assert.Equal(t, "other", alert.ServerKey)
+		}
+	}
+}
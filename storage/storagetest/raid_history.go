@@ -0,0 +1,86 @@
+package storagetest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func testRaidHistory(t *testing.T, s storage.Storage) {
+	raidHistory := s.RaidHistory()
+
+	raids, err := raidHistory.Find(storage.RaidHistoryQuery{})
+	assert.Nil(t, err)
+	assert.Empty(t, raids)
+
+	end := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	raid := func(playerID, serverName, serverKey string, endedAt time.Time) types.RaidHistory {
+		return types.RaidHistory{
+			ID:            bson.NewObjectId(),
+			PlayerID:      playerID,
+			ServerName:    serverName,
+			ServerKey:     serverKey,
+			GridPositions: []string{"A1"},
+			Items:         map[string]int{"wall": 2},
+			StartedAt:     endedAt.Add(-time.Minute),
+			EndedAt:       endedAt,
+			NotifyCount:   2,
+		}
+	}
+
+	oldest := raid("game:1", "Server One", "key1", end.Add(-2*time.Hour))
+	middle := raid("game:2", "Server One", "key1", end.Add(-time.Hour))
+	newest := raid("game:1", "Server Two", "key2", end)
+	for _, rh := range []types.RaidHistory{oldest, middle, newest} {
+		assert.Nil(t, raidHistory.Add(rh))
+	}
+
+	// Adding the same alert again replaces it
+	middle.NotifyCount = 1
+	assert.Nil(t, raidHistory.Add(middle))
+
+	ids := func(raids []types.RaidHistory) []bson.ObjectId {
+		var ids []bson.ObjectId
+		for _, rh := range raids {
+			ids = append(ids, rh.ID)
+		}
+		return ids
+	}
+
+	tests := []struct {
+		name  string
+		query storage.RaidHistoryQuery
+		want  []bson.ObjectId
+	}{
+		{"all newest first", storage.RaidHistoryQuery{}, []bson.ObjectId{newest.ID, middle.ID, oldest.ID}},
+		{"server key", storage.RaidHistoryQuery{ServerKey: "key1"}, []bson.ObjectId{middle.ID, oldest.ID}},
+		{"server name", storage.RaidHistoryQuery{ServerName: "server two"}, []bson.ObjectId{newest.ID}},
+		{"player IDs", storage.RaidHistoryQuery{PlayerIDs: []string{"game:1"}}, []bson.ObjectId{newest.ID, oldest.ID}},
+		{"since", storage.RaidHistoryQuery{Since: end.Add(-time.Hour)}, []bson.ObjectId{newest.ID, middle.ID}},
+		{"limit", storage.RaidHistoryQuery{Limit: 1}, []bson.ObjectId{newest.ID}},
+		{"no match", storage.RaidHistoryQuery{PlayerIDs: []string{"game:3"}}, nil},
+		{"empty player IDs", storage.RaidHistoryQuery{PlayerIDs: []string{}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raids, err := raidHistory.Find(tt.query)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, ids(raids))
+		})
+	}
+
+	raids, _ = raidHistory.Find(storage.RaidHistoryQuery{ServerKey: "key1", PlayerIDs: []string{"game:2"}})
+	if assert.Len(t, raids, 1) {
+		got := raids[0]
+		assert.Equal(t, 1, got.NotifyCount)
+		assert.Equal(t, "Server One", got.ServerName)
+		assert.Equal(t, []string{"A1"}, got.GridPositions)
+		assert.Equal(t, map[string]int{"wall": 2}, got.Items)
+		assert.True(t, middle.StartedAt.Equal(got.StartedAt))
+		assert.True(t, middle.EndedAt.Equal(got.EndedAt))
+	}
+}
@@ -0,0 +1,62 @@
+package storagetest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func testServerStatuses(t *testing.T, s storage.Storage) {
+	ss := s.ServerStatuses()
+
+	_, err := ss.Get("key1")
+	assertErrorIs(t, err, storage.ErrNotFound, "unreported server should not be found")
+	assertErrorIs(t, ss.SetMessage("key1", "1234", "5678"), storage.ErrNotFound, "unreported server should not be found")
+
+	at := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	status := types.ServerStatus{
+		ServerKey:  "key1",
+		Players:    12,
+		MaxPlayers: 50,
+		Map:        "Procedural Map",
+		Seed:       1234567,
+		Uptime:     3600,
+		FPS:        59.5,
+		ReportedAt: at,
+	}
+	assert.Nil(t, ss.Report(status))
+	assert.Nil(t, ss.Report(types.ServerStatus{ServerKey: "key2", ReportedAt: at.Add(time.Hour)}))
+
+	got, err := ss.Get("key1")
+	assert.Nil(t, err)
+	assert.Equal(t, status, got)
+
+	assert.Nil(t, ss.SetMessage("key1", "1234", "5678"))
+	status.ChannelID = "1234"
+	status.MessageID = "5678"
+	got, _ = ss.Get("key1")
+	assert.Equal(t, status, got)
+
+	marked, err := ss.MarkOffline(at.Add(time.Minute))
+	assert.Nil(t, err)
+	status.Offline = true
+	assert.Equal(t, []types.ServerStatus{status}, marked, "only servers that haven't reported since should be marked")
+	got, _ = ss.Get("key1")
+	assert.True(t, got.Offline)
+
+	marked, err = ss.MarkOffline(at.Add(time.Minute))
+	assert.Nil(t, err)
+	assert.Empty(t, marked, "offline servers should not be marked again")
+
+	// Reporting again brings the server back online with its message
+	status.Players = 13
+	status.ReportedAt = at.Add(2 * time.Minute)
+	assert.Nil(t, ss.Report(status))
+	got, _ = ss.Get("key1")
+	assert.False(t, got.Offline)
+	assert.Equal(t, 13, got.Players)
+	assert.Equal(t, "5678", got.MessageID, "reporting should keep the status message")
+}
@@ -0,0 +1,57 @@
+// Package storagetest is a conformance suite for storage.Storage
+// implementations. Every backend should run it from its own tests so they
+// all behave the same way:
+//
+//	func TestStorage(t *testing.T) {
+//		storagetest.Run(t, func() storage.Storage { return NewMemory() })
+//	}
+package storagetest
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/poundbot/poundbot/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+// Run runs the whole suite. newStorage must return a new, empty store for
+// every call. Run calls Init before each test and Close after it.
+func Run(t *testing.T, newStorage func() storage.Storage) {
+	tests := []struct {
+		name string
+		test func(*testing.T, storage.Storage)
+	}{
+		{"Accounts", testAccounts},
+		{"Users", testUsers},
+		{"DiscordAuths", testDiscordAuths},
+		{"RaidAlerts", testRaidAlerts},
+		{"RaidHistory", testRaidHistory},
+		{"ChatLog", testChatLog},
+		{"ChatQueue", testChatQueue},
+		{"GameMessages", testGameMessages},
+		{"OnlinePlayers", testOnlinePlayers},
+		{"ServerStatuses", testServerStatuses},
+		{"MessageLocks", testMessageLocks},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newStorage()
+			s.Init()
+			defer s.Close()
+
+			tt.test(t, s)
+		})
+	}
+}
+
+// assertErrorIs asserts that errors.Is(err, target)
+func assertErrorIs(t *testing.T, err, target error, msg string) bool {
+	t.Helper()
+	if errors.Is(err, target) {
+		return true
+	}
+	return assert.Fail(t, fmt.Sprintf("error %v is not %q", err, target), msg)
+}
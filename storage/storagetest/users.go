@@ -0,0 +1,67 @@
+package storagetest
+
+import (
+	"testing"
+
+	"github.com/poundbot/poundbot/storage"
+	"github.com/poundbot/poundbot/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func userInfo(playerID, snowflake string) types.DiscordAuth {
+	return types.DiscordAuth{PlayerID: playerID, DiscordInfo: types.DiscordInfo{Snowflake: snowflake}}
+}
+
+func testUsers(t *testing.T, s storage.Storage) {
+	users := s.Users()
+
+	_, err := users.GetByDiscordID("one")
+	assertErrorIs(t, err, storage.ErrNotFound, "missing user should not be found")
+	_, err = users.GetByPlayerID("game:1")
+	assertErrorIs(t, err, storage.ErrNotFound, "missing player should not be found")
+
+	assert.Nil(t, users.UpsertPlayer(userInfo("game:1", "one")))
+	assert.Nil(t, users.UpsertPlayer(userInfo("game:2", "one")))
+	assert.Nil(t, users.UpsertPlayer(userInfo("game:2", "one")), "upserting the same player again is allowed")
+	assert.Nil(t, users.UpsertPlayer(userInfo("game:3", "two")))
+	assertErrorIs(t, users.UpsertPlayer(userInfo("game:1", "two")), storage.ErrConflict, "player IDs can only belong to one user")
+
+	user, err := users.GetByPlayerID("game:2")
+	assert.Nil(t, err)
+	assert.Equal(t, "one", user.Snowflake)
+	assert.Equal(t, []string{"game:1", "game:2"}, user.PlayerIDs)
+	assert.False(t, user.CreatedAt.IsZero(), "CreatedAt should be set on insert")
+
+	user, err = users.GetByDiscordID("two")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"game:3"}, user.PlayerIDs)
+
+	var all []types.User
+	assert.Nil(t, users.All(&all))
+	snowflakes := []string{}
+	for _, user := range all {
+		snowflakes = append(snowflakes, user.Snowflake)
+	}
+	assert.ElementsMatch(t, []string{"one", "two"}, snowflakes)
+
+	playerIDs, err := users.GetPlayerIDsByDiscordIDs([]string{"one", "two", "missing"})
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{"game:1", "game:2", "game:3"}, playerIDs)
+
+	playerIDs, err = users.GetPlayerIDsByDiscordIDs([]string{"missing"})
+	assert.Nil(t, err)
+	assert.Empty(t, playerIDs)
+
+	assert.Nil(t, users.RemovePlayerID("one", "game:1"))
+	user, _ = users.GetByDiscordID("one")
+	assert.Equal(t, []string{"game:2"}, user.PlayerIDs)
+	_, err = users.GetByPlayerID("game:1")
+	assertErrorIs(t, err, storage.ErrNotFound, "removed player should not be found")
+	assert.Nil(t, users.UpsertPlayer(userInfo("game:1", "two")), "removed player IDs can be reused")
+
+	assert.Nil(t, users.RemovePlayerID("one", "all"))
+	_, err = users.GetByDiscordID("one")
+	assertErrorIs(t, err, storage.ErrNotFound, "removing all should remove the user")
+	_, err = users.GetByPlayerID("game:2")
+	assertErrorIs(t, err, storage.ErrNotFound, "removing all should remove the player IDs")
+}
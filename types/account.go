@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/globalsign/mgo/bson"
 )
@@ -15,8 +16,14 @@ type AccountServer struct {
 	Clans        []Clan
 	RaidDelay    string
 	RaidCooldown string
+	OfflineAlert string `bson:",omitempty" json:"offline_alert"`
 	Timestamp    `bson:",inline"`
 	Channels     []AccountServerChannel `bson:",omitempty" json:"channels"`
+	RoleMaps     []RoleMap              `bson:",omitempty" json:"role_maps"`
+	KillFeed     KillFeedFilter         `bson:",omitempty" json:"kill_feed"`
+	LastWipe     time.Time              `bson:",omitempty" json:"last_wipe"`
+	NextWipe     time.Time              `bson:",omitempty" json:"next_wipe"`
+	WipeMessage  string                 `bson:",omitempty" json:"wipe_message"`
 }
 
 // ChannelIDForTag returns the discord channel id for a message tag
@@ -84,6 +91,42 @@ func (s *AccountServer) SetChannelIDForTag(channel string, tag string) (changed
 	return true
 }
 
+// SetRoleMap maps a discord role to a game group, replacing the role's
+// previous group
+func (s *AccountServer) SetRoleMap(roleID, group string) {
+	for i := range s.RoleMaps {
+		if s.RoleMaps[i].RoleID == roleID {
+			s.RoleMaps[i].Group = group
+			return
+		}
+	}
+	s.RoleMaps = append(s.RoleMaps, RoleMap{RoleID: roleID, Group: group})
+}
+
+// RemoveRoleMap removes a discord role's game group
+func (s *AccountServer) RemoveRoleMap(roleID string) (found bool) {
+	for i := range s.RoleMaps {
+		if s.RoleMaps[i].RoleID == roleID {
+			s.RoleMaps = append(s.RoleMaps[:i], s.RoleMaps[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// OfflineAlertAfter returns how long the server can go without checking in
+// before admins are alerted. Alerts are off if it isn't set.
+func (s AccountServer) OfflineAlertAfter() (after time.Duration, on bool) {
+	if len(s.OfflineAlert) == 0 {
+		return 0, false
+	}
+	after, err := time.ParseDuration(s.OfflineAlert)
+	if err != nil || after <= 0 {
+		return 0, false
+	}
+	return after, true
+}
+
 // UsersClan returns the clan for a given set of playerIDs
 func (s AccountServer) UsersClan(playerIDs []string) (bool, Clan) {
 	for _, clan := range s.Clans {
@@ -103,6 +146,13 @@ type AccountServerChannel struct {
 	Tags      []string
 }
 
+// RoleMap maps a discord role to a game group, so the group's members
+// follow the role
+type RoleMap struct {
+	RoleID string `bson:"role_id" json:"role_id"`
+	Group  string
+}
+
 type BaseAccount struct {
 	GuildSnowflake      string
 	OwnerSnowflake      string
@@ -3,6 +3,7 @@ package types
 import (
 	"reflect"
 	"testing"
+	"time"
 )
 
 func Benchmark_GetRegisteredPlayerIDs(b *testing.B) {
@@ -415,3 +416,52 @@ func TestServer_SetChannelIDForTag(t *testing.T) {
 		})
 	}
 }
+
+func TestServer_RoleMaps(t *testing.T) {
+	t.Parallel()
+
+	var s AccountServer
+	s.SetRoleMap("1", "vip")
+	s.SetRoleMap("2", "admin")
+	s.SetRoleMap("1", "donor")
+	want := []RoleMap{{RoleID: "1", Group: "donor"}, {RoleID: "2", Group: "admin"}}
+	if !reflect.DeepEqual(s.RoleMaps, want) {
+		t.Errorf("Server.SetRoleMap() RoleMaps = %v, want %v", s.RoleMaps, want)
+	}
+
+	if !s.RemoveRoleMap("1") {
+		t.Error("Server.RemoveRoleMap() = false, want true")
+	}
+	if s.RemoveRoleMap("1") {
+		t.Error("Server.RemoveRoleMap() = true for a removed role, want false")
+	}
+	want = []RoleMap{{RoleID: "2", Group: "admin"}}
+	if !reflect.DeepEqual(s.RoleMaps, want) {
+		t.Errorf("Server.RemoveRoleMap() RoleMaps = %v, want %v", s.RoleMaps, want)
+	}
+}
+
+func TestServer_OfflineAlertAfter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		alert  string
+		want   time.Duration
+		wantOn bool
+	}{
+		{name: "not set"},
+		{name: "set", alert: "10m", want: 10 * time.Minute, wantOn: true},
+		{name: "invalid", alert: "soon"},
+		{name: "negative", alert: "-1m"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := AccountServer{OfflineAlert: tt.alert}
+			got, on := s.OfflineAlertAfter()
+			if got != tt.want || on != tt.wantOn {
+				t.Errorf("Server.OfflineAlertAfter() = %v, %v, want %v, %v", got, on, tt.want, tt.wantOn)
+			}
+		})
+	}
+}
@@ -1,16 +1,25 @@
 package types
 
-import "github.com/globalsign/mgo/bson"
+import (
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+)
 
 type ChatMessage struct {
-	ID           bson.ObjectId `bson:"_id,omitempty"`
-	DiscordInfo  `bson:",inline" json:"-"`
-	ChannelID    string `json:"-"`
-	ClanTag      string
-	DisplayName  string
-	Message      string
-	PlayerID     string
-	ServerKey    string `json:"-"`
-	SentToServer bool   `json:"-"`
-	Tag          string
+	ID          bson.ObjectId `bson:"_id,omitempty"`
+	DiscordInfo `bson:",inline" json:"-"`
+	ChannelID   string `json:"-"`
+	ClanTag     string
+	DisplayName string
+	Message     string
+	PlayerID    string
+	ServerKey   string `json:"-"`
+	Tag         string
+	QueuedAt    time.Time `json:"-"`
+	LeasedUntil time.Time `json:"-"` // Zero until handed out to the game server
+
+	// ErrorResponse receives the error sending chat from the game to
+	// Discord, and is closed when it is done
+	ErrorResponse chan<- error `bson:"-" json:"-"`
 }
@@ -0,0 +1,45 @@
+package types
+
+import (
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+// Chat log sources
+const (
+	ChatSourceDiscord = "discord" // Sent from Discord to the game
+	ChatSourceGame    = "game"    // Sent from the game to Discord
+)
+
+// A ChatLogEntry is a relayed ChatMessage, kept for moderation
+type ChatLogEntry struct {
+	ID          bson.ObjectId `bson:"_id,omitempty"`
+	Source      string        // ChatSourceDiscord or ChatSourceGame
+	ServerKey   string
+	Tag         string
+	Snowflake   string // The Discord user, if known
+	DiscordName string
+	PlayerID    string // The game player, if known
+	DisplayName string
+	ClanTag     string
+	Message     string
+	SentAt      time.Time
+}
+
+// NewChatLogEntry creates a log entry for a chat message relayed now
+func NewChatLogEntry(source string, cm ChatMessage) ChatLogEntry {
+	return ChatLogEntry{
+		ID:          bson.NewObjectId(),
+		Source:      source,
+		ServerKey:   cm.ServerKey,
+		Tag:         cm.Tag,
+		Snowflake:   cm.Snowflake,
+		DiscordName: cm.DiscordName,
+		PlayerID:    cm.PlayerID,
+		DisplayName: cm.DisplayName,
+		ClanTag:     cm.ClanTag,
+		Message:     cm.Message,
+		SentAt:      iclock().Now().UTC(),
+	}
+}
@@ -1,5 +1,21 @@
 package types
 
+import "time"
+
+// DiscordAuthTTL is how long a PIN can be used after it is requested
+const DiscordAuthTTL = 15 * time.Minute
+
+// DiscordAuthMaxAttempts is how many wrong PINs lock an auth until it
+// expires
+const DiscordAuthMaxAttempts = 5
+
+// DiscordAuth statuses
+const (
+	DiscordAuthPending = "pending" // Waiting for the PIN
+	DiscordAuthExpired = "expired" // The PIN can no longer be used
+	DiscordAuthLocked  = "locked"  // Too many wrong PINs
+)
+
 type DiscordInfo struct {
 	DiscordName string
 	Snowflake   string
@@ -12,7 +28,9 @@ type DiscordAuth struct {
 	PlayerID       string
 	DiscordInfo    `bson:",inline"`
 	Pin            int
-	Ack            Ack `bson:"-" json:"-"`
+	ExpiresAt      time.Time // The PIN can't be used after this
+	Attempts       int       // Wrong PINs entered
+	Ack            Ack       `bson:"-" json:"-"`
 }
 
 func (d DiscordAuth) GetPlayerID() string {
@@ -22,3 +40,24 @@ func (d DiscordAuth) GetPlayerID() string {
 func (d DiscordAuth) GetDiscordID() string {
 	return d.Snowflake
 }
+
+// Status is DiscordAuthPending, DiscordAuthExpired or DiscordAuthLocked.
+// An auth without an expiry is expired.
+func (d DiscordAuth) Status(now time.Time) string {
+	switch {
+	case !now.Before(d.ExpiresAt):
+		return DiscordAuthExpired
+	case d.Attempts >= DiscordAuthMaxAttempts:
+		return DiscordAuthLocked
+	}
+	return DiscordAuthPending
+}
+
+// AttemptsLeft is how many more wrong PINs can be entered before the auth
+// is locked
+func (d DiscordAuth) AttemptsLeft() int {
+	if d.Attempts >= DiscordAuthMaxAttempts {
+		return 0
+	}
+	return DiscordAuthMaxAttempts - d.Attempts
+}
@@ -1,6 +1,9 @@
 package types
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 func TestDiscordAuth_GetPlayerID(t *testing.T) {
 	tests := []struct {
@@ -35,3 +38,27 @@ func TestDiscordAuth_GetDiscordID(t *testing.T) {
 		})
 	}
 }
+
+func TestDiscordAuth_Status(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		d    DiscordAuth
+		want string
+	}{
+		{"pending", DiscordAuth{ExpiresAt: now.Add(time.Minute), Attempts: 1}, DiscordAuthPending},
+		{"no expiry", DiscordAuth{}, DiscordAuthExpired},
+		{"expired", DiscordAuth{ExpiresAt: now}, DiscordAuthExpired},
+		{"locked", DiscordAuth{ExpiresAt: now.Add(time.Minute), Attempts: DiscordAuthMaxAttempts}, DiscordAuthLocked},
+		{"locked and expired", DiscordAuth{ExpiresAt: now, Attempts: DiscordAuthMaxAttempts}, DiscordAuthExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.d.Status(now); got != tt.want {
+				t.Errorf("DiscordAuth.Status() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
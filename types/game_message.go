@@ -1,8 +1,10 @@
 package types
 
 import (
+	"errors"
 	"fmt"
 	"image/color"
+	"time"
 
 	"golang.org/x/image/colornames"
 )
@@ -36,16 +38,90 @@ type GameMessagePart struct {
 	Escape  bool
 }
 
-// GameMessage is a message from the game server intended for discord
+// GameMessageEmbedAuthor is the author shown at the top of an embed
+type GameMessageEmbedAuthor struct {
+	Name    []GameMessagePart
+	URL     string
+	IconURL string
+}
+
+// GameMessageEmbedField is a titled field of an embed. Inline fields are
+// shown side by side.
+type GameMessageEmbedField struct {
+	Name   []GameMessagePart
+	Value  []GameMessagePart
+	Inline bool
+}
+
+// GameMessageEmbedFooter is the footer at the bottom of an embed
+type GameMessageEmbedFooter struct {
+	Text    []GameMessagePart
+	IconURL string
+}
+
+// GameMessageEmbed is everything other than the description and color of
+// an embed. Text is made of parts which are escaped like MessageParts.
+type GameMessageEmbed struct {
+	Title        []GameMessagePart
+	URL          string
+	Author       GameMessageEmbedAuthor
+	Fields       []GameMessageEmbedField
+	Footer       GameMessageEmbedFooter
+	Timestamp    time.Time
+	ImageURL     string
+	ThumbnailURL string
+}
+
+// GameMessageAction is what to do with a game message in Discord
+type GameMessageAction int
+
+const (
+	GameMessageActionSend GameMessageAction = iota
+	GameMessageActionEdit
+	GameMessageActionDelete
+)
+
+// GameMessage is a message from the game server intended for discord.
+// MessageParts is the content of plain messages and the description of
+// embeds. MessageID is the message to edit or delete. Sent messages are
+// pinned with Pin.
 type GameMessage struct {
-	Type          GameMessageType
-	EmbedStyle    GameMessageEmbedStyle
-	ChannelName   string
-	MessageParts  []GameMessagePart
-	Snowflake     string       `json:"-"`
-	ErrorResponse chan<- error `json:"-"`
+	Type         GameMessageType
+	EmbedStyle   GameMessageEmbedStyle
+	Embed        *GameMessageEmbed `json:",omitempty"`
+	ChannelName  string
+	MessageParts []GameMessagePart
+	Action       GameMessageAction          `json:"-"`
+	MessageID    string                     `json:"-"`
+	Pin          bool                       `json:"-"`
+	Snowflake    string                     `json:"-"`
+	Response     chan<- GameMessageResponse `json:"-"`
+}
+
+// GameMessageResponse is the result of a GameMessage. ChannelID and
+// MessageID are the Discord message that was sent, edited or deleted.
+type GameMessageResponse struct {
+	ChannelID string
+	MessageID string
+	Error     error
 }
 
+// SentGameMessage is a Discord message sent by a game server
+type SentGameMessage struct {
+	MessageID string
+	ChannelID string
+	ServerKey string
+	SentAt    time.Time
+}
+
+var (
+	// ErrInvalidEmbed is returned for embeds that Discord would reject
+	ErrInvalidEmbed = errors.New("invalid embed")
+	// ErrMessageNotFound is returned when editing or deleting a message
+	// that is no longer in Discord
+	ErrMessageNotFound = errors.New("message not found")
+)
+
 func parseHexColor(s string) (c color.RGBA, err error) {
 	c.A = 0xff
 	switch len(s) {
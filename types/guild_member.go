@@ -0,0 +1,35 @@
+package types
+
+import "time"
+
+// GuildMemberRole is a Discord role a guild member has
+type GuildMemberRole struct {
+	ID    string
+	Name  string
+	Color int
+}
+
+// GuildMember is a Discord user's membership of a guild. Roles are highest
+// first. BoostingSince is zero if the member isn't boosting the guild.
+type GuildMember struct {
+	Snowflake     string
+	DiscordName   string
+	Nick          string
+	Roles         []GuildMemberRole
+	JoinedAt      time.Time
+	BoostingSince time.Time
+}
+
+// GuildMemberRequest asks for a user's membership of a guild. The response
+// Member is nil if the user isn't a member.
+type GuildMemberRequest struct {
+	GuildID      string
+	Snowflake    string
+	ResponseChan chan<- GuildMemberResponse
+}
+
+// GuildMemberResponse is the response to a GuildMemberRequest
+type GuildMemberResponse struct {
+	Member *GuildMember
+	Error  error
+}
@@ -0,0 +1,48 @@
+package types
+
+// PlayerDeathPlayer is the victim or killer of a player death
+type PlayerDeathPlayer struct {
+	PlayerID    string
+	DisplayName string
+	ClanTag     string
+}
+
+// PlayerDeath is a player dying on a game server. Killer is nil for deaths
+// that weren't caused by a player. Distance is in meters.
+type PlayerDeath struct {
+	Victim   PlayerDeathPlayer
+	Killer   *PlayerDeathPlayer `json:",omitempty"`
+	Weapon   string
+	Distance float64
+	GridPos  string
+}
+
+// IsPvP is true when the victim was killed by another player
+func (d PlayerDeath) IsPvP() bool {
+	return d.Killer != nil && len(d.Killer.PlayerID) != 0 && d.Killer.PlayerID != d.Victim.PlayerID
+}
+
+// KillFeedFilter chooses the player deaths posted to a server's kill feed.
+// LinkedOnly posts deaths with a linked victim or killer. MinDistance is in
+// meters, and zero posts deaths from any distance. It only applies to PvP
+// deaths, since other deaths have no shot to measure.
+type KillFeedFilter struct {
+	PvPOnly     bool
+	LinkedOnly  bool
+	MinDistance float64
+}
+
+// Allows reports whether a death is posted to the kill feed. linked reports
+// whether a player is linked to a Discord user.
+func (f KillFeedFilter) Allows(d PlayerDeath, linked func(playerID string) bool) bool {
+	if f.PvPOnly && !d.IsPvP() {
+		return false
+	}
+	if d.IsPvP() && d.Distance < f.MinDistance {
+		return false
+	}
+	if f.LinkedOnly && !linked(d.Victim.PlayerID) && !(d.Killer != nil && linked(d.Killer.PlayerID)) {
+		return false
+	}
+	return true
+}
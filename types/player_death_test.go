@@ -0,0 +1,44 @@
+package types
+
+import "testing"
+
+func TestKillFeedFilter_Allows(t *testing.T) {
+	t.Parallel()
+
+	linked := func(playerID string) bool { return playerID == "game:linked" }
+	pvp := PlayerDeath{
+		Victim:   PlayerDeathPlayer{PlayerID: "game:1"},
+		Killer:   &PlayerDeathPlayer{PlayerID: "game:linked"},
+		Distance: 120,
+	}
+	npc := PlayerDeath{Victim: PlayerDeathPlayer{PlayerID: "game:1"}, Weapon: "Bear"}
+	suicide := PlayerDeath{
+		Victim: PlayerDeathPlayer{PlayerID: "game:1"},
+		Killer: &PlayerDeathPlayer{PlayerID: "game:1"},
+	}
+
+	tests := []struct {
+		name   string
+		filter KillFeedFilter
+		death  PlayerDeath
+		want   bool
+	}{
+		{name: "no filter", death: npc, want: true},
+		{name: "pvp only", filter: KillFeedFilter{PvPOnly: true}, death: pvp, want: true},
+		{name: "pvp only npc", filter: KillFeedFilter{PvPOnly: true}, death: npc},
+		{name: "pvp only suicide", filter: KillFeedFilter{PvPOnly: true}, death: suicide},
+		{name: "linked killer", filter: KillFeedFilter{LinkedOnly: true}, death: pvp, want: true},
+		{name: "not linked", filter: KillFeedFilter{LinkedOnly: true}, death: npc},
+		{name: "far enough", filter: KillFeedFilter{MinDistance: 100}, death: pvp, want: true},
+		{name: "too close", filter: KillFeedFilter{MinDistance: 150}, death: pvp},
+		{name: "min distance npc", filter: KillFeedFilter{MinDistance: 150}, death: npc, want: true},
+		{name: "min distance suicide", filter: KillFeedFilter{MinDistance: 150}, death: suicide, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Allows(tt.death, linked); got != tt.want {
+				t.Errorf("KillFeedFilter.Allows() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package types
+
+import "errors"
+
+// PlayerDM is a game message sent to a player's Discord user as a direct
+// message. Message.Snowflake is the guild the user must be a member of, and
+// Message.ChannelName is ignored.
+type PlayerDM struct {
+	Snowflake string // The Discord user
+	Message   GameMessage
+}
+
+var (
+	// ErrNotGuildMember is returned for DMs to users who have left the guild
+	ErrNotGuildMember = errors.New("user is not a member of the guild")
+	// ErrDMsClosed is returned for DMs to users who don't accept them
+	ErrDMsClosed = errors.New("user does not accept direct messages")
+	// ErrRateLimited is returned when too many DMs have been sent
+	ErrRateLimited = errors.New("too many direct messages")
+)
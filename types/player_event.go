@@ -0,0 +1,28 @@
+package types
+
+import "time"
+
+// Player event types. Game servers send a startup event when they start,
+// before players connect, to clear players left from before a restart.
+const (
+	PlayerEventConnect    = "connect"
+	PlayerEventDisconnect = "disconnect"
+	PlayerEventStartup    = "startup"
+)
+
+// PlayerEvent is a player connecting to or disconnecting from a game server,
+// or the game server starting
+type PlayerEvent struct {
+	Type        string
+	PlayerID    string
+	DisplayName string
+	Timestamp   time.Time
+}
+
+// OnlinePlayer is a player connected to a game server
+type OnlinePlayer struct {
+	ServerKey   string
+	PlayerID    string
+	DisplayName string
+	ConnectedAt time.Time
+}
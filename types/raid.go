@@ -31,10 +31,49 @@ type RaidAlert struct {
 	Items         map[string]int
 	AlertAt       time.Time
 	ValidUntil    time.Time
-	MessageID     string // The private message ID in discord
+	StartedAt     time.Time // The first entity death
+	LastEventAt   time.Time // The most recent entity death
+	MessageID     string    // The private message ID in discord
 	NotifyCount   int
 }
 
+// A RaidHistory is a finished raid, kept after its RaidAlert is removed
+type RaidHistory struct {
+	ID            bson.ObjectId `bson:"_id,omitempty"` // The ID of the RaidAlert
+	PlayerID      string
+	ServerName    string
+	ServerKey     string
+	GridPositions []string
+	Items         map[string]int
+	StartedAt     time.Time
+	EndedAt       time.Time
+	NotifyCount   int
+}
+
+// NewRaidHistory creates the history for a finished raid alert
+func NewRaidHistory(ra RaidAlert) RaidHistory {
+	return RaidHistory{
+		ID:            ra.ID,
+		PlayerID:      ra.PlayerID,
+		ServerName:    ra.ServerName,
+		ServerKey:     ra.ServerKey,
+		GridPositions: ra.GridPositions,
+		Items:         ra.Items,
+		StartedAt:     ra.StartedAt,
+		EndedAt:       ra.LastEventAt,
+		NotifyCount:   ra.NotifyCount,
+	}
+}
+
+// ItemCount is the total number of entities destroyed
+func (rh RaidHistory) ItemCount() int {
+	count := 0
+	for _, v := range rh.Items {
+		count += v
+	}
+	return count
+}
+
 type RaiAlertWithMessageChannel struct {
 	RaidAlert
 	MessageIDChannel chan string
@@ -0,0 +1,24 @@
+package types
+
+// RoleMembers are the members of a Discord role. Found is false if the role
+// no longer exists in the guild.
+type RoleMembers struct {
+	RoleID     string
+	Name       string
+	Found      bool
+	Snowflakes []string
+}
+
+// RoleMembersRequest asks for the members of a guild's roles
+type RoleMembersRequest struct {
+	GuildID      string
+	RoleIDs      []string
+	ResponseChan chan<- RoleMembersResponse
+}
+
+// RoleMembersResponse is the response to a RoleMembersRequest. Roles are in
+// the order they were requested.
+type RoleMembersResponse struct {
+	Roles []RoleMembers
+	Error error
+}
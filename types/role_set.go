@@ -1,11 +1,17 @@
 package types
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
+// RoleSet sets a Discord role's members to the users linked to PlayerIDs.
+// If ResultChan is set, the RoleSetResult is sent to it.
 type RoleSet struct {
-	GuildID   string `json:"-"`
-	Role      string
-	PlayerIDs []string
+	GuildID    string `json:"-"`
+	Role       string
+	PlayerIDs  []string
+	ResultChan chan<- RoleSetResult `json:"-"`
 }
 
 func (gs *RoleSet) SetGame(game string) {
@@ -13,3 +19,73 @@ func (gs *RoleSet) SetGame(game string) {
 		gs.PlayerIDs[i] = fmt.Sprintf("%s:%s", game, gs.PlayerIDs[i])
 	}
 }
+
+// RoleSetMember is a Discord user whose role was changed by a RoleSet.
+// PlayerID is only set by RoleMemberChanges.
+type RoleSetMember struct {
+	PlayerID    string `json:",omitempty"`
+	Snowflake   string
+	DiscordName string
+}
+
+// RoleSetFailure is a Discord user whose role could not be changed
+type RoleSetFailure struct {
+	RoleSetMember
+	Error string
+}
+
+// RoleSetResult reports what a RoleSet did. PermissionOK is false if the
+// role is above the bot's highest role that can manage roles. Error is set
+// if the role set could not run at all.
+type RoleSetResult struct {
+	Role         string
+	RoleID       string `json:",omitempty"`
+	RoleFound    bool
+	PermissionOK bool
+	Added        []RoleSetMember
+	Removed      []RoleSetMember
+	Failures     []RoleSetFailure
+	Error        string `json:",omitempty"`
+}
+
+// RoleMemberChange adds the users linked to PlayerIDs to a Discord role, or
+// removes them if Remove is set. If ResultChan is set, the RoleSetResult is
+// sent to it.
+type RoleMemberChange struct {
+	GuildID    string `json:"-"`
+	Role       string `json:"-"`
+	PlayerIDs  []string
+	Remove     bool                 `json:"-"`
+	ResultChan chan<- RoleSetResult `json:"-"`
+}
+
+// SetGame adds the game name to all IDs
+func (rmc *RoleMemberChange) SetGame(game string) {
+	for i := range rmc.PlayerIDs {
+		rmc.PlayerIDs[i] = fmt.Sprintf("%s:%s", game, rmc.PlayerIDs[i])
+	}
+}
+
+// RemoveGame removes the game name from the members' player IDs
+func (r *RoleSetResult) RemoveGame(game string) {
+	prefix := game + ":"
+	for i := range r.Added {
+		r.Added[i].PlayerID = strings.TrimPrefix(r.Added[i].PlayerID, prefix)
+	}
+	for i := range r.Removed {
+		r.Removed[i].PlayerID = strings.TrimPrefix(r.Removed[i].PlayerID, prefix)
+	}
+	for i := range r.Failures {
+		r.Failures[i].PlayerID = strings.TrimPrefix(r.Failures[i].PlayerID, prefix)
+	}
+}
+
+// NewRoleSetResult creates an empty result for the role
+func NewRoleSetResult(role string) RoleSetResult {
+	return RoleSetResult{
+		Role:     role,
+		Added:    []RoleSetMember{},
+		Removed:  []RoleSetMember{},
+		Failures: []RoleSetFailure{},
+	}
+}
@@ -0,0 +1,19 @@
+package types
+
+import "time"
+
+// ServerStatus is the status last reported by a game server. ChannelID and
+// MessageID are the Discord message showing it.
+type ServerStatus struct {
+	ServerKey  string
+	Players    int
+	MaxPlayers int
+	Map        string
+	Seed       int64
+	Uptime     int64 // seconds
+	FPS        float64
+	ReportedAt time.Time
+	Offline    bool
+	ChannelID  string
+	MessageID  string
+}